@@ -0,0 +1,90 @@
+package auth0
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// AudienceRouter returns the audiences accepted for the request, so a single
+// validator serves an API exposed under several audiences. The tokens must
+// have any of the audiences in their aud claim. Requests with no audience
+// are rejected with ErrInvalidAudience.
+type AudienceRouter func(r *http.Request) []string
+
+// WithAudienceRouter makes the validator accept, for the tokens validated
+// within an HTTP request, the audiences of the router instead of the ones
+// of the configuration or of WithAcceptedAudiences, which still apply to
+// the tokens validated outside a request.
+func WithAudienceRouter(router AudienceRouter) ValidatorOption {
+	return func(v *JWTValidator) {
+		v.audienceRouter = router
+	}
+}
+
+// AudiencesByHost creates an AudienceRouter returning the audiences of
+// the host of the request, without its port, such as
+// {"api.example.com": {"https://api.example.com/"}}.
+func AudiencesByHost(audiences map[string][]string) AudienceRouter {
+	return func(r *http.Request) []string {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		return audiences[strings.ToLower(host)]
+	}
+}
+
+// AudiencesByPathPrefix creates an AudienceRouter returning the audiences
+// of the longest prefix of the URL path of the request, such as
+// {"/v1/": {"https://v1.api.example.com/"}}.
+func AudiencesByPathPrefix(audiences map[string][]string) AudienceRouter {
+	return func(r *http.Request) []string {
+		var match string
+		var found bool
+		for prefix := range audiences {
+			if strings.HasPrefix(r.URL.Path, prefix) && (!found || len(prefix) > len(match)) {
+				match, found = prefix, true
+			}
+		}
+		return audiences[match]
+	}
+}
+
+// routedAudiences returns the audiences of the router for the
+// request of the context, if any.
+func (v *JWTValidator) routedAudiences(ctx context.Context) ([]string, bool) {
+	if v.audienceRouter == nil {
+		return nil, false
+	}
+	r, ok := requestFromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	return v.audienceRouter(r), true
+}
+
+// checkAudience validates the aud claim against the audiences of the router
+// for the request of the context, or else any of the accepted audiences, or
+// else all the audiences of the configuration.
+func (v *JWTValidator) checkAudience(ctx context.Context, claims jwt.Claims) error {
+	audiences, routed := v.routedAudiences(ctx)
+	if !routed {
+		audiences = v.audiences
+	}
+	if routed || len(audiences) > 0 {
+		if !containsAnyAudience(claims.Audience, audiences) {
+			return newClaimError(jwt.ErrInvalidAudience, claims)
+		}
+		return nil
+	}
+	for _, aud := range v.config.expectedClaims.Audience {
+		if !claims.Audience.Contains(aud) {
+			return newClaimError(jwt.ErrInvalidAudience, claims)
+		}
+	}
+	return nil
+}
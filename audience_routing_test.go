@@ -0,0 +1,94 @@
+package auth0
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func genTestAudienceRequest(target, token string) *http.Request {
+	req := httptest.NewRequest("GET", target, nil)
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+	return req
+}
+
+func TestAudienceRouter(t *testing.T) {
+	expiry := time.Now().Add(time.Hour)
+	tokenA := getTestToken([]string{"https://a.example.com/"}, defaultIssuer, expiry, jose.HS256, defaultSecret)
+	tokenB := getTestToken([]string{"https://b.example.com/"}, defaultIssuer, expiry, jose.HS256, defaultSecret)
+	tokenDefault := getTestToken(defaultAudience, defaultIssuer, expiry, jose.HS256, defaultSecret)
+
+	byHost := AudiencesByHost(map[string][]string{
+		"a.example.com": {"https://a.example.com/"},
+		"b.example.com": {"https://b.example.com/"},
+	})
+	byPath := AudiencesByPathPrefix(map[string][]string{
+		"/":   {"https://a.example.com/"},
+		"/b/": {"https://b.example.com/"},
+	})
+
+	tests := []struct {
+		name          string
+		router        AudienceRouter
+		target        string
+		token         string
+		expectedError error
+	}{
+		{"pass - audience of the host", byHost, "http://a.example.com:8080/users", tokenA, nil},
+		{"pass - audience of the other host", byHost, "http://B.example.com/users", tokenB, nil},
+		{"fail - audience of another host", byHost, "http://a.example.com/users", tokenB, ErrInvalidAudience},
+		{"fail - unknown host", byHost, "http://c.example.com/users", tokenA, ErrInvalidAudience},
+		{"fail - audience of the configuration", byHost, "http://a.example.com/users", tokenDefault, ErrInvalidAudience},
+		{"pass - audience of the longest prefix", byPath, "http://localhost/b/users", tokenB, nil},
+		{"fail - audience of a shorter prefix", byPath, "http://localhost/b/users", tokenA, ErrInvalidAudience},
+		{"pass - audience of the root prefix", byPath, "http://localhost/users", tokenA, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			validator := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), nil,
+				WithAudienceRouter(test.router))
+			_, err := validator.ValidateRequest(genTestAudienceRequest(test.target, test.token))
+			if test.expectedError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, test.expectedError), "got %v", err)
+			}
+		})
+	}
+}
+
+func TestAudienceRouterOutsideRequest(t *testing.T) {
+	expiry := time.Now().Add(time.Hour)
+	validator := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), nil,
+		WithAudienceRouter(AudiencesByHost(map[string][]string{"a.example.com": {"https://a.example.com/"}})))
+
+	_, err := validator.ValidateRawToken(getTestToken(defaultAudience, defaultIssuer, expiry, jose.HS256, defaultSecret))
+	assert.NoError(t, err)
+	_, err = validator.ValidateRawToken(getTestToken([]string{"https://a.example.com/"}, defaultIssuer, expiry, jose.HS256, defaultSecret))
+	assert.True(t, errors.Is(err, ErrInvalidAudience), "got %v", err)
+}
+
+func TestAudienceRouterValidationCache(t *testing.T) {
+	validator := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), nil,
+		WithAudienceRouter(AudiencesByHost(map[string][]string{
+			"a.example.com": {"https://a.example.com/"},
+			"b.example.com": {"https://b.example.com/"},
+		})),
+		WithValidationCache(time.Minute, 10),
+	)
+	token := getTestToken([]string{"https://a.example.com/"}, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, defaultSecret)
+
+	_, err := validator.ValidateRequest(genTestAudienceRequest("http://a.example.com/", token))
+	assert.NoError(t, err)
+	_, err = validator.ValidateRequest(genTestAudienceRequest("http://b.example.com/", token))
+	assert.True(t, errors.Is(err, ErrInvalidAudience), "got %v", err)
+	_, err = validator.ValidateRawToken(token)
+	assert.True(t, errors.Is(err, ErrInvalidAudience), "got %v", err)
+}
@@ -0,0 +1,149 @@
+package auth0
+
+import (
+	"context"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// AuditDecision is the decision of a validation, see AuditEvent.
+type AuditDecision string
+
+// Decisions of the validations.
+const (
+	AuditAllow AuditDecision = "allow"
+	AuditDeny  AuditDecision = "deny"
+)
+
+// AuditEvent is the record of a validation decision of a JWTValidator.
+//
+// The claims of the denied tokens are NOT verified: they are recorded as
+// sent, and may have been forged by the sender of the token.
+type AuditEvent struct {
+	// Time is the time of the decision, from the clock of the validator.
+	Time     time.Time
+	Decision AuditDecision
+	// Subject, Issuer and Audience are the sub, iss and aud claims of the
+	// token, empty when the token could not be extracted or parsed.
+	Subject  string
+	Issuer   string
+	Audience []string
+	// KeyID is the kid header of the token.
+	KeyID string
+	// Err is the error of the denied validations, nil for the allowed ones,
+	// and Reason its low cardinality class, see FailureReason.
+	Err    error
+	Reason string
+	// ClientIP is the IP address of the client of the validated request,
+	// empty when the token is not validated within a request.
+	ClientIP string
+}
+
+// AuditSink receives the AuditEvent of each validation decision, such as to
+// keep the audit trail required by compliance. Audit is called synchronously
+// and must be safe for concurrent use.
+type AuditSink interface {
+	Audit(ctx context.Context, event AuditEvent)
+}
+
+// AuditSinkFunc simple wrappers to audit
+// the decisions with functions.
+type AuditSinkFunc func(ctx context.Context, event AuditEvent)
+
+// Audit implements the AuditSink interface.
+func (f AuditSinkFunc) Audit(ctx context.Context, event AuditEvent) {
+	f(ctx, event)
+}
+
+// AuditOptions configures the events sent to the AuditSink.
+type AuditOptions struct {
+	// AllowedSampling and DeniedSampling are the fractions, between 0 and 1,
+	// of the allowed and denied decisions sent to the sink. Zero fields
+	// default to 1, all the decisions, negative ones send none.
+	AllowedSampling float64
+	DeniedSampling  float64
+	// ClientIP returns the IP address of the client of the request, such as
+	// from the X-Forwarded-For header set by a trusted proxy. Defaults to the
+	// host of the RemoteAddr of the request.
+	ClientIP func(r *http.Request) string
+}
+
+// auditor sends the sampled validation decisions to the sink.
+type auditor struct {
+	sink    AuditSink
+	options AuditOptions
+}
+
+// WithAuditSink sets the sink receiving the events of the validation
+// decisions, including the requests with no token or a malformed one.
+func WithAuditSink(sink AuditSink, options AuditOptions) ValidatorOption {
+	return func(v *JWTValidator) {
+		v.auditor = &auditor{sink: sink, options: options}
+	}
+}
+
+// sampled reports whether the decision is sent to the sink.
+func (a *auditor) sampled(decision AuditDecision) bool {
+	rate := a.options.AllowedSampling
+	if decision == AuditDeny {
+		rate = a.options.DeniedSampling
+	}
+	switch {
+	case rate == 0 || rate >= 1:
+		return true
+	case rate < 0:
+		return false
+	}
+	return rand.Float64() < rate
+}
+
+// clientIP returns the IP address of the client of the request.
+func (a *auditor) clientIP(r *http.Request) string {
+	if a.options.ClientIP != nil {
+		return a.options.ClientIP(r)
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// audit sends the decision of the validation of the token, nil if it could
+// not be extracted or parsed, or of the opaque token of the introspected
+// claims, to the audit sink of the validator, if any.
+func (v *JWTValidator) audit(ctx context.Context, token *jwt.JSONWebToken, introspected map[string]interface{}, err error) {
+	if v.auditor == nil {
+		return
+	}
+	event := AuditEvent{Time: v.now(), Decision: AuditAllow}
+	if err != nil {
+		event.Decision = AuditDeny
+		event.Err = err
+		event.Reason = FailureReason(err)
+	}
+	if !v.auditor.sampled(event.Decision) {
+		return
+	}
+
+	claims := jwt.Claims{}
+	if token != nil && len(token.Headers) > 0 {
+		event.KeyID = token.Headers[0].KeyID
+		if token.UnsafeClaimsWithoutVerification(&claims) != nil {
+			claims = jwt.Claims{}
+		}
+	} else if introspected != nil {
+		claims, _ = registeredClaims(introspected)
+	}
+	event.Subject = claims.Subject
+	event.Issuer = claims.Issuer
+	event.Audience = claims.Audience
+	if r, ok := requestFromContext(ctx); ok {
+		event.ClientIP = v.auditor.clientIP(r)
+	}
+	v.auditor.sink.Audit(ctx, event)
+}
@@ -0,0 +1,173 @@
+package auth0
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+type recordingAuditSink struct {
+	mu     sync.Mutex
+	events []AuditEvent
+}
+
+func (s *recordingAuditSink) Audit(_ context.Context, event AuditEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+}
+
+func TestAuditSink(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	sink := &recordingAuditSink{}
+	configuration := NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256)
+	validator := NewValidator(configuration, nil, WithAuditSink(sink, AuditOptions{}), WithClock(func() time.Time { return now }))
+
+	claims := jwt.Claims{Subject: "auth0|123", Issuer: defaultIssuer, Audience: defaultAudience, Expiry: jwt.NewNumericDate(now.Add(time.Hour))}
+	_, err := validator.ValidateRequest(genTestMiddlewareRequest(getTestTokenWithClaims(jose.HS256, defaultSecret, claims)))
+	assert.NoError(t, err)
+	claims.Expiry = jwt.NewNumericDate(now.Add(-time.Hour))
+	_, err = validator.ValidateRequest(genTestMiddlewareRequest(getTestTokenWithClaims(jose.HS256, defaultSecret, claims)))
+	assert.True(t, errors.Is(err, ErrTokenExpired))
+	_, err = validator.ValidateRequest(genTestMiddlewareRequest(""))
+	assert.Equal(t, ErrTokenNotFound, err)
+	_, err = validator.ValidateRawToken("malformed")
+	assert.Error(t, err)
+
+	if !assert.Len(t, sink.events, 4) {
+		return
+	}
+	assert.Equal(t, AuditEvent{
+		Time:     now,
+		Decision: AuditAllow,
+		Subject:  "auth0|123",
+		Issuer:   defaultIssuer,
+		Audience: defaultAudience,
+		ClientIP: "192.0.2.1",
+	}, sink.events[0])
+
+	expired := sink.events[1]
+	assert.Equal(t, AuditDeny, expired.Decision)
+	assert.Equal(t, "auth0|123", expired.Subject)
+	assert.Equal(t, "expired", expired.Reason)
+	assert.True(t, errors.Is(expired.Err, ErrTokenExpired))
+
+	assert.Equal(t, AuditEvent{
+		Time:     now,
+		Decision: AuditDeny,
+		Err:      ErrTokenNotFound,
+		Reason:   "token_not_found",
+		ClientIP: "192.0.2.1",
+	}, sink.events[2])
+
+	// Tokens validated outside of a request have no client IP.
+	assert.Equal(t, "malformed", sink.events[3].Reason)
+	assert.Empty(t, sink.events[3].ClientIP)
+}
+
+func TestAuditOptions(t *testing.T) {
+	var events []AuditEvent
+	sink := AuditSinkFunc(func(_ context.Context, event AuditEvent) {
+		events = append(events, event)
+	})
+	configuration := NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256)
+	validator := NewValidator(configuration, nil, WithAuditSink(sink, AuditOptions{
+		AllowedSampling: -1,
+		ClientIP: func(r *http.Request) string {
+			return r.Header.Get("X-Forwarded-For")
+		},
+	}))
+
+	r := genTestMiddlewareRequest(getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, defaultSecret))
+	_, err := validator.ValidateRequest(r)
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+
+	r = genTestMiddlewareRequest("")
+	r.Header.Set("X-Forwarded-For", "203.0.113.7")
+	_, err = validator.ValidateRequest(r)
+	assert.Equal(t, ErrTokenNotFound, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, "203.0.113.7", events[0].ClientIP)
+	}
+}
+
+func TestAuditSampling(t *testing.T) {
+	tests := []struct {
+		rate float64
+		want bool
+	}{
+		{rate: 0, want: true},
+		{rate: 1, want: true},
+		{rate: -1, want: false},
+	}
+	for _, test := range tests {
+		a := &auditor{options: AuditOptions{AllowedSampling: test.rate, DeniedSampling: test.rate}}
+		assert.Equal(t, test.want, a.sampled(AuditAllow), "rate %v", test.rate)
+		assert.Equal(t, test.want, a.sampled(AuditDeny), "rate %v", test.rate)
+	}
+
+	a := &auditor{options: AuditOptions{DeniedSampling: 0.5}}
+	sampled := 0
+	for i := 0; i < 1000; i++ {
+		if a.sampled(AuditDeny) {
+			sampled++
+		}
+	}
+	assert.InDelta(t, 500, sampled, 150)
+}
+
+func TestAuditFinalDecision(t *testing.T) {
+	sink := &recordingAuditSink{}
+	configuration := NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256)
+	validator := NewValidator(configuration, nil, WithAuditSink(sink, AuditOptions{}))
+	registered := jwt.Claims{Subject: "user", Issuer: defaultIssuer, Audience: jwt.Audience{defaultAudience[0]}, Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+
+	// The ID token checks come after the validation of the token.
+	_, err := validator.ValidateIDToken(getTestTokenWithClaims(jose.HS256, defaultSecret, registered, map[string]interface{}{"nonce": "other"}), "nonce")
+	assert.Equal(t, ErrInvalidNonce, err)
+	if assert.Len(t, sink.events, 1) {
+		assert.Equal(t, AuditDeny, sink.events[0].Decision)
+		assert.Equal(t, ErrInvalidNonce, sink.events[0].Err)
+	}
+
+	// The handler wrappers deny the requests with a valid token.
+	sink.events = nil
+	handler := Middleware(validator)(RequireScope("write:users")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	handler.ServeHTTP(httptest.NewRecorder(), genTestMiddlewareRequest(getTestTokenWithClaims(jose.HS256, defaultSecret, registered, map[string]interface{}{"scope": "read:users"})))
+	if assert.Len(t, sink.events, 1) {
+		assert.Equal(t, AuditDeny, sink.events[0].Decision)
+		var scopeErr *InsufficientScopeError
+		assert.True(t, errors.As(sink.events[0].Err, &scopeErr), "got %v", sink.events[0].Err)
+		assert.Equal(t, "user", sink.events[0].Subject)
+	}
+
+	// The introspected opaque tokens are audited once, not as malformed JWTs.
+	var counter uint64
+	ts := genTestIntrospectionServer(t, &counter)
+	defer ts.Close()
+	client := NewIntrospectionClient(IntrospectionClientOptions{URI: ts.URL, ClientID: "client", ClientSecret: "secret"})
+	handler = Middleware(validator, WithIntrospection(client))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for _, token := range []string{"active", "revoked"} {
+		sink.events = nil
+		handler.ServeHTTP(httptest.NewRecorder(), genTestMiddlewareRequest(token))
+		if !assert.Len(t, sink.events, 1, token) {
+			continue
+		}
+		if token == "active" {
+			assert.Equal(t, AuditAllow, sink.events[0].Decision)
+			assert.Equal(t, "user", sink.events[0].Subject)
+		} else {
+			assert.Equal(t, AuditDeny, sink.events[0].Decision)
+			assert.Equal(t, ErrInactiveToken, sink.events[0].Err)
+		}
+	}
+}
@@ -1,10 +1,16 @@
 package auth0
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
 	"errors"
 	"net/http"
+	"strings"
 	"time"
 
+	"golang.org/x/crypto/ed25519"
 	"gopkg.in/square/go-jose.v2"
 	"gopkg.in/square/go-jose.v2/jwt"
 )
@@ -15,6 +21,23 @@ type SecretProvider interface {
 	GetSecret(token *jwt.JSONWebToken) (interface{}, error)
 }
 
+// ContextSecretProvider is implemented by the secret providers using the
+// context of the validation, such as the JWKClient, to cancel the download
+// of the keys along with the request and to trace it.
+type ContextSecretProvider interface {
+	SecretProvider
+	GetSecretContext(ctx context.Context, token *jwt.JSONWebToken) (interface{}, error)
+}
+
+// getSecret provides the secret of the token, passing the
+// context to the provider if it is a ContextSecretProvider.
+func getSecret(ctx context.Context, provider SecretProvider, token *jwt.JSONWebToken) (interface{}, error) {
+	if p, ok := provider.(ContextSecretProvider); ok {
+		return p.GetSecretContext(ctx, token)
+	}
+	return provider.GetSecret(token)
+}
+
 // SecretProviderFunc simple wrappers to provide
 // secret with functions.
 type SecretProviderFunc func(token *jwt.JSONWebToken) (interface{}, error)
@@ -31,26 +54,179 @@ func NewKeyProvider(key interface{}) SecretProvider {
 	})
 }
 
+// NewHS256SecretProvider provides the shared secret of a tenant signing its
+// tokens with HS256. Tokens signed with any other algorithm are rejected with
+// ErrInvalidAlgorithm, so the secret is never used along asymmetric algorithms.
+// The signatures are compared in constant time by go-jose.
+func NewHS256SecretProvider(secret []byte) SecretProvider {
+	return newHMACSecretProvider(jose.HS256, secret)
+}
+
+// NewHS384SecretProvider provides the shared secret of a tenant
+// signing its tokens with HS384, like NewHS256SecretProvider.
+func NewHS384SecretProvider(secret []byte) SecretProvider {
+	return newHMACSecretProvider(jose.HS384, secret)
+}
+
+// NewHS512SecretProvider provides the shared secret of a tenant
+// signing its tokens with HS512, like NewHS256SecretProvider.
+func NewHS512SecretProvider(secret []byte) SecretProvider {
+	return newHMACSecretProvider(jose.HS512, secret)
+}
+
+func newHMACSecretProvider(alg jose.SignatureAlgorithm, secret []byte) SecretProvider {
+	key := append([]byte(nil), secret...)
+	return SecretProviderFunc(func(token *jwt.JSONWebToken) (interface{}, error) {
+		if len(token.Headers) < 1 {
+			return nil, ErrNoJWTHeaders
+		}
+		for _, header := range token.Headers {
+			if header.Algorithm != string(alg) {
+				return nil, ErrInvalidAlgorithm
+			}
+		}
+		return key, nil
+	})
+}
+
+// NewIssuerSecretProvider provides the secret of the token using the
+// provider of its issuer, such as the JWKClient of each trusted tenant.
+// The issuer is read from the unverified claims of the token, the
+// validator checks it once the signature is verified.
+func NewIssuerSecretProvider(providers map[string]SecretProvider) SecretProvider {
+	return issuerSecretProvider(providers)
+}
+
+type issuerSecretProvider map[string]SecretProvider
+
+func (p issuerSecretProvider) GetSecret(token *jwt.JSONWebToken) (interface{}, error) {
+	return p.GetSecretContext(context.Background(), token)
+}
+
+func (p issuerSecretProvider) GetSecretContext(ctx context.Context, token *jwt.JSONWebToken) (interface{}, error) {
+	claims := jwt.Claims{}
+	if err := token.UnsafeClaimsWithoutVerification(&claims); err != nil {
+		return nil, err
+	}
+	provider, ok := p[claims.Issuer]
+	if !ok {
+		return nil, ErrUnknownIssuer
+	}
+	return getSecret(ctx, provider, token)
+}
+
+// NewMultiSecretProvider provides the secret of the token using the first
+// of the providers finding it, in order, such as a static emergency key then
+// the JWKClient of the tenant, for migrations between tenants and disaster
+// recovery keys. When all the providers fail, the last error other than a
+// missing key is returned, ErrKeyNotFound if the key is missing from all.
+func NewMultiSecretProvider(providers ...SecretProvider) SecretProvider {
+	return multiSecretProvider(providers)
+}
+
+type multiSecretProvider []SecretProvider
+
+func (p multiSecretProvider) GetSecret(token *jwt.JSONWebToken) (interface{}, error) {
+	return p.GetSecretContext(context.Background(), token)
+}
+
+func (p multiSecretProvider) GetSecretContext(ctx context.Context, token *jwt.JSONWebToken) (interface{}, error) {
+	err := ErrKeyNotFound
+	for _, provider := range p {
+		secret, providerErr := getSecret(ctx, provider, token)
+		if providerErr == nil {
+			return secret, nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		if !errors.Is(providerErr, ErrNoKeyFound) {
+			err = providerErr
+		}
+	}
+	return nil, err
+}
+
 var (
 	// ErrNoJWTHeaders is returned when there are no headers in the JWT.
 	ErrNoJWTHeaders = errors.New("No headers in the token")
+	// ErrIssuedInTheFuture is returned when the iat claim of the token
+	// is later than the current time plus the leeway.
+	ErrIssuedInTheFuture = errors.New("token issued in the future (iat)")
+	// ErrUnknownIssuer is returned by the issuer secret provider when
+	// there is no provider for the issuer of the token.
+	ErrUnknownIssuer = errors.New("no secret provider for the issuer of the token")
+	// ErrUnsecuredToken is returned when the token uses the "none"
+	// algorithm or has an empty signature.
+	ErrUnsecuredToken = errors.New("token is not signed")
+	// ErrKeyAlgorithmMismatch is returned when the type, curve or algorithm
+	// of the key of the token does not match the algorithm of the token.
+	ErrKeyAlgorithmMismatch = errors.New("key does not match the algorithm of the token")
 )
 
+// parseSigned parses the compact serialized token,
+// rejecting unsecured tokens and the ones exceeding the default limits.
+func parseSigned(raw string) (*jwt.JSONWebToken, error) {
+	return parseSignedWithLimits(raw, TokenLimits{})
+}
+
+// parseSignedWithLimits parses the compact serialized token,
+// rejecting unsecured tokens and the ones exceeding the limits.
+func parseSignedWithLimits(raw string, limits TokenLimits) (_ *jwt.JSONWebToken, err error) {
+	defer recoverPanic(panicInputToken, &err)
+	if err := limits.checkLength(raw); err != nil {
+		return nil, err
+	}
+	if strings.HasSuffix(raw, ".") {
+		return nil, ErrUnsecuredToken
+	}
+	token, err := jwt.ParseSigned(raw)
+	if err != nil {
+		return nil, malformedError(err)
+	}
+	if isUnsecured(token) {
+		return nil, ErrUnsecuredToken
+	}
+	if err := limits.checkToken(token, 0); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// isUnsecured reports whether the token uses the "none" algorithm.
+func isUnsecured(token *jwt.JSONWebToken) bool {
+	for _, header := range token.Headers {
+		if header.Algorithm == "" || header.Algorithm == "none" {
+			return true
+		}
+	}
+	return false
+}
+
 // Configuration contains
 // all the information about the
 // Auth0 service.
 type Configuration struct {
 	secretProvider SecretProvider
 	expectedClaims jwt.Expected
-	signIn         jose.SignatureAlgorithm
+	signIn         []jose.SignatureAlgorithm
 }
 
 // NewConfiguration creates a configuration for server
 func NewConfiguration(provider SecretProvider, audience []string, issuer string, method jose.SignatureAlgorithm) Configuration {
+	if method == "" {
+		return NewConfigurationTrustProvider(provider, audience, issuer)
+	}
+	return NewConfigurationWithAlgorithms(provider, audience, issuer, method)
+}
+
+// NewConfigurationWithAlgorithms creates a configuration for server accepting
+// tokens signed with any of the provided algorithms.
+func NewConfigurationWithAlgorithms(provider SecretProvider, audience []string, issuer string, methods ...jose.SignatureAlgorithm) Configuration {
 	return Configuration{
 		secretProvider: provider,
 		expectedClaims: jwt.Expected{Issuer: issuer, Audience: audience},
-		signIn:         method,
+		signIn:         methods,
 	}
 }
 
@@ -65,24 +241,129 @@ func NewConfigurationTrustProvider(provider SecretProvider, audience []string, i
 // JWTValidator helps middleware
 // to validate token
 type JWTValidator struct {
-	config    Configuration
-	extractor RequestTokenExtractor
+	config           Configuration
+	extractor        RequestTokenExtractor
+	leeway           time.Duration
+	clock            func() time.Time
+	audiences        []string
+	audienceRouter   AudienceRouter
+	issuers          func(iss string) bool
+	checks           []claimsCheck
+	customValidators []CustomClaimsValidator
+	// requestChecks validate the claims along with the request,
+	// and only when the token is validated within a request.
+	requestChecks []requestCheck
+	revocation    RevocationChecker
+	replay        ReplayDetector
+
+	decryption      DecryptionKeyProvider
+	limits          TokenLimits
+	criticalHeaders map[string]bool // Extensions of the crit header understood by the application
+	types           []string        // Accepted typ headers, any if empty
+	observer        ValidationObserver
+	auditor         *auditor
+	tracer          Tracer
+	logger          Logger
+	cache           *validationCache
+}
+
+// claimsCheck validates the verified claims of a token
+// once the registered claims have been validated.
+type claimsCheck func(claims map[string]interface{}) error
+
+// requestCheck validates the verified claims of a token
+// along with the request it is sent with.
+type requestCheck func(r *http.Request, claims map[string]interface{}) error
+
+// requestContextKey is the key of the request validated in the context
+// passed down the validation, for the request checks.
+type requestContextKey struct{}
+
+func contextWithRequest(ctx context.Context, r *http.Request) context.Context {
+	return context.WithValue(ctx, requestContextKey{}, r)
+}
+
+func requestFromContext(ctx context.Context) (*http.Request, bool) {
+	r, ok := ctx.Value(requestContextKey{}).(*http.Request)
+	return r, ok && r != nil
+}
+
+// ValidatorOption configures optional
+// behaviours of a JWTValidator.
+type ValidatorOption func(*JWTValidator)
+
+// WithLeeway sets the leeway used to compare time values
+// of the exp, nbf and iat claims to tolerate clock skew.
+// Defaults to jwt.DefaultLeeway, one minute.
+func WithLeeway(leeway time.Duration) ValidatorOption {
+	return func(v *JWTValidator) {
+		v.leeway = leeway
+	}
+}
+
+// WithClock sets the clock the validator compares the time claims, such as
+// exp and nbf, with, for deterministic tests or to correct a known skew.
+// Defaults to time.Now.
+func WithClock(clock func() time.Time) ValidatorOption {
+	return func(v *JWTValidator) {
+		v.clock = clock
+	}
+}
+
+// WithAcceptedAudiences makes the validator accept tokens whose aud
+// claim contains any of the audiences, instead of requiring all the
+// audiences of the configuration.
+func WithAcceptedAudiences(audiences ...string) ValidatorOption {
+	return func(v *JWTValidator) {
+		v.audiences = audiences
+	}
+}
+
+// WithTrustedIssuers makes the validator accept tokens issued by any
+// of the issuers, instead of the issuer of the configuration.
+func WithTrustedIssuers(issuers ...string) ValidatorOption {
+	return WithIssuerValidator(func(iss string) bool {
+		for _, issuer := range issuers {
+			if iss == issuer {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// WithIssuerValidator makes the validator accept tokens whose issuer
+// is trusted by the callback, instead of the issuer of the configuration.
+func WithIssuerValidator(trusted func(iss string) bool) ValidatorOption {
+	return func(v *JWTValidator) {
+		v.issuers = trusted
+	}
 }
 
 // NewValidator creates a new
 // validator with the provided configuration.
-func NewValidator(config Configuration, extractor RequestTokenExtractor) *JWTValidator {
+func NewValidator(config Configuration, extractor RequestTokenExtractor, opts ...ValidatorOption) *JWTValidator {
 	if extractor == nil {
-		extractor = RequestTokenExtractorFunc(FromHeader)
+		extractor = FromHeaderWithScheme()
 	}
-	return &JWTValidator{config, extractor}
+	v := &JWTValidator{
+		config:    config,
+		extractor: extractor,
+		leeway:    jwt.DefaultLeeway,
+		clock:     time.Now,
+		logger:    nopLogger{},
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
 }
 
 // ValidateRequest validates the token within
 // the http request.
-// A default leeway value of one minute is used to compare time values.
+// The leeway of the validator, one minute by default, is used to compare time values.
 func (v *JWTValidator) ValidateRequest(r *http.Request) (*jwt.JSONWebToken, error) {
-	return v.validateRequestWithLeeway(r, jwt.DefaultLeeway)
+	return v.validateRequestWithLeeway(r, v.leeway)
 }
 
 // ValidateRequestWithLeeway validates the token within
@@ -92,53 +373,344 @@ func (v *JWTValidator) ValidateRequestWithLeeway(r *http.Request, leeway time.Du
 	return v.validateRequestWithLeeway(r, leeway)
 }
 
-func (v *JWTValidator) validateRequestWithLeeway(r *http.Request, leeway time.Duration) (*jwt.JSONWebToken, error) {
+// ValidateRequestClaims validates the token within
+// the http request and unmarshalls its claims into the values.
+// The leeway of the validator, one minute by default, is used to compare time values.
+func (v *JWTValidator) ValidateRequestClaims(r *http.Request, values ...interface{}) (*jwt.JSONWebToken, error) {
+	return v.validateRequestWithLeeway(r, v.leeway, values...)
+}
+
+// validateRequestWithLeeway validates the token within the http request
+// and reports the outcome.
+func (v *JWTValidator) validateRequestWithLeeway(r *http.Request, leeway time.Duration, values ...interface{}) (*jwt.JSONWebToken, error) {
+	start := time.Now()
+	ctx, token, _, err := v.validateRequest(r, leeway, false, values...)
+	v.report(ctx, time.Since(start), token, nil, err)
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// validateRequest validates the token within the http request, in a span
+// child of the span of the request context. It returns the context of the
+// span and the token, even if invalid, for the report of the outcome, and
+// the compact serialized token if extracted, always when withRaw is set
+// and the extractor is a RawRequestTokenExtractor.
+func (v *JWTValidator) validateRequest(r *http.Request, leeway time.Duration, withRaw bool, values ...interface{}) (_ context.Context, _ *jwt.JSONWebToken, _ string, err error) {
+	ctx := context.Background()
+	if r != nil {
+		ctx = r.Context()
+	}
+	ctx, span := startSpan(ctx, v.tracer, SpanValidateRequest)
+	defer func() {
+		span.End(err)
+	}()
+	ctx = contextWithRequest(ctx, r)
+
+	// The validator parses the raw tokens to apply its limits, its cache and
+	// its decryption key provider, and to return them in the results of
+	// ValidateRequestResult and the contexts of the middleware. They are
+	// extracted once, as extractors such as FromWebSocketProtocol remove
+	// them from the request.
+	_, withResult := resultFromContext(ctx)
+	if extractor, ok := v.extractor.(RawRequestTokenExtractor); ok && (withRaw || v.cache != nil || v.limits != TokenLimits{} || withResult || v.decryption != nil) {
+		raw, err := extractor.ExtractRaw(r)
+		if err != nil {
+			return ctx, nil, "", err
+		}
+		parse := v.parseRawToken
+		if encrypted, ok := v.extractor.(encryptedHeaderExtractor); ok {
+			parse = func(raw string) (*jwt.JSONWebToken, error) {
+				return decryptNested(raw, encrypted.provider, v.limits)
+			}
+		}
+		token, err := v.validateRaw(ctx, raw, parse, leeway, values...)
+		if token != nil {
+			setTokenAttributes(span, token)
+		}
+		return ctx, token, raw, err
+	}
+
 	token, err := v.extractor.Extract(r)
+	if err != nil {
+		return ctx, nil, "", err
+	}
+	setTokenAttributes(span, token)
+	setResultToken(ctx, "", token)
+	return ctx, token, "", v.validateToken(ctx, token, leeway, values...)
+}
+
+// setTokenAttributes sets the key ID and the unverified issuer of the token on the span.
+func setTokenAttributes(span Span, token *jwt.JSONWebToken) {
+	if len(token.Headers) > 0 {
+		span.SetAttribute(AttributeKeyID, token.Headers[0].KeyID)
+	}
+	claims := jwt.Claims{}
+	if err := token.UnsafeClaimsWithoutVerification(&claims); err == nil {
+		span.SetAttribute(AttributeIssuer, claims.Issuer)
+	}
+}
+
+// ValidateToken validates the token.
+// The leeway of the validator, one minute by default, is used to compare time values.
+func (v *JWTValidator) ValidateToken(token *jwt.JSONWebToken) error {
+	return v.ValidateTokenWithLeeway(token, v.leeway)
+}
+
+// ValidateTokenWithLeeway validates the token.
+// The provided leeway value is used to compare time values.
+func (v *JWTValidator) ValidateTokenWithLeeway(token *jwt.JSONWebToken, leeway time.Duration) error {
+	ctx, start := context.Background(), time.Now()
+	err := v.validateToken(ctx, token, leeway)
+	v.report(ctx, time.Since(start), token, nil, err)
+	return err
+}
+
+// ValidateRawToken parses the compact serialized token, validates it
+// and unmarshalls its claims into the values. Nested tokens are decrypted
+// with the provider of WithDecryptionKeyProvider.
+// The leeway of the validator, one minute by default, is used to compare time values.
+func (v *JWTValidator) ValidateRawToken(raw string, values ...interface{}) (*jwt.JSONWebToken, error) {
+	return v.ValidateRawTokenContext(context.Background(), raw, values...)
+}
+
+// ValidateRawTokenContext validates the compact serialized token as
+// ValidateRawToken does, passing the context to the secret provider, so the
+// key lookup stops once it is done, and to the checks of the validator.
+func (v *JWTValidator) ValidateRawTokenContext(ctx context.Context, raw string, values ...interface{}) (*jwt.JSONWebToken, error) {
+	start := time.Now()
+	token, err := v.validateRaw(ctx, raw, v.parseRawToken, v.leeway, values...)
+	v.report(ctx, time.Since(start), token, nil, err)
 	if err != nil {
 		return nil, err
 	}
+	return token, nil
+}
+
+// validateRaw validates the compact serialized token once parsed, unless it
+// is in the validation cache, and unmarshalls its claims into the values.
+// The token is returned even if invalid, nil if it could not be parsed, for
+// the report of the outcome.
+func (v *JWTValidator) validateRaw(ctx context.Context, raw string, parse func(string) (*jwt.JSONWebToken, error), leeway time.Duration, values ...interface{}) (*jwt.JSONWebToken, error) {
+	setResultToken(ctx, raw, nil)
+	if token, ok := v.cache.get(raw, v.now()); ok {
+		// The signature of the token has already been verified.
+		setResultToken(ctx, "", token)
+		if result, ok := resultFromContext(ctx); ok {
+			result.CacheHit = true
+		}
+		var err error
+		if v.audienceRouter != nil {
+			// The token may have been validated for the audiences of another request.
+			claims := jwt.Claims{}
+			if err = token.UnsafeClaimsWithoutVerification(&claims); err == nil {
+				err = v.checkAudience(ctx, claims)
+			}
+		}
+		if err == nil && len(values) > 0 {
+			err = token.UnsafeClaimsWithoutVerification(values...)
+		}
+		if err == nil && v.hasUseChecks() {
+			claims := map[string]interface{}{}
+			if err = token.UnsafeClaimsWithoutVerification(&claims); err == nil {
+				err = v.checkUse(ctx, claims)
+			}
+		}
+		return token, err
+	}
 
-	if err := v.validateTokenWithLeeway(token, leeway); err != nil {
+	token, err := parse(raw)
+	if err != nil {
 		return nil, err
 	}
+	setResultToken(ctx, "", token)
+
+	if err := v.validateToken(ctx, token, leeway, values...); err != nil {
+		return token, err
+	}
 
+	v.cache.add(raw, token, v.now())
 	return token, nil
 }
 
-func (v *JWTValidator) ValidateToken(token *jwt.JSONWebToken) error {
-	return v.validateTokenWithLeeway(token, jwt.DefaultLeeway)
+// parseRawToken parses the compact serialized token,
+// decrypting it first if it is a nested token.
+func (v *JWTValidator) parseRawToken(raw string) (*jwt.JSONWebToken, error) {
+	if !isEncrypted(raw) {
+		return v.parseSignedToken(raw)
+	}
+	if v.decryption == nil {
+		return nil, ErrEncryptedToken
+	}
+	return decryptNested(raw, v.decryption, v.limits)
 }
 
-func (v *JWTValidator) ValidateTokenWithLeeway(token *jwt.JSONWebToken, leeway time.Duration) error {
-	return v.validateTokenWithLeeway(token, leeway)
+// parseSignedToken parses the compact serialized
+// token within the limits of the validator.
+func (v *JWTValidator) parseSignedToken(raw string) (*jwt.JSONWebToken, error) {
+	return parseSignedWithLimits(raw, v.limits)
 }
 
-func (v *JWTValidator) validateTokenWithLeeway(token *jwt.JSONWebToken, leeway time.Duration) error {
+// report notifies the observer, the audit sink and the logger of the
+// validator of the final outcome of a validation lasting duration. Each
+// entry point reports its outcome once, after all its checks. The token is
+// nil if it could not be extracted or parsed, the claims are those of the
+// opaque tokens, if any.
+func (v *JWTValidator) report(ctx context.Context, duration time.Duration, token *jwt.JSONWebToken, claims map[string]interface{}, err error) {
+	v.observe(duration, err)
+	v.audit(ctx, token, claims, err)
+	if err != nil {
+		v.logFailure(token, err)
+	}
+}
+
+// validateToken validates the token and unmarshalls
+// its verified claims into the values, if any.
+// The context is passed to the secret provider.
+func (v *JWTValidator) validateToken(ctx context.Context, token *jwt.JSONWebToken, leeway time.Duration, values ...interface{}) error {
 	if len(token.Headers) < 1 {
 		return ErrNoJWTHeaders
 	}
 
+	// reject unsecured tokens before any key lookup
+	if isUnsecured(token) {
+		return ErrUnsecuredToken
+	}
+
+	// reject unsupported critical extensions before any key lookup
+	if err := v.checkCritical(token.Headers[0]); err != nil {
+		return err
+	}
+	if err := v.checkType(token.Headers[0]); err != nil {
+		return err
+	}
+
 	// trust secret provider when sig alg not configured and skip check
-	if v.config.signIn != "" {
+	if len(v.config.signIn) > 0 {
 		header := token.Headers[0]
-		if header.Algorithm != string(v.config.signIn) {
+		if !containsAlgorithm(v.config.signIn, header.Algorithm) {
 			return ErrInvalidAlgorithm
 		}
 	}
 
 	claims := jwt.Claims{}
-	key, err := v.config.secretProvider.GetSecret(token)
+	key, err := getSecret(ctx, v.config.secretProvider, token)
 	if err != nil {
 		return err
 	}
+	if !keyMatchesAlgorithm(key, token.Headers[0].Algorithm) {
+		return ErrKeyAlgorithmMismatch
+	}
 
-	if err = token.Claims(key, &claims); err != nil {
+	dests := []interface{}{&claims}
+	var custom map[string]interface{}
+	if len(v.checks) > 0 || v.hasUseChecks() {
+		dests = append(dests, &custom)
+	}
+	if err = verifyClaims(token, key, append(dests, values...)); err != nil {
 		return err
 	}
+	return v.checkVerifiedClaims(ctx, claims, custom, leeway)
+}
 
-	expected := v.config.expectedClaims.WithTime(time.Now())
-	err = claims.ValidateWithLeeway(expected, leeway)
-	return err
+// checkVerifiedClaims validates the registered claims of a verified token,
+// or of an introspected opaque token, and runs the claims checks and the
+// use checks of the validator on its claims.
+func (v *JWTValidator) checkVerifiedClaims(ctx context.Context, claims jwt.Claims, custom map[string]interface{}, leeway time.Duration) error {
+	now := v.now()
+	expected := v.config.expectedClaims.WithTime(now)
+	if _, routed := v.routedAudiences(ctx); routed || len(v.audiences) > 0 {
+		expected.Audience = nil
+	}
+	if v.issuers != nil {
+		expected.Issuer = ""
+	}
+	if err := claims.ValidateWithLeeway(expected, leeway); err != nil {
+		return newClaimError(err, claims)
+	}
+
+	if err := v.checkAudience(ctx, claims); err != nil {
+		return err
+	}
+
+	if v.issuers != nil && !v.issuers(claims.Issuer) {
+		return newClaimError(jwt.ErrInvalidIssuer, claims)
+	}
+
+	if claims.IssuedAt != 0 && now.Add(leeway).Before(claims.IssuedAt.Time()) {
+		return newClaimError(ErrIssuedInTheFuture, claims)
+	}
+
+	if err := v.checkClaims(custom); err != nil {
+		return err
+	}
+	return v.checkUse(ctx, custom)
+}
+
+// verifyClaims verifies the signature of the token with the key and
+// unmarshalls its claims into the dests, recovering the panics of go-jose.
+func verifyClaims(token *jwt.JSONWebToken, key interface{}, dests []interface{}) (err error) {
+	defer recoverPanic(panicInputToken, &err)
+	if err := token.Claims(key, dests...); err != nil {
+		return claimsError(err)
+	}
+	return nil
+}
+
+// now returns the current time of the clock of the validator.
+func (v *JWTValidator) now() time.Time {
+	if v.clock == nil {
+		return time.Now()
+	}
+	return v.clock()
+}
+
+// checkClaims runs the claims checks of the validator.
+func (v *JWTValidator) checkClaims(claims map[string]interface{}) error {
+	for _, check := range v.checks {
+		if err := check(claims); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasUseChecks reports whether the validator has checks run by checkUse.
+func (v *JWTValidator) hasUseChecks() bool {
+	return len(v.customValidators) > 0 || len(v.requestChecks) > 0 || v.revocation != nil || v.replay != nil
+}
+
+// checkUse runs the checks depending on the context, the request and the
+// previous uses of the token rather than on the token alone, in order: the
+// custom claims validators, the request checks, the revocation checker and
+// the replay detector. They also run for the tokens of the validation cache.
+func (v *JWTValidator) checkUse(ctx context.Context, claims map[string]interface{}) error {
+	if err := v.checkCustomClaims(ctx, claims); err != nil {
+		return err
+	}
+	if err := v.checkRequest(ctx, claims); err != nil {
+		return err
+	}
+	if err := v.checkRevocation(ctx, claims); err != nil {
+		return err
+	}
+	return v.checkReplay(claims)
+}
+
+// checkRequest runs the request checks of the validator
+// when the token is validated within a request.
+func (v *JWTValidator) checkRequest(ctx context.Context, claims map[string]interface{}) error {
+	r, ok := requestFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	for _, check := range v.requestChecks {
+		if err := check(r, claims); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Claims unmarshall the claims of the provided token
@@ -148,4 +720,58 @@ func (v *JWTValidator) Claims(token *jwt.JSONWebToken, values ...interface{}) er
 		return err
 	}
 	return token.Claims(key, values...)
-}
\ No newline at end of file
+}
+
+func containsAlgorithm(algorithms []jose.SignatureAlgorithm, algorithm string) bool {
+	for _, alg := range algorithms {
+		if string(alg) == algorithm {
+			return true
+		}
+	}
+	return false
+}
+
+// keyMatchesAlgorithm reports whether the key can verify signatures of the
+// algorithm: RSA keys for RS and PS, ECDSA keys of the matching curve for ES,
+// Ed25519 keys for EdDSA and byte secrets for HS. The algorithm of JWKs must
+// match when present. Keys of other types are left to the verification of go-jose.
+func keyMatchesAlgorithm(key interface{}, alg string) bool {
+	switch k := key.(type) {
+	case jose.JSONWebKey:
+		return (k.Algorithm == "" || k.Algorithm == alg) && keyMatchesAlgorithm(k.Key, alg)
+	case *jose.JSONWebKey:
+		return keyMatchesAlgorithm(*k, alg)
+	case *rsa.PublicKey, *rsa.PrivateKey:
+		return strings.HasPrefix(alg, "RS") || strings.HasPrefix(alg, "PS")
+	case *ecdsa.PublicKey:
+		return ecdsaMatchesAlgorithm(k.Curve, alg)
+	case *ecdsa.PrivateKey:
+		return ecdsaMatchesAlgorithm(k.Curve, alg)
+	case ed25519.PublicKey, ed25519.PrivateKey:
+		return alg == string(jose.EdDSA)
+	case []byte:
+		return strings.HasPrefix(alg, "HS")
+	}
+	return true
+}
+
+func ecdsaMatchesAlgorithm(curve elliptic.Curve, alg string) bool {
+	switch jose.SignatureAlgorithm(alg) {
+	case jose.ES256:
+		return curve == elliptic.P256()
+	case jose.ES384:
+		return curve == elliptic.P384()
+	case jose.ES512:
+		return curve == elliptic.P521()
+	}
+	return false
+}
+
+func containsAnyAudience(audience jwt.Audience, audiences []string) bool {
+	for _, aud := range audiences {
+		if audience.Contains(aud) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package auth0
+
+import (
+	"encoding/json"
+	"strings"
+
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// Auth0Claims are the claims commonly found in the access tokens issued by
+// Auth0, along with all the claims of the token to read the custom ones.
+// Use it with ValidateWithClaims or ValidateRequestClaims.
+type Auth0Claims struct {
+	jwt.Claims
+	// AuthorizedParty is the client ID of the application the token has been issued to.
+	AuthorizedParty string `json:"azp,omitempty"`
+	// Scope is the space-delimited list of the scopes of the token.
+	Scope string `json:"scope,omitempty"`
+	// Permissions are the permissions of the user for the API, added
+	// when RBAC is enabled for the API.
+	Permissions []string `json:"permissions,omitempty"`
+	// OrganizationID and OrganizationName identify the Organization the
+	// token has been issued for.
+	OrganizationID   string `json:"org_id,omitempty"`
+	OrganizationName string `json:"org_name,omitempty"`
+	// GrantType is the grant the token has been issued with,
+	// such as "client-credentials" for machine-to-machine tokens.
+	GrantType string `json:"gty,omitempty"`
+
+	// Raw holds all the claims of the token.
+	Raw map[string]interface{} `json:"-"`
+}
+
+// UnmarshalJSON decodes the claims, keeping all of them in Raw.
+func (c *Auth0Claims) UnmarshalJSON(data []byte) error {
+	type auth0Claims Auth0Claims
+	claims := auth0Claims{}
+	if err := json.Unmarshal(data, &claims); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &claims.Raw); err != nil {
+		return err
+	}
+	*c = Auth0Claims(claims)
+	return nil
+}
+
+// Scopes returns the scopes of the scope claim.
+func (c *Auth0Claims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// HasScope reports whether the scope claim contains the scope.
+func (c *Auth0Claims) HasScope(scope string) bool {
+	return containsString(c.Scopes(), scope)
+}
+
+// HasPermission reports whether the permissions claim contains the permission.
+func (c *Auth0Claims) HasPermission(permission string) bool {
+	return containsString(c.Permissions, permission)
+}
+
+// Claim returns the claim with the name, nil if the token has none.
+func (c *Auth0Claims) Claim(name string) interface{} {
+	return c.Raw[name]
+}
+
+// Namespace returns the custom claims of the namespace, such as
+// https://myapp.example.com/, keyed by their name without the namespace.
+// Auth0 requires the custom claims added by Actions to access tokens to
+// be namespaced. A trailing slash is added to the namespace if it has none.
+func (c *Auth0Claims) Namespace(namespace string) map[string]interface{} {
+	if !strings.HasSuffix(namespace, "/") {
+		namespace += "/"
+	}
+	claims := map[string]interface{}{}
+	for name, value := range c.Raw {
+		if strings.HasPrefix(name, namespace) && len(name) > len(namespace) {
+			claims[name[len(namespace):]] = value
+		}
+	}
+	return claims
+}
@@ -0,0 +1,48 @@
+package auth0
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestAuth0Claims(t *testing.T) {
+	registered := jwt.Claims{Issuer: defaultIssuer, Audience: defaultAudience, Subject: "auth0|123", Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+	token := getTestTokenWithClaims(jose.HS256, defaultSecret, registered, map[string]interface{}{
+		"azp":                          "client1",
+		"scope":                        "openid read:users",
+		"permissions":                  []string{"read:users", "write:users"},
+		"org_id":                       "org_123",
+		"org_name":                     "acme",
+		"gty":                          "password",
+		"https://example.com/roles":    []string{"admin"},
+		"https://example.com/plan":     "premium",
+		"https://other.example.com/id": "other",
+	})
+	validator := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), nil)
+
+	claims, err := ValidateRawTokenWithClaims[Auth0Claims](validator, token)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "auth0|123", claims.Subject)
+	assert.Equal(t, "client1", claims.AuthorizedParty)
+	assert.Equal(t, []string{"openid", "read:users"}, claims.Scopes())
+	assert.True(t, claims.HasScope("read:users"))
+	assert.False(t, claims.HasScope("write:users"))
+	assert.True(t, claims.HasPermission("write:users"))
+	assert.False(t, claims.HasPermission("delete:users"))
+	assert.Equal(t, "org_123", claims.OrganizationID)
+	assert.Equal(t, "acme", claims.OrganizationName)
+	assert.Equal(t, "password", claims.GrantType)
+	assert.Equal(t, "premium", claims.Claim("https://example.com/plan"))
+	assert.Nil(t, claims.Claim("missing"))
+
+	expected := map[string]interface{}{"roles": []interface{}{"admin"}, "plan": "premium"}
+	assert.Equal(t, expected, claims.Namespace("https://example.com/"))
+	assert.Equal(t, expected, claims.Namespace("https://example.com"))
+	assert.Empty(t, claims.Namespace("https://unknown.example.com/"))
+}
@@ -1,6 +1,7 @@
 package auth0
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"net/http"
@@ -133,7 +134,7 @@ func TestValidateRequestAndClaims(t *testing.T) {
 				jose.RS256,
 				defaultSecretRS256,
 			),
-			expectedErrorMsg: "error in cryptographic primitive",
+			expectedErrorMsg: ErrKeyAlgorithmMismatch.Error(),
 		},
 		{
 			name: "fail - invalid config secret provider",
@@ -400,7 +401,7 @@ func TestValidateRequestAndClaimsWithLeeway(t *testing.T) {
 				defaultSecretRS256,
 			),
 			leeway:           jwt.DefaultLeeway,
-			expectedErrorMsg: "error in cryptographic primitive",
+			expectedErrorMsg: ErrKeyAlgorithmMismatch.Error(),
 		},
 		{
 			name: "fail - invalid config secret provider",
@@ -539,3 +540,406 @@ func TestValidateRequestAndClaimsWithLeeway(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateWithAlgorithms(t *testing.T) {
+	configuration := NewConfigurationWithAlgorithms(
+		SecretProviderFunc(func(token *jwt.JSONWebToken) (interface{}, error) {
+			if token.Headers[0].Algorithm == string(jose.ES384) {
+				return defaultSecretES384.Public(), nil
+			}
+			return defaultSecret, nil
+		}),
+		defaultAudience,
+		defaultIssuer,
+		jose.HS256, jose.ES384,
+	)
+	validator := NewValidator(configuration, nil)
+
+	tests := []struct {
+		name             string
+		token            string
+		expectedErrorMsg string
+	}{
+		{
+			name:  "pass - token HS256",
+			token: getTestToken(defaultAudience, defaultIssuer, time.Now().Add(24*time.Hour), jose.HS256, defaultSecret),
+		},
+		{
+			name:  "pass - token ES384",
+			token: getTestToken(defaultAudience, defaultIssuer, time.Now().Add(24*time.Hour), jose.ES384, defaultSecretES384),
+		},
+		{
+			name:             "fail - token HS384",
+			token:            getTestToken(defaultAudience, defaultIssuer, time.Now().Add(24*time.Hour), jose.HS384, defaultSecret),
+			expectedErrorMsg: "algorithm is invalid",
+		},
+		{
+			name:             "fail - malformed token",
+			token:            "malformed",
+			expectedErrorMsg: "compact JWS format must have three parts",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			claims := map[string]interface{}{}
+			_, err := validator.ValidateRawToken(test.token, &claims)
+
+			if test.expectedErrorMsg != "" {
+				if err == nil {
+					t.Errorf("Validation should have failed with error with substring: " + test.expectedErrorMsg)
+				} else if !strings.Contains(err.Error(), test.expectedErrorMsg) {
+					t.Errorf("Validation should have failed with error with substring: " + test.expectedErrorMsg + ", but got: " + err.Error())
+				}
+			} else {
+				if err != nil {
+					t.Errorf("Validation should not have failed with error, but got: " + err.Error())
+				}
+				if claims["iss"] != defaultIssuer {
+					t.Errorf("Claims should have been unmarshalled, but got: %v", claims)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateRequestClaims(t *testing.T) {
+	token := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(24*time.Hour), jose.HS256, defaultSecret)
+	validator, req := genTestConfiguration(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), token)
+
+	claims := jwt.Claims{}
+	custom := map[string]interface{}{}
+	if _, err := validator.ValidateRequestClaims(req, &claims, &custom); err != nil {
+		t.Errorf("Validation should not have failed with error, but got: " + err.Error())
+	}
+	if claims.Issuer != defaultIssuer || custom["iss"] != defaultIssuer {
+		t.Errorf("Claims should have been unmarshalled, but got: %v, %v", claims, custom)
+	}
+
+	_, req = genTestConfiguration(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), "")
+	req.Header.Del("Authorization")
+	if _, err := validator.ValidateRequestClaims(req, &custom); err != ErrTokenNotFound {
+		t.Errorf("Validation should have failed with ErrTokenNotFound, but got: %v", err)
+	}
+}
+
+func TestValidateWithLeewayOption(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name          string
+		leeway        time.Duration
+		claims        jwt.Claims
+		expectedError error
+	}{
+		{
+			name:   "pass - expired within leeway",
+			leeway: 2 * time.Minute,
+			claims: jwt.Claims{Issuer: defaultIssuer, Audience: defaultAudience, Expiry: jwt.NewNumericDate(now.Add(-time.Minute))},
+		},
+		{
+			name:          "fail - expired with no leeway",
+			leeway:        0,
+			claims:        jwt.Claims{Issuer: defaultIssuer, Audience: defaultAudience, Expiry: jwt.NewNumericDate(now.Add(-5 * time.Second))},
+			expectedError: jwt.ErrExpired,
+		},
+		{
+			name:   "pass - not valid yet within leeway",
+			leeway: 10 * time.Second,
+			claims: jwt.Claims{Issuer: defaultIssuer, Audience: defaultAudience, NotBefore: jwt.NewNumericDate(now.Add(5 * time.Second)), Expiry: jwt.NewNumericDate(now.Add(time.Hour))},
+		},
+		{
+			name:          "fail - not valid yet with no leeway",
+			leeway:        0,
+			claims:        jwt.Claims{Issuer: defaultIssuer, Audience: defaultAudience, NotBefore: jwt.NewNumericDate(now.Add(5 * time.Second)), Expiry: jwt.NewNumericDate(now.Add(time.Hour))},
+			expectedError: jwt.ErrNotValidYet,
+		},
+		{
+			name:   "pass - issued in the future within leeway",
+			leeway: 10 * time.Second,
+			claims: jwt.Claims{Issuer: defaultIssuer, Audience: defaultAudience, IssuedAt: jwt.NewNumericDate(now.Add(5 * time.Second)), Expiry: jwt.NewNumericDate(now.Add(time.Hour))},
+		},
+		{
+			name:          "fail - issued in the future",
+			leeway:        0,
+			claims:        jwt.Claims{Issuer: defaultIssuer, Audience: defaultAudience, IssuedAt: jwt.NewNumericDate(now.Add(5 * time.Second)), Expiry: jwt.NewNumericDate(now.Add(time.Hour))},
+			expectedError: ErrIssuedInTheFuture,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configuration := NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256)
+			validator := NewValidator(configuration, nil, WithLeeway(test.leeway))
+
+			_, err := validator.ValidateRawToken(getTestTokenWithClaims(jose.HS256, defaultSecret, test.claims))
+			if !errors.Is(err, test.expectedError) {
+				t.Errorf("Validation should have returned %v, but got: %v", test.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestValidateWithAcceptedAudiences(t *testing.T) {
+	expiry := time.Now().Add(time.Hour).Unix()
+	tests := []struct {
+		name          string
+		claims        map[string]interface{}
+		expectedError error
+	}{
+		{
+			name:   "pass - single string audience",
+			claims: map[string]interface{}{"iss": defaultIssuer, "aud": "api2", "exp": expiry},
+		},
+		{
+			name:   "pass - audience array with one accepted audience",
+			claims: map[string]interface{}{"iss": defaultIssuer, "aud": []string{"other", "api1"}, "exp": expiry},
+		},
+		{
+			name:          "fail - no accepted audience",
+			claims:        map[string]interface{}{"iss": defaultIssuer, "aud": []string{"other"}, "exp": expiry},
+			expectedError: jwt.ErrInvalidAudience,
+		},
+		{
+			name:          "fail - no audience",
+			claims:        map[string]interface{}{"iss": defaultIssuer, "exp": expiry},
+			expectedError: jwt.ErrInvalidAudience,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configuration := NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256)
+			validator := NewValidator(configuration, nil, WithAcceptedAudiences("api1", "api2"))
+
+			_, err := validator.ValidateRawToken(getTestTokenWithClaims(jose.HS256, defaultSecret, test.claims))
+			if !errors.Is(err, test.expectedError) {
+				t.Errorf("Validation should have returned %v, but got: %v", test.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestValidateWithTrustedIssuers(t *testing.T) {
+	secretTenant1 := []byte("secret tenant1")
+	secretTenant2 := []byte("secret tenant2")
+	provider := NewIssuerSecretProvider(map[string]SecretProvider{
+		"https://tenant1.auth0.com/": NewKeyProvider(secretTenant1),
+		"https://tenant2.auth0.com/": NewKeyProvider(secretTenant2),
+		"https://tenant3.auth0.com/": NewKeyProvider(defaultSecret),
+	})
+	configuration := NewConfiguration(provider, defaultAudience, emptyIssuer, jose.HS256)
+	validator := NewValidator(configuration, nil, WithTrustedIssuers("https://tenant1.auth0.com/", "https://tenant2.auth0.com/"))
+
+	tests := []struct {
+		name             string
+		token            string
+		expectedErrorMsg string
+	}{
+		{
+			name:  "pass - tenant1",
+			token: getTestToken(defaultAudience, "https://tenant1.auth0.com/", time.Now().Add(time.Hour), jose.HS256, secretTenant1),
+		},
+		{
+			name:  "pass - tenant2",
+			token: getTestToken(defaultAudience, "https://tenant2.auth0.com/", time.Now().Add(time.Hour), jose.HS256, secretTenant2),
+		},
+		{
+			name:             "fail - tenant1 signed with key of tenant2",
+			token:            getTestToken(defaultAudience, "https://tenant1.auth0.com/", time.Now().Add(time.Hour), jose.HS256, secretTenant2),
+			expectedErrorMsg: "error in cryptographic primitive",
+		},
+		{
+			name:             "fail - issuer not trusted",
+			token:            getTestToken(defaultAudience, "https://tenant3.auth0.com/", time.Now().Add(time.Hour), jose.HS256, defaultSecret),
+			expectedErrorMsg: "invalid issuer claim (iss)",
+		},
+		{
+			name:             "fail - unknown issuer",
+			token:            getTestToken(defaultAudience, "https://tenant4.auth0.com/", time.Now().Add(time.Hour), jose.HS256, defaultSecret),
+			expectedErrorMsg: ErrUnknownIssuer.Error(),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := validator.ValidateRawToken(test.token)
+
+			if test.expectedErrorMsg != "" {
+				if err == nil {
+					t.Errorf("Validation should have failed with error with substring: " + test.expectedErrorMsg)
+				} else if !strings.Contains(err.Error(), test.expectedErrorMsg) {
+					t.Errorf("Validation should have failed with error with substring: " + test.expectedErrorMsg + ", but got: " + err.Error())
+				}
+			} else if err != nil {
+				t.Errorf("Validation should not have failed with error, but got: " + err.Error())
+			}
+		})
+	}
+}
+
+func TestMultiSecretProvider(t *testing.T) {
+	emergencyKey := genRSASSAJWK(jose.RS256, "emergency")
+	tenantKey := genRSASSAJWK(jose.RS256, "tenant")
+	unknownKey := genRSASSAJWK(jose.RS256, "unknown")
+	errDownload := errors.New("download failed")
+	failing := SecretProviderFunc(func(_ *jwt.JSONWebToken) (interface{}, error) {
+		return nil, errDownload
+	})
+
+	tests := []struct {
+		name          string
+		providers     []SecretProvider
+		key           jose.JSONWebKey
+		expectedError error
+	}{
+		{
+			name:      "pass - first provider",
+			providers: []SecretProvider{NewStaticSecretProvider(emergencyKey), NewStaticSecretProvider(tenantKey)},
+			key:       emergencyKey,
+		},
+		{
+			name:      "pass - second provider",
+			providers: []SecretProvider{NewStaticSecretProvider(emergencyKey), NewStaticSecretProvider(tenantKey)},
+			key:       tenantKey,
+		},
+		{
+			name:      "pass - after a failing provider",
+			providers: []SecretProvider{failing, NewStaticSecretProvider(tenantKey)},
+			key:       tenantKey,
+		},
+		{
+			name:          "fail - key not found",
+			providers:     []SecretProvider{NewStaticSecretProvider(emergencyKey), NewStaticSecretProvider(tenantKey)},
+			key:           unknownKey,
+			expectedError: ErrKeyNotFound,
+		},
+		{
+			name:          "fail - error of a failing provider",
+			providers:     []SecretProvider{failing, NewStaticSecretProvider(tenantKey)},
+			key:           unknownKey,
+			expectedError: errDownload,
+		},
+		{
+			name:          "fail - no provider",
+			key:           tenantKey,
+			expectedError: ErrKeyNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider := NewMultiSecretProvider(test.providers...)
+			validator := NewValidator(NewConfigurationTrustProvider(provider, defaultAudience, defaultIssuer), nil)
+
+			_, err := validator.ValidateRawToken(getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.RS256, test.key))
+			if !errors.Is(err, test.expectedError) {
+				t.Errorf("Validation should have returned %v, but got: %v", test.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestValidateUnsecuredToken(t *testing.T) {
+	provider := SecretProviderFunc(func(_ *jwt.JSONWebToken) (interface{}, error) {
+		t.Error("The secret provider should not have been called")
+		return defaultSecret, nil
+	})
+	validator := NewValidator(NewConfigurationTrustProvider(provider, defaultAudience, defaultIssuer), nil)
+
+	signed := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, defaultSecret)
+	parts := strings.Split(signed, ".")
+	none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." + parts[1]
+
+	for name, raw := range map[string]string{
+		"alg none":                  none + ".",
+		"alg none with a signature": none + "." + parts[2],
+		"empty signature":           parts[0] + "." + parts[1] + ".",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := validator.ValidateRawToken(raw); err != ErrUnsecuredToken {
+				t.Errorf("Validation should have failed with ErrUnsecuredToken, but got: %v", err)
+			}
+		})
+	}
+
+	token, err := jwt.ParseSigned(none + "." + parts[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := validator.ValidateToken(token); err != ErrUnsecuredToken {
+		t.Errorf("Validation should have failed with ErrUnsecuredToken, but got: %v", err)
+	}
+	if _, err := NewJWKClient(JWKClientOptions{}, nil).GetSecret(token); err != ErrUnsecuredToken {
+		t.Errorf("GetSecret should have failed with ErrUnsecuredToken, but got: %v", err)
+	}
+}
+
+func TestHMACSecretProviders(t *testing.T) {
+	rsaKey := genRSASSAJWK(jose.RS256, "keyRS256")
+	tests := []struct {
+		name     string
+		provider SecretProvider
+		token    string
+		wantErr  error
+	}{
+		{"pass - HS256", NewHS256SecretProvider(defaultSecret), getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, defaultSecret), nil},
+		{"pass - HS384", NewHS384SecretProvider(defaultSecret), getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS384, defaultSecret), nil},
+		{"pass - HS512", NewHS512SecretProvider(defaultSecret), getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS512, defaultSecret), nil},
+		{"fail - other HMAC algorithm", NewHS256SecretProvider(defaultSecret), getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS512, defaultSecret), ErrInvalidAlgorithm},
+		{"fail - asymmetric algorithm", NewHS256SecretProvider(defaultSecret), getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.RS256, rsaKey), ErrInvalidAlgorithm},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configuration := NewConfigurationWithAlgorithms(test.provider, defaultAudience, defaultIssuer, jose.HS256, jose.HS384, jose.HS512, jose.RS256)
+			validator := NewValidator(configuration, nil)
+			if _, err := validator.ValidateRawToken(test.token); err != test.wantErr {
+				t.Errorf("Validation should have failed with %v, but got: %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestWithClock(t *testing.T) {
+	issuedAt := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	token := getTestTokenWithClaims(jose.HS256, defaultSecret, jwt.Claims{
+		Issuer:    defaultIssuer,
+		Audience:  defaultAudience,
+		NotBefore: jwt.NewNumericDate(issuedAt),
+		Expiry:    jwt.NewNumericDate(issuedAt.Add(time.Hour)),
+	})
+
+	tests := []struct {
+		name          string
+		now           time.Time
+		expectedError error
+	}{
+		{"pass - within the lifetime", issuedAt.Add(30 * time.Minute), nil},
+		{"fail - expired", issuedAt.Add(2 * time.Hour), ErrTokenExpired},
+		{"fail - not valid yet", issuedAt.Add(-time.Hour), ErrTokenNotValidYet},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			now := test.now
+			validator := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), nil,
+				WithClock(func() time.Time { return now }), WithValidationCache(time.Hour, 10))
+			_, err := validator.ValidateRequest(genTestMiddlewareRequest(token))
+			if test.expectedError == nil {
+				if err != nil {
+					t.Fatalf("Validation should not have failed with error, but got: %v", err)
+				}
+				// The cached validation expires with the token on the clock.
+				now = now.Add(time.Hour)
+				_, err = validator.ValidateRequest(genTestMiddlewareRequest(token))
+				if !errors.Is(err, ErrTokenExpired) {
+					t.Errorf("Validation should have failed with ErrTokenExpired, but got: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, test.expectedError) {
+				t.Errorf("Validation should have failed with %v, but got: %v", test.expectedError, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+// Package auth0test provides an in-process JWKS server and signing keys, to
+// test the services validating tokens with go-auth0 without calling Auth0.
+package auth0test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/ed25519"
+	"gopkg.in/square/go-jose.v2"
+)
+
+var (
+	// ErrUnsupportedAlgorithm is returned when no key
+	// can be generated for the signing algorithm.
+	ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+)
+
+// GenerateKey generates the private key of the RS, PS, ES or EdDSA signing
+// algorithm, with the key ID. Its public part is published by the Server.
+func GenerateKey(alg jose.SignatureAlgorithm, kid string) (jose.JSONWebKey, error) {
+	var key interface{}
+	var err error
+	switch {
+	case strings.HasPrefix(string(alg), "RS"), strings.HasPrefix(string(alg), "PS"):
+		bits := 2048
+		if alg == jose.RS512 || alg == jose.PS512 {
+			bits = 4096
+		}
+		key, err = rsa.GenerateKey(rand.Reader, bits)
+	case alg == jose.ES256:
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case alg == jose.ES384:
+		key, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case alg == jose.ES512:
+		key, err = ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	case alg == jose.EdDSA:
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return jose.JSONWebKey{}, ErrUnsupportedAlgorithm
+	}
+	if err != nil {
+		return jose.JSONWebKey{}, err
+	}
+	return jose.JSONWebKey{Key: key, KeyID: kid, Algorithm: string(alg), Use: "sig"}, nil
+}
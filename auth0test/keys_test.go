@@ -0,0 +1,25 @@
+package auth0test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func TestGenerateKey(t *testing.T) {
+	for _, alg := range []jose.SignatureAlgorithm{jose.RS256, jose.PS384, jose.ES256, jose.ES384, jose.ES512, jose.EdDSA} {
+		t.Run(string(alg), func(t *testing.T) {
+			key, err := GenerateKey(alg, "kid")
+			assert.NoError(t, err)
+			assert.Equal(t, "kid", key.KeyID)
+			assert.Equal(t, string(alg), key.Algorithm)
+			assert.False(t, key.IsPublic())
+			public := key.Public()
+			assert.True(t, public.Valid())
+		})
+	}
+
+	_, err := GenerateKey(jose.HS256, "kid")
+	assert.Equal(t, ErrUnsupportedAlgorithm, err)
+}
@@ -0,0 +1,135 @@
+package auth0test
+
+import (
+	"errors"
+	"time"
+
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// DefaultTokenLifetime is the lifetime of the tokens of MintToken.
+const DefaultTokenLifetime = time.Hour
+
+var (
+	// ErrNoAlgorithm is returned when the signing algorithm of MintToken
+	// is neither set with WithAlgorithm nor the one of the key.
+	ErrNoAlgorithm = errors.New("no signing algorithm")
+)
+
+// MintOption configures the tokens of MintToken.
+type MintOption func(*mintConfig)
+
+type mintConfig struct {
+	alg         jose.SignatureAlgorithm
+	headers     map[jose.HeaderKey]interface{}
+	now         time.Time
+	expired     bool
+	notYetValid bool
+}
+
+// WithAlgorithm sets the signing algorithm of the token, such as HS256 for
+// a []byte secret. Defaults to the algorithm of the jose.JSONWebKey.
+func WithAlgorithm(alg jose.SignatureAlgorithm) MintOption {
+	return func(c *mintConfig) {
+		c.alg = alg
+	}
+}
+
+// WithHeader adds the header parameter to the token, such as
+// jose.HeaderType to set its typ header, "JWT" by default.
+func WithHeader(key jose.HeaderKey, value interface{}) MintOption {
+	return func(c *mintConfig) {
+		c.headers[key] = value
+	}
+}
+
+// WithNow sets the time the token is issued at. Defaults to time.Now.
+func WithNow(now time.Time) MintOption {
+	return func(c *mintConfig) {
+		c.now = now
+	}
+}
+
+// Expired makes the token expired for an hour,
+// overriding the iat and exp claims.
+func Expired() MintOption {
+	return func(c *mintConfig) {
+		c.expired = true
+	}
+}
+
+// NotYetValid makes the token valid in an hour only,
+// overriding the nbf and exp claims.
+func NotYetValid() MintOption {
+	return func(c *mintConfig) {
+		c.notYetValid = true
+	}
+}
+
+// MintToken signs the claims, a map or a struct such as jwt.Claims, with the
+// key, a private jose.JSONWebKey such as the SigningKey of the Server, or a
+// []byte secret along with WithAlgorithm. The iat and exp claims default to
+// now and DefaultTokenLifetime later.
+//
+//	raw, err := auth0test.MintToken(map[string]interface{}{
+//		"iss": server.Issuer(),
+//		"aud": "https://api.example.com/",
+//		"sub": "auth0|123",
+//	}, server.SigningKey(), auth0test.Expired())
+func MintToken(claims interface{}, key interface{}, opts ...MintOption) (string, error) {
+	return mintToken(nil, claims, key, opts)
+}
+
+// MintToken signs the claims with the SigningKey of the server, the iss
+// claim defaulting to the Issuer of the server. See MintToken.
+func (s *Server) MintToken(claims interface{}, opts ...MintOption) (string, error) {
+	return mintToken(map[string]interface{}{"iss": s.Issuer()}, claims, s.SigningKey(), opts)
+}
+
+// mintToken signs the claims, merged over the defaults.
+func mintToken(defaults map[string]interface{}, claims interface{}, key interface{}, opts []MintOption) (string, error) {
+	c := mintConfig{
+		headers: map[jose.HeaderKey]interface{}{jose.HeaderType: "JWT"},
+		now:     time.Now(),
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	if c.alg == "" {
+		if jwk, ok := key.(jose.JSONWebKey); ok {
+			c.alg = jose.SignatureAlgorithm(jwk.Algorithm)
+		}
+	}
+	if c.alg == "" {
+		return "", ErrNoAlgorithm
+	}
+
+	signerOpts := &jose.SignerOptions{}
+	for k, v := range c.headers {
+		signerOpts = signerOpts.WithHeader(k, v)
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: c.alg, Key: key}, signerOpts)
+	if err != nil {
+		return "", err
+	}
+
+	times := map[string]interface{}{
+		"iat": c.now.Unix(),
+		"exp": c.now.Add(DefaultTokenLifetime).Unix(),
+	}
+	for k, v := range defaults {
+		times[k] = v
+	}
+	overrides := map[string]interface{}{}
+	if c.expired {
+		overrides["iat"] = c.now.Add(-DefaultTokenLifetime - time.Hour).Unix()
+		overrides["exp"] = c.now.Add(-time.Hour).Unix()
+	}
+	if c.notYetValid {
+		overrides["nbf"] = c.now.Add(time.Hour).Unix()
+		overrides["exp"] = c.now.Add(time.Hour + DefaultTokenLifetime).Unix()
+	}
+	return jwt.Signed(signer).Claims(times).Claims(claims).Claims(overrides).CompactSerialize()
+}
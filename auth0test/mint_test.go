@@ -0,0 +1,97 @@
+package auth0test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/auth0-community/go-auth0"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func TestMintToken(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	client, err := auth0.NewJWKClientFromIssuer(server.Issuer(), auth0.JWKClientOptions{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configuration := auth0.NewConfiguration(client, []string{"audience"}, server.Issuer(), jose.RS256)
+	claims := map[string]interface{}{"aud": "audience", "sub": "auth0|123"}
+
+	tests := []struct {
+		name      string
+		opts      []MintOption
+		validator []auth0.ValidatorOption
+		err       error
+	}{
+		{name: "valid"},
+		{name: "expired", opts: []MintOption{Expired()}, err: auth0.ErrTokenExpired},
+		{name: "not yet valid", opts: []MintOption{NotYetValid()}, err: auth0.ErrTokenNotValidYet},
+		{
+			name:      "access token type",
+			opts:      []MintOption{WithHeader(jose.HeaderType, auth0.HeaderTypeAccessToken)},
+			validator: []auth0.ValidatorOption{auth0.RequireAccessTokenType()},
+		},
+		{
+			name:      "default type",
+			validator: []auth0.ValidatorOption{auth0.RequireAccessTokenType()},
+			err:       auth0.ErrInvalidTokenType,
+		},
+		{
+			name: "issued in the past",
+			opts: []MintOption{WithNow(time.Now().Add(-2 * DefaultTokenLifetime))},
+			err:  auth0.ErrTokenExpired,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			raw, err := server.MintToken(claims, test.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			validator := auth0.NewValidator(configuration, nil, test.validator...)
+			_, err = validator.ValidateRawToken(raw)
+			if test.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, test.err), "got %v", err)
+			}
+		})
+	}
+}
+
+func TestMintTokenKeys(t *testing.T) {
+	es256, err := GenerateKey(jose.ES256, "es256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := []byte("secret")
+
+	tests := []struct {
+		name     string
+		key      interface{}
+		opts     []MintOption
+		provider auth0.SecretProvider
+		alg      jose.SignatureAlgorithm
+	}{
+		{name: "ES256", key: es256, provider: auth0.NewKeyProvider(es256.Public().Key), alg: jose.ES256},
+		{name: "HS256", key: secret, opts: []MintOption{WithAlgorithm(jose.HS256)}, provider: auth0.NewKeyProvider(secret), alg: jose.HS256},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			raw, err := MintToken(map[string]interface{}{"iss": "issuer", "aud": "audience"}, test.key, test.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			validator := auth0.NewValidator(auth0.NewConfiguration(test.provider, []string{"audience"}, "issuer", test.alg), nil)
+			_, err = validator.ValidateRawToken(raw)
+			assert.NoError(t, err)
+		})
+	}
+
+	_, err = MintToken(map[string]interface{}{}, secret)
+	assert.Equal(t, ErrNoAlgorithm, err)
+}
@@ -0,0 +1,183 @@
+package auth0test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/auth0-community/go-auth0"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// Paths of the endpoints of the Server.
+const (
+	JWKSPath      = "/.well-known/jwks.json"
+	DiscoveryPath = "/.well-known/openid-configuration"
+)
+
+// Server is an in-process issuer publishing its JWKS and its OpenID
+// configuration, whose keys can be rotated and whose responses can fail or
+// be delayed to simulate an unavailable JWKS endpoint. It is safe for
+// concurrent use.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	keys     []jose.JSONWebKey // Private keys, the first one signs the tokens
+	status   int               // Status code of the failing responses, if not zero
+	delay    time.Duration
+	requests int // Number of requests of the JWKS
+	rotated  int // Number of keys generated by Rotate
+}
+
+// NewServer starts a Server publishing the public part of the keys. An RS256
+// key is generated when there is none. The caller should call Close when
+// finished, to shut it down.
+func NewServer(keys ...jose.JSONWebKey) *Server {
+	if len(keys) == 0 {
+		key, err := GenerateKey(jose.RS256, "key-0")
+		if err != nil {
+			panic(fmt.Sprintf("auth0test: failed to generate a key: %v", err))
+		}
+		keys = []jose.JSONWebKey{key}
+	}
+
+	s := &Server{keys: keys}
+	mux := http.NewServeMux()
+	mux.HandleFunc(JWKSPath, s.serveJWKS)
+	mux.HandleFunc(DiscoveryPath, s.serveDiscovery)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Issuer returns the issuer of the server, its URL with a trailing
+// slash as for Auth0 tenants, to use as the iss claim of the tokens.
+func (s *Server) Issuer() string {
+	return s.URL + "/"
+}
+
+// JWKSURI returns the URI of the JWKS of the server.
+func (s *Server) JWKSURI() string {
+	return s.URL + JWKSPath
+}
+
+// SigningKey returns the private key signing the tokens, the first key.
+func (s *Server) SigningKey() jose.JSONWebKey {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.keys[0]
+}
+
+// Keys returns the private keys of the server.
+func (s *Server) Keys() []jose.JSONWebKey {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]jose.JSONWebKey(nil), s.keys...)
+}
+
+// SetKeys replaces the keys of the server, the first one signing the tokens.
+func (s *Server) SetKeys(keys ...jose.JSONWebKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = append([]jose.JSONWebKey(nil), keys...)
+}
+
+// Rotate generates a new key of the algorithm signing the tokens from now on,
+// the previous keys being still published as during an Auth0 key rotation.
+// Call RemoveKey to revoke them.
+func (s *Server) Rotate(alg jose.SignatureAlgorithm) (jose.JSONWebKey, error) {
+	s.mu.Lock()
+	s.rotated++
+	kid := fmt.Sprintf("key-%d", s.rotated)
+	s.mu.Unlock()
+
+	key, err := GenerateKey(alg, kid)
+	if err != nil {
+		return jose.JSONWebKey{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = append([]jose.JSONWebKey{key}, s.keys...)
+	return key, nil
+}
+
+// RemoveKey stops publishing the key with the ID.
+func (s *Server) RemoveKey(kid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := s.keys[:0]
+	for _, key := range s.keys {
+		if key.KeyID != kid {
+			keys = append(keys, key)
+		}
+	}
+	s.keys = keys
+}
+
+// FailWith makes the JWKS endpoint respond with the status code,
+// such as http.StatusInternalServerError, until called with zero.
+func (s *Server) FailWith(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+}
+
+// SetDelay delays the responses of the JWKS endpoint, until the
+// client gives up, to simulate a slow endpoint. Zero removes the delay.
+func (s *Server) SetDelay(delay time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delay = delay
+}
+
+// Requests returns the number of requests of the JWKS served so far.
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func (s *Server) serveJWKS(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	status, delay := s.status, s.delay
+	jwks := auth0.JWKS{Keys: make([]jose.JSONWebKey, 0, len(s.keys))}
+	for _, key := range s.keys {
+		jwks.Keys = append(jwks.Keys, key.Public())
+	}
+	s.mu.Unlock()
+
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if status != 0 {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(jwks)
+}
+
+func (s *Server) serveDiscovery(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	algs := make([]string, 0, len(s.keys))
+	for _, key := range s.keys {
+		algs = append(algs, key.Algorithm)
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(auth0.OpenIDConfiguration{
+		Issuer:                           s.Issuer(),
+		JWKSURI:                          s.JWKSURI(),
+		IDTokenSigningAlgValuesSupported: algs,
+	})
+}
@@ -0,0 +1,82 @@
+package auth0test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/auth0-community/go-auth0"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func signTestToken(t *testing.T, key jose.JSONWebKey, issuer string) string {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.SignatureAlgorithm(key.Algorithm), Key: key}, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := jwt.Signed(signer).Claims(jwt.Claims{
+		Issuer:   issuer,
+		Audience: jwt.Audience{"audience"},
+		Expiry:   jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}).CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestServer(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	client, err := auth0.NewJWKClientFromIssuer(server.Issuer(), auth0.JWKClientOptions{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	validator := auth0.NewValidator(auth0.NewConfiguration(client, []string{"audience"}, server.Issuer(), jose.RS256), nil)
+
+	_, err = validator.ValidateRawToken(signTestToken(t, server.SigningKey(), server.Issuer()))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, server.Requests())
+
+	// A token signed by the key rotated in is validated once the JWKS is downloaded again.
+	previous := server.SigningKey()
+	key, err := server.Rotate(jose.RS256)
+	assert.NoError(t, err)
+	assert.Equal(t, key.KeyID, server.SigningKey().KeyID)
+	assert.Len(t, server.Keys(), 2)
+	_, err = validator.ValidateRawToken(signTestToken(t, key, server.Issuer()))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, server.Requests())
+
+	server.RemoveKey(previous.KeyID)
+	assert.Len(t, server.Keys(), 1)
+	server.SetKeys(previous)
+	assert.Equal(t, previous.KeyID, server.SigningKey().KeyID)
+}
+
+func TestServerFailures(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	server.FailWith(http.StatusInternalServerError)
+	client := auth0.NewJWKClient(auth0.JWKClientOptions{URI: server.JWKSURI()}, nil)
+	_, err := client.GetKey(server.SigningKey().KeyID)
+	var fetchErr *auth0.JWKSFetchError
+	assert.True(t, errors.As(err, &fetchErr), "got %v", err)
+
+	server.FailWith(0)
+	server.SetDelay(100 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = client.GetKeyContext(ctx, server.SigningKey().KeyID)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "got %v", err)
+
+	// The download outlives the caller whose deadline is exceeded.
+	_, err = client.GetKey(server.SigningKey().KeyID)
+	assert.NoError(t, err)
+}
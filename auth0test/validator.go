@@ -0,0 +1,53 @@
+package auth0test
+
+import (
+	"net/http"
+
+	"github.com/auth0-community/go-auth0"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// staticSecret is the key of the tokens of the static validators.
+var staticSecret = []byte("auth0test static validator secret")
+
+// StaticValidator creates a validator accepting every request, with or
+// without a token, as if sent with a valid token of the claims, a map or a
+// struct such as jwt.Claims. The token of the request, if any, is ignored.
+// The iat and exp claims default to the time of the request and
+// DefaultTokenLifetime later.
+//
+// The validator has no issuer or audience. The options, such as
+// auth0.RequireScopes, apply to the claims, to test the authorization
+// logic of the handlers with no key or token. Only the requests are faked:
+// ValidateRawToken, as used by the gRPC interceptors, rejects every token,
+// which can be signed with MintToken instead.
+func StaticValidator(claims interface{}, opts ...auth0.ValidatorOption) *auth0.JWTValidator {
+	extractor := auth0.RequestTokenExtractorFunc(func(_ *http.Request) (*jwt.JSONWebToken, error) {
+		raw, err := mintToken(nil, claims, staticSecret, []MintOption{WithAlgorithm(jose.HS256)})
+		if err != nil {
+			return nil, err
+		}
+		return jwt.ParseSigned(raw)
+	})
+	provider := auth0.NewKeyProvider(staticSecret)
+	return auth0.NewValidator(auth0.NewConfiguration(provider, nil, "", jose.HS256), extractor, opts...)
+}
+
+// ErrValidator creates a validator rejecting every request and signed token
+// with the error, such as auth0.ErrTokenExpired or auth0.ErrTokenNotFound,
+// to test the handling of the failed validations.
+func ErrValidator(err error) *auth0.JWTValidator {
+	extractor := auth0.RequestTokenExtractorFunc(func(_ *http.Request) (*jwt.JSONWebToken, error) {
+		return nil, err
+	})
+	return auth0.NewValidator(auth0.NewConfigurationTrustProvider(ErrSecretProvider(err), nil, ""), extractor)
+}
+
+// ErrSecretProvider creates a provider failing with the error for every
+// token, such as auth0.ErrKeyNotFound or a *auth0.JWKSFetchError.
+func ErrSecretProvider(err error) auth0.SecretProvider {
+	return auth0.SecretProviderFunc(func(_ *jwt.JSONWebToken) (interface{}, error) {
+		return nil, err
+	})
+}
@@ -0,0 +1,91 @@
+package auth0test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/auth0-community/go-auth0"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestStaticValidator(t *testing.T) {
+	claims := map[string]interface{}{"sub": "auth0|123", "scope": "read:messages"}
+
+	tests := []struct {
+		name   string
+		opts   []auth0.ValidatorOption
+		status int
+	}{
+		{name: "no options", status: http.StatusOK},
+		{name: "granted scope", opts: []auth0.ValidatorOption{auth0.RequireScopes("read:messages")}, status: http.StatusOK},
+		{name: "missing scope", opts: []auth0.ValidatorOption{auth0.RequireScopes("write:messages")}, status: http.StatusForbidden},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var sub interface{}
+			handler := auth0.Middleware(StaticValidator(claims, test.opts...))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				claims, _ := auth0.ClaimsFromContext(r.Context())
+				sub = claims["sub"]
+			}))
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.Equal(t, test.status, w.Code)
+			if test.status == http.StatusOK {
+				assert.Equal(t, "auth0|123", sub)
+			}
+		})
+	}
+
+	// The tokens of the requests are ignored.
+	validator := StaticValidator(jwt.Claims{Subject: "auth0|123"})
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer invalid")
+	var got jwt.Claims
+	_, err := validator.ValidateRequestClaims(r, &got)
+	assert.NoError(t, err)
+	assert.Equal(t, "auth0|123", got.Subject)
+
+	server := NewServer()
+	defer server.Close()
+	raw, err := server.MintToken(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = validator.ValidateRawToken(raw)
+	assert.True(t, errors.Is(err, auth0.ErrInvalidAlgorithm), "got %v", err)
+}
+
+func TestErrValidator(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{err: auth0.ErrTokenNotFound, status: http.StatusUnauthorized},
+		{err: auth0.ErrTokenExpired, status: http.StatusUnauthorized},
+		{err: auth0.ErrInsufficientScope, status: http.StatusForbidden},
+	}
+	for _, test := range tests {
+		t.Run(test.err.Error(), func(t *testing.T) {
+			handler := auth0.Middleware(ErrValidator(test.err))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				t.Error("the request passed the validator")
+			}))
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.Equal(t, test.status, w.Code)
+		})
+	}
+
+	// Signed tokens fail with the error of the secret provider.
+	raw, err := MintToken(map[string]interface{}{}, []byte("secret"), WithAlgorithm(jose.HS256))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ErrValidator(auth0.ErrKeyNotFound).ValidateRawToken(raw)
+	assert.True(t, errors.Is(err, auth0.ErrKeyNotFound), "got %v", err)
+}
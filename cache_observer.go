@@ -0,0 +1,57 @@
+package auth0
+
+import "time"
+
+// CacheObserver is notified of the key cache and JWKS download events of a
+// JWKClient and its key cacher, to report them to a monitoring system.
+// The methods are called synchronously and must be safe for concurrent use.
+type CacheObserver interface {
+	// OnHit is called when a requested key is served from the cache.
+	OnHit(keyID string)
+	// OnMiss is called when a requested key is not cached or expired.
+	OnMiss(keyID string)
+	// OnAdd is called when a downloaded key is added to the cache.
+	OnAdd(keyID string)
+	// OnEvict is called when a key cacher removes a key, because it
+	// expired or to bound its size.
+	OnEvict(keyID string)
+	// OnDownload is called after each attempt at downloading the JWKS with
+	// its duration, the status code of the response, 0 if none, and the error if any.
+	OnDownload(duration time.Duration, statusCode int, err error)
+}
+
+// ObservedKeyCacher is implemented by the key cachers reporting their
+// evictions. The JWKClient sets the observer of its options on its key cacher.
+type ObservedKeyCacher interface {
+	KeyCacher
+	SetCacheObserver(observer CacheObserver)
+}
+
+// SharedDownloadObserver is implemented by the observers reporting whether
+// the key lookups downloading the JWKS shared the download with simultaneous
+// lookups, such as to count the downloads saved by the deduplication.
+type SharedDownloadObserver interface {
+	CacheObserver
+	// OnSharedDownload is called when a key lookup downloaded the JWKS
+	// of the URI, reporting whether simultaneous lookups shared it.
+	OnSharedDownload(uri string, shared bool)
+}
+
+// NopCacheObserver is a CacheObserver ignoring every event.
+// Embed it to implement only some of the methods.
+type NopCacheObserver struct{}
+
+// OnHit implements the CacheObserver interface.
+func (NopCacheObserver) OnHit(string) {}
+
+// OnMiss implements the CacheObserver interface.
+func (NopCacheObserver) OnMiss(string) {}
+
+// OnAdd implements the CacheObserver interface.
+func (NopCacheObserver) OnAdd(string) {}
+
+// OnEvict implements the CacheObserver interface.
+func (NopCacheObserver) OnEvict(string) {}
+
+// OnDownload implements the CacheObserver interface.
+func (NopCacheObserver) OnDownload(time.Duration, int, error) {}
@@ -0,0 +1,85 @@
+package auth0
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+type recordingCacheObserver struct {
+	mu        sync.Mutex
+	events    []string
+	downloads []int
+}
+
+func (o *recordingCacheObserver) record(event string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.events = append(o.events, event)
+}
+
+func (o *recordingCacheObserver) OnHit(keyID string)   { o.record("hit " + keyID) }
+func (o *recordingCacheObserver) OnMiss(keyID string)  { o.record("miss " + keyID) }
+func (o *recordingCacheObserver) OnAdd(keyID string)   { o.record("add " + keyID) }
+func (o *recordingCacheObserver) OnEvict(keyID string) { o.record("evict " + keyID) }
+
+func (o *recordingCacheObserver) OnDownload(_ time.Duration, statusCode int, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.events = append(o.events, "download")
+	o.downloads = append(o.downloads, statusCode)
+}
+
+func TestCacheObserver(t *testing.T) {
+	opts, tokenRS256, _, err := genNewTestServer(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	observer := &recordingCacheObserver{}
+	opts.Observer = observer
+	client := NewJWKClientWithCache(opts, nil, NewLRUKeyCacher(1, time.Hour))
+
+	testGetSecret(t, client, tokenRS256)
+	testGetSecret(t, client, tokenRS256)
+
+	assert.Equal(t, []string{"miss keyRS256", "download", "evict keyES384", "add keyRS256", "hit keyRS256"}, observer.events)
+	assert.Equal(t, []int{http.StatusOK}, observer.downloads)
+}
+
+func TestCacheObserverDownloadFailure(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	observer := &recordingCacheObserver{}
+	client := NewJWKClient(JWKClientOptions{
+		URI:         ts.URL,
+		Observer:    observer,
+		RetryPolicy: RetryPolicy{MaxAttempts: 2, BaseBackoff: time.Millisecond},
+	}, nil)
+
+	_, err := client.GetKey("keyRS256")
+	assert.Error(t, err)
+	assert.Equal(t, []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable}, observer.downloads)
+}
+
+func TestMemoryKeyCacherObserver(t *testing.T) {
+	observer := &recordingCacheObserver{}
+	mkc := NewMemoryKeyCacher(time.Hour, 1).(ObservedKeyCacher)
+	mkc.SetCacheObserver(observer)
+
+	key := genRSASSAJWK("", "key1")
+	_, err := mkc.Add("key1", nil)
+	assert.Equal(t, ErrNoKeyFound, err)
+	_, _ = mkc.Add("key1", []jose.JSONWebKey{key})
+	time.Sleep(time.Millisecond)
+	key.KeyID = "key2"
+	_, _ = mkc.Add("key2", []jose.JSONWebKey{key})
+	assert.Equal(t, []string{"evict key1"}, observer.events)
+}
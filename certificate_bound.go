@@ -0,0 +1,75 @@
+package auth0
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"net/http"
+)
+
+var (
+	// ErrNoClientCertificate is returned when the token is bound to a
+	// certificate and the request has no TLS client certificate.
+	ErrNoClientCertificate = errors.New("no client certificate for the certificate-bound token")
+	// ErrCertificateThumbprintMismatch is returned when the TLS client
+	// certificate of the request is not the certificate the token is bound to.
+	ErrCertificateThumbprintMismatch = errors.New("client certificate does not match the cnf claim (x5t#S256)")
+)
+
+// confirmationClaim is the cnf claim of the tokens bound
+// to a key or certificate of the client (RFC 7800).
+type confirmationClaim struct {
+	// x5tS256 is the thumbprint of the client certificate
+	// of mutual-TLS bound tokens (RFC 8705).
+	x5tS256 string
+	// jkt is the thumbprint of the key of DPoP bound tokens (RFC 9449).
+	jkt string
+}
+
+// confirmation returns the cnf claim of the claims, if any.
+func confirmation(claims map[string]interface{}) (confirmationClaim, bool) {
+	cnf, ok := claims["cnf"].(map[string]interface{})
+	if !ok {
+		return confirmationClaim{}, false
+	}
+	x5t, _ := cnf["x5t#S256"].(string)
+	jkt, _ := cnf["jkt"].(string)
+	return confirmationClaim{x5tS256: x5t, jkt: jkt}, true
+}
+
+// CertificateThumbprint returns the x5t#S256 thumbprint of the certificate,
+// the base64url SHA-256 of its DER encoding.
+func CertificateThumbprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// WithCertificateBoundTokens makes the validator reject the certificate-bound
+// access tokens (RFC 8705), whose cnf claim has a x5t#S256 thumbprint, sent
+// with no TLS client certificate or with another certificate than the one
+// they are bound to. Tokens with no x5t#S256 thumbprint are accepted.
+//
+// The check only applies to tokens validated within a request, the client
+// certificate being read from r.TLS.PeerCertificates.
+func WithCertificateBoundTokens() ValidatorOption {
+	return func(v *JWTValidator) {
+		v.requestChecks = append(v.requestChecks, checkCertificateBinding)
+	}
+}
+
+func checkCertificateBinding(r *http.Request, claims map[string]interface{}) error {
+	cnf, ok := confirmation(claims)
+	if !ok || cnf.x5tS256 == "" {
+		return nil
+	}
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return ErrNoClientCertificate
+	}
+	thumbprint := CertificateThumbprint(r.TLS.PeerCertificates[0])
+	if subtle.ConstantTimeCompare([]byte(thumbprint), []byte(cnf.x5tS256)) != 1 {
+		return ErrCertificateThumbprintMismatch
+	}
+	return nil
+}
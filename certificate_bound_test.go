@@ -0,0 +1,84 @@
+package auth0
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestCertificateBoundTokens(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCert := genCertificate(t, "client", &key.PublicKey, nil, key, false)
+	otherCert := genCertificate(t, "other", &key.PublicKey, nil, key, false)
+	sum := sha256.Sum256(clientCert.Raw)
+	thumbprint := base64.RawURLEncoding.EncodeToString(sum[:])
+	assert.Equal(t, thumbprint, CertificateThumbprint(clientCert))
+
+	registered := jwt.Claims{Issuer: defaultIssuer, Audience: defaultAudience, Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+	boundToken := getTestTokenWithClaims(jose.HS256, defaultSecret, registered, map[string]interface{}{"cnf": map[string]interface{}{"x5t#S256": thumbprint}})
+	unboundToken := getTestTokenWithClaims(jose.HS256, defaultSecret, registered)
+
+	tests := []struct {
+		name          string
+		token         string
+		cert          *x509.Certificate
+		expectedError error
+	}{
+		{name: "pass - bound token with its certificate", token: boundToken, cert: clientCert},
+		{name: "pass - unbound token with no certificate", token: unboundToken},
+		{name: "pass - unbound token with a certificate", token: unboundToken, cert: otherCert},
+		{name: "fail - bound token with no certificate", token: boundToken, expectedError: ErrNoClientCertificate},
+		{name: "fail - bound token with another certificate", token: boundToken, cert: otherCert, expectedError: ErrCertificateThumbprintMismatch},
+	}
+
+	for _, test := range tests {
+		for _, cached := range []bool{false, true} {
+			name := test.name
+			opts := []ValidatorOption{WithCertificateBoundTokens()}
+			if cached {
+				name += " (cached)"
+				opts = append(opts, WithValidationCache(time.Minute, 0))
+			}
+			t.Run(name, func(t *testing.T) {
+				validator := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), nil, opts...)
+				if cached {
+					// Validate the token with its certificate first, so the next validation hits the cache.
+					req := genTestMiddlewareRequest(test.token)
+					req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{clientCert}}
+					_, err := validator.ValidateRequest(req)
+					assert.NoError(t, err)
+				}
+
+				req := genTestMiddlewareRequest(test.token)
+				if test.cert != nil {
+					req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{test.cert}}
+				}
+				_, err := validator.ValidateRequest(req)
+				if test.expectedError == nil {
+					assert.NoError(t, err)
+				} else {
+					assert.True(t, errors.Is(err, test.expectedError), "got %v", err)
+				}
+			})
+		}
+	}
+
+	t.Run("pass - raw tokens are not bound to a request", func(t *testing.T) {
+		validator := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), nil, WithCertificateBoundTokens())
+		_, err := validator.ValidateRawToken(boundToken)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,123 @@
+package auth0
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// DefaultCircuitOpenDuration is how long the circuit stays open
+// when CircuitBreakerPolicy.OpenDuration is not set.
+const DefaultCircuitOpenDuration = 30 * time.Second
+
+var (
+	// ErrCircuitOpen is returned when the JWKS is not downloaded
+	// because the circuit breaker of the client is open.
+	ErrCircuitOpen = errors.New("JWKS endpoint circuit breaker is open")
+)
+
+// CircuitBreakerPolicy configures the circuit breaker around the downloads
+// of the JWKS, so a failing endpoint does not add a full timeout to every
+// request of a key that is not cached. Once FailureThreshold downloads in a
+// row failed, the circuit opens: downloads fail fast with ErrCircuitOpen and
+// the cached keys are served, for OpenDuration. The circuit is then half-open,
+// letting HalfOpenProbes downloads through: it closes once one succeeds and
+// opens again once one fails. Downloads canceled by their caller are not
+// counted. The zero value disables the circuit breaker.
+type CircuitBreakerPolicy struct {
+	// FailureThreshold is the number of consecutive failed
+	// downloads opening the circuit. Zero disables the breaker.
+	FailureThreshold int
+	// OpenDuration is how long the circuit stays open before letting
+	// probes through. Defaults to DefaultCircuitOpenDuration.
+	OpenDuration time.Duration
+	// HalfOpenProbes is the number of simultaneous downloads let through
+	// while the circuit is half-open. Defaults to 1.
+	HalfOpenProbes int
+}
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+type circuitBreaker struct {
+	policy CircuitBreakerPolicy
+
+	mu       sync.Mutex
+	state    circuitState
+	failures int       // Consecutive failures while closed
+	openedAt time.Time // Time the circuit last opened
+	probes   int       // Downloads in flight while half-open
+}
+
+func newCircuitBreaker(policy CircuitBreakerPolicy) *circuitBreaker {
+	if policy.OpenDuration <= 0 {
+		policy.OpenDuration = DefaultCircuitOpenDuration
+	}
+	if policy.HalfOpenProbes <= 0 {
+		policy.HalfOpenProbes = 1
+	}
+	return &circuitBreaker{policy: policy}
+}
+
+// allow reports whether a download may start, counting
+// it as a probe when the circuit is half-open.
+func (b *circuitBreaker) allow() bool {
+	if b.policy.FailureThreshold <= 0 {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == circuitOpen {
+		if time.Since(b.openedAt) < b.policy.OpenDuration {
+			return false
+		}
+		b.state, b.probes = circuitHalfOpen, 0
+	}
+	if b.state == circuitHalfOpen {
+		if b.probes >= b.policy.HalfOpenProbes {
+			return false
+		}
+		b.probes++
+	}
+	return true
+}
+
+// done records the outcome of an allowed download and reports
+// whether the circuit opened or closed because of it.
+func (b *circuitBreaker) done(err error, canceled bool) (opened, closed bool) {
+	if b.policy.FailureThreshold <= 0 {
+		return false, false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == circuitHalfOpen && b.probes > 0 {
+		b.probes--
+	}
+	switch {
+	case err != nil && canceled:
+		return false, false
+	case err == nil:
+		closed = b.state != circuitClosed
+		b.state, b.failures = circuitClosed, 0
+		return false, closed
+	case b.state == circuitHalfOpen:
+		b.state, b.openedAt = circuitOpen, time.Now()
+		return true, false
+	case b.state == circuitClosed:
+		b.failures++
+		if b.failures >= b.policy.FailureThreshold {
+			b.state, b.openedAt, b.failures = circuitOpen, time.Now(), 0
+			return true, false
+		}
+	}
+	return false, false
+}
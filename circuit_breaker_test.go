@@ -0,0 +1,103 @@
+package auth0
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	failure := errors.New("failure")
+
+	t.Run("pass - disabled", func(t *testing.T) {
+		b := newCircuitBreaker(CircuitBreakerPolicy{})
+		for i := 0; i < 10; i++ {
+			assert.True(t, b.allow())
+			b.done(failure, false)
+		}
+	})
+
+	t.Run("pass - opens, probes and closes", func(t *testing.T) {
+		b := newCircuitBreaker(CircuitBreakerPolicy{FailureThreshold: 2, OpenDuration: 20 * time.Millisecond})
+
+		assert.True(t, b.allow())
+		b.done(failure, false)
+		assert.True(t, b.allow())
+		b.done(nil, false)
+		assert.True(t, b.allow())
+		b.done(failure, false)
+		assert.True(t, b.allow())
+		opened, _ := b.done(failure, false)
+		assert.True(t, opened)
+		assert.False(t, b.allow())
+
+		time.Sleep(30 * time.Millisecond)
+		assert.True(t, b.allow())
+		assert.False(t, b.allow(), "a single probe is let through")
+		opened, _ = b.done(failure, false)
+		assert.True(t, opened)
+		assert.False(t, b.allow())
+
+		time.Sleep(30 * time.Millisecond)
+		assert.True(t, b.allow())
+		_, closed := b.done(nil, false)
+		assert.True(t, closed)
+		assert.True(t, b.allow())
+	})
+
+	t.Run("pass - canceled downloads not counted", func(t *testing.T) {
+		b := newCircuitBreaker(CircuitBreakerPolicy{FailureThreshold: 1})
+		assert.True(t, b.allow())
+		b.done(context.Canceled, true)
+		assert.True(t, b.allow())
+	})
+}
+
+func TestJWKClientCircuitBreaker(t *testing.T) {
+	key := genRSASSAJWK(jose.RS256, "keyRS256")
+	jwks := genJWKSServer(key)
+	defer jwks.Close()
+
+	var failing int32
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if atomic.LoadInt32(&failing) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Cache-Control", "no-cache")
+		jwks.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	client := NewJWKClient(JWKClientOptions{
+		URI:             ts.URL,
+		UseCacheHeaders: true,
+		CircuitBreaker:  CircuitBreakerPolicy{FailureThreshold: 2, OpenDuration: time.Hour},
+	}, nil)
+
+	_, err := client.GetKey("keyRS256")
+	assert.NoError(t, err)
+
+	atomic.StoreInt32(&failing, 1)
+	for i := 0; i < 2; i++ {
+		_, err = client.GetKey("unknown")
+		assert.True(t, errors.Is(err, ErrJWKSFetchFailed), "got %v", err)
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	// The circuit is open: the cached key is served, the others fail fast.
+	_, err = client.GetKey("keyRS256")
+	assert.NoError(t, err)
+	_, err = client.GetKey("unknown")
+	assert.Equal(t, ErrCircuitOpen, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,159 @@
+package auth0
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var (
+	// ErrClaimConstraint is matched by the errors of the tokens whose claims
+	// do not satisfy the constraints of RequireClaim. Use errors.As with
+	// *ClaimConstraintError to retrieve the claim.
+	ErrClaimConstraint = errors.New("claim does not satisfy its constraint")
+)
+
+// ClaimConstraintError is returned when a claim of the token does not
+// satisfy its constraint. It matches ErrClaimConstraint with errors.Is.
+type ClaimConstraintError struct {
+	// Claim is the name of the claim, such as "gty".
+	Claim string
+	// Constraint describes the constraint, such as `equals "client-credentials"`.
+	Constraint string
+	// Value is the value of the claim in the token, nil if missing.
+	Value interface{}
+}
+
+func (e *ClaimConstraintError) Error() string {
+	if e.Value == nil {
+		return fmt.Sprintf("%s: %s %s, missing", ErrClaimConstraint, e.Claim, e.Constraint)
+	}
+	return fmt.Sprintf("%s: %s %s, got %s", ErrClaimConstraint, e.Claim, e.Constraint, formatClaimValue(e.Value))
+}
+
+// Is makes errors.Is match ErrClaimConstraint.
+func (e *ClaimConstraintError) Is(target error) bool {
+	return target == ErrClaimConstraint
+}
+
+// ClaimConstraint builds the ValidatorOptions constraining the value of a
+// top-level claim, see RequireClaim.
+type ClaimConstraint struct {
+	name string
+}
+
+// RequireClaim constrains the value of the named top-level claim, the tokens
+// whose claim is missing or does not satisfy the constraint being rejected
+// with a *ClaimConstraintError. Numbers are compared by value, whatever
+// their Go type.
+//
+//	validator := NewValidator(configuration, nil,
+//		RequireClaim("gty").Equals("client-credentials"),
+//		RequireClaim("azp").OneOf("client-a", "client-b"),
+//	)
+func RequireClaim(name string) ClaimConstraint {
+	return ClaimConstraint{name: name}
+}
+
+// Equals requires the claim to equal the value.
+func (c ClaimConstraint) Equals(value interface{}) ValidatorOption {
+	return c.constraint(fmt.Sprintf("equals %s", formatClaimValue(value)), func(claim interface{}) bool {
+		return claimValueEqual(claim, value)
+	})
+}
+
+// IsTrue requires the claim to be the boolean true,
+// such as the email_verified claim.
+func (c ClaimConstraint) IsTrue() ValidatorOption {
+	return c.Equals(true)
+}
+
+// OneOf requires the claim to equal any of the values.
+func (c ClaimConstraint) OneOf(values ...interface{}) ValidatorOption {
+	return c.constraint(fmt.Sprintf("one of %s", formatClaimValue(values)), func(claim interface{}) bool {
+		for _, value := range values {
+			if claimValueEqual(claim, value) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Contains requires the claim to be a list containing the value,
+// such as a roles claim.
+func (c ClaimConstraint) Contains(value interface{}) ValidatorOption {
+	return c.constraint(fmt.Sprintf("contains %s", formatClaimValue(value)), func(claim interface{}) bool {
+		list, ok := claim.([]interface{})
+		if !ok {
+			return false
+		}
+		for _, item := range list {
+			if claimValueEqual(item, value) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// constraint appends the check of the claim against the
+// predicate, described by the constraint, to the validator.
+func (c ClaimConstraint) constraint(constraint string, satisfied func(claim interface{}) bool) ValidatorOption {
+	return func(v *JWTValidator) {
+		v.checks = append(v.checks, func(claims map[string]interface{}) error {
+			claim := claims[c.name]
+			if claim == nil || !satisfied(claim) {
+				return &ClaimConstraintError{Claim: c.name, Constraint: constraint, Value: claim}
+			}
+			return nil
+		})
+	}
+}
+
+// claimValueEqual reports whether the value of a decoded claim equals the
+// value of a constraint, comparing the numbers by value.
+func claimValueEqual(claim, value interface{}) bool {
+	if a, ok := claimNumber(claim); ok {
+		b, ok := claimNumber(value)
+		return ok && a == b
+	}
+	return reflect.DeepEqual(claim, value)
+}
+
+// claimNumber returns the value of the number as a float64,
+// the type of the numbers of the decoded claims.
+func claimNumber(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// formatClaimValue formats the value of a constraint as JSON.
+func formatClaimValue(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
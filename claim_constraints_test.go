@@ -0,0 +1,95 @@
+package auth0
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestRequireClaim(t *testing.T) {
+	tests := []struct {
+		name       string
+		constraint ValidatorOption
+		claims     map[string]interface{}
+		expected   string
+	}{
+		{
+			name:       "pass - equals",
+			constraint: RequireClaim("gty").Equals("client-credentials"),
+			claims:     map[string]interface{}{"gty": "client-credentials"},
+		},
+		{
+			name:       "pass - equals number",
+			constraint: RequireClaim("tier").Equals(2),
+			claims:     map[string]interface{}{"tier": 2},
+		},
+		{
+			name:       "pass - is true",
+			constraint: RequireClaim("email_verified").IsTrue(),
+			claims:     map[string]interface{}{"email_verified": true},
+		},
+		{
+			name:       "pass - one of",
+			constraint: RequireClaim("azp").OneOf("client-a", "client-b"),
+			claims:     map[string]interface{}{"azp": "client-b"},
+		},
+		{
+			name:       "pass - contains",
+			constraint: RequireClaim("roles").Contains("admin"),
+			claims:     map[string]interface{}{"roles": []string{"user", "admin"}},
+		},
+		{
+			name:       "fail - not equal",
+			constraint: RequireClaim("gty").Equals("client-credentials"),
+			claims:     map[string]interface{}{"gty": "password"},
+			expected:   `claim does not satisfy its constraint: gty equals "client-credentials", got "password"`,
+		},
+		{
+			name:       "fail - missing",
+			constraint: RequireClaim("gty").Equals("client-credentials"),
+			claims:     map[string]interface{}{},
+			expected:   `claim does not satisfy its constraint: gty equals "client-credentials", missing`,
+		},
+		{
+			name:       "fail - not true",
+			constraint: RequireClaim("email_verified").IsTrue(),
+			claims:     map[string]interface{}{"email_verified": "true"},
+			expected:   `claim does not satisfy its constraint: email_verified equals true, got "true"`,
+		},
+		{
+			name:       "fail - none of",
+			constraint: RequireClaim("azp").OneOf("client-a", "client-b"),
+			claims:     map[string]interface{}{"azp": "client-c"},
+			expected:   `claim does not satisfy its constraint: azp one of ["client-a","client-b"], got "client-c"`,
+		},
+		{
+			name:       "fail - not contained",
+			constraint: RequireClaim("roles").Contains("admin"),
+			claims:     map[string]interface{}{"roles": "admin"},
+			expected:   `claim does not satisfy its constraint: roles contains "admin", got "admin"`,
+		},
+	}
+
+	configuration := NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256)
+	registered := jwt.Claims{Issuer: defaultIssuer, Audience: defaultAudience, Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			validator := NewValidator(configuration, nil, test.constraint)
+			_, err := validator.ValidateRawToken(getTestTokenWithClaims(jose.HS256, defaultSecret, registered, test.claims))
+			if test.expected == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.True(t, errors.Is(err, ErrClaimConstraint), "got %v", err)
+			var constraintErr *ClaimConstraintError
+			if assert.True(t, errors.As(err, &constraintErr)) {
+				assert.EqualError(t, constraintErr, test.expected)
+			}
+		})
+	}
+}
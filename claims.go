@@ -0,0 +1,34 @@
+package auth0
+
+import (
+	"net/http"
+
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// ValidateWithClaims validates the token within the http request
+// and returns its claims unmarshalled into a new T. Embedding
+// jwt.Claims in T decodes both registered and custom claims in one pass.
+func ValidateWithClaims[T any](v *JWTValidator, r *http.Request) (*T, error) {
+	claims := new(T)
+	if _, err := v.ValidateRequestClaims(r, claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// ValidateRawTokenWithClaims parses and validates the compact serialized
+// token and returns its claims unmarshalled into a new T.
+func ValidateRawTokenWithClaims[T any](v *JWTValidator, raw string) (*T, error) {
+	claims := new(T)
+	if _, err := v.ValidateRawToken(raw, claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// ClaimsInto unmarshalls the claims of the provided token into dest,
+// verifying its signature with the secret provider of the validator.
+func ClaimsInto[T any](v *JWTValidator, token *jwt.JSONWebToken, dest *T) error {
+	return v.Claims(token, dest)
+}
@@ -0,0 +1,46 @@
+package auth0
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+type testCustomClaims struct {
+	jwt.Claims
+	Scope string `json:"scope"`
+}
+
+func TestValidateWithClaims(t *testing.T) {
+	raw := getTestTokenWithClaims(jose.HS256, defaultSecret, jwt.Claims{
+		Issuer:   defaultIssuer,
+		Audience: defaultAudience,
+		Expiry:   jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}, map[string]interface{}{"scope": "read:users"})
+	validator, req := genTestConfiguration(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), raw)
+
+	claims, err := ValidateWithClaims[testCustomClaims](validator, req)
+	if assert.NoError(t, err) {
+		assert.Equal(t, defaultIssuer, claims.Issuer)
+		assert.Equal(t, "read:users", claims.Scope)
+	}
+
+	claims, err = ValidateRawTokenWithClaims[testCustomClaims](validator, raw)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "read:users", claims.Scope)
+	}
+
+	_, err = ValidateRawTokenWithClaims[testCustomClaims](validator, getTestToken(defaultAudience, "invalid iss", time.Now().Add(time.Hour), jose.HS256, defaultSecret))
+	assert.True(t, errors.Is(err, jwt.ErrInvalidIssuer))
+
+	token, err := jwt.ParseSigned(raw)
+	if assert.NoError(t, err) {
+		dest := testCustomClaims{}
+		assert.NoError(t, ClaimsInto(validator, token, &dest))
+		assert.Equal(t, "read:users", dest.Scope)
+	}
+}
@@ -0,0 +1,146 @@
+package auth0
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"time"
+
+	"golang.org/x/crypto/ed25519"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// DefaultClientAssertionLifetime is the default lifetime of the client assertions.
+const DefaultClientAssertionLifetime = time.Minute
+
+// ErrUnsupportedAssertionKey is returned when no signature algorithm
+// can be derived from the key of the client assertions.
+var ErrUnsupportedAssertionKey = errors.New("unsupported client assertion key")
+
+const clientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+// PrivateKeyJWT authenticates the client to the token endpoint with
+// assertions signed with its private key (private_key_jwt, RFC 7523)
+// instead of a client secret.
+type PrivateKeyJWT struct {
+	// Key is the private key signing the assertions. Its kid header is the
+	// kid of the key, or its RFC 7638 thumbprint if it has none.
+	Key jose.JSONWebKey
+	// Algorithm signs the assertions. Defaults to the algorithm of the key,
+	// or RS256, ES256, ES384, ES512 or EdDSA according to its type.
+	Algorithm jose.SignatureAlgorithm
+	// Lifetime is the lifetime of each assertion.
+	// Defaults to DefaultClientAssertionLifetime.
+	Lifetime time.Duration
+	// Audience is the aud claim of the assertions. Defaults to the
+	// tenant of the token endpoint, such as https://mydomain.eu.auth0.com/.
+	Audience string
+}
+
+// assertion returns a new assertion of the client for the token endpoint.
+func (p *PrivateKeyJWT) assertion(clientID, tokenURL string) (string, error) {
+	key := p.Key
+	if key.KeyID == "" {
+		kid, err := KeyThumbprint(key)
+		if err != nil {
+			return "", err
+		}
+		key.KeyID = kid
+	}
+	alg := p.Algorithm
+	if alg == "" {
+		alg = assertionAlgorithm(key)
+	}
+	if alg == "" {
+		return "", ErrUnsupportedAssertionKey
+	}
+	lifetime := p.Lifetime
+	if lifetime <= 0 {
+		lifetime = DefaultClientAssertionLifetime
+	}
+	audience := p.Audience
+	if audience == "" {
+		u, err := url.Parse(tokenURL)
+		if err != nil {
+			return "", err
+		}
+		audience = u.Scheme + "://" + u.Host + "/"
+	}
+	jti, err := randomString(16)
+	if err != nil {
+		return "", err
+	}
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: alg, Key: key}, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	claims := jwt.Claims{
+		Issuer:   clientID,
+		Subject:  clientID,
+		Audience: jwt.Audience{audience},
+		ID:       jti,
+		IssuedAt: jwt.NewNumericDate(now),
+		Expiry:   jwt.NewNumericDate(now.Add(lifetime)),
+	}
+	return jwt.Signed(signer).Claims(claims).CompactSerialize()
+}
+
+// assertionAlgorithm returns the signature algorithm of the key.
+func assertionAlgorithm(key jose.JSONWebKey) jose.SignatureAlgorithm {
+	if key.Algorithm != "" {
+		return jose.SignatureAlgorithm(key.Algorithm)
+	}
+	switch k := key.Key.(type) {
+	case *rsa.PrivateKey:
+		return jose.RS256
+	case *ecdsa.PrivateKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return jose.ES256
+		case elliptic.P384():
+			return jose.ES384
+		case elliptic.P521():
+			return jose.ES512
+		}
+	case ed25519.PrivateKey:
+		return jose.EdDSA
+	}
+	return ""
+}
+
+// authenticateClient adds the credentials of the client to the form of a
+// request to the token endpoint: a client assertion if privateKeyJWT is
+// set, the client secret otherwise.
+func authenticateClient(form url.Values, clientID, clientSecret string, privateKeyJWT *PrivateKeyJWT, tokenURL string) error {
+	if clientID == "" {
+		return nil
+	}
+	form.Set("client_id", clientID)
+	if privateKeyJWT == nil {
+		form.Set("client_secret", clientSecret)
+		return nil
+	}
+	assertion, err := privateKeyJWT.assertion(clientID, tokenURL)
+	if err != nil {
+		return err
+	}
+	form.Set("client_assertion_type", clientAssertionType)
+	form.Set("client_assertion", assertion)
+	return nil
+}
+
+// randomString returns n random bytes, base64url encoded.
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
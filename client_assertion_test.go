@@ -0,0 +1,115 @@
+package auth0
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestPrivateKeyJWT(t *testing.T) {
+	rsaKey := genRSASSAJWK(jose.RS256, "keyRS256")
+	rsaKey.Algorithm = ""
+	ecKey := genECDSAJWK(jose.ES384, "")
+	ecKey.Algorithm = ""
+	ecThumbprint, err := KeyThumbprint(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name             string
+		privateKeyJWT    PrivateKeyJWT
+		expectedKID      string
+		expectedAlg      string
+		expectedAudience string
+		expectedLifetime time.Duration
+	}{
+		{
+			name:             "pass - RSA key with kid",
+			privateKeyJWT:    PrivateKeyJWT{Key: rsaKey},
+			expectedKID:      "keyRS256",
+			expectedAlg:      "RS256",
+			expectedAudience: "tenant",
+			expectedLifetime: DefaultClientAssertionLifetime,
+		},
+		{
+			name:             "pass - ECDSA key identified by its thumbprint",
+			privateKeyJWT:    PrivateKeyJWT{Key: ecKey},
+			expectedKID:      ecThumbprint,
+			expectedAlg:      "ES384",
+			expectedAudience: "tenant",
+			expectedLifetime: DefaultClientAssertionLifetime,
+		},
+		{
+			name:             "pass - algorithm, audience and lifetime",
+			privateKeyJWT:    PrivateKeyJWT{Key: rsaKey, Algorithm: jose.PS256, Audience: "https://login.example.com/", Lifetime: 5 * time.Minute},
+			expectedKID:      "keyRS256",
+			expectedAlg:      "PS256",
+			expectedAudience: "https://login.example.com/",
+			expectedLifetime: 5 * time.Minute,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var ts *httptest.Server
+			ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "client", r.PostFormValue("client_id"))
+				assert.Empty(t, r.PostFormValue("client_secret"))
+				assert.Equal(t, "urn:ietf:params:oauth:client-assertion-type:jwt-bearer", r.PostFormValue("client_assertion_type"))
+
+				assertion, err := jwt.ParseSigned(r.PostFormValue("client_assertion"))
+				if !assert.NoError(t, err) {
+					return
+				}
+				assert.Equal(t, test.expectedKID, assertion.Headers[0].KeyID)
+				assert.Equal(t, test.expectedAlg, assertion.Headers[0].Algorithm)
+
+				claims := jwt.Claims{}
+				assert.NoError(t, assertion.Claims(test.privateKeyJWT.Key.Public().Key, &claims))
+				audience := test.expectedAudience
+				if audience == "tenant" {
+					audience = ts.URL + "/"
+				}
+				assert.NoError(t, claims.Validate(jwt.Expected{Issuer: "client", Subject: "client", Audience: jwt.Audience{audience}}))
+				assert.NotEmpty(t, claims.ID)
+				assert.Equal(t, test.expectedLifetime, claims.Expiry.Time().Sub(claims.IssuedAt.Time()))
+
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"access_token":"token","expires_in":3600}`))
+			}))
+			defer ts.Close()
+
+			privateKeyJWT := test.privateKeyJWT
+			credentials := NewClientCredentials(ClientCredentialsOptions{
+				TokenURL:      ts.URL + "/oauth/token",
+				ClientID:      "client",
+				PrivateKeyJWT: &privateKeyJWT,
+			})
+			token, err := credentials.Token(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, "token", token.AccessToken)
+		})
+	}
+
+	t.Run("pass - unique assertions", func(t *testing.T) {
+		p := &PrivateKeyJWT{Key: rsaKey}
+		first, err := p.assertion("client", "https://mydomain.eu.auth0.com/oauth/token")
+		assert.NoError(t, err)
+		second, err := p.assertion("client", "https://mydomain.eu.auth0.com/oauth/token")
+		assert.NoError(t, err)
+		assert.NotEqual(t, first, second)
+	})
+
+	t.Run("fail - unsupported key", func(t *testing.T) {
+		p := &PrivateKeyJWT{Key: jose.JSONWebKey{Key: defaultSecret, KeyID: "secret"}}
+		_, err := p.assertion("client", "https://mydomain.eu.auth0.com/oauth/token")
+		assert.Equal(t, ErrUnsupportedAssertionKey, err)
+	})
+}
@@ -0,0 +1,221 @@
+package auth0
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultTokenExpiryDelta is the default time before their expiry
+// the cached access tokens are requested again.
+const DefaultTokenExpiryDelta = time.Minute
+
+// DefaultTokenRequestTimeout is the default time limit of the requests
+// to the token endpoint that outlive the callers waiting for them.
+const DefaultTokenRequestTimeout = 30 * time.Second
+
+// Token is an access token issued by a token endpoint.
+type Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type,omitempty"`
+	Scope       string `json:"scope,omitempty"`
+	ExpiresIn   int64  `json:"expires_in,omitempty"`
+	// IssuedTokenType is the type of the token issued by a token exchange,
+	// such as TokenTypeAccessToken.
+	IssuedTokenType string `json:"issued_token_type,omitempty"`
+	// RefreshToken and IDToken are issued by the authorization code and
+	// refresh token grants, along with the access token.
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	// Expiry is the time the token expires, computed from ExpiresIn when
+	// the token is received. It is zero when the endpoint does not say, the
+	// token being requested again on every call rather than cached.
+	Expiry time.Time `json:"-"`
+}
+
+// expired reports whether the token expires within the delta.
+func (t *Token) expired(now time.Time, delta time.Duration) bool {
+	return !t.Expiry.IsZero() && !now.Add(delta).Before(t.Expiry)
+}
+
+// TokenSource is implemented by the sources of access tokens, such as
+// ClientCredentials, to call other APIs.
+type TokenSource interface {
+	Token(ctx context.Context) (*Token, error)
+}
+
+// ClientCredentialsOptions configures the client credentials
+// grant client requesting machine-to-machine access tokens.
+type ClientCredentialsOptions struct {
+	// TokenURL is the token endpoint, such as
+	// https://mydomain.eu.auth0.com/oauth/token.
+	TokenURL string
+	// ClientID and ClientSecret authenticate the client
+	// to the endpoint in the body of the request.
+	ClientID     string
+	ClientSecret string
+	// PrivateKeyJWT authenticates the client with signed
+	// assertions instead of ClientSecret when set.
+	PrivateKeyJWT *PrivateKeyJWT
+	// Audience is the identifier of the API the tokens are requested for.
+	Audience string
+	// Scopes are the scopes requested, all the scopes granted
+	// to the client by Auth0 when empty.
+	Scopes []string
+	// Params are additional parameters of the request, such as organization.
+	Params url.Values
+	Client *http.Client
+	// ExpiryDelta is how long before its expiry the token is requested
+	// again, so it does not expire in flight. Defaults to DefaultTokenExpiryDelta.
+	ExpiryDelta time.Duration
+	// RequestTimeout bounds each request of a token, which is not canceled
+	// with the callers waiting for it. Defaults to DefaultTokenRequestTimeout.
+	RequestTimeout time.Duration
+}
+
+// ClientCredentials requests access tokens with the OAuth2 client
+// credentials grant, such as Auth0 machine-to-machine tokens for calling
+// other APIs. The token is cached until shortly before its expiry.
+type ClientCredentials struct {
+	options ClientCredentialsOptions
+
+	mu    sync.Mutex // Used to lock reads/writes to the token
+	token *Token
+	sf    singleflight.Group // Used to collapse the requests of tokens
+}
+
+// NewClientCredentials creates a new ClientCredentials
+// instance from the provided options.
+func NewClientCredentials(options ClientCredentialsOptions) *ClientCredentials {
+	if options.Client == nil {
+		options.Client = http.DefaultClient
+	}
+	if options.ExpiryDelta <= 0 {
+		options.ExpiryDelta = DefaultTokenExpiryDelta
+	}
+	if options.RequestTimeout <= 0 {
+		options.RequestTimeout = DefaultTokenRequestTimeout
+	}
+	return &ClientCredentials{options: options}
+}
+
+// Token returns the cached access token, requesting a new one when there is
+// none or it is about to expire. Simultaneous calls result in a single
+// request, every caller waiting for it until its own context is done.
+func (c *ClientCredentials) Token(ctx context.Context) (*Token, error) {
+	c.mu.Lock()
+	token := c.token
+	c.mu.Unlock()
+	if token != nil && !token.expired(time.Now(), c.options.ExpiryDelta) {
+		return token, nil
+	}
+
+	ch := c.sf.DoChan("", func() (interface{}, error) {
+		ctx, cancel := detach(ctx, c.options.RequestTimeout)
+		defer cancel()
+		form, err := c.form()
+		if err != nil {
+			return nil, err
+		}
+		token, err := requestToken(ctx, c.options.Client, c.options.TokenURL, form)
+		if err != nil {
+			return nil, err
+		}
+		// Tokens with no expiry are not cached, as they may expire at any time.
+		if !token.Expiry.IsZero() {
+			c.mu.Lock()
+			c.token = token
+			c.mu.Unlock()
+		}
+		return token, nil
+	})
+
+	var res singleflight.Result
+	select {
+	case res = <-ch:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if res.Err != nil {
+		return nil, res.Err
+	}
+	return res.Val.(*Token), nil
+}
+
+func (c *ClientCredentials) form() (url.Values, error) {
+	form := url.Values{}
+	for key, values := range c.options.Params {
+		form[key] = values
+	}
+	form.Set("grant_type", "client_credentials")
+	if err := authenticateClient(form, c.options.ClientID, c.options.ClientSecret, c.options.PrivateKeyJWT, c.options.TokenURL); err != nil {
+		return nil, err
+	}
+	if c.options.Audience != "" {
+		form.Set("audience", c.options.Audience)
+	}
+	if len(c.options.Scopes) > 0 {
+		form.Set("scope", strings.Join(c.options.Scopes, " "))
+	}
+	return form, nil
+}
+
+// requestToken posts the form to the token endpoint and returns the
+// issued token, or a *TokenError if the endpoint rejects the request.
+func requestToken(ctx context.Context, client *http.Client, tokenURL string, form url.Values) (*Token, error) {
+	req, err := http.NewRequest("POST", tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newTokenError(resp)
+	}
+	if contentH := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentH, "application/json") {
+		return nil, ErrInvalidContentType
+	}
+
+	token := &Token{}
+	if err := json.NewDecoder(resp.Body).Decode(token); err != nil {
+		return nil, err
+	}
+	if token.AccessToken == "" {
+		return nil, ErrNoAccessToken
+	}
+	if token.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+// newTokenError returns the error of the response, decoding the OAuth2
+// error of its body or keeping the beginning of the body if there is none.
+func newTokenError(resp *http.Response) *TokenError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	tokenErr := &TokenError{StatusCode: resp.StatusCode}
+	oauthErr := struct {
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}{}
+	if json.Unmarshal(body, &oauthErr) == nil && oauthErr.Error != "" {
+		tokenErr.Code, tokenErr.Description = oauthErr.Error, oauthErr.ErrorDescription
+	} else {
+		tokenErr.Body = strings.TrimSpace(strings.ToValidUTF8(string(body), ""))
+	}
+	return tokenErr
+}
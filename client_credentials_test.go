@@ -0,0 +1,240 @@
+package auth0
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func genTestTokenServer(t *testing.T, counter *uint64, expiresIn int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddUint64(counter, 1)
+		assert.Equal(t, "client_credentials", r.PostFormValue("grant_type"))
+		assert.Equal(t, "client", r.PostFormValue("client_id"))
+		assert.Equal(t, "secret", r.PostFormValue("client_secret"))
+		assert.Equal(t, "https://api.example.com", r.PostFormValue("audience"))
+		assert.Equal(t, "read:users write:users", r.PostFormValue("scope"))
+		assert.Equal(t, "org_1", r.PostFormValue("organization"))
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "token" + strconv.FormatUint(n, 10),
+			"token_type":   "Bearer",
+			"expires_in":   expiresIn,
+		})
+	}))
+}
+
+func genTestClientCredentials(uri string, expiryDelta time.Duration) *ClientCredentials {
+	return NewClientCredentials(ClientCredentialsOptions{
+		TokenURL:     uri,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Audience:     "https://api.example.com",
+		Scopes:       []string{"read:users", "write:users"},
+		Params:       map[string][]string{"organization": {"org_1"}},
+		ExpiryDelta:  expiryDelta,
+	})
+}
+
+func TestClientCredentials(t *testing.T) {
+	var counter uint64
+	ts := genTestTokenServer(t, &counter, 3600)
+	defer ts.Close()
+
+	client := genTestClientCredentials(ts.URL, 0)
+
+	token, err := client.Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "token1", token.AccessToken)
+	assert.Equal(t, "Bearer", token.TokenType)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), token.Expiry, time.Minute)
+
+	token, err = client.Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "token1", token.AccessToken)
+	assert.Equal(t, uint64(1), atomic.LoadUint64(&counter), "the token should be cached")
+}
+
+func TestClientCredentialsExpiry(t *testing.T) {
+	var counter uint64
+	ts := genTestTokenServer(t, &counter, 30)
+	defer ts.Close()
+
+	client := genTestClientCredentials(ts.URL, time.Minute)
+
+	token, err := client.Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "token1", token.AccessToken)
+
+	token, err = client.Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "token2", token.AccessToken, "the token expiring within the delta should be requested again")
+}
+
+func TestClientCredentialsNoExpiry(t *testing.T) {
+	var counter uint64
+	ts := genTestTokenServer(t, &counter, 0)
+	defer ts.Close()
+
+	client := genTestClientCredentials(ts.URL, 0)
+
+	for _, expected := range []string{"token1", "token2"} {
+		token, err := client.Token(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, expected, token.AccessToken, "the token with no expiry should not be cached")
+		assert.True(t, token.Expiry.IsZero())
+	}
+}
+
+func TestClientCredentialsConcurrent(t *testing.T) {
+	var counter uint64
+	block := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint64(&counter, 1)
+		<-block
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"token","expires_in":3600}`))
+	}))
+	defer ts.Close()
+
+	client := genTestClientCredentials(ts.URL, 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := client.Token(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, "token", token.AccessToken)
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(block)
+	wg.Wait()
+	assert.Equal(t, uint64(1), atomic.LoadUint64(&counter), "simultaneous calls should result in a single request")
+}
+
+func TestClientCredentialsCanceled(t *testing.T) {
+	var counter uint64
+	tokens := genTestTokenServer(t, &counter, 3600)
+	defer tokens.Close()
+	received, release := make(chan struct{}), make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadUint64(&counter) == 0 {
+			close(received)
+			<-release
+		}
+		tokens.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	client := genTestClientCredentials(ts.URL, 0)
+
+	// The first caller leaves, the others get the token of the shared request.
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error)
+	go func() {
+		_, err := client.Token(ctx)
+		errs <- err
+	}()
+	<-received
+	shared := make(chan *Token)
+	go func() {
+		token, err := client.Token(context.Background())
+		assert.NoError(t, err)
+		shared <- token
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	assert.Equal(t, context.Canceled, <-errs)
+
+	close(release)
+	assert.Equal(t, "token1", (<-shared).AccessToken)
+	assert.Equal(t, uint64(1), atomic.LoadUint64(&counter))
+}
+
+func TestClientCredentialsErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		contentType   string
+		body          string
+		expectedError error
+		expectedMsg   string
+	}{
+		{
+			name:          "fail - OAuth2 error",
+			status:        http.StatusForbidden,
+			contentType:   "application/json",
+			body:          `{"error":"access_denied","error_description":"Unauthorized"}`,
+			expectedError: ErrTokenRequestFailed,
+			expectedMsg:   "token request failed with status code 403: access_denied: Unauthorized",
+		},
+		{
+			name:          "fail - not an OAuth2 error",
+			status:        http.StatusBadGateway,
+			contentType:   "text/plain",
+			body:          "bad gateway\n",
+			expectedError: ErrTokenRequestFailed,
+			expectedMsg:   "token request failed with status code 502: bad gateway",
+		},
+		{
+			name:          "fail - no access token",
+			status:        http.StatusOK,
+			contentType:   "application/json",
+			body:          `{"token_type":"Bearer"}`,
+			expectedError: ErrNoAccessToken,
+			expectedMsg:   ErrNoAccessToken.Error(),
+		},
+		{
+			name:          "fail - invalid content type",
+			status:        http.StatusOK,
+			contentType:   "text/html",
+			body:          "<html></html>",
+			expectedError: ErrInvalidContentType,
+			expectedMsg:   ErrInvalidContentType.Error(),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", test.contentType)
+				w.WriteHeader(test.status)
+				w.Write([]byte(test.body))
+			}))
+			defer ts.Close()
+
+			_, err := genTestClientCredentials(ts.URL, 0).Token(context.Background())
+			assert.True(t, errors.Is(err, test.expectedError), "got %v", err)
+			assert.EqualError(t, err, test.expectedMsg)
+		})
+	}
+
+	t.Run("fail - status code of the token error", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid_client"}`))
+		}))
+		defer ts.Close()
+
+		_, err := genTestClientCredentials(ts.URL, 0).Token(context.Background())
+		var tokenErr *TokenError
+		if assert.True(t, errors.As(err, &tokenErr)) {
+			assert.Equal(t, http.StatusUnauthorized, tokenErr.StatusCode)
+			assert.Equal(t, "invalid_client", tokenErr.Code)
+		}
+	})
+}
@@ -9,8 +9,10 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"testing"
 	"time"
 
+	"golang.org/x/crypto/ed25519"
 	jose "gopkg.in/square/go-jose.v2"
 	"gopkg.in/square/go-jose.v2/jwt"
 )
@@ -34,11 +36,8 @@ var (
 )
 
 func genRSASSAJWK(sigAlg jose.SignatureAlgorithm, kid string) jose.JSONWebKey {
-	var bits int
-	if sigAlg == jose.RS256 {
-		bits = 2048
-	}
-	if sigAlg == jose.RS512 {
+	bits := 2048
+	if sigAlg == jose.RS512 || sigAlg == jose.PS512 {
 		bits = 4096
 	}
 
@@ -62,6 +61,9 @@ func genECDSAJWK(sigAlg jose.SignatureAlgorithm, kid string) jose.JSONWebKey {
 	if sigAlg == jose.ES384 {
 		c = elliptic.P384()
 	}
+	if sigAlg == jose.ES512 {
+		c = elliptic.P521()
+	}
 
 	key, _ := ecdsa.GenerateKey(c, rand.Reader)
 
@@ -74,6 +76,19 @@ func genECDSAJWK(sigAlg jose.SignatureAlgorithm, kid string) jose.JSONWebKey {
 	return jsonWebKey
 }
 
+func genEd25519JWK(kid string) jose.JSONWebKey {
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+
+	jsonWebKey := jose.JSONWebKey{
+		Key:       key,
+		KeyID:     kid,
+		Use:       "sig",
+		Algorithm: string(jose.EdDSA),
+	}
+
+	return jsonWebKey
+}
+
 func getTestToken(audience []string, issuer string, expTime time.Time, alg jose.SignatureAlgorithm, key interface{}) string {
 	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: alg, Key: key}, (&jose.SignerOptions{}).WithType("JWT"))
 	if err != nil {
@@ -147,5 +162,56 @@ func genNewTestServer(genJWKS bool) (JWKClientOptions, *jwt.JSONWebToken, *jwt.J
 		w.Header().Set("Content-Type", "application/json")
 		fmt.Fprintln(w, string(value))
 	}))
-	return JWKClientOptions{URI: ts.URL}, tokenRS256, tokenES384, err
+	return JWKClientOptions{URI: ts.URL, AllowedAlgorithms: []jose.SignatureAlgorithm{jose.RS256, jose.ES384}}, tokenRS256, tokenES384, err
+}
+
+func getTestTokenWithClaims(alg jose.SignatureAlgorithm, key interface{}, claims ...interface{}) string {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: alg, Key: key}, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		panic(err)
+	}
+
+	builder := jwt.Signed(signer)
+	for _, c := range claims {
+		builder = builder.Claims(c)
+	}
+
+	raw, err := builder.CompactSerialize()
+	if err != nil {
+		panic(err)
+	}
+	return raw
+}
+
+// genJWKSServer serves a JWKS holding the public part of the keys.
+func genJWKSServer(keys ...jose.JSONWebKey) *httptest.Server {
+	jwks := JWKS{Keys: []jose.JSONWebKey{}}
+	for _, key := range keys {
+		jwks.Keys = append(jwks.Keys, key.Public())
+	}
+	value, err := json.Marshal(&jwks)
+	if err != nil {
+		panic(err)
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, string(value))
+	}))
+}
+
+// eventually polls the condition every tick until it holds or waitFor
+// elapses. Unlike assert.Eventually of testify 1.4.0, whose pending checks
+// panic sending on a closed channel once it returned, it checks synchronously.
+func eventually(t *testing.T, condition func() bool, waitFor, tick time.Duration) bool {
+	t.Helper()
+	deadline := time.Now().Add(waitFor)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Error("Condition never satisfied")
+			return false
+		}
+		time.Sleep(tick)
+	}
+	return true
 }
@@ -0,0 +1,82 @@
+package auth0
+
+import (
+	"context"
+	"time"
+
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// rawTokenContextKey is the request context key of the compact
+// serialization of the token validated by the middleware.
+const rawTokenContextKey = contextKey("auth0-raw-token")
+
+// NewContext returns a copy of the context holding the validated token,
+// its compact serialization and its claims, as the middleware does, for
+// the integrations validating tokens outside of it. The token may be nil
+// for opaque tokens and raw may be empty when unknown.
+func NewContext(ctx context.Context, token *jwt.JSONWebToken, raw string, claims map[string]interface{}) context.Context {
+	if token != nil {
+		ctx = context.WithValue(ctx, TokenContextKey, token)
+	}
+	if raw != "" {
+		ctx = context.WithValue(ctx, rawTokenContextKey, raw)
+	}
+	return context.WithValue(ctx, ClaimsContextKey, claims)
+}
+
+// ClaimsFromContext returns the claims validated by the middleware,
+// reporting whether a token has been validated.
+func ClaimsFromContext(ctx context.Context) (map[string]interface{}, bool) {
+	claims, ok := ctx.Value(ClaimsContextKey).(map[string]interface{})
+	return claims, ok
+}
+
+// SubjectFromContext returns the sub claim of the token validated by the
+// middleware, reporting whether a token with a subject has been validated.
+func SubjectFromContext(ctx context.Context) (string, bool) {
+	claims, _ := ClaimsFromContext(ctx)
+	sub, ok := claims["sub"].(string)
+	return sub, ok && sub != ""
+}
+
+// TokenFromContext returns the token validated by the middleware, reporting
+// whether a JWT has been validated. There is none for opaque tokens.
+func TokenFromContext(ctx context.Context) (*jwt.JSONWebToken, bool) {
+	token, ok := ctx.Value(TokenContextKey).(*jwt.JSONWebToken)
+	return token, ok
+}
+
+// RawTokenFromContext returns the compact serialization of the token
+// validated by the middleware, such as to forward it to other services,
+// reporting whether it is known. It is known when the extractor of the
+// validator is a RawRequestTokenExtractor, such as the default one.
+func RawTokenFromContext(ctx context.Context) (string, bool) {
+	raw, ok := ctx.Value(rawTokenContextKey).(string)
+	return raw, ok
+}
+
+// detachedContext holds the values of its parent, such as its span, but is
+// never canceled with it, as context.WithoutCancel of Go 1.21.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
+
+// detach returns a context holding the values of ctx but not canceled with
+// it, bounded by the timeout if positive, for the work shared by several
+// callers, which must complete even when the caller that started it is gone.
+func detach(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	detached := context.Context(detachedContext{parent: ctx})
+	if timeout <= 0 {
+		return detached, func() {}
+	}
+	return context.WithTimeout(detached, timeout)
+}
@@ -0,0 +1,74 @@
+package auth0
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestContextHelpers(t *testing.T) {
+	t.Run("pass - validated token", func(t *testing.T) {
+		registered := jwt.Claims{Subject: "user", Issuer: defaultIssuer, Audience: defaultAudience, Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+		raw := getTestTokenWithClaims(jose.HS256, defaultSecret, registered)
+		validator := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), nil)
+
+		called := false
+		handler := Middleware(validator)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+			claims, ok := ClaimsFromContext(r.Context())
+			assert.True(t, ok)
+			assert.Equal(t, "user", claims["sub"])
+			sub, ok := SubjectFromContext(r.Context())
+			assert.True(t, ok)
+			assert.Equal(t, "user", sub)
+			_, ok = TokenFromContext(r.Context())
+			assert.True(t, ok)
+			token, ok := RawTokenFromContext(r.Context())
+			assert.True(t, ok)
+			assert.Equal(t, raw, token)
+		}))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, genTestMiddlewareRequest(raw))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.True(t, called)
+	})
+
+	t.Run("pass - opaque token", func(t *testing.T) {
+		ctx := NewContext(context.Background(), nil, "opaque", map[string]interface{}{"sub": "user"})
+		_, ok := TokenFromContext(ctx)
+		assert.False(t, ok)
+		raw, ok := RawTokenFromContext(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, "opaque", raw)
+		sub, ok := SubjectFromContext(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, "user", sub)
+	})
+
+	t.Run("fail - no validated token", func(t *testing.T) {
+		ctx := context.Background()
+		_, ok := ClaimsFromContext(ctx)
+		assert.False(t, ok)
+		_, ok = SubjectFromContext(ctx)
+		assert.False(t, ok)
+		_, ok = TokenFromContext(ctx)
+		assert.False(t, ok)
+		_, ok = RawTokenFromContext(ctx)
+		assert.False(t, ok)
+	})
+
+	t.Run("fail - no subject", func(t *testing.T) {
+		ctx := NewContext(context.Background(), nil, "", map[string]interface{}{})
+		_, ok := SubjectFromContext(ctx)
+		assert.False(t, ok)
+		_, ok = RawTokenFromContext(ctx)
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,96 @@
+package auth0
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func getTestTokenWithHeaders(headers map[jose.HeaderKey]interface{}) string {
+	opts := (&jose.SignerOptions{}).WithType("JWT")
+	for k, v := range headers {
+		opts = opts.WithHeader(k, v)
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: defaultSecret}, opts)
+	if err != nil {
+		panic(err)
+	}
+	raw, err := jwt.Signed(signer).Claims(jwt.Claims{
+		Issuer:   defaultIssuer,
+		Audience: defaultAudience,
+		Expiry:   jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}).CompactSerialize()
+	if err != nil {
+		panic(err)
+	}
+	return raw
+}
+
+func TestCriticalHeaders(t *testing.T) {
+	tests := []struct {
+		name          string
+		headers       map[jose.HeaderKey]interface{}
+		opts          []ValidatorOption
+		expectedError error
+	}{
+		{
+			name: "pass - no crit header",
+		},
+		{
+			name:          "fail - unsupported extension",
+			headers:       map[jose.HeaderKey]interface{}{"crit": []string{"exp"}, "exp": 1},
+			expectedError: ErrUnsupportedCriticalHeader,
+		},
+		{
+			name:          "fail - registered extension rejected by the signature verification",
+			headers:       map[jose.HeaderKey]interface{}{"crit": []string{"exp"}, "exp": 1},
+			opts:          []ValidatorOption{WithCriticalHeaders("exp")},
+			expectedError: ErrInvalidSignature,
+		},
+		{
+			name:          "fail - empty crit header",
+			headers:       map[jose.HeaderKey]interface{}{"crit": []string{}},
+			expectedError: ErrTokenMalformed,
+		},
+		{
+			name:          "fail - header parameter of the specification",
+			headers:       map[jose.HeaderKey]interface{}{"crit": []string{"kid"}},
+			opts:          []ValidatorOption{WithCriticalHeaders("kid")},
+			expectedError: ErrTokenMalformed,
+		},
+		{
+			name:          "fail - missing extension parameter",
+			headers:       map[jose.HeaderKey]interface{}{"crit": []string{"exp"}},
+			opts:          []ValidatorOption{WithCriticalHeaders("exp")},
+			expectedError: ErrTokenMalformed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider := &countingSecretProvider{}
+			validator := NewValidator(NewConfiguration(provider, defaultAudience, defaultIssuer, jose.HS256), nil, test.opts...)
+			_, err := validator.ValidateRawToken(getTestTokenWithHeaders(test.headers))
+			if test.expectedError == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, test.expectedError), "got %v", err)
+			if test.expectedError != ErrInvalidSignature {
+				assert.Equal(t, int32(0), provider.calls, "the key should not be looked up")
+			}
+		})
+	}
+
+	var critErr *CriticalHeaderError
+	_, err := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), nil).
+		ValidateRawToken(getTestTokenWithHeaders(map[jose.HeaderKey]interface{}{"crit": []string{"exp", "b64"}, "exp": 1, "b64": false}))
+	assert.True(t, errors.As(err, &critErr), "got %v", err)
+	if critErr != nil {
+		assert.ElementsMatch(t, []string{"exp", "b64"}, critErr.Names)
+	}
+}
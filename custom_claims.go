@@ -0,0 +1,39 @@
+package auth0
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrInvalidCustomClaims is matched by the errors returned
+// by the custom claims validators, along with the error itself.
+var ErrInvalidCustomClaims = errors.New("invalid custom claims")
+
+// CustomClaimsValidator validates the verified claims of a token
+// against the business rules of the application, such as the tenant of the
+// user, feature flags or the email_verified claim. The context is the context
+// of the request, or context.Background() outside of a request.
+type CustomClaimsValidator func(ctx context.Context, claims map[string]interface{}) error
+
+// WithCustomClaimsValidator makes the validator reject the tokens whose claims
+// are rejected by the validator function. The errors it returns match
+// ErrInvalidCustomClaims with errors.Is, and still match their own class,
+// so returning ErrInsufficientScope responds with 403 Forbidden.
+//
+// The validators run once the checks of the other options passed, including
+// for the tokens of the validation cache, in the order of the options.
+func WithCustomClaimsValidator(validator CustomClaimsValidator) ValidatorOption {
+	return func(v *JWTValidator) {
+		v.customValidators = append(v.customValidators, validator)
+	}
+}
+
+// checkCustomClaims runs the custom claims validators of the validator.
+func (v *JWTValidator) checkCustomClaims(ctx context.Context, claims map[string]interface{}) error {
+	for _, validator := range v.customValidators {
+		if err := validator(ctx, claims); err != nil {
+			return &classifiedError{class: ErrInvalidCustomClaims, cause: err}
+		}
+	}
+	return nil
+}
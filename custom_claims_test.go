@@ -0,0 +1,94 @@
+package auth0
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+type tenantContextKey struct{}
+
+func TestCustomClaimsValidator(t *testing.T) {
+	registered := jwt.Claims{Issuer: defaultIssuer, Audience: defaultAudience, Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+	verified := getTestTokenWithClaims(jose.HS256, defaultSecret, registered, map[string]interface{}{"email_verified": true, "tenant": "acme"})
+	unverified := getTestTokenWithClaims(jose.HS256, defaultSecret, registered, map[string]interface{}{"email_verified": false, "tenant": "acme"})
+	otherTenant := getTestTokenWithClaims(jose.HS256, defaultSecret, registered, map[string]interface{}{"email_verified": true, "tenant": "other"})
+
+	errEmailNotVerified := errors.New("email not verified")
+	errInvalidTenant := errors.New("invalid tenant")
+	emailVerified := func(_ context.Context, claims map[string]interface{}) error {
+		if verified, _ := claims["email_verified"].(bool); !verified {
+			return errEmailNotVerified
+		}
+		return nil
+	}
+	sameTenant := func(ctx context.Context, claims map[string]interface{}) error {
+		if tenant, ok := ctx.Value(tenantContextKey{}).(string); ok && claims["tenant"] != tenant {
+			return errInvalidTenant
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name          string
+		token         string
+		expectedError error
+	}{
+		{name: "pass - valid claims", token: verified},
+		{name: "fail - email not verified", token: unverified, expectedError: errEmailNotVerified},
+		{name: "fail - other tenant", token: otherTenant, expectedError: errInvalidTenant},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			validator := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), nil,
+				WithCustomClaimsValidator(emailVerified), WithCustomClaimsValidator(sameTenant))
+
+			req := genTestMiddlewareRequest(test.token)
+			req = req.WithContext(context.WithValue(req.Context(), tenantContextKey{}, "acme"))
+			_, err := validator.ValidateRequest(req)
+			if test.expectedError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, test.expectedError), "got %v", err)
+				assert.True(t, errors.Is(err, ErrInvalidCustomClaims), "got %v", err)
+				assert.EqualError(t, err, test.expectedError.Error())
+			}
+		})
+	}
+
+	t.Run("fail - insufficient scope responds with 403", func(t *testing.T) {
+		validator := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), nil,
+			WithCustomClaimsValidator(func(context.Context, map[string]interface{}) error {
+				return &InsufficientScopeError{Missing: []string{"admin"}}
+			}))
+
+		_, err := validator.ValidateRawToken(verified)
+		status, _ := BearerError(err)
+		assert.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("fail - cached tokens are validated again", func(t *testing.T) {
+		rejected := false
+		validator := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), nil,
+			WithValidationCache(time.Minute, 0),
+			WithCustomClaimsValidator(func(context.Context, map[string]interface{}) error {
+				if rejected {
+					return errInvalidTenant
+				}
+				return nil
+			}))
+
+		_, err := validator.ValidateRawToken(verified)
+		assert.NoError(t, err)
+		rejected = true
+		_, err = validator.ValidateRawToken(verified)
+		assert.True(t, errors.Is(err, errInvalidTenant), "got %v", err)
+	})
+}
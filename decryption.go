@@ -0,0 +1,118 @@
+package auth0
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+var (
+	// ErrEncryptedToken is returned when the token is encrypted
+	// and no DecryptionKeyProvider is configured.
+	ErrEncryptedToken = errors.New("token is encrypted but no decryption key provider is configured")
+)
+
+// DecryptionKeyProvider will provide the key
+// needed to decrypt nested tokens, signed then encrypted.
+type DecryptionKeyProvider interface {
+	GetDecryptionKey(token *jwt.NestedJSONWebToken) (interface{}, error)
+}
+
+// DecryptionKeyProviderFunc simple wrappers to provide
+// decryption key with functions.
+type DecryptionKeyProviderFunc func(token *jwt.NestedJSONWebToken) (interface{}, error)
+
+// GetDecryptionKey implements the DecryptionKeyProvider interface.
+func (f DecryptionKeyProviderFunc) GetDecryptionKey(token *jwt.NestedJSONWebToken) (interface{}, error) {
+	return f(token)
+}
+
+// NewDecryptionKeyProvider provide a simple decryption key provider,
+// such as the *rsa.PrivateKey or *ecdsa.PrivateKey the tokens are encrypted for.
+func NewDecryptionKeyProvider(key interface{}) DecryptionKeyProvider {
+	return DecryptionKeyProviderFunc(func(_ *jwt.NestedJSONWebToken) (interface{}, error) {
+		return key, nil
+	})
+}
+
+// WithDecryptionKeyProvider makes the validator accept nested tokens,
+// decrypting them with the key of the provider before validating the
+// signed token they hold, whether they are validated with ValidateRawToken,
+// ValidateRequest or the middleware.
+func WithDecryptionKeyProvider(provider DecryptionKeyProvider) ValidatorOption {
+	return func(v *JWTValidator) {
+		v.decryption = provider
+	}
+}
+
+// FromEncryptedHeader returns an extractor looking for a nested token in the
+// Authorization header with the Bearer scheme, as FromHeaderWithScheme does,
+// and decrypting it with the key of the provider. The signed token it holds
+// is returned, to be validated as usual. The validator applies its limits
+// to the nested token, before its decryption when possible.
+func FromEncryptedHeader(provider DecryptionKeyProvider) RequestTokenExtractor {
+	return encryptedHeaderExtractor{
+		header:   FromHeaderWithScheme().(RawRequestTokenExtractor),
+		provider: provider,
+	}
+}
+
+// encryptedHeaderExtractor extracts the nested tokens of the
+// Authorization header, decrypted with the key of the provider.
+type encryptedHeaderExtractor struct {
+	header   RawRequestTokenExtractor
+	provider DecryptionKeyProvider
+}
+
+// ExtractRaw returns the compact serialized nested token.
+func (e encryptedHeaderExtractor) ExtractRaw(r *http.Request) (string, error) {
+	return e.header.ExtractRaw(r)
+}
+
+// Extract returns the signed token held by the nested token.
+func (e encryptedHeaderExtractor) Extract(r *http.Request) (*jwt.JSONWebToken, error) {
+	raw, err := e.ExtractRaw(r)
+	if err != nil {
+		return nil, err
+	}
+	return decryptNested(raw, e.provider, TokenLimits{})
+}
+
+// isEncrypted reports whether the compact serialized token is a JWE.
+func isEncrypted(raw string) bool {
+	return strings.Count(raw, ".") == 4
+}
+
+// decryptNested parses and decrypts the compact serialized nested token
+// and returns the signed token it holds, rejecting the tokens exceeding
+// the limits, before their decryption when possible.
+func decryptNested(raw string, provider DecryptionKeyProvider, limits TokenLimits) (_ *jwt.JSONWebToken, err error) {
+	defer recoverPanic(panicInputToken, &err)
+	if err := limits.checkLength(raw); err != nil {
+		return nil, err
+	}
+	if err := limits.checkDepth(2); err != nil {
+		return nil, err
+	}
+	nested, err := jwt.ParseSignedAndEncrypted(raw)
+	if err != nil {
+		return nil, malformedError(err)
+	}
+	key, err := provider.GetDecryptionKey(nested)
+	if err != nil {
+		return nil, err
+	}
+	token, err := nested.Decrypt(key)
+	if err != nil {
+		return nil, malformedError(err)
+	}
+	if isUnsecured(token) {
+		return nil, ErrUnsecuredToken
+	}
+	if err := limits.checkToken(token, 1); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
@@ -0,0 +1,125 @@
+package auth0
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func getTestNestedToken(t *testing.T, encryptionKey *rsa.PublicKey) string {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: defaultSecret}, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypter, err := jose.NewEncrypter(jose.A256GCM, jose.Recipient{Algorithm: jose.RSA_OAEP, Key: encryptionKey}, (&jose.EncrypterOptions{}).WithContentType("JWT"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := jwt.SignedAndEncrypted(signer, encrypter).Claims(jwt.Claims{
+		Issuer:   defaultIssuer,
+		Audience: defaultAudience,
+		Expiry:   jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}).CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestValidateNestedToken(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	nested := getTestNestedToken(t, &key.PublicKey)
+	configuration := NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256)
+
+	tests := []struct {
+		name             string
+		opts             []ValidatorOption
+		token            string
+		expectedErrorMsg string
+	}{
+		{"pass - nested token", []ValidatorOption{WithDecryptionKeyProvider(NewDecryptionKeyProvider(key))}, nested, ""},
+		{"pass - signed token", []ValidatorOption{WithDecryptionKeyProvider(NewDecryptionKeyProvider(key))}, getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, defaultSecret), ""},
+		{"fail - no decryption key provider", nil, nested, ErrEncryptedToken.Error()},
+		{"fail - other decryption key", []ValidatorOption{WithDecryptionKeyProvider(NewDecryptionKeyProvider(otherKey))}, nested, "error in cryptographic primitive"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			validator := NewValidator(configuration, nil, test.opts...)
+			claims := jwt.Claims{}
+			_, err := validator.ValidateRawToken(test.token, &claims)
+			if test.expectedErrorMsg != "" {
+				assert.Error(t, err)
+				if err != nil {
+					assert.Contains(t, err.Error(), test.expectedErrorMsg)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, defaultIssuer, claims.Issuer)
+		})
+	}
+
+	t.Run("fail - undecryptable token is malformed", func(t *testing.T) {
+		validator := NewValidator(configuration, nil, WithDecryptionKeyProvider(NewDecryptionKeyProvider(otherKey)))
+		_, err := validator.ValidateRawToken(nested)
+		assert.True(t, errors.Is(err, ErrTokenMalformed), "got %v", err)
+		_, err = validator.ValidateRawToken("a.b.c.d.e")
+		assert.True(t, errors.Is(err, ErrTokenMalformed), "got %v", err)
+	})
+}
+
+func TestValidateRequestNestedToken(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	nested := getTestNestedToken(t, &key.PublicKey)
+	configuration := NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256)
+	validator := NewValidator(configuration, nil, WithDecryptionKeyProvider(NewDecryptionKeyProvider(key)))
+
+	token, err := validator.ValidateRequest(genTestMiddlewareRequest(nested))
+	assert.NoError(t, err)
+	assert.NotNil(t, token)
+
+	var issuer string
+	handler := Middleware(validator)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, _ := ClaimsFromContext(r.Context())
+		issuer, _ = claims["iss"].(string)
+	}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, genTestMiddlewareRequest(nested))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, defaultIssuer, issuer)
+}
+
+func TestFromEncryptedHeader(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	configuration := NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256)
+	validator := NewValidator(configuration, FromEncryptedHeader(NewDecryptionKeyProvider(key)))
+
+	r := httptest.NewRequest("", "http://example.com", nil)
+	r.Header.Set("Authorization", "Bearer "+getTestNestedToken(t, &key.PublicKey))
+	_, err := validator.ValidateRequest(r)
+	assert.NoError(t, err)
+
+	r.Header.Set("Authorization", "bearer  "+getTestNestedToken(t, &key.PublicKey)+" ")
+	_, err = validator.ValidateRequest(r)
+	assert.NoError(t, err)
+
+	r.Header.Del("Authorization")
+	_, err = validator.ValidateRequest(r)
+	assert.Equal(t, ErrTokenNotFound, err)
+
+	// The limits of the validator apply to the nested tokens.
+	validator = NewValidator(configuration, FromEncryptedHeader(NewDecryptionKeyProvider(key)), WithTokenLimits(TokenLimits{MaxLength: 100}))
+	r.Header.Set("Authorization", "Bearer "+getTestNestedToken(t, &key.PublicKey))
+	_, err = validator.ValidateRequest(r)
+	assert.True(t, errors.Is(err, ErrTokenTooLarge), "got %v", err)
+}
@@ -0,0 +1,124 @@
+package auth0
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"gopkg.in/square/go-jose.v2"
+)
+
+var (
+	// ErrIssuerMismatch is returned when the issuer of the OpenID configuration
+	// does not match the issuer it has been retrieved from.
+	ErrIssuerMismatch = errors.New("issuer of the OpenID configuration does not match")
+	// ErrNoJWKSURI is returned when the OpenID configuration has no jwks_uri.
+	ErrNoJWKSURI = errors.New("no jwks_uri in the OpenID configuration")
+)
+
+// OpenIDConfiguration is the OpenID Provider metadata
+// published by the issuer at /.well-known/openid-configuration.
+type OpenIDConfiguration struct {
+	Issuer                           string   `json:"issuer"`
+	AuthorizationEndpoint            string   `json:"authorization_endpoint,omitempty"`
+	TokenEndpoint                    string   `json:"token_endpoint,omitempty"`
+	UserInfoEndpoint                 string   `json:"userinfo_endpoint,omitempty"`
+	JWKSURI                          string   `json:"jwks_uri"`
+	IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported,omitempty"`
+}
+
+// SigningAlgorithms returns the ID token signing algorithms
+// advertised by the issuer.
+func (c *OpenIDConfiguration) SigningAlgorithms() []jose.SignatureAlgorithm {
+	algs := make([]jose.SignatureAlgorithm, 0, len(c.IDTokenSigningAlgValuesSupported))
+	for _, alg := range c.IDTokenSigningAlgValuesSupported {
+		algs = append(algs, jose.SignatureAlgorithm(alg))
+	}
+	return algs
+}
+
+// DiscoveryURI returns the URI of the OpenID configuration of the issuer.
+// The https scheme is used when the issuer has none.
+func DiscoveryURI(issuer string) string {
+	return strings.TrimSuffix(issuerURL(issuer), "/") + "/.well-known/openid-configuration"
+}
+
+func issuerURL(issuer string) string {
+	if !strings.Contains(issuer, "://") {
+		return "https://" + issuer
+	}
+	return issuer
+}
+
+// FetchOpenIDConfiguration downloads the OpenID configuration of the issuer
+// using the provided HTTP client, or http.DefaultClient if nil.
+func FetchOpenIDConfiguration(ctx context.Context, client *http.Client, issuer string) (*OpenIDConfiguration, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequest("GET", DiscoveryURI(issuer), new(bytes.Buffer))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d from OpenID configuration endpoint", resp.StatusCode)
+	}
+
+	if contentH := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentH, "application/json") {
+		return nil, ErrInvalidContentType
+	}
+
+	config := &OpenIDConfiguration{}
+	if err := json.NewDecoder(resp.Body).Decode(config); err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSuffix(config.Issuer, "/") != strings.TrimSuffix(issuerURL(issuer), "/") {
+		return nil, ErrIssuerMismatch
+	}
+	if config.JWKSURI == "" {
+		return nil, ErrNoJWKSURI
+	}
+
+	return config, nil
+}
+
+// NewJWKClientFromIssuer creates a new JWKClient instance from the OpenID
+// configuration of the issuer. The URI of the options is replaced by the
+// jwks_uri of the configuration.
+func NewJWKClientFromIssuer(issuer string, options JWKClientOptions, extractor RequestTokenExtractor) (*JWKClient, error) {
+	return NewJWKClientFromIssuerContext(context.Background(), issuer, options, extractor)
+}
+
+// NewJWKClientFromIssuerContext is like NewJWKClientFromIssuer, the context is
+// used to cancel or time-bound the download of the OpenID configuration.
+func NewJWKClientFromIssuerContext(ctx context.Context, issuer string, options JWKClientOptions, extractor RequestTokenExtractor) (*JWKClient, error) {
+	options.Client = options.httpClient()
+	config, err := FetchOpenIDConfiguration(ctx, options.Client, issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	options.URI = config.JWKSURI
+	client := NewJWKClient(options, extractor)
+	client.openIDConfiguration = config
+
+	return client, nil
+}
+
+// OpenIDConfiguration returns the OpenID configuration the client has been
+// created from, or nil if it has not been created with NewJWKClientFromIssuer.
+func (j *JWKClient) OpenIDConfiguration() *OpenIDConfiguration {
+	return j.openIDConfiguration
+}
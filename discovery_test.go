@@ -0,0 +1,100 @@
+package auth0
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func genNewDiscoveryServer(issuerSuffix string, jwksPath string) (*httptest.Server, *jose.JSONWebKey) {
+	jsonWebKeyRS256 := genRSASSAJWK(jose.RS256, "keyRS256")
+	jwks := JWKS{Keys: []jose.JSONWebKey{jsonWebKeyRS256.Public()}}
+
+	mux := http.NewServeMux()
+	ts := httptest.NewServer(mux)
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		config := OpenIDConfiguration{
+			Issuer:                           ts.URL + issuerSuffix,
+			IDTokenSigningAlgValuesSupported: []string{"HS256", "RS256"},
+		}
+		if jwksPath != "" {
+			config.JWKSURI = ts.URL + jwksPath
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		json.NewEncoder(w).Encode(config)
+	})
+	mux.HandleFunc("/.well-known/jwks.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(jwks)
+	})
+
+	return ts, &jsonWebKeyRS256
+}
+
+func TestDiscoveryURI(t *testing.T) {
+	assert.Equal(t, "https://mydomain.eu.auth0.com/.well-known/openid-configuration", DiscoveryURI("https://mydomain.eu.auth0.com/"))
+	assert.Equal(t, "https://mydomain.eu.auth0.com/.well-known/openid-configuration", DiscoveryURI("mydomain.eu.auth0.com"))
+	assert.Equal(t, "http://localhost:8080/.well-known/openid-configuration", DiscoveryURI("http://localhost:8080"))
+}
+
+func TestNewJWKClientFromIssuer(t *testing.T) {
+	ts, jsonWebKeyRS256 := genNewDiscoveryServer("/", "/.well-known/jwks.json")
+	defer ts.Close()
+
+	client, err := NewJWKClientFromIssuer(ts.URL+"/", JWKClientOptions{}, nil)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	assert.Equal(t, ts.URL+"/.well-known/jwks.json", client.options.URI)
+	assert.Equal(t, []jose.SignatureAlgorithm{jose.HS256, jose.RS256}, client.OpenIDConfiguration().SigningAlgorithms())
+
+	token := getTestTokenWithKid(defaultAudience, defaultIssuer, time.Now().Add(24*time.Hour), jose.RS256, *jsonWebKeyRS256, "keyRS256")
+	testGetSecret(t, client, token)
+}
+
+func TestNewJWKClientFromIssuerFailed(t *testing.T) {
+	tests := []struct {
+		name          string
+		issuerSuffix  string
+		jwksPath      string
+		expectedError error
+	}{
+		{
+			name:          "fail - issuer mismatch",
+			issuerSuffix:  "/other",
+			jwksPath:      "/.well-known/jwks.json",
+			expectedError: ErrIssuerMismatch,
+		},
+		{
+			name:          "fail - no jwks_uri",
+			issuerSuffix:  "/",
+			jwksPath:      "",
+			expectedError: ErrNoJWKSURI,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ts, _ := genNewDiscoveryServer(test.issuerSuffix, test.jwksPath)
+			defer ts.Close()
+
+			_, err := NewJWKClientFromIssuer(ts.URL, JWKClientOptions{}, nil)
+			assert.Equal(t, test.expectedError, err)
+		})
+	}
+
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+	_, err := NewJWKClientFromIssuer(ts.URL, JWKClientOptions{}, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), fmt.Sprint(http.StatusNotFound))
+}
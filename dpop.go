@@ -0,0 +1,187 @@
+package auth0
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// DefaultDPoPProofMaxAge is the default maximum age of the DPoP proofs.
+const DefaultDPoPProofMaxAge = time.Minute
+
+var (
+	// ErrDPoPProofMissing is returned when a DPoP-bound token is not
+	// sent with the DPoP scheme and a single DPoP proof.
+	ErrDPoPProofMissing = errors.New("no DPoP proof for the DPoP-bound token")
+	// ErrInvalidDPoPProof is matched by the errors of
+	// the DPoP proofs that are not valid for the request.
+	ErrInvalidDPoPProof = errors.New("invalid DPoP proof")
+	// ErrDPoPKeyMismatch is returned when the DPoP proof is not signed
+	// with the key the token is bound to (cnf.jkt).
+	ErrDPoPKeyMismatch = errors.New("DPoP proof key does not match the cnf claim (jkt)")
+	// ErrDPoPProofReplayed is returned when the jti of
+	// the DPoP proof has already been seen.
+	ErrDPoPProofReplayed = errors.New("DPoP proof has already been used")
+)
+
+// DPoPOptions configures the validation of the DPoP proofs.
+type DPoPOptions struct {
+	// MaxAge is the maximum age of the proofs, compared to their iat
+	// claim. Defaults to DefaultDPoPProofMaxAge.
+	MaxAge time.Duration
+	// ReplayDetector rejects the proofs whose jti has already been seen.
+	// The proofs may be replayed during MaxAge when nil.
+	ReplayDetector ReplayDetector
+	// RequestURL returns the URL the request has been sent to, compared
+	// to the htu claim of the proofs, such as from the X-Forwarded headers
+	// behind a proxy. Defaults to the scheme of the connection, the Host
+	// header and the path of the request.
+	RequestURL func(r *http.Request) *url.URL
+}
+
+// dpopProofClaims are the claims of a DPoP proof (RFC 9449).
+type dpopProofClaims struct {
+	ID          string           `json:"jti"`
+	Method      string           `json:"htm"`
+	URL         string           `json:"htu"`
+	IssuedAt    *jwt.NumericDate `json:"iat"`
+	AccessToken string           `json:"ath"`
+}
+
+// WithDPoP makes the validator reject the DPoP-bound access tokens
+// (RFC 9449), whose cnf claim has a jkt thumbprint, not sent with the DPoP
+// scheme and a proof of the request signed with the key they are bound to.
+// The htm, htu, iat, jti and ath claims of the proof are checked. Tokens
+// with no jkt thumbprint are accepted. Accept the DPoP scheme with the
+// extractor of the validator, such as FromHeaderWithScheme("Bearer", "DPoP").
+//
+// The check only applies to tokens validated within a request.
+func WithDPoP(options DPoPOptions) ValidatorOption {
+	if options.MaxAge <= 0 {
+		options.MaxAge = DefaultDPoPProofMaxAge
+	}
+	if options.RequestURL == nil {
+		options.RequestURL = requestURL
+	}
+	return func(v *JWTValidator) {
+		if clocked, ok := options.ReplayDetector.(ClockedReplayDetector); ok {
+			clocked.SetClock(v.now)
+		}
+		v.requestChecks = append(v.requestChecks, func(r *http.Request, claims map[string]interface{}) error {
+			return checkDPoPProof(r, claims, options, v.leeway, v.now())
+		})
+	}
+}
+
+func checkDPoPProof(r *http.Request, claims map[string]interface{}, options DPoPOptions, leeway time.Duration, now time.Time) error {
+	cnf, ok := confirmation(claims)
+	if !ok || cnf.jkt == "" {
+		return nil
+	}
+
+	accessToken, ok := dpopAccessToken(r)
+	proofs := r.Header.Values("DPoP")
+	if !ok || len(proofs) != 1 {
+		return ErrDPoPProofMissing
+	}
+	proof, err := jwt.ParseSigned(proofs[0])
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidDPoPProof, err)
+	}
+	if len(proof.Headers) != 1 {
+		return fmt.Errorf("%w: %v", ErrInvalidDPoPProof, ErrNoJWTHeaders)
+	}
+	header := proof.Headers[0]
+	if typ, _ := header.ExtraHeaders["typ"].(string); !strings.EqualFold(typ, "dpop+jwt") {
+		return fmt.Errorf("%w: typ is not dpop+jwt", ErrInvalidDPoPProof)
+	}
+	if header.JSONWebKey == nil || !header.JSONWebKey.IsPublic() {
+		return fmt.Errorf("%w: no public jwk header", ErrInvalidDPoPProof)
+	}
+	if isUnsecured(proof) || strings.HasPrefix(header.Algorithm, "HS") || !keyMatchesAlgorithm(header.JSONWebKey.Key, header.Algorithm) {
+		return fmt.Errorf("%w: invalid algorithm %s", ErrInvalidDPoPProof, header.Algorithm)
+	}
+
+	proofClaims := dpopProofClaims{}
+	if err := proof.Claims(header.JSONWebKey.Key, &proofClaims); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidDPoPProof, err)
+	}
+	if proofClaims.ID == "" {
+		return fmt.Errorf("%w: no jti", ErrInvalidDPoPProof)
+	}
+	if proofClaims.Method != r.Method {
+		return fmt.Errorf("%w: htm does not match the request method", ErrInvalidDPoPProof)
+	}
+	if !sameURL(proofClaims.URL, options.RequestURL(r)) {
+		return fmt.Errorf("%w: htu does not match the request URL", ErrInvalidDPoPProof)
+	}
+	if proofClaims.IssuedAt == nil {
+		return fmt.Errorf("%w: no iat", ErrInvalidDPoPProof)
+	}
+	iat := proofClaims.IssuedAt.Time()
+	if now.Add(leeway).Before(iat) || iat.Add(options.MaxAge+leeway).Before(now) {
+		return fmt.Errorf("%w: iat is not recent", ErrInvalidDPoPProof)
+	}
+	ath := sha256.Sum256([]byte(accessToken))
+	if subtle.ConstantTimeCompare([]byte(proofClaims.AccessToken), []byte(base64.RawURLEncoding.EncodeToString(ath[:]))) != 1 {
+		return fmt.Errorf("%w: ath does not match the access token", ErrInvalidDPoPProof)
+	}
+
+	thumbprint, err := KeyThumbprint(*header.JSONWebKey)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidDPoPProof, err)
+	}
+	if subtle.ConstantTimeCompare([]byte(thumbprint), []byte(cnf.jkt)) != 1 {
+		return ErrDPoPKeyMismatch
+	}
+
+	if options.ReplayDetector != nil && options.ReplayDetector.Seen(thumbprint+":"+proofClaims.ID, iat.Add(options.MaxAge+leeway)) {
+		return ErrDPoPProofReplayed
+	}
+	return nil
+}
+
+// dpopAccessToken returns the access token of the
+// Authorization header if sent with the DPoP scheme.
+func dpopAccessToken(r *http.Request) (string, bool) {
+	h := strings.TrimSpace(r.Header.Get("Authorization"))
+	if len(h) <= 5 || !strings.EqualFold(h[:5], "DPoP ") {
+		return "", false
+	}
+	token := strings.TrimSpace(h[5:])
+	return token, token != ""
+}
+
+// requestURL returns the URL of the request, with no query.
+func requestURL(r *http.Request) *url.URL {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return &url.URL{Scheme: scheme, Host: r.Host, Path: r.URL.Path}
+}
+
+// sameURL reports whether the htu claim is the URL,
+// ignoring the query and fragment (RFC 9449 section 4.3).
+func sameURL(htu string, u *url.URL) bool {
+	parsed, err := url.Parse(htu)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(parsed.Scheme, u.Scheme) && strings.EqualFold(parsed.Host, u.Host) && urlPath(parsed) == urlPath(u)
+}
+
+func urlPath(u *url.URL) string {
+	if path := u.EscapedPath(); path != "" {
+		return path
+	}
+	return "/"
+}
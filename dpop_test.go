@@ -0,0 +1,183 @@
+package auth0
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func genDPoPProof(key jose.JSONWebKey, typ string, claims map[string]interface{}) string {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.SignatureAlgorithm(key.Algorithm), Key: key.Key}, (&jose.SignerOptions{EmbedJWK: true}).WithType(jose.ContentType(typ)))
+	if err != nil {
+		panic(err)
+	}
+	raw, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	if err != nil {
+		panic(err)
+	}
+	return raw
+}
+
+func TestDPoP(t *testing.T) {
+	proofKey := genECDSAJWK(jose.ES256, "")
+	otherKey := genECDSAJWK(jose.ES256, "")
+	jkt, err := KeyThumbprint(proofKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registered := jwt.Claims{Issuer: defaultIssuer, Audience: defaultAudience, Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+	boundToken := getTestTokenWithClaims(jose.HS256, defaultSecret, registered, map[string]interface{}{"cnf": map[string]interface{}{"jkt": jkt}})
+	bearerToken := getTestTokenWithClaims(jose.HS256, defaultSecret, registered)
+	ath := sha256.Sum256([]byte(boundToken))
+
+	proofClaims := func(overrides map[string]interface{}) map[string]interface{} {
+		claims := map[string]interface{}{
+			"jti": "proof-" + time.Now().String(),
+			"htm": "GET",
+			"htu": "http://api.example.com/orders",
+			"iat": time.Now().Unix(),
+			"ath": base64.RawURLEncoding.EncodeToString(ath[:]),
+		}
+		for key, value := range overrides {
+			if value == nil {
+				delete(claims, key)
+			} else {
+				claims[key] = value
+			}
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name          string
+		scheme        string
+		token         string
+		proofs        []string
+		expectedError error
+	}{
+		{
+			name:   "pass - valid proof",
+			scheme: "DPoP",
+			token:  boundToken,
+			proofs: []string{genDPoPProof(proofKey, "dpop+jwt", proofClaims(nil))},
+		},
+		{
+			name:   "pass - bearer token with no proof",
+			scheme: "Bearer",
+			token:  bearerToken,
+		},
+		{
+			name:          "fail - bound token with the Bearer scheme",
+			scheme:        "Bearer",
+			token:         boundToken,
+			proofs:        []string{genDPoPProof(proofKey, "dpop+jwt", proofClaims(nil))},
+			expectedError: ErrDPoPProofMissing,
+		},
+		{
+			name:          "fail - no proof",
+			scheme:        "DPoP",
+			token:         boundToken,
+			expectedError: ErrDPoPProofMissing,
+		},
+		{
+			name:          "fail - several proofs",
+			scheme:        "DPoP",
+			token:         boundToken,
+			proofs:        []string{genDPoPProof(proofKey, "dpop+jwt", proofClaims(nil)), genDPoPProof(proofKey, "dpop+jwt", proofClaims(nil))},
+			expectedError: ErrDPoPProofMissing,
+		},
+		{
+			name:          "fail - invalid typ",
+			scheme:        "DPoP",
+			token:         boundToken,
+			proofs:        []string{genDPoPProof(proofKey, "JWT", proofClaims(nil))},
+			expectedError: ErrInvalidDPoPProof,
+		},
+		{
+			name:          "fail - other method",
+			scheme:        "DPoP",
+			token:         boundToken,
+			proofs:        []string{genDPoPProof(proofKey, "dpop+jwt", proofClaims(map[string]interface{}{"htm": "POST"}))},
+			expectedError: ErrInvalidDPoPProof,
+		},
+		{
+			name:          "fail - other URL",
+			scheme:        "DPoP",
+			token:         boundToken,
+			proofs:        []string{genDPoPProof(proofKey, "dpop+jwt", proofClaims(map[string]interface{}{"htu": "http://api.example.com/users"}))},
+			expectedError: ErrInvalidDPoPProof,
+		},
+		{
+			name:          "fail - old proof",
+			scheme:        "DPoP",
+			token:         boundToken,
+			proofs:        []string{genDPoPProof(proofKey, "dpop+jwt", proofClaims(map[string]interface{}{"iat": time.Now().Add(-time.Hour).Unix()}))},
+			expectedError: ErrInvalidDPoPProof,
+		},
+		{
+			name:          "fail - no jti",
+			scheme:        "DPoP",
+			token:         boundToken,
+			proofs:        []string{genDPoPProof(proofKey, "dpop+jwt", proofClaims(map[string]interface{}{"jti": nil}))},
+			expectedError: ErrInvalidDPoPProof,
+		},
+		{
+			name:          "fail - ath of another token",
+			scheme:        "DPoP",
+			token:         boundToken,
+			proofs:        []string{genDPoPProof(proofKey, "dpop+jwt", proofClaims(map[string]interface{}{"ath": "other"}))},
+			expectedError: ErrInvalidDPoPProof,
+		},
+		{
+			name:          "fail - proof of another key",
+			scheme:        "DPoP",
+			token:         boundToken,
+			proofs:        []string{genDPoPProof(otherKey, "dpop+jwt", proofClaims(nil))},
+			expectedError: ErrDPoPKeyMismatch,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			validator := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), FromHeaderWithScheme("Bearer", "DPoP"), WithDPoP(DPoPOptions{}))
+			req := httptest.NewRequest("GET", "http://api.example.com/orders?page=2", nil)
+			req.Header.Set("Authorization", test.scheme+" "+test.token)
+			for _, proof := range test.proofs {
+				req.Header.Add("DPoP", proof)
+			}
+
+			_, err := validator.ValidateRequest(req)
+			if test.expectedError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, test.expectedError), "got %v", err)
+			}
+		})
+	}
+
+	t.Run("fail - replayed proof", func(t *testing.T) {
+		validator := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), FromHeaderWithScheme("DPoP"),
+			WithDPoP(DPoPOptions{ReplayDetector: NewMemoryReplayDetector(0)}))
+		proof := genDPoPProof(proofKey, "dpop+jwt", proofClaims(nil))
+		genRequest := func() *http.Request {
+			req := httptest.NewRequest("GET", "http://api.example.com/orders", nil)
+			req.Header.Set("Authorization", "DPoP "+boundToken)
+			req.Header.Set("DPoP", proof)
+			return req
+		}
+
+		_, err := validator.ValidateRequest(genRequest())
+		assert.NoError(t, err)
+		_, err = validator.ValidateRequest(genRequest())
+		assert.Equal(t, ErrDPoPProofReplayed, err)
+	})
+}
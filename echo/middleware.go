@@ -0,0 +1,52 @@
+// Package echo provides an Echo middleware validating the token of the
+// requests with an auth0.JWTValidator.
+package echo
+
+import (
+	"net/http"
+
+	"github.com/auth0-community/go-auth0"
+	"github.com/labstack/echo/v4"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+const (
+	// TokenKey is the Echo context key of the
+	// *jwt.JSONWebToken validated by the middleware.
+	TokenKey = "auth0-token"
+	// ClaimsKey is the Echo context key of the
+	// map[string]interface{} claims validated by the middleware.
+	ClaimsKey = "auth0-claims"
+)
+
+// Middleware creates an Echo middleware validating the token of the requests
+// with the validator, like auth0.Middleware. The validated token and its
+// claims are stored in the Echo context under TokenKey and ClaimsKey, and in
+// the request context under the auth0 keys.
+func Middleware(validator *auth0.JWTValidator, opts ...auth0.MiddlewareOption) echo.MiddlewareFunc {
+	mw := auth0.Middleware(validator, opts...)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			var err error
+			mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				c.SetRequest(r)
+				c.Set(TokenKey, r.Context().Value(auth0.TokenContextKey))
+				c.Set(ClaimsKey, r.Context().Value(auth0.ClaimsContextKey))
+				err = next(c)
+			})).ServeHTTP(c.Response(), c.Request())
+			return err
+		}
+	}
+}
+
+// Token returns the token validated by the middleware.
+func Token(c echo.Context) (*jwt.JSONWebToken, bool) {
+	token, ok := c.Get(TokenKey).(*jwt.JSONWebToken)
+	return token, ok
+}
+
+// Claims returns the claims validated by the middleware.
+func Claims(c echo.Context) (map[string]interface{}, bool) {
+	claims, ok := c.Get(ClaimsKey).(map[string]interface{})
+	return claims, ok
+}
@@ -0,0 +1,324 @@
+package auth0
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// Classes of validation errors. The errors returned by the validator match
+// one of them with errors.Is, along with the underlying go-jose error, such
+// as jwt.ErrExpired, so responses can tell an expired token from a malformed one.
+var (
+	// ErrTokenExpired is matched by the errors of tokens whose exp claim is past.
+	ErrTokenExpired = errors.New("token is expired")
+	// ErrTokenNotValidYet is matched by the errors of tokens whose nbf
+	// or iat claim is in the future.
+	ErrTokenNotValidYet = errors.New("token is not valid yet")
+	// ErrInvalidAudience is matched by the errors of tokens
+	// not issued for the expected audiences.
+	ErrInvalidAudience = errors.New("invalid audience")
+	// ErrInvalidIssuer is matched by the errors of tokens
+	// not issued by a trusted issuer.
+	ErrInvalidIssuer = errors.New("invalid issuer")
+	// ErrInvalidSignature is matched by the errors of tokens
+	// whose signature cannot be verified with their key.
+	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrTokenMalformed is matched by the errors of tokens
+	// that cannot be parsed or whose claims cannot be decoded.
+	ErrTokenMalformed = errors.New("token is malformed")
+	// ErrJWKSFetchFailed is matched by the errors of downloads of the JWKS
+	// failing with an unexpected status code. Use errors.As with
+	// *JWKSFetchError to retrieve the status code.
+	ErrJWKSFetchFailed = errors.New("JWKS download failed")
+	// ErrTokenRequestFailed is matched by the errors of requests of access
+	// tokens rejected by the token endpoint. Use errors.As with *TokenError
+	// to retrieve the OAuth2 error code.
+	ErrTokenRequestFailed = errors.New("token request failed")
+	// ErrIntrospectionFailed is matched by the errors of introspections
+	// failing with an unexpected status code. Use errors.As with
+	// *IntrospectionError to retrieve the status code.
+	ErrIntrospectionFailed = errors.New("token introspection failed")
+	// ErrNoAccessToken is returned when the response
+	// of the token endpoint has no access_token.
+	ErrNoAccessToken = errors.New("no access_token in the token response")
+	// ErrParserPanic is matched by the errors of the inputs, such as tokens
+	// or JWKS, whose parsing panicked. Use errors.As with *PanicError to
+	// retrieve the value of the panic.
+	ErrParserPanic = errors.New("parser panicked")
+)
+
+// ClaimError is returned when a registered claim of the token is invalid.
+// It matches its class, such as ErrTokenExpired, and the go-jose error
+// it wraps, such as jwt.ErrExpired, with errors.Is.
+type ClaimError struct {
+	// Claim is the name of the invalid claim, such as "exp".
+	Claim string
+	// Value is the value of the claim in the token: a time.Time for
+	// exp, nbf and iat, a jwt.Audience for aud and a string otherwise.
+	Value interface{}
+	// Err is the class of the error, such as ErrTokenExpired.
+	Err error
+
+	cause error
+}
+
+func (e *ClaimError) Error() string {
+	return fmt.Sprintf("%s: %s=%v", e.cause, e.Claim, e.Value)
+}
+
+// Is makes errors.Is match the class of the error.
+func (e *ClaimError) Is(target error) bool {
+	return target == e.Err
+}
+
+// Unwrap returns the underlying error, such as jwt.ErrExpired.
+func (e *ClaimError) Unwrap() error {
+	return e.cause
+}
+
+// JWKSFetchError is returned when the JWKS endpoint responds
+// with an unexpected status code.
+type JWKSFetchError struct {
+	StatusCode int
+	// Body is the beginning of the body of the response,
+	// at most maxErrorBodySize bytes.
+	Body string
+}
+
+func (e *JWKSFetchError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("unexpected status code %d from JWKS endpoint", e.StatusCode)
+	}
+	return fmt.Sprintf("unexpected status code %d from JWKS endpoint: %s", e.StatusCode, e.Body)
+}
+
+// Is makes errors.Is match ErrJWKSFetchFailed.
+func (e *JWKSFetchError) Is(target error) bool {
+	return target == ErrJWKSFetchFailed
+}
+
+// IntrospectionError is returned when the introspection endpoint
+// responds with an unexpected status code.
+type IntrospectionError struct {
+	StatusCode int
+	// Body is the beginning of the body of the response,
+	// at most maxErrorBodySize bytes.
+	Body string
+}
+
+func (e *IntrospectionError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("unexpected status code %d from introspection endpoint", e.StatusCode)
+	}
+	return fmt.Sprintf("unexpected status code %d from introspection endpoint: %s", e.StatusCode, e.Body)
+}
+
+// Is makes errors.Is match ErrIntrospectionFailed.
+func (e *IntrospectionError) Is(target error) bool {
+	return target == ErrIntrospectionFailed
+}
+
+// TokenError is returned when the token endpoint rejects
+// the request of an access token.
+type TokenError struct {
+	StatusCode int
+	// Code and Description are the OAuth2 error of the
+	// response, such as "access_denied".
+	Code        string
+	Description string
+	// Body is the beginning of the body of the response
+	// when it holds no OAuth2 error.
+	Body string
+}
+
+func (e *TokenError) Error() string {
+	switch {
+	case e.Code != "" && e.Description != "":
+		return fmt.Sprintf("token request failed with status code %d: %s: %s", e.StatusCode, e.Code, e.Description)
+	case e.Code != "":
+		return fmt.Sprintf("token request failed with status code %d: %s", e.StatusCode, e.Code)
+	case e.Body != "":
+		return fmt.Sprintf("token request failed with status code %d: %s", e.StatusCode, e.Body)
+	}
+	return fmt.Sprintf("token request failed with status code %d", e.StatusCode)
+}
+
+// Is makes errors.Is match ErrTokenRequestFailed.
+func (e *TokenError) Is(target error) bool {
+	return target == ErrTokenRequestFailed
+}
+
+// PanicError is returned when the parsing of a malformed input panics, the
+// panic being recovered. The errors of the tokens also match
+// ErrTokenMalformed. It matches ErrParserPanic with errors.Is.
+type PanicError struct {
+	// Input is the parsed input, such as "token" or "JWKS".
+	Input string
+	// Value is the value of the recovered panic.
+	Value interface{}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("parsing of the %s panicked: %v", e.Input, e.Value)
+}
+
+// Is makes errors.Is match ErrParserPanic.
+func (e *PanicError) Is(target error) bool {
+	return target == ErrParserPanic
+}
+
+// Inputs of the PanicErrors.
+const (
+	panicInputToken = "token"
+	panicInputJWKS  = "JWKS"
+)
+
+// recoverPanic recovers the panic of the parsing of the input, if any,
+// setting err to its *PanicError. Deferred by the parsing functions.
+func recoverPanic(input string, err *error) {
+	if v := recover(); v != nil {
+		*err = &PanicError{Input: input, Value: v}
+		if input == panicInputToken {
+			*err = malformedError(*err)
+		}
+	}
+}
+
+// maxErrorBodySize is the size of the beginning
+// of the body of the responses kept in errors.
+const maxErrorBodySize = 512
+
+// newJWKSFetchError returns the error of the response,
+// reading the beginning of its body.
+func newJWKSFetchError(resp *http.Response) *JWKSFetchError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	return &JWKSFetchError{
+		StatusCode: resp.StatusCode,
+		Body:       strings.TrimSpace(strings.ToValidUTF8(string(body), "")),
+	}
+}
+
+// newIntrospectionError returns the error of the response,
+// reading the beginning of its body.
+func newIntrospectionError(resp *http.Response) *IntrospectionError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	return &IntrospectionError{
+		StatusCode: resp.StatusCode,
+		Body:       strings.TrimSpace(strings.ToValidUTF8(string(body), "")),
+	}
+}
+
+// FailureReason returns the low cardinality class of a validation error,
+// such as "expired" or "invalid_signature", to label metrics and audit events.
+// The errors of no known class are "invalid".
+func FailureReason(err error) string {
+	switch {
+	case errors.Is(err, ErrTokenNotFound):
+		return "token_not_found"
+	case errors.Is(err, ErrTokenExpired):
+		return "expired"
+	case errors.Is(err, ErrTokenNotValidYet):
+		return "not_valid_yet"
+	case errors.Is(err, ErrInvalidAudience):
+		return "invalid_audience"
+	case errors.Is(err, ErrInvalidIssuer), errors.Is(err, ErrUnknownIssuer):
+		return "invalid_issuer"
+	case errors.Is(err, ErrInvalidSignature):
+		return "invalid_signature"
+	case errors.Is(err, ErrTokenMalformed):
+		return "malformed"
+	case errors.Is(err, ErrInvalidAlgorithm), errors.Is(err, ErrUnsecuredToken), errors.Is(err, ErrKeyAlgorithmMismatch):
+		return "invalid_algorithm"
+	case errors.Is(err, ErrNoKeyFound), errors.Is(err, ErrRefreshCooldown):
+		return "key_not_found"
+	case IsKeyUnavailable(err):
+		return "jwks_unavailable"
+	case errors.Is(err, ErrInsufficientScope):
+		return "insufficient_scope"
+	case errors.Is(err, ErrInsufficientPermissions):
+		return "insufficient_permissions"
+	case errors.Is(err, ErrTokenKind):
+		return "token_kind"
+	case errors.Is(err, ErrMissingClaims):
+		return "missing_claims"
+	case errors.Is(err, ErrClaimConstraint):
+		return "claim_constraint"
+	case errors.Is(err, ErrInvalidCustomClaims):
+		return "invalid_custom_claims"
+	default:
+		return "invalid"
+	}
+}
+
+// IsKeyUnavailable reports whether the validation failed because the key of
+// the token could not be obtained, rather than because of the token: the
+// JWKS download failed with an unexpected status code or a network error,
+// or the circuit breaker is open. The validation may succeed once retried.
+func IsKeyUnavailable(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, ErrJWKSFetchFailed) || errors.Is(err, ErrCircuitOpen) || errors.As(err, &netErr)
+}
+
+// classifiedError wraps an error of go-jose
+// so errors.Is also matches its class.
+type classifiedError struct {
+	class error
+	cause error
+}
+
+func (e *classifiedError) Error() string {
+	return e.cause.Error()
+}
+
+func (e *classifiedError) Is(target error) bool {
+	return target == e.class
+}
+
+func (e *classifiedError) Unwrap() error {
+	return e.cause
+}
+
+// malformedError classifies an error of the
+// parsing of a token as ErrTokenMalformed.
+func malformedError(err error) error {
+	return &classifiedError{class: ErrTokenMalformed, cause: err}
+}
+
+// claimsError classifies an error of the verification of the signature
+// or of the decoding of the claims of a token.
+func claimsError(err error) error {
+	if errors.Is(err, jose.ErrCryptoFailure) {
+		return &classifiedError{class: ErrInvalidSignature, cause: err}
+	}
+	return malformedError(err)
+}
+
+// newClaimError returns the ClaimError of the error returned
+// by the validation of the registered claims.
+func newClaimError(err error, claims jwt.Claims) error {
+	switch err {
+	case jwt.ErrExpired:
+		return &ClaimError{Claim: "exp", Value: numericDateTime(claims.Expiry), Err: ErrTokenExpired, cause: err}
+	case jwt.ErrNotValidYet:
+		return &ClaimError{Claim: "nbf", Value: numericDateTime(claims.NotBefore), Err: ErrTokenNotValidYet, cause: err}
+	case ErrIssuedInTheFuture:
+		return &ClaimError{Claim: "iat", Value: numericDateTime(claims.IssuedAt), Err: ErrTokenNotValidYet, cause: err}
+	case jwt.ErrInvalidAudience:
+		return &ClaimError{Claim: "aud", Value: claims.Audience, Err: ErrInvalidAudience, cause: err}
+	case jwt.ErrInvalidIssuer:
+		return &ClaimError{Claim: "iss", Value: claims.Issuer, Err: ErrInvalidIssuer, cause: err}
+	}
+	return err
+}
+
+func numericDateTime(date jwt.NumericDate) time.Time {
+	return date.Time().UTC()
+}
@@ -0,0 +1,186 @@
+package auth0
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestValidationErrorClasses(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	valid := jwt.Claims{Issuer: defaultIssuer, Audience: defaultAudience, Expiry: jwt.NewNumericDate(now.Add(time.Hour))}
+
+	tests := []struct {
+		name          string
+		token         string
+		expectedClass error
+		expectedCause error
+		expectedClaim string
+		expectedValue interface{}
+	}{
+		{
+			name:          "expired",
+			token:         getTestTokenWithClaims(jose.HS256, defaultSecret, jwt.Claims{Issuer: defaultIssuer, Audience: defaultAudience, Expiry: jwt.NewNumericDate(now.Add(-time.Hour))}),
+			expectedClass: ErrTokenExpired,
+			expectedCause: jwt.ErrExpired,
+			expectedClaim: "exp",
+			expectedValue: now.Add(-time.Hour).UTC(),
+		},
+		{
+			name:          "not valid yet",
+			token:         getTestTokenWithClaims(jose.HS256, defaultSecret, valid, jwt.Claims{NotBefore: jwt.NewNumericDate(now.Add(time.Hour))}),
+			expectedClass: ErrTokenNotValidYet,
+			expectedCause: jwt.ErrNotValidYet,
+			expectedClaim: "nbf",
+			expectedValue: now.Add(time.Hour).UTC(),
+		},
+		{
+			name:          "issued in the future",
+			token:         getTestTokenWithClaims(jose.HS256, defaultSecret, valid, jwt.Claims{IssuedAt: jwt.NewNumericDate(now.Add(time.Hour))}),
+			expectedClass: ErrTokenNotValidYet,
+			expectedCause: ErrIssuedInTheFuture,
+			expectedClaim: "iat",
+			expectedValue: now.Add(time.Hour).UTC(),
+		},
+		{
+			name:          "invalid audience",
+			token:         getTestTokenWithClaims(jose.HS256, defaultSecret, valid, jwt.Claims{Audience: jwt.Audience{"other"}}),
+			expectedClass: ErrInvalidAudience,
+			expectedCause: jwt.ErrInvalidAudience,
+			expectedClaim: "aud",
+			expectedValue: jwt.Audience{"other"},
+		},
+		{
+			name:          "invalid issuer",
+			token:         getTestTokenWithClaims(jose.HS256, defaultSecret, valid, jwt.Claims{Issuer: "other"}),
+			expectedClass: ErrInvalidIssuer,
+			expectedCause: jwt.ErrInvalidIssuer,
+			expectedClaim: "iss",
+			expectedValue: "other",
+		},
+		{
+			name:          "invalid signature",
+			token:         getTestTokenWithClaims(jose.HS256, []byte("other secret"), valid),
+			expectedClass: ErrInvalidSignature,
+			expectedCause: jose.ErrCryptoFailure,
+		},
+		{
+			name:          "malformed",
+			token:         "not.a.token",
+			expectedClass: ErrTokenMalformed,
+		},
+	}
+
+	validator := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), nil)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := validator.ValidateRawToken(test.token)
+			assert.True(t, errors.Is(err, test.expectedClass), "got %v", err)
+			if test.expectedCause != nil {
+				assert.True(t, errors.Is(err, test.expectedCause), "got %v", err)
+			}
+
+			var claimErr *ClaimError
+			if test.expectedClaim == "" {
+				assert.False(t, errors.As(err, &claimErr))
+				return
+			}
+			if assert.True(t, errors.As(err, &claimErr)) {
+				assert.Equal(t, test.expectedClaim, claimErr.Claim)
+				assert.Equal(t, test.expectedValue, claimErr.Value)
+			}
+		})
+	}
+}
+
+func TestJWKSFetchError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	client := NewJWKClient(JWKClientOptions{URI: ts.URL, RetryPolicy: RetryPolicy{MaxAttempts: 2, BaseBackoff: time.Millisecond}}, nil)
+	_, err := client.GetKey("keyRS256")
+
+	var fetchErr *JWKSFetchError
+	assert.True(t, errors.Is(err, ErrJWKSFetchFailed))
+	if assert.True(t, errors.As(err, &fetchErr)) {
+		assert.Equal(t, http.StatusServiceUnavailable, fetchErr.StatusCode)
+	}
+}
+
+func TestIsKeyUnavailable(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	uri := ts.URL
+	ts.Close()
+
+	// The JWKS endpoint is unreachable.
+	_, err := NewJWKClient(JWKClientOptions{URI: uri}, nil).GetKey("keyRS256")
+	assert.True(t, IsKeyUnavailable(err), "got %v", err)
+	assert.Equal(t, "jwks_unavailable", FailureReason(err))
+
+	assert.True(t, IsKeyUnavailable(&JWKSFetchError{StatusCode: http.StatusServiceUnavailable}))
+	assert.True(t, IsKeyUnavailable(fmt.Errorf("download: %w", ErrCircuitOpen)))
+	assert.False(t, IsKeyUnavailable(ErrKeyNotFound))
+	assert.False(t, IsKeyUnavailable(ErrTokenExpired))
+}
+
+func TestJWKSFetchErrorBody(t *testing.T) {
+	tests := []struct {
+		name         string
+		statusCode   int
+		body         string
+		expectedBody string
+	}{
+		{"JSON error body", http.StatusNotFound, `{"error":"not_found"}`, `{"error":"not_found"}`},
+		{"empty body", http.StatusInternalServerError, "", ""},
+		{"truncated body", http.StatusBadGateway, strings.Repeat("a", 2*maxErrorBodySize), strings.Repeat("a", maxErrorBodySize)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(test.statusCode)
+				fmt.Fprint(w, test.body)
+			}))
+			defer ts.Close()
+
+			client := NewJWKClient(JWKClientOptions{URI: ts.URL}, nil)
+			_, err := client.GetKey("keyRS256")
+
+			var fetchErr *JWKSFetchError
+			if assert.True(t, errors.As(err, &fetchErr), "got %v", err) {
+				assert.Equal(t, test.statusCode, fetchErr.StatusCode)
+				assert.Equal(t, test.expectedBody, fetchErr.Body)
+			}
+		})
+	}
+}
+
+func TestRecoverPanic(t *testing.T) {
+	parse := func(input string) (err error) {
+		defer recoverPanic(input, &err)
+		panic("index out of range")
+	}
+
+	err := parse(panicInputToken)
+	var panicErr *PanicError
+	assert.True(t, errors.As(err, &panicErr), "got %v", err)
+	assert.Equal(t, "index out of range", panicErr.Value)
+	assert.True(t, errors.Is(err, ErrParserPanic))
+	assert.True(t, errors.Is(err, ErrTokenMalformed))
+
+	err = parse(panicInputJWKS)
+	assert.True(t, errors.Is(err, ErrParserPanic))
+	assert.False(t, errors.Is(err, ErrTokenMalformed))
+	assert.EqualError(t, err, "parsing of the JWKS panicked: index out of range")
+}
@@ -2,13 +2,14 @@ module github.com/auth0-community/go-auth0/example
 
 require (
 	github.com/auth0-community/go-auth0 v1.0.1-0.20190927140239-2f65fab42a93
-	github.com/gin-contrib/cors v0.0.0-20180514151808-6f0a820f94be // indirect
+	github.com/gin-contrib/cors v0.0.0-20180514151808-6f0a820f94be
 	github.com/gin-contrib/sse v0.0.0-20170109093832-22d885f9ecc7 // indirect
-	github.com/gin-gonic/gin v1.3.0 // indirect
+	github.com/gin-gonic/gin v1.3.0
 	github.com/golang/protobuf v1.1.0 // indirect
 	github.com/mattn/go-isatty v0.0.3 // indirect
 	github.com/ugorji/go v1.1.1 // indirect
 	gopkg.in/go-playground/validator.v8 v8.18.2 // indirect
+	gopkg.in/square/go-jose.v2 v2.1.7
 )
 
 go 1.13
@@ -0,0 +1,174 @@
+package auth0
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+var (
+	// ErrInvalidKeyFile is returned when the file of a
+	// file key cacher cannot be decrypted.
+	ErrInvalidKeyFile = errors.New("key file cannot be decrypted")
+)
+
+type fileKeyCacher struct {
+	path string
+	aead cipher.AEAD // Encrypts the file when set
+
+	logger  Logger
+	mu      sync.RWMutex
+	entries map[string]jose.JSONWebKey
+}
+
+// NewFileKeyCacher creates a new KeyCacher persisting the last downloaded
+// JWKS to the file at path, and loading it if the file exists, so tokens
+// can be validated at startup while the JWKS endpoint is unreachable.
+// The file is replaced atomically and, if encryptionKey is not nil, encrypted
+// with AES-GCM using the 16, 24 or 32 bytes key. Keys never expire, as with
+// the default persistent key cacher.
+// Failing to write the file fails neither Add nor Set, the keys being cached
+// in memory: the failure is logged by the Logger of the JWKClient.
+func NewFileKeyCacher(path string, encryptionKey []byte) (KeyCacher, error) {
+	c := &fileKeyCacher{
+		path:    path,
+		logger:  nopLogger{},
+		entries: map[string]jose.JSONWebKey{},
+	}
+	if encryptionKey != nil {
+		block, err := aes.NewCipher(encryptionKey)
+		if err != nil {
+			return nil, err
+		}
+		if c.aead, err = cipher.NewGCM(block); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Get obtains a key from the cache.
+func (c *fileKeyCacher) Get(keyID string) (*jose.JSONWebKey, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	key, ok := c.entries[keyID]
+	if !ok {
+		return nil, ErrNoKeyFound
+	}
+	return &key, nil
+}
+
+// Add replaces the cached keys with the downloaded
+// keys and persists them to the file.
+func (c *fileKeyCacher) Add(keyID string, downloadedKeys []jose.JSONWebKey) (*jose.JSONWebKey, error) {
+	c.Set(downloadedKeys)
+
+	for _, key := range downloadedKeys {
+		if key.KeyID == keyID && key.Key != nil {
+			return &key, nil
+		}
+	}
+	return nil, ErrNoKeyFound
+}
+
+// Set implements the KeySetCacher interface, replacing the
+// cached keys with the downloaded keys and persisting them.
+func (c *fileKeyCacher) Set(downloadedKeys []jose.JSONWebKey) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries := map[string]jose.JSONWebKey{}
+	jwks := JWKS{Keys: make([]jose.JSONWebKey, 0, len(downloadedKeys))}
+	for _, key := range downloadedKeys {
+		entries[key.KeyID] = key
+		jwks.Keys = append(jwks.Keys, key.Public())
+	}
+	c.entries = entries
+	if err := c.save(jwks); err != nil {
+		c.logger.Warn("key file not saved", "path", c.path, "error", err)
+	}
+	return nil
+}
+
+// SetLogger implements the LoggedKeyCacher interface.
+func (c *fileKeyCacher) SetLogger(logger Logger) {
+	c.logger = logger
+}
+
+// load reads the keys of the file, if any.
+func (c *fileKeyCacher) load() error {
+	data, err := os.ReadFile(c.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if c.aead != nil {
+		size := c.aead.NonceSize()
+		if len(data) < size {
+			return ErrInvalidKeyFile
+		}
+		if data, err = c.aead.Open(nil, data[:size], data[size:], nil); err != nil {
+			return ErrInvalidKeyFile
+		}
+	}
+
+	jwks, err := parseJWKS(data)
+	if err != nil {
+		return err
+	}
+	for _, key := range jwks.Keys {
+		c.entries[key.KeyID] = key
+	}
+	return nil
+}
+
+// save writes the keys to a temporary file in the directory
+// of the file, then renames it over the file.
+func (c *fileKeyCacher) save(jwks JWKS) error {
+	data, err := json.Marshal(&jwks)
+	if err != nil {
+		return err
+	}
+	if c.aead != nil {
+		nonce := make([]byte, c.aead.NonceSize())
+		if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+			return err
+		}
+		data = c.aead.Seal(nonce, nonce, data, nil)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path)
+}
@@ -0,0 +1,102 @@
+package auth0
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func TestFileKeyCacher(t *testing.T) {
+	keyRS256 := genRSASSAJWK(jose.RS256, "keyRS256")
+	keyES384 := genECDSAJWK(jose.ES384, "keyES384")
+	keys := []jose.JSONWebKey{keyRS256.Public(), keyES384.Public()}
+
+	for name, encryptionKey := range map[string][]byte{"plain": nil, "encrypted": bytes.Repeat([]byte("k"), 32)} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "jwks.json")
+			cacher, err := NewFileKeyCacher(path, encryptionKey)
+			assert.NoError(t, err)
+
+			_, err = cacher.Get("keyRS256")
+			assert.Equal(t, ErrNoKeyFound, err)
+			key, err := cacher.Add("keyRS256", keys)
+			assert.NoError(t, err)
+			assert.Equal(t, "keyRS256", key.KeyID)
+
+			data, err := os.ReadFile(path)
+			assert.NoError(t, err)
+			assert.Equal(t, encryptionKey == nil, bytes.Contains(data, []byte("keyES384")))
+
+			// A new instance loads the keys of the file at startup.
+			cacher, err = NewFileKeyCacher(path, encryptionKey)
+			assert.NoError(t, err)
+			key, err = cacher.Get("keyES384")
+			assert.NoError(t, err)
+			assert.True(t, key.Valid())
+
+			// The keys missing from the last JWKS are dropped.
+			assert.NoError(t, cacher.(KeySetCacher).Set(keys[:1]))
+			cacher, err = NewFileKeyCacher(path, encryptionKey)
+			assert.NoError(t, err)
+			_, err = cacher.Get("keyES384")
+			assert.Equal(t, ErrNoKeyFound, err)
+
+			entries, err := os.ReadDir(filepath.Dir(path))
+			assert.NoError(t, err)
+			assert.Len(t, entries, 1, "temporary files should be removed")
+		})
+	}
+}
+
+func TestFileKeyCacherInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	cacher, err := NewFileKeyCacher(path, bytes.Repeat([]byte("k"), 32))
+	assert.NoError(t, err)
+	key := genRSASSAJWK(jose.RS256, "keyRS256")
+	_, err = cacher.Add("keyRS256", []jose.JSONWebKey{key.Public()})
+	assert.NoError(t, err)
+
+	_, err = NewFileKeyCacher(path, bytes.Repeat([]byte("x"), 32))
+	assert.Equal(t, ErrInvalidKeyFile, err)
+
+	_, err = NewFileKeyCacher(path, []byte("short"))
+	assert.Error(t, err)
+}
+
+func TestFileKeyCacherSaveFailure(t *testing.T) {
+	opts, tokenRS256, _, err := genNewTestServer(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "missing", "jwks.json")
+	cacher, err := NewFileKeyCacher(path, nil)
+	assert.NoError(t, err)
+	logger := &recordingLogger{}
+	opts.Logger = logger
+
+	// The keys are served from memory, the failure is logged.
+	testGetSecret(t, NewJWKClientWithCache(opts, nil, cacher), tokenRS256)
+	assert.True(t, logger.hasPrefix("WARN key file not saved path "+path))
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestFileKeyCacherWithJWKClient(t *testing.T) {
+	opts, tokenRS256, _, err := genNewTestServer(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	cacher, err := NewFileKeyCacher(path, nil)
+	assert.NoError(t, err)
+	testGetSecret(t, NewJWKClientWithCache(opts, nil, cacher), tokenRS256)
+
+	// The JWKS endpoint is unreachable at startup.
+	cacher, err = NewFileKeyCacher(path, nil)
+	assert.NoError(t, err)
+	testGetSecret(t, NewJWKClientWithCache(JWKClientOptions{URI: "http://127.0.0.1:1"}, nil, cacher), tokenRS256)
+}
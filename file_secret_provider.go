@@ -0,0 +1,170 @@
+package auth0
+
+import (
+	"bytes"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// DefaultFilePollInterval is the default interval between two checks
+// of the key file of a FileSecretProvider.
+const DefaultFilePollInterval = 10 * time.Second
+
+// FileSecretProviderOptions configures a FileSecretProvider.
+type FileSecretProviderOptions struct {
+	// PollInterval is the interval between two checks of the modification
+	// time and size of the file. Defaults to DefaultFilePollInterval.
+	PollInterval time.Duration
+	// Logger logs the reloads of the file and their failures.
+	// Defaults to no logging.
+	Logger Logger
+}
+
+// FileSecretProvider provides the key of the token among the keys of a
+// file, reloaded when the file changes so keys delivered by a sidecar or a
+// mounted secret rotate without restarting the service. The file is a JWKS,
+// as served by a JWKS endpoint, or a PEM file as read by NewPEMSecretProvider.
+// Call Stop to terminate the polling of the file.
+type FileSecretProvider struct {
+	path    string
+	options FileSecretProviderOptions
+	keys    atomic.Value // *staticSecretProvider
+
+	mu      sync.Mutex // Used to serialize the reloads
+	modTime time.Time
+	size    int64
+
+	stop     chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+// NewFileSecretProvider loads the keys of the file and polls it for changes.
+// It fails if the file cannot be loaded. A file failing to load later on is
+// logged and the last keys are kept.
+func NewFileSecretProvider(path string, options FileSecretProviderOptions) (*FileSecretProvider, error) {
+	if options.PollInterval <= 0 {
+		options.PollInterval = DefaultFilePollInterval
+	}
+	if options.Logger == nil {
+		options.Logger = nopLogger{}
+	}
+
+	p := &FileSecretProvider{
+		path:    path,
+		options: options,
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	if err := p.Reload(); err != nil {
+		return nil, err
+	}
+
+	go p.poll()
+	return p, nil
+}
+
+// GetSecret implements the SecretProvider interface.
+func (p *FileSecretProvider) GetSecret(token *jwt.JSONWebToken) (interface{}, error) {
+	return p.keys.Load().(*staticSecretProvider).GetSecret(token)
+}
+
+// Reload loads the keys of the file and swaps them
+// atomically with the previous ones.
+func (p *FileSecretProvider) Reload() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return err
+	}
+	return p.load(info)
+}
+
+// load loads the keys of the file of the provided info.
+// The caller must hold mu.
+func (p *FileSecretProvider) load(info os.FileInfo) error {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return err
+	}
+	keys, err := parseKeyFile(data)
+	if err != nil {
+		return err
+	}
+
+	p.keys.Store(keys)
+	p.modTime, p.size = info.ModTime(), info.Size()
+	return nil
+}
+
+// poll reloads the file when its modification time or size
+// changes, until Stop is called.
+func (p *FileSecretProvider) poll() {
+	defer close(p.stopped)
+
+	ticker := time.NewTicker(p.options.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.reloadIfChanged()
+		}
+	}
+}
+
+func (p *FileSecretProvider) reloadIfChanged() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	info, err := os.Stat(p.path)
+	if err != nil {
+		p.options.Logger.Warn("key file cannot be checked, the last keys are kept", "path", p.path, "error", err)
+		return
+	}
+	if info.ModTime().Equal(p.modTime) && info.Size() == p.size {
+		return
+	}
+	if err := p.load(info); err != nil {
+		p.options.Logger.Warn("key file cannot be reloaded, the last keys are kept", "path", p.path, "error", err)
+		return
+	}
+	p.options.Logger.Info("key file reloaded", "path", p.path)
+}
+
+// Stop terminates the polling of the file and waits for it to return.
+// It is safe to call Stop multiple times.
+func (p *FileSecretProvider) Stop() {
+	p.stopOnce.Do(func() {
+		close(p.stop)
+	})
+	<-p.stopped
+}
+
+// parseKeyFile parses the keys of a JWKS or PEM file.
+func parseKeyFile(data []byte) (*staticSecretProvider, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		jwks, err := parseJWKS(trimmed)
+		if err != nil {
+			return nil, err
+		}
+		if len(jwks.Keys) == 0 {
+			return nil, ErrNoKeyFound
+		}
+		return &staticSecretProvider{keys: publicKeys(jwks.Keys)}, nil
+	}
+
+	keys, err := parsePEMKeys(data)
+	if err != nil {
+		return nil, err
+	}
+	return &staticSecretProvider{keys: keys, anyKID: true}, nil
+}
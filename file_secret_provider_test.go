@@ -0,0 +1,110 @@
+package auth0
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func writeJWKSFile(t *testing.T, path string, keys ...jose.JSONWebKey) {
+	data, err := json.Marshal(JWKS{Keys: publicKeys(keys)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileSecretProvider(t *testing.T) {
+	oldKey := genRSASSAJWK(jose.RS256, "old")
+	newKey := genECDSAJWK(jose.ES256, "new")
+	oldToken := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.RS256, oldKey)
+	newToken := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.ES256, newKey)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKSFile(t, path, oldKey)
+
+	logger := &recordingLogger{}
+	provider, err := NewFileSecretProvider(path, FileSecretProviderOptions{PollInterval: 10 * time.Millisecond, Logger: logger})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer provider.Stop()
+	validator := NewValidator(NewConfigurationTrustProvider(provider, defaultAudience, defaultIssuer), nil)
+
+	_, err = validator.ValidateRawToken(oldToken)
+	assert.NoError(t, err)
+	_, err = validator.ValidateRawToken(newToken)
+	assert.True(t, errors.Is(err, ErrKeyNotFound), "got %v", err)
+
+	writeJWKSFile(t, path, newKey)
+	eventually(t, func() bool {
+		_, err := validator.ValidateRawToken(newToken)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	_, err = validator.ValidateRawToken(oldToken)
+	assert.True(t, errors.Is(err, ErrKeyNotFound), "got %v", err)
+
+	if err := os.WriteFile(path, []byte("{not a JWKS"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, func() bool {
+		return logger.hasPrefix("WARN key file cannot be reloaded, the last keys are kept")
+	}, time.Second, 10*time.Millisecond)
+	_, err = validator.ValidateRawToken(newToken)
+	assert.NoError(t, err)
+}
+
+func TestFileSecretProviderPEM(t *testing.T) {
+	key := genRSASSAJWK(jose.RS256, "any")
+	der, err := x509.MarshalPKIXPublicKey(&key.Key.(*rsa.PrivateKey).PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := writePEMFile(t, &pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	provider, err := NewFileSecretProvider(path, FileSecretProviderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer provider.Stop()
+	validator := NewValidator(NewConfigurationTrustProvider(provider, defaultAudience, defaultIssuer), nil)
+
+	_, err = validator.ValidateRawToken(getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.RS256, key))
+	assert.NoError(t, err)
+}
+
+func TestFileSecretProviderErrors(t *testing.T) {
+	t.Run("fail - missing file", func(t *testing.T) {
+		_, err := NewFileSecretProvider(filepath.Join(t.TempDir(), "missing.json"), FileSecretProviderOptions{})
+		assert.True(t, errors.Is(err, os.ErrNotExist))
+	})
+
+	t.Run("fail - empty JWKS", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "jwks.json")
+		writeJWKSFile(t, path)
+		_, err := NewFileSecretProvider(path, FileSecretProviderOptions{})
+		assert.Equal(t, ErrNoKeyFound, err)
+	})
+
+	t.Run("pass - stop twice", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "jwks.json")
+		writeJWKSFile(t, path, genRSASSAJWK(jose.RS256, "kid"))
+		provider, err := NewFileSecretProvider(path, FileSecretProviderOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		provider.Stop()
+		provider.Stop()
+	})
+}
@@ -0,0 +1,96 @@
+package auth0
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gopkg.in/square/go-jose.v2"
+)
+
+// fuzzSeeds returns a signed token, an unsecured one and malformed ones.
+func fuzzSeeds(key jose.JSONWebKey) []string {
+	raw := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.RS256, key)
+	return []string{
+		raw,
+		"eyJhbGciOiJub25lIn0.eyJpc3MiOiJpc3N1ZXIifQ.",
+		"a.b.c",
+		"a.b.c.d.e",
+		"....",
+		"",
+	}
+}
+
+// checkTokenError fails the test if the parsing of a token
+// failed with an error of an unexpected class.
+func checkTokenError(t *testing.T, err error) {
+	if err != nil && !errors.Is(err, ErrTokenMalformed) && err != ErrUnsecuredToken && err != ErrTokenEncrypted {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func FuzzParseSigned(f *testing.F) {
+	for _, seed := range fuzzSeeds(genRSASSAJWK(jose.RS256, "")) {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, raw string) {
+		token, err := parseSigned(raw)
+		checkTokenError(t, err)
+		if err == nil && len(token.Headers) == 0 {
+			t.Error("token with no headers")
+		}
+	})
+}
+
+func FuzzPeek(f *testing.F) {
+	for _, seed := range fuzzSeeds(genRSASSAJWK(jose.RS256, "")) {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, raw string) {
+		_, err := PeekHeaders(raw)
+		checkTokenError(t, err)
+		_, err = PeekClaims(raw)
+		checkTokenError(t, err)
+	})
+}
+
+func FuzzFromHeaderWithScheme(f *testing.F) {
+	for _, seed := range fuzzSeeds(genRSASSAJWK(jose.RS256, "")) {
+		f.Add("Bearer " + seed)
+	}
+	f.Add("bearer")
+	f.Add(" JWT  a.b.c ")
+	extractor := FromHeaderWithScheme("Bearer", "JWT")
+	f.Fuzz(func(t *testing.T, header string) {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Authorization", header)
+		_, _ = extractor.Extract(r)
+	})
+}
+
+func FuzzParseJWKS(f *testing.F) {
+	f.Add([]byte(`{"keys":[]}`))
+	f.Add([]byte(`{"keys":[{"kty":"EC","crv":"P-256","x":"","y":""}]}`))
+	f.Add([]byte(`{"keys":[{"kty":"RSA","n":"AQAB","e":""}]}`))
+	f.Add([]byte(`{"keys":[{"kty":"oct","k":"c2VjcmV0"}]}`))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		jwks, err := parseJWKS(data)
+		if err == nil {
+			for _, key := range jwks.Keys {
+				_ = key.Valid()
+			}
+		}
+	})
+}
+
+func FuzzValidateRawToken(f *testing.F) {
+	key := genRSASSAJWK(jose.RS256, "")
+	for _, seed := range fuzzSeeds(key) {
+		f.Add(seed)
+	}
+	validator := NewValidator(NewConfiguration(NewKeyProvider(key.Public().Key), defaultAudience, defaultIssuer, jose.RS256), nil)
+	f.Fuzz(func(t *testing.T, raw string) {
+		_, _ = validator.ValidateRawToken(raw)
+	})
+}
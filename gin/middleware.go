@@ -0,0 +1,55 @@
+// Package gin provides a Gin middleware validating the token of the
+// requests with an auth0.JWTValidator.
+package gin
+
+import (
+	"net/http"
+
+	"github.com/auth0-community/go-auth0"
+	"github.com/gin-gonic/gin"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+const (
+	// TokenKey is the Gin context key of the
+	// *jwt.JSONWebToken validated by the middleware.
+	TokenKey = "auth0-token"
+	// ClaimsKey is the Gin context key of the
+	// map[string]interface{} claims validated by the middleware.
+	ClaimsKey = "auth0-claims"
+)
+
+// Middleware creates a Gin middleware validating the token of the requests
+// with the validator, like auth0.Middleware. The validated token and its
+// claims are stored in the Gin context under TokenKey and ClaimsKey, and in
+// the request context under the auth0 keys. Rejected requests are aborted.
+func Middleware(validator *auth0.JWTValidator, opts ...auth0.MiddlewareOption) gin.HandlerFunc {
+	mw := auth0.Middleware(validator, opts...)
+	return func(c *gin.Context) {
+		passed := false
+		mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			passed = true
+			c.Request = r
+			c.Set(TokenKey, r.Context().Value(auth0.TokenContextKey))
+			c.Set(ClaimsKey, r.Context().Value(auth0.ClaimsContextKey))
+			c.Next()
+		})).ServeHTTP(c.Writer, c.Request)
+		if !passed {
+			c.Abort()
+		}
+	}
+}
+
+// Token returns the token validated by the middleware.
+func Token(c *gin.Context) (*jwt.JSONWebToken, bool) {
+	value, _ := c.Get(TokenKey)
+	token, ok := value.(*jwt.JSONWebToken)
+	return token, ok
+}
+
+// Claims returns the claims validated by the middleware.
+func Claims(c *gin.Context) (map[string]interface{}, bool) {
+	value, _ := c.Get(ClaimsKey)
+	claims, ok := value.(map[string]interface{})
+	return claims, ok
+}
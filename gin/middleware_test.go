@@ -0,0 +1,81 @@
+package gin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/auth0-community/go-auth0"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+var (
+	testSecret   = []byte("secret")
+	testAudience = []string{"audience"}
+	testIssuer   = "issuer"
+)
+
+func getTestToken(t *testing.T, scope string) string {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: testSecret}, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := jwt.Signed(signer).Claims(jwt.Claims{
+		Issuer:   testIssuer,
+		Audience: testAudience,
+		Expiry:   jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}).Claims(map[string]interface{}{"scope": scope}).CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestMiddleware(t *testing.T) {
+	configuration := auth0.NewConfiguration(auth0.NewKeyProvider(testSecret), testAudience, testIssuer, jose.HS256)
+	validator := auth0.NewValidator(configuration, nil, auth0.RequireScopes("read:users"))
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware(validator))
+	router.GET("/", func(c *gin.Context) {
+		_, ok := Token(c)
+		assert.True(t, ok)
+		claims, ok := Claims(c)
+		assert.True(t, ok)
+		assert.Equal(t, "read:users", claims["scope"])
+		_, ok = c.Request.Context().Value(auth0.ClaimsContextKey).(map[string]interface{})
+		assert.True(t, ok)
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name           string
+		authorization  string
+		expectedStatus int
+	}{
+		{"pass - valid token", "Bearer " + getTestToken(t, "read:users"), http.StatusOK},
+		{"fail - no token", "", http.StatusUnauthorized},
+		{"fail - invalid token", "Bearer invalid", http.StatusUnauthorized},
+		{"fail - insufficient scope", "Bearer " + getTestToken(t, "write:users"), http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.authorization != "" {
+				r.Header.Set("Authorization", test.authorization)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, test.expectedStatus, w.Code)
+			if test.expectedStatus != http.StatusOK {
+				assert.Contains(t, w.Header().Get("WWW-Authenticate"), "Bearer")
+			}
+		})
+	}
+}
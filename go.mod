@@ -2,9 +2,15 @@ module github.com/auth0-community/go-auth0
 
 require (
 	github.com/stretchr/testify v1.4.0
-	golang.org/x/crypto v0.0.0-20180802221240-56440b844dfe // indirect
+	golang.org/x/crypto v0.0.0-20180802221240-56440b844dfe
 	golang.org/x/sync v0.0.0-20200317015054-43a5402ce75a
 	gopkg.in/square/go-jose.v2 v2.1.7
 )
 
-go 1.13
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v2 v2.2.2 // indirect
+)
+
+go 1.18
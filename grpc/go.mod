@@ -0,0 +1,26 @@
+module github.com/auth0-community/go-auth0/grpc
+
+go 1.18
+
+require (
+	github.com/auth0-community/go-auth0 v0.0.0-20261014151049-3d87a288140b
+	github.com/stretchr/testify v1.4.0
+	google.golang.org/grpc v1.57.2
+	gopkg.in/square/go-jose.v2 v2.1.7
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/golang/protobuf v1.5.3 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/crypto v0.0.0-20180802221240-56440b844dfe // indirect
+	golang.org/x/net v0.9.0 // indirect
+	golang.org/x/sync v0.0.0-20200317015054-43a5402ce75a // indirect
+	golang.org/x/sys v0.7.0 // indirect
+	golang.org/x/text v0.9.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20230525234030-28d5490b6b19 // indirect
+	google.golang.org/protobuf v1.30.0 // indirect
+	gopkg.in/yaml.v2 v2.2.2 // indirect
+)
+
+replace github.com/auth0-community/go-auth0 => ../
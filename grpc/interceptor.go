@@ -0,0 +1,100 @@
+// Package grpc provides gRPC server interceptors validating the bearer
+// token of incoming calls with an auth0.JWTValidator.
+package grpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/auth0-community/go-auth0"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// FromMetadata returns the raw token of the "authorization"
+// metadata of the incoming context, using the Bearer scheme.
+func FromMetadata(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", auth0.ErrTokenNotFound
+	}
+	for _, h := range md.Get("authorization") {
+		if len(h) > 7 && strings.EqualFold(h[0:7], "BEARER ") {
+			return h[7:], nil
+		}
+	}
+	return "", auth0.ErrTokenNotFound
+}
+
+// validate validates the token of the incoming context and returns a
+// context holding the validated token and claims under the auth0 keys.
+func validate(ctx context.Context, validator *auth0.JWTValidator) (context.Context, error) {
+	raw, err := FromMetadata(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	claims := map[string]interface{}{}
+	token, err := validator.ValidateRawTokenContext(ctx, raw, &claims)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return auth0.NewContext(ctx, token, raw, claims), nil
+}
+
+// statusError returns the status of the validation error.
+func statusError(err error) error {
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	case auth0.IsKeyUnavailable(err):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, auth0.ErrInsufficientScope), errors.Is(err, auth0.ErrInsufficientPermissions), errors.Is(err, auth0.ErrTokenKind):
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return status.Error(codes.Unauthenticated, err.Error())
+}
+
+// UnaryServerInterceptor creates a unary server interceptor validating
+// the bearer token of the calls with the validator. Calls with no valid
+// token fail with codes.Unauthenticated, calls whose token lacks the
+// required scopes or permissions, or is not of the required kind,
+// with codes.PermissionDenied, and calls whose key could not be obtained,
+// see auth0.IsKeyUnavailable, with codes.Unavailable so clients retry them.
+// The key lookup stops with codes.Canceled or codes.DeadlineExceeded once
+// the call is done.
+func UnaryServerInterceptor(validator *auth0.JWTValidator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := validate(ctx, validator)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor creates a stream server interceptor validating
+// the bearer token of the calls with the validator, like UnaryServerInterceptor.
+func StreamServerInterceptor(validator *auth0.JWTValidator) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := validate(ss.Context(), validator)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// serverStream overrides the context of the wrapped stream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,147 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/auth0-community/go-auth0"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+var (
+	testSecret   = []byte("secret")
+	testAudience = []string{"audience"}
+	testIssuer   = "issuer"
+)
+
+func getTestToken(t *testing.T, scope string) string {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: testSecret}, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := jwt.Signed(signer).Claims(jwt.Claims{
+		Issuer:   testIssuer,
+		Audience: testAudience,
+		Expiry:   jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}).Claims(map[string]interface{}{"scope": scope}).CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func genTestContext(authorization string) context.Context {
+	if authorization == "" {
+		return context.Background()
+	}
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", authorization))
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	configuration := auth0.NewConfiguration(auth0.NewKeyProvider(testSecret), testAudience, testIssuer, jose.HS256)
+	validator := auth0.NewValidator(configuration, nil, auth0.RequireScopes("read:users"))
+	interceptor := UnaryServerInterceptor(validator)
+
+	tests := []struct {
+		name          string
+		authorization string
+		expectedCode  codes.Code
+	}{
+		{"pass - valid token", "Bearer " + getTestToken(t, "read:users"), codes.OK},
+		{"pass - lower case scheme", "bearer " + getTestToken(t, "read:users"), codes.OK},
+		{"fail - no metadata", "", codes.Unauthenticated},
+		{"fail - other scheme", "Basic dXNlcjpwYXNz", codes.Unauthenticated},
+		{"fail - invalid token", "Bearer invalid", codes.Unauthenticated},
+		{"fail - insufficient scope", "Bearer " + getTestToken(t, "write:users"), codes.PermissionDenied},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := interceptor(genTestContext(test.authorization), nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				claims, ok := ctx.Value(auth0.ClaimsContextKey).(map[string]interface{})
+				assert.True(t, ok)
+				assert.Equal(t, "read:users", claims["scope"])
+				return nil, nil
+			})
+			assert.Equal(t, test.expectedCode, status.Code(err))
+		})
+	}
+}
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	configuration := auth0.NewConfiguration(auth0.NewKeyProvider(testSecret), testAudience, testIssuer, jose.HS256)
+	validator := auth0.NewValidator(configuration, nil)
+	interceptor := StreamServerInterceptor(validator)
+
+	called := false
+	err := interceptor(nil, &testServerStream{ctx: genTestContext("Bearer " + getTestToken(t, "read:users"))}, &grpc.StreamServerInfo{}, func(srv interface{}, ss grpc.ServerStream) error {
+		called = true
+		_, ok := ss.Context().Value(auth0.TokenContextKey).(*jwt.JSONWebToken)
+		assert.True(t, ok)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.True(t, called)
+
+	err = interceptor(nil, &testServerStream{ctx: genTestContext("")}, &grpc.StreamServerInfo{}, func(srv interface{}, ss grpc.ServerStream) error {
+		t.Error("The handler should not have been called")
+		return nil
+	})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+// contextSecretProvider waits for the context of the
+// validation, as a JWKS download would, unless it fails with err.
+type contextSecretProvider struct {
+	err error
+}
+
+func (p contextSecretProvider) GetSecret(token *jwt.JSONWebToken) (interface{}, error) {
+	return p.GetSecretContext(context.Background(), token)
+}
+
+func (p contextSecretProvider) GetSecretContext(ctx context.Context, token *jwt.JSONWebToken) (interface{}, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestServerInterceptorKeyFailures(t *testing.T) {
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		t.Error("The handler should not have been called")
+		return nil, nil
+	}
+	token := "Bearer " + getTestToken(t, "read:users")
+
+	// The calls whose key is unavailable can be retried.
+	for _, err := range []error{&auth0.JWKSFetchError{StatusCode: 503}, auth0.ErrCircuitOpen} {
+		validator := auth0.NewValidator(auth0.NewConfiguration(contextSecretProvider{err: err}, testAudience, testIssuer, jose.HS256), nil)
+		_, err = UnaryServerInterceptor(validator)(genTestContext(token), nil, &grpc.UnaryServerInfo{}, handler)
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	}
+
+	// The key lookup stops with the call.
+	validator := auth0.NewValidator(auth0.NewConfiguration(contextSecretProvider{}, testAudience, testIssuer, jose.HS256), nil)
+	ctx, cancel := context.WithTimeout(genTestContext(token), 10*time.Millisecond)
+	defer cancel()
+	_, err := UnaryServerInterceptor(validator)(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+}
@@ -0,0 +1,45 @@
+package auth0
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// HTTPClientOptions configures the transport of the HTTP client of the
+// outgoing requests, such as to the JWKS or token endpoints, in locked-down
+// environments.
+type HTTPClientOptions struct {
+	// ProxyURL is the URL of the proxy of the requests.
+	// Defaults to the proxy of the environment, see http.ProxyFromEnvironment.
+	ProxyURL *url.URL
+	// TLSConfig configures the TLS connections, such as the RootCAs of a
+	// custom CA bundle or the MinVersion. Defaults to the Go defaults.
+	TLSConfig *tls.Config
+	// DialTimeout bounds the establishment of the TCP connections.
+	// Defaults to the timeout of http.DefaultTransport.
+	DialTimeout time.Duration
+	// Timeout bounds the duration of each request, response body included.
+	// No timeout is applied when zero.
+	Timeout time.Duration
+}
+
+// NewHTTPClient creates an HTTP client with a copy of the default transport
+// configured by the options, to set as the Client of the options of the
+// clients of this package.
+func NewHTTPClient(options HTTPClientOptions) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if options.ProxyURL != nil {
+		transport.Proxy = http.ProxyURL(options.ProxyURL)
+	}
+	if options.TLSConfig != nil {
+		transport.TLSClientConfig = options.TLSConfig.Clone()
+	}
+	if options.DialTimeout > 0 {
+		dialer := &net.Dialer{Timeout: options.DialTimeout, KeepAlive: 30 * time.Second}
+		transport.DialContext = dialer.DialContext
+	}
+	return &http.Client{Transport: transport, Timeout: options.Timeout}
+}
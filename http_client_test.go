@@ -0,0 +1,62 @@
+package auth0
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func TestNewHTTPClient(t *testing.T) {
+	key := genRSASSAJWK(jose.RS256, "keyRS256")
+	jwks := genJWKSServer(key)
+	defer jwks.Close()
+
+	t.Run("pass - proxy", func(t *testing.T) {
+		var proxied string
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			proxied = r.URL.String()
+			jwks.Config.Handler.ServeHTTP(w, r)
+		}))
+		defer proxy.Close()
+		proxyURL, err := url.Parse(proxy.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		client := NewJWKClientWithOptions("http://jwks.invalid/.well-known/jwks.json", WithProxyURL(proxyURL))
+		_, err = client.GetKey("keyRS256")
+		assert.NoError(t, err)
+		assert.Equal(t, "http://jwks.invalid/.well-known/jwks.json", proxied)
+	})
+
+	t.Run("pass - custom CA bundle", func(t *testing.T) {
+		ts := httptest.NewTLSServer(jwks.Config.Handler)
+		defer ts.Close()
+		roots := x509.NewCertPool()
+		roots.AddCert(ts.Certificate())
+
+		client := NewJWKClient(JWKClientOptions{
+			URI:       ts.URL,
+			Transport: HTTPClientOptions{TLSConfig: &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}, DialTimeout: time.Second},
+		}, nil)
+		_, err := client.GetKey("keyRS256")
+		assert.NoError(t, err)
+
+		_, err = NewJWKClient(JWKClientOptions{URI: ts.URL}, nil).GetKey("keyRS256")
+		assert.Error(t, err, "the certificate of the server should not be trusted by default")
+	})
+
+	t.Run("pass - client takes precedence", func(t *testing.T) {
+		client := &http.Client{}
+		options := JWKClientOptions{Client: client, Transport: HTTPClientOptions{DialTimeout: time.Second}}
+		assert.Equal(t, client, options.httpClient())
+		assert.Equal(t, http.DefaultClient, JWKClientOptions{}.httpClient())
+	})
+}
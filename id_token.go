@@ -0,0 +1,151 @@
+package auth0
+
+import (
+	"context"
+	"crypto"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	// Register the hash functions of the at_hash claim.
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+var (
+	// ErrInvalidNonce is returned when the nonce claim of
+	// the ID token does not match the expected nonce.
+	ErrInvalidNonce = errors.New("invalid nonce claim (nonce)")
+	// ErrInvalidAuthorizedParty is returned when the azp claim of the ID
+	// token is not one of the audiences of the validator, or is missing
+	// from an ID token with multiple audiences.
+	ErrInvalidAuthorizedParty = errors.New("invalid authorized party claim (azp)")
+	// ErrAuthTimeExpired is returned when the auth_time claim of the ID token
+	// is missing or older than the max age.
+	ErrAuthTimeExpired = errors.New("authentication is too old (auth_time)")
+	// ErrInvalidAccessTokenHash is returned when the at_hash claim of the ID
+	// token is missing or does not match the access token.
+	ErrInvalidAccessTokenHash = errors.New("invalid access token hash claim (at_hash)")
+)
+
+// IDTokenClaims are the claims of an OpenID Connect ID token.
+type IDTokenClaims struct {
+	jwt.Claims
+	Nonce           string          `json:"nonce,omitempty"`
+	AuthorizedParty string          `json:"azp,omitempty"`
+	AuthTime        jwt.NumericDate `json:"auth_time,omitempty"`
+	AccessTokenHash string          `json:"at_hash,omitempty"`
+}
+
+// IDTokenOption configures optional
+// checks of ValidateIDToken.
+type IDTokenOption func(*idTokenChecks)
+
+type idTokenChecks struct {
+	maxAge      time.Duration
+	accessToken string
+}
+
+// WithMaxAge requires the auth_time claim of the ID token to be at most
+// maxAge old, as when the max_age parameter is part of the authentication request.
+func WithMaxAge(maxAge time.Duration) IDTokenOption {
+	return func(c *idTokenChecks) {
+		c.maxAge = maxAge
+	}
+}
+
+// WithAccessToken requires the at_hash claim of the ID token to match
+// the access token issued along with it.
+func WithAccessToken(accessToken string) IDTokenOption {
+	return func(c *idTokenChecks) {
+		c.accessToken = accessToken
+	}
+}
+
+// ValidateIDToken validates the compact serialized ID token following the
+// OpenID Connect Core rules: its signature, issuer, audience and expiry are
+// validated like access tokens, the azp claim must be one of the audiences
+// of the validator when present or when the token has multiple audiences,
+// and the nonce claim must match the expected nonce if not empty.
+// The audiences of the validator are the client IDs of the application.
+func (v *JWTValidator) ValidateIDToken(raw, expectedNonce string, opts ...IDTokenOption) (*IDTokenClaims, error) {
+	checks := idTokenChecks{}
+	for _, opt := range opts {
+		opt(&checks)
+	}
+
+	// The outcome is reported once the ID token checks are done.
+	ctx, start := context.Background(), time.Now()
+	claims := &IDTokenClaims{}
+	token, err := v.validateRaw(ctx, raw, v.parseRawToken, v.leeway, claims)
+	if err == nil {
+		err = v.checkIDToken(token, claims, expectedNonce, checks)
+	}
+	v.report(ctx, time.Since(start), token, nil, err)
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// checkIDToken applies the OpenID Connect checks of ValidateIDToken
+// to the claims of the validated ID token.
+func (v *JWTValidator) checkIDToken(token *jwt.JSONWebToken, claims *IDTokenClaims, expectedNonce string, checks idTokenChecks) error {
+	if claims.AuthorizedParty != "" || len(claims.Audience) > 1 {
+		if !jwt.Audience(v.clientIDs()).Contains(claims.AuthorizedParty) {
+			return ErrInvalidAuthorizedParty
+		}
+	}
+
+	if expectedNonce != "" && subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(expectedNonce)) != 1 {
+		return ErrInvalidNonce
+	}
+
+	if checks.maxAge > 0 {
+		if claims.AuthTime == 0 || v.now().After(claims.AuthTime.Time().Add(checks.maxAge+v.leeway)) {
+			return ErrAuthTimeExpired
+		}
+	}
+
+	if checks.accessToken != "" {
+		hash, ok := accessTokenHash(checks.accessToken, token.Headers[0].Algorithm)
+		if !ok || subtle.ConstantTimeCompare([]byte(claims.AccessTokenHash), []byte(hash)) != 1 {
+			return ErrInvalidAccessTokenHash
+		}
+	}
+
+	return nil
+}
+
+// clientIDs returns the audiences accepted by the validator.
+func (v *JWTValidator) clientIDs() []string {
+	if len(v.audiences) > 0 {
+		return v.audiences
+	}
+	return v.config.expectedClaims.Audience
+}
+
+// accessTokenHash returns the at_hash of the access token: the base64url
+// encoded left-most half of its hash with the hash function of the algorithm.
+func accessTokenHash(accessToken, alg string) (string, bool) {
+	var hash crypto.Hash
+	switch jose.SignatureAlgorithm(alg) {
+	case jose.HS256, jose.RS256, jose.PS256, jose.ES256:
+		hash = crypto.SHA256
+	case jose.HS384, jose.RS384, jose.PS384, jose.ES384:
+		hash = crypto.SHA384
+	case jose.HS512, jose.RS512, jose.PS512, jose.ES512, jose.EdDSA:
+		hash = crypto.SHA512
+	default:
+		return "", false
+	}
+
+	h := hash.New()
+	h.Write([]byte(accessToken))
+	sum := h.Sum(nil)
+	return base64.RawURLEncoding.EncodeToString(sum[:len(sum)/2]), true
+}
@@ -0,0 +1,66 @@
+package auth0
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestValidateIDToken(t *testing.T) {
+	key := genRSASSAJWK(jose.RS256, "")
+	configuration := NewConfiguration(NewKeyProvider(key.Public()), []string{"client"}, defaultIssuer, jose.RS256)
+	validator := NewValidator(configuration, nil)
+
+	accessToken := "access-token"
+	atHash, _ := accessTokenHash(accessToken, string(jose.RS256))
+	registered := jwt.Claims{Issuer: defaultIssuer, Audience: jwt.Audience{"client"}, Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+	multipleAudiences := jwt.Claims{Issuer: defaultIssuer, Audience: jwt.Audience{"client", "api"}, Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+	recentAuth := jwt.NewNumericDate(time.Now().Add(-time.Minute))
+	oldAuth := jwt.NewNumericDate(time.Now().Add(-time.Hour))
+
+	tests := []struct {
+		name    string
+		claims  []interface{}
+		nonce   string
+		opts    []IDTokenOption
+		wantErr error
+	}{
+		{"pass - nonce", []interface{}{registered, map[string]interface{}{"nonce": "n-0S6"}}, "n-0S6", nil, nil},
+		{"pass - no expected nonce", []interface{}{registered}, "", nil, nil},
+		{"pass - azp with multiple audiences", []interface{}{multipleAudiences, map[string]interface{}{"azp": "client"}}, "", nil, nil},
+		{"pass - max age", []interface{}{registered, map[string]interface{}{"auth_time": recentAuth}}, "", []IDTokenOption{WithMaxAge(10 * time.Minute)}, nil},
+		{"pass - at_hash", []interface{}{registered, map[string]interface{}{"at_hash": atHash}}, "", []IDTokenOption{WithAccessToken(accessToken)}, nil},
+		{"fail - nonce mismatch", []interface{}{registered, map[string]interface{}{"nonce": "other"}}, "n-0S6", nil, ErrInvalidNonce},
+		{"fail - missing nonce", []interface{}{registered}, "n-0S6", nil, ErrInvalidNonce},
+		{"fail - multiple audiences without azp", []interface{}{multipleAudiences}, "", nil, ErrInvalidAuthorizedParty},
+		{"fail - azp of another client", []interface{}{registered, map[string]interface{}{"azp": "other"}}, "", nil, ErrInvalidAuthorizedParty},
+		{"fail - authentication too old", []interface{}{registered, map[string]interface{}{"auth_time": oldAuth}}, "", []IDTokenOption{WithMaxAge(10 * time.Minute)}, ErrAuthTimeExpired},
+		{"fail - missing auth_time", []interface{}{registered}, "", []IDTokenOption{WithMaxAge(10 * time.Minute)}, ErrAuthTimeExpired},
+		{"fail - at_hash mismatch", []interface{}{registered, map[string]interface{}{"at_hash": atHash}}, "", []IDTokenOption{WithAccessToken("other")}, ErrInvalidAccessTokenHash},
+		{"fail - missing at_hash", []interface{}{registered}, "", []IDTokenOption{WithAccessToken(accessToken)}, ErrInvalidAccessTokenHash},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			raw := getTestTokenWithClaims(jose.RS256, key, test.claims...)
+			claims, err := validator.ValidateIDToken(raw, test.nonce, test.opts...)
+			assert.Equal(t, test.wantErr, err)
+			if err == nil {
+				assert.Equal(t, defaultIssuer, claims.Issuer)
+			}
+		})
+	}
+}
+
+func TestAccessTokenHash(t *testing.T) {
+	// Example of the OpenID Connect Core specification, section A.3.
+	hash, ok := accessTokenHash("jHkWEdUXMU1BwAsC4vtUsZwnNvTIxEl0z9K3vx5KF0Y", string(jose.RS256))
+	assert.True(t, ok)
+	assert.Equal(t, "77QmUPtjPfzWtF2AnpK9RQ", hash)
+
+	_, ok = accessTokenHash("token", "none")
+	assert.False(t, ok)
+}
@@ -0,0 +1,224 @@
+package auth0
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+var (
+	// ErrInactiveToken is returned when the introspection
+	// endpoint reports the token as not active.
+	ErrInactiveToken = errors.New("token is not active")
+	// ErrIntrospectionTooLarge is returned when the body of the introspection
+	// response is larger than MaxResponseSize.
+	ErrIntrospectionTooLarge = errors.New("introspection response is too large")
+)
+
+// DefaultIntrospectionCacheSize is the default maximum
+// number of active tokens cached by an IntrospectionClient.
+const DefaultIntrospectionCacheSize = 1000
+
+// DefaultMaxIntrospectionSize is the default size
+// limit of the introspection responses.
+const DefaultMaxIntrospectionSize = 1 << 20
+
+// IntrospectionClientOptions configures the
+// OAuth2 token introspection (RFC 7662) client.
+type IntrospectionClientOptions struct {
+	URI string
+	// ClientID and ClientSecret authenticate the
+	// client to the endpoint with HTTP Basic.
+	ClientID     string
+	ClientSecret string
+	Client       *http.Client
+	// CacheTTL is how long active tokens are cached, bounded by their exp.
+	// Tokens are introspected on every call when zero.
+	CacheTTL time.Duration
+	// MaxCacheEntries is the maximum number of cached tokens, the least
+	// recently used ones being evicted beyond. Defaults to
+	// DefaultIntrospectionCacheSize.
+	MaxCacheEntries int
+	// MaxResponseSize is the size limit in bytes of the body of the
+	// responses, larger ones fail with ErrIntrospectionTooLarge. Defaults
+	// to DefaultMaxIntrospectionSize.
+	MaxResponseSize int64
+}
+
+// IntrospectionClient validates opaque tokens with
+// an OAuth2 token introspection endpoint.
+type IntrospectionClient struct {
+	options IntrospectionClientOptions
+
+	mu    sync.Mutex                 // Used to lock reads/writes to the cache
+	cache map[[32]byte]*list.Element // Active tokens, by hash of the token
+	order *list.List                 // Front is the most recently used token
+}
+
+type introspectionHit struct {
+	hash      [32]byte
+	claims    map[string]interface{}
+	expiresAt time.Time
+}
+
+// NewIntrospectionClient creates a new IntrospectionClient
+// instance from the provided options.
+func NewIntrospectionClient(options IntrospectionClientOptions) *IntrospectionClient {
+	if options.Client == nil {
+		options.Client = http.DefaultClient
+	}
+	if options.MaxCacheEntries <= 0 {
+		options.MaxCacheEntries = DefaultIntrospectionCacheSize
+	}
+	if options.MaxResponseSize <= 0 {
+		options.MaxResponseSize = DefaultMaxIntrospectionSize
+	}
+	return &IntrospectionClient{
+		options: options,
+		cache:   map[[32]byte]*list.Element{},
+		order:   list.New(),
+	}
+}
+
+// Introspect returns the claims of the token reported by the
+// introspection endpoint, such as scope, sub, aud and exp, or
+// ErrInactiveToken if the token is not active. The claims are a copy
+// of the cached ones, which the caller may modify.
+func (c *IntrospectionClient) Introspect(ctx context.Context, token string) (map[string]interface{}, error) {
+	hash := sha256.Sum256([]byte(token))
+	now := time.Now()
+
+	if claims, ok := c.cached(hash, now); ok {
+		return claims, nil
+	}
+
+	claims, err := c.introspect(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.options.CacheTTL > 0 {
+		expiresAt := now.Add(c.options.CacheTTL)
+		if exp, ok := claims["exp"].(float64); ok && time.Unix(int64(exp), 0).Before(expiresAt) {
+			expiresAt = time.Unix(int64(exp), 0)
+		}
+		c.add(&introspectionHit{hash: hash, claims: claims, expiresAt: expiresAt})
+		return copyClaims(claims), nil
+	}
+	return claims, nil
+}
+
+// cached returns the claims of the cached token of the hash unless expired
+// at now, marking it as recently used.
+func (c *IntrospectionClient) cached(hash [32]byte, now time.Time) (map[string]interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.cache[hash]
+	if !ok {
+		return nil, false
+	}
+	hit := elem.Value.(*introspectionHit)
+	if !now.Before(hit.expiresAt) {
+		c.order.Remove(elem)
+		delete(c.cache, hash)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return copyClaims(hit.claims), true
+}
+
+// copyClaims returns a shallow copy of the claims.
+func copyClaims(claims map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(claims))
+	for name, value := range claims {
+		copied[name] = value
+	}
+	return copied
+}
+
+// add caches the active token, evicting the least
+// recently used tokens beyond MaxCacheEntries.
+func (c *IntrospectionClient) add(hit *introspectionHit) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.cache[hit.hash]; ok {
+		elem.Value = hit
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.cache[hit.hash] = c.order.PushFront(hit)
+	for c.order.Len() > c.options.MaxCacheEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.cache, oldest.Value.(*introspectionHit).hash)
+	}
+}
+
+func (c *IntrospectionClient) introspect(ctx context.Context, token string) (map[string]interface{}, error) {
+	form := url.Values{"token": {token}, "token_type_hint": {"access_token"}}
+	req, err := http.NewRequest("POST", c.options.URI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if c.options.ClientID != "" {
+		req.SetBasicAuth(url.QueryEscape(c.options.ClientID), url.QueryEscape(c.options.ClientSecret))
+	}
+
+	resp, err := c.options.Client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newIntrospectionError(resp)
+	}
+	if contentH := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentH, "application/json") {
+		return nil, ErrInvalidContentType
+	}
+
+	maxSize := c.options.MaxResponseSize
+	if resp.ContentLength > maxSize {
+		return nil, ErrIntrospectionTooLarge
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > maxSize {
+		return nil, ErrIntrospectionTooLarge
+	}
+
+	claims := map[string]interface{}{}
+	if err := json.Unmarshal(body, &claims); err != nil {
+		return nil, err
+	}
+	if active, _ := claims["active"].(bool); !active {
+		return nil, ErrInactiveToken
+	}
+	return claims, nil
+}
+
+// registeredClaims returns the registered claims of the introspected claims of an opaque token.
+func registeredClaims(claims map[string]interface{}) (jwt.Claims, error) {
+	registered := jwt.Claims{}
+	data, err := json.Marshal(claims)
+	if err == nil {
+		err = json.Unmarshal(data, &registered)
+	}
+	return registered, err
+}
@@ -0,0 +1,163 @@
+package auth0
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func genTestIntrospectionServer(t *testing.T, counter *uint64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint64(counter, 1)
+		id, secret, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "client", id)
+		assert.Equal(t, "secret", secret)
+		assert.Equal(t, "access_token", r.PostFormValue("token_type_hint"))
+
+		response := map[string]interface{}{
+			"active": true,
+			"scope":  "read:users",
+			"sub":    "user",
+			"iss":    defaultIssuer,
+			"aud":    defaultAudience,
+			"exp":    time.Now().Add(time.Hour).Unix(),
+		}
+		switch r.PostFormValue("token") {
+		case "active":
+		case "other-audience":
+			response["aud"] = "other"
+		case "other-issuer":
+			response["iss"] = "other"
+		default:
+			response = map[string]interface{}{"active": false}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+}
+
+func TestIntrospect(t *testing.T) {
+	var counter uint64
+	ts := genTestIntrospectionServer(t, &counter)
+	defer ts.Close()
+
+	client := NewIntrospectionClient(IntrospectionClientOptions{URI: ts.URL, ClientID: "client", ClientSecret: "secret", CacheTTL: time.Minute})
+
+	claims, err := client.Introspect(context.Background(), "active")
+	assert.NoError(t, err)
+	assert.Equal(t, "read:users", claims["scope"])
+	assert.Equal(t, "user", claims["sub"])
+
+	// The cached claims are not modified through the returned ones.
+	claims["scope"] = "admin"
+	claims, err = client.Introspect(context.Background(), "active")
+	assert.NoError(t, err)
+	assert.Equal(t, "read:users", claims["scope"])
+	claims["scope"] = "admin"
+	claims, err = client.Introspect(context.Background(), "active")
+	assert.NoError(t, err)
+	assert.Equal(t, "read:users", claims["scope"])
+	assert.Equal(t, uint64(1), atomic.LoadUint64(&counter), "active tokens should be cached")
+
+	_, err = client.Introspect(context.Background(), "revoked")
+	assert.Equal(t, ErrInactiveToken, err)
+	_, err = client.Introspect(context.Background(), "revoked")
+	assert.Equal(t, ErrInactiveToken, err)
+	assert.Equal(t, uint64(3), atomic.LoadUint64(&counter), "inactive tokens should not be cached")
+}
+
+func TestIntrospectCacheSize(t *testing.T) {
+	var counter uint64
+	ts := genTestIntrospectionServer(t, &counter)
+	defer ts.Close()
+
+	client := NewIntrospectionClient(IntrospectionClientOptions{URI: ts.URL, ClientID: "client", ClientSecret: "secret", CacheTTL: time.Minute, MaxCacheEntries: 2})
+	assert.Equal(t, DefaultIntrospectionCacheSize, NewIntrospectionClient(IntrospectionClientOptions{}).options.MaxCacheEntries)
+
+	for _, token := range []string{"active", "other-audience", "active", "other-issuer"} {
+		_, err := client.Introspect(context.Background(), token)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, uint64(3), atomic.LoadUint64(&counter))
+	assert.Equal(t, 2, client.order.Len())
+
+	// The least recently used token was evicted, not the active one.
+	_, err := client.Introspect(context.Background(), "active")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), atomic.LoadUint64(&counter))
+	_, err = client.Introspect(context.Background(), "other-audience")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4), atomic.LoadUint64(&counter))
+}
+
+func TestIntrospectErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.PostFormValue("token") == "unavailable" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("maintenance"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"active": true, "sub": strings.Repeat("a", 100)})
+	}))
+	defer ts.Close()
+
+	client := NewIntrospectionClient(IntrospectionClientOptions{URI: ts.URL, MaxResponseSize: 64})
+	assert.Equal(t, int64(DefaultMaxIntrospectionSize), NewIntrospectionClient(IntrospectionClientOptions{}).options.MaxResponseSize)
+
+	_, err := client.Introspect(context.Background(), "unavailable")
+	assert.True(t, errors.Is(err, ErrIntrospectionFailed), "got %v", err)
+	var introspectionErr *IntrospectionError
+	assert.True(t, errors.As(err, &introspectionErr))
+	assert.Equal(t, http.StatusServiceUnavailable, introspectionErr.StatusCode)
+	assert.Equal(t, "maintenance", introspectionErr.Body)
+
+	_, err = client.Introspect(context.Background(), "large")
+	assert.Equal(t, ErrIntrospectionTooLarge, err)
+}
+
+func TestMiddlewareWithIntrospection(t *testing.T) {
+	var counter uint64
+	ts := genTestIntrospectionServer(t, &counter)
+	defer ts.Close()
+	client := NewIntrospectionClient(IntrospectionClientOptions{URI: ts.URL, ClientID: "client", ClientSecret: "secret"})
+
+	tests := []struct {
+		name           string
+		opts           []ValidatorOption
+		token          string
+		expectedStatus int
+	}{
+		{"pass - active opaque token", nil, "active", http.StatusOK},
+		{"pass - JWT", nil, getTestTokenWithClaims(jose.HS256, defaultSecret, map[string]interface{}{"iss": defaultIssuer, "aud": defaultAudience, "exp": time.Now().Add(time.Hour).Unix(), "scope": "read:users"}), http.StatusOK},
+		{"fail - inactive opaque token", nil, "revoked", http.StatusUnauthorized},
+		{"fail - audience of the opaque token", nil, "other-audience", http.StatusUnauthorized},
+		{"fail - issuer of the opaque token", nil, "other-issuer", http.StatusUnauthorized},
+		{"fail - insufficient scope", []ValidatorOption{RequireScopes("write:users")}, "active", http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			validator := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), nil, test.opts...)
+			handler := Middleware(validator, WithIntrospection(client))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				claims, ok := r.Context().Value(ClaimsContextKey).(map[string]interface{})
+				assert.True(t, ok)
+				assert.Equal(t, "read:users", claims["scope"])
+			}))
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, genTestMiddlewareRequest(test.token))
+			assert.Equal(t, test.expectedStatus, rec.Code)
+		})
+	}
+}
@@ -2,13 +2,19 @@ package auth0
 
 import (
 	"bytes"
+	"context"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"golang.org/x/sync/singleflight"
 	"gopkg.in/square/go-jose.v2/jwt"
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"gopkg.in/square/go-jose.v2"
 )
@@ -16,24 +22,167 @@ import (
 var (
 	ErrInvalidContentType = errors.New("should have a JSON content type for JWKS endpoint")
 	ErrInvalidAlgorithm   = errors.New("algorithm is invalid")
+	// ErrRefreshCooldown is returned when a key is not cached and the JWKS
+	// has been downloaded less than RefreshCooldown ago.
+	ErrRefreshCooldown = errors.New("keys have been downloaded too recently")
+	// ErrKeyNotFound is returned when the requested key is not part of the
+	// downloaded JWKS. It wraps ErrNoKeyFound.
+	ErrKeyNotFound = fmt.Errorf("key is not part of the JWKS: %w", ErrNoKeyFound)
+	// ErrJWKSTooLarge is returned when the body of the JWKS
+	// response is larger than MaxResponseSize.
+	ErrJWKSTooLarge = errors.New("JWKS response is too large")
+	// ErrNoJWKS is returned by Healthy when the client holds no JWKS,
+	// or only one that is stale according to its cache headers.
+	ErrNoJWKS = errors.New("no fresh JWKS has been downloaded")
 )
 
+// DefaultMaxJWKSSize is the default size limit of the JWKS responses.
+const DefaultMaxJWKSSize = 4 << 20
+
+// DefaultMinJWKSCacheTTL is the default minimum time a JWKS is fresh
+// under UseCacheHeaders.
+const DefaultMinJWKSCacheTTL = 10 * time.Second
+
 type JWKClientOptions struct {
-	URI    string
-	Client *http.Client
+	URI string
+	// FallbackURIs are the URIs of mirrors of the JWKS, such as the canonical
+	// domain of the tenant when URI is its custom domain, tried in order when
+	// the download from the previous URI fails, retries included.
+	FallbackURIs []string
+	Client       *http.Client
+	// Transport configures the proxy, TLS configuration and dial timeout of
+	// the HTTP client created with NewHTTPClient when Client is nil.
+	Transport HTTPClientOptions
+	// RefreshInterval enables a background refresh of the JWKS when set to a
+	// positive duration. The downloaded keys are added to the key cacher so key
+	// rotation does not require a download on the request path.
+	// Call Stop on the client to terminate the refresh.
+	RefreshInterval time.Duration
+	// UseCacheHeaders makes the client honor the Cache-Control max-age and
+	// Expires headers of the JWKS response: once the JWKS is stale, it is
+	// downloaded again even if the requested key is still cached.
+	UseCacheHeaders bool
+	// MinCacheTTL is the minimum time the JWKS is fresh under UseCacheHeaders,
+	// including when its response has a no-cache, no-store or max-age=0
+	// directive, so such a JWKS is revalidated with a conditional request at
+	// most once per MinCacheTTL rather than downloaded on every request.
+	// Defaults to DefaultMinJWKSCacheTTL.
+	MinCacheTTL time.Duration
+	// RefreshCooldown is the minimum interval between two on-demand downloads
+	// of the JWKS. Requests for keys not cached during the cooldown fail fast
+	// with ErrRefreshCooldown instead of downloading the JWKS again.
+	RefreshCooldown time.Duration
+	// NegativeCacheTTL is how long a key ID missing from the downloaded JWKS
+	// is remembered. Requests for such a key fail with ErrKeyNotFound without
+	// downloading the JWKS again until the TTL is elapsed.
+	NegativeCacheTTL time.Duration
+	// RetryPolicy configures how failed downloads of the JWKS are retried.
+	// The zero value does not retry.
+	RetryPolicy RetryPolicy
+	// MaxStaleOnError is how long the keys of the last downloaded JWKS are
+	// served when the key cacher no longer holds them and downloading the
+	// JWKS fails, counted from the last successful download. The cached keys
+	// are always served when their refresh fails. Zero disables it.
+	MaxStaleOnError time.Duration
+	// CircuitBreaker stops the downloads of the JWKS for a while once they
+	// keep failing, the cached keys being served and the other requests
+	// failing fast with ErrCircuitOpen. The zero value disables it.
+	CircuitBreaker CircuitBreakerPolicy
+	// AllowedAlgorithms is the set of signature algorithms accepted by
+	// GetSecret. Tokens signed with other algorithms are rejected with
+	// ErrInvalidAlgorithm before any key lookup. Defaults to jose.RS256, the
+	// algorithm of Auth0 tenants: callers widen the allowlist for the other
+	// asymmetric algorithms of their JWKS, such as jose.PS256 or jose.ES256,
+	// but never to the HMAC ones, as the public keys of the JWKS must never
+	// be used as HMAC secrets.
+	AllowedAlgorithms []jose.SignatureAlgorithm
+	// Observer is notified of the cache hits, misses, additions and evictions
+	// and of the downloads of the JWKS. It is set on the key cacher if it
+	// implements ObservedKeyCacher.
+	Observer CacheObserver
+	// Tracer starts the spans of the key lookups and of the downloads of
+	// the JWKS, children of the span of the validation of the token.
+	Tracer Tracer
+	// Logger logs the configuration of the client, the downloads of the
+	// JWKS and the refreshes of the cached keys. It is set on the key cacher
+	// if it implements LoggedKeyCacher. Defaults to no logging.
+	Logger Logger
+	// FetchTimeout bounds the duration of each attempt at downloading the
+	// JWKS, including the read of the response, independently of the
+	// timeout of Client. No timeout is applied when zero.
+	FetchTimeout time.Duration
+	// UserAgent is the User-Agent header of the requests of the JWKS.
+	// Go's default User-Agent is sent when empty.
+	UserAgent string
+	// Headers are added to the requests of the JWKS, such as the
+	// identifying header required by an API gateway. UserAgent takes
+	// precedence over a User-Agent header.
+	Headers http.Header
+	// MaxResponseSize is the size limit in bytes of the body of the JWKS
+	// responses, larger ones fail with ErrJWKSTooLarge. Defaults to
+	// DefaultMaxJWKSSize.
+	MaxResponseSize int64
+	// X5CRoots enables the verification of the x5c certificate chains of the
+	// keys of the JWKS: only the keys whose chain is issued by these roots,
+	// with a leaf certificate matching the public key, are used. Downloads
+	// fail with ErrInvalidCertificateChain when no key is trusted.
+	X5CRoots *x509.CertPool
+	// PinnedKeys are the RFC 7638 thumbprints of the keys of the JWKS that
+	// are used, as computed by KeyThumbprint. The other keys are ignored,
+	// protecting against a compromised JWKS endpoint. Downloads fail with
+	// ErrNoPinnedKey when no key is pinned. Every key is used when empty.
+	//
+	// X5CRoots and PinnedKeys also apply to the keys of the key cacher,
+	// such as those of NewFileKeyCacher or NewSharedKeyCacher, ignored if they fail.
+	PinnedKeys []string
 }
 
 type JWKS struct {
 	Keys []jose.JSONWebKey `json:"keys"`
 }
 
+// parseJWKS decodes the JSON JWKS, recovering the panics of go-jose.
+func parseJWKS(data []byte) (jwks JWKS, err error) {
+	defer recoverPanic(panicInputJWKS, &err)
+	err = json.Unmarshal(data, &jwks)
+	return jwks, err
+}
+
 type JWKClient struct {
-	keyCacher KeyCacher
+	keyCacher KeyCacherV2
 	options   JWKClientOptions
 	extractor RequestTokenExtractor
+	observer  CacheObserver
+	logger    Logger
+	breaker   *circuitBreaker
+
+	openIDConfiguration *OpenIDConfiguration // Set when created from the issuer
+
+	mu     sync.RWMutex         // Used to lock reads/writes to the keycacher
+	sf     singleflight.Group   // Used to collapse requests to download keys, keyed by URI
+	misses map[string]time.Time // Expiry of the key IDs missing from the JWKS, guarded by mu
+
+	revalidatingMu sync.Mutex
+	revalidating   map[string]bool // Key IDs being refreshed in the background
 
-	mu sync.RWMutex       // Used to lock reads/writes to the keycacher
-	sf singleflight.Group // Used to collapse requests to download keys
+	lastMu       sync.Mutex  // Used to lock reads/writes to the last downloaded JWKS
+	last         jwksVersion // Last downloaded JWKS, used for conditional requests
+	lastDownload time.Time   // Completion time of the last download, successful or not
+
+	stop     chan struct{} // Closed to terminate the background refresh
+	stopped  chan struct{} // Closed once the background refresh has returned
+	stopOnce sync.Once
+}
+
+// jwksVersion holds the keys of a downloaded JWKS along with
+// the validators needed to issue conditional requests.
+type jwksVersion struct {
+	uri          string
+	keys         []jose.JSONWebKey
+	etag         string
+	lastModified string
+	expiresAt    time.Time
+	refreshedAt  time.Time // Time of the last download, or validation, of the JWKS
 }
 
 // NewJWKClient creates a new JWKClient instance from the
@@ -46,49 +195,375 @@ func NewJWKClient(options JWKClientOptions, extractor RequestTokenExtractor) *JW
 // provided options and a custom keycacher interface.
 // Passing nil to keyCacher will create a persistent key cacher
 func NewJWKClientWithCache(options JWKClientOptions, extractor RequestTokenExtractor, keyCacher KeyCacher) *JWKClient {
+	if keyCacher == nil {
+		keyCacher = newMemoryPersistentKeyCacher()
+	}
+	return NewJWKClientWithCacheV2(options, extractor, AdaptKeyCacher(keyCacher))
+}
+
+// NewJWKClientWithCacheV2 creates a new JWKClient instance from the
+// provided options and a context-aware keycacher interface, called with
+// the context of the key lookups.
+// Passing nil to keyCacher will create a persistent key cacher
+func NewJWKClientWithCacheV2(options JWKClientOptions, extractor RequestTokenExtractor, keyCacher KeyCacherV2) *JWKClient {
 	if extractor == nil {
 		extractor = RequestTokenExtractorFunc(FromHeader)
 	}
 	if keyCacher == nil {
-		keyCacher = newMemoryPersistentKeyCacher()
+		keyCacher = AdaptKeyCacher(newMemoryPersistentKeyCacher())
 	}
-	if options.Client == nil {
-		options.Client = http.DefaultClient
+	options.Client = options.httpClient()
+	observer := options.Observer
+	if observer == nil {
+		observer = NopCacheObserver{}
+	} else if observed, ok := keyCacher.(interface{ SetCacheObserver(CacheObserver) }); ok {
+		observed.SetCacheObserver(observer)
 	}
 
-	return &JWKClient{
+	logger := options.Logger
+	if logger == nil {
+		logger = nopLogger{}
+	}
+	if logged, ok := keyCacher.(interface{ SetLogger(Logger) }); ok {
+		logged.SetLogger(logger)
+	}
+	logger.Info("JWKS client configured",
+		"uri", options.URI,
+		"fallback_uris", options.FallbackURIs,
+		"refresh_interval", options.RefreshInterval,
+		"use_cache_headers", options.UseCacheHeaders,
+		"refresh_cooldown", options.RefreshCooldown,
+		"negative_cache_ttl", options.NegativeCacheTTL,
+		"max_attempts", options.RetryPolicy.MaxAttempts,
+		"circuit_failure_threshold", options.CircuitBreaker.FailureThreshold,
+		"allowed_algorithms", options.AllowedAlgorithms,
+	)
+
+	client := &JWKClient{
 		keyCacher: keyCacher,
 		options:   options,
 		extractor: extractor,
+		observer:  observer,
+		logger:    logger,
+		breaker:   newCircuitBreaker(options.CircuitBreaker),
+		misses:    map[string]time.Time{},
+
+		revalidating: map[string]bool{},
+		stop:         make(chan struct{}),
+		stopped:      make(chan struct{}),
+	}
+
+	if options.RefreshInterval > 0 {
+		go client.refresh(options.RefreshInterval)
+	} else {
+		close(client.stopped)
+	}
+
+	return client
+}
+
+// Stop terminates the background refresh of the JWKS, if any, and waits
+// for it to return. It is safe to call Stop multiple times.
+func (j *JWKClient) Stop() {
+	j.stopOnce.Do(func() {
+		close(j.stop)
+	})
+	<-j.stopped
+}
+
+// refresh downloads the JWKS every interval and adds the keys
+// to the key cacher until Stop is called.
+func (j *JWKClient) refresh(interval time.Duration) {
+	defer close(j.stopped)
+
+	// Stop waiting for any in-flight download as soon as Stop is called.
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-j.stop
+		cancel()
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-j.stop:
+			return
+		case <-ticker.C:
+			// Keys are downloaded again on demand if missing.
+			if err := j.refreshKeys(ctx); err != nil {
+				j.logger.Warn("background refresh of the JWKS failed", "uri", j.options.URI, "error", err)
+			}
+		}
+	}
+}
+
+// revalidate downloads the keys in the background and adds the key ID
+// to the key cacher, unless the key ID is already being refreshed or
+// the JWKS has been downloaded less than RefreshCooldown ago.
+func (j *JWKClient) revalidate(ID string) {
+	if j.inCooldown() {
+		return
+	}
+
+	j.revalidatingMu.Lock()
+	defer j.revalidatingMu.Unlock()
+	if j.revalidating[ID] {
+		return
+	}
+	j.revalidating[ID] = true
+
+	go func() {
+		defer func() {
+			j.revalidatingMu.Lock()
+			delete(j.revalidating, ID)
+			j.revalidatingMu.Unlock()
+		}()
+
+		v, err, _ := j.sf.Do(j.options.URI, func() (interface{}, error) {
+			return j.downloadKeysContext(context.Background())
+		})
+		if err != nil {
+			// The stale key is served until it is expired for longer than the max stale duration.
+			j.logger.Warn("refresh of the stale key failed", "kid", ID, "error", err)
+			return
+		}
+
+		j.mu.Lock()
+		defer j.mu.Unlock()
+		if _, err := j.addKeys(context.Background(), ID, v.([]jose.JSONWebKey)); err == nil {
+			j.observer.OnAdd(ID)
+		}
+	}()
+}
+
+// addKeys caches the downloaded keys, replacing the cached ones unless the
+// key cacher is a KeyCacher adding them, and returns the key with the ID.
+// The caller must hold mu.
+func (j *JWKClient) addKeys(ctx context.Context, ID string, keys []jose.JSONWebKey) (*jose.JSONWebKey, error) {
+	if adapter, ok := j.keyCacher.(*keyCacherAdapter); ok && adapter.adds() {
+		return j.keyCacher.Add(ctx, ID, keys)
+	}
+
+	// The key with the ID is set last so the bounded key cachers keep it.
+	var addingKey *jose.JSONWebKey
+	ordered := make([]jose.JSONWebKey, 0, len(keys))
+	for i, key := range keys {
+		if key.KeyID == ID && key.Key != nil {
+			addingKey = &keys[i]
+			continue
+		}
+		ordered = append(ordered, key)
+	}
+	if addingKey != nil {
+		ordered = append(ordered, *addingKey)
+	}
+
+	if err := j.keyCacher.Set(ctx, ordered); err != nil {
+		return nil, err
+	}
+	if addingKey == nil {
+		return nil, ErrNoKeyFound
+	}
+	key := *addingKey
+	return &key, nil
+}
+
+// Prefetch downloads the JWKS and adds every key to the key cacher, to call
+// during the startup of the service or in its readiness probe so the first
+// requests do not wait for the download. The context bounds how long Prefetch
+// waits, the download being shared with the simultaneous key lookups.
+func (j *JWKClient) Prefetch(ctx context.Context) error {
+	return j.refreshKeys(ctx)
+}
+
+// Healthy returns nil if the client holds a JWKS downloaded by Prefetch or a
+// key lookup that is not stale, according to its cache headers when
+// UseCacheHeaders is set, and ErrNoJWKS otherwise. It never downloads the
+// JWKS, so it is cheap enough for the readiness probes.
+func (j *JWKClient) Healthy(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	j.lastMu.Lock()
+	downloaded := len(j.last.keys) > 0
+	j.lastMu.Unlock()
+
+	if !downloaded || j.jwksExpired() {
+		return ErrNoJWKS
+	}
+	return nil
+}
+
+// refreshKeys downloads the JWKS and adds every key to the key cacher.
+// The download is shared with the simultaneous key lookups, which keep
+// waiting for it when the context is done.
+func (j *JWKClient) refreshKeys(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	ch := j.sf.DoChan(j.options.URI, func() (interface{}, error) {
+		return j.downloadKeysContext(detachedContext{parent: ctx})
+	})
+
+	var res singleflight.Result
+	select {
+	case res = <-ch:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if res.Err != nil {
+		return res.Err
+	}
+
+	keys := res.Val.([]jose.JSONWebKey)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err := j.keyCacher.Set(ctx, keys); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		j.observer.OnAdd(key.KeyID)
+	}
+	j.logger.Debug("cached keys refreshed", "keys", len(keys))
+	return nil
+}
+
+// minCacheTTL returns MinCacheTTL, or its default if not positive.
+func (o JWKClientOptions) minCacheTTL() time.Duration {
+	if o.MinCacheTTL <= 0 {
+		return DefaultMinJWKSCacheTTL
+	}
+	return o.MinCacheTTL
+}
+
+// httpClient returns the Client of the options, an HTTP client created from
+// Transport if nil, or http.DefaultClient if Transport is not set either.
+func (o JWKClientOptions) httpClient() *http.Client {
+	switch {
+	case o.Client != nil:
+		return o.Client
+	case o.Transport != HTTPClientOptions{}:
+		return NewHTTPClient(o.Transport)
+	default:
+		return http.DefaultClient
 	}
 }
 
 // GetKey returns the key associated with the provided ID.
 func (j *JWKClient) GetKey(ID string) (jose.JSONWebKey, error) {
+	return j.GetKeyContext(context.Background(), ID)
+}
+
+// GetKeyContext returns the key associated with the provided ID.
+// The context bounds how long the caller waits for the download of the
+// JWKS when the key is not already cached. The download is shared by the
+// simultaneous lookups and only bounded by FetchTimeout.
+func (j *JWKClient) GetKeyContext(ctx context.Context, ID string) (_ jose.JSONWebKey, err error) {
+	ctx, span := startSpan(ctx, j.options.Tracer, SpanGetKey)
+	span.SetAttribute(AttributeKeyID, ID)
+	defer func() {
+		span.End(err)
+	}()
+	return j.getKey(ctx, span, ID)
+}
+
+func (j *JWKClient) getKey(ctx context.Context, span Span, ID string) (jose.JSONWebKey, error) {
 	j.mu.RLock()
-	searchedKey, err := j.keyCacher.Get(ID)
+	searchedKey, err := j.keyCacher.Get(ctx, ID)
+	missExpiry, missing := j.misses[ID]
 	j.mu.RUnlock()
 
-	if err != nil {
-		// All simultaneous calls of `GetKey` will result in only a single call to `downloadKeys` due to `sf.Do`
-		v, err, _ := j.sf.Do("", func() (interface{}, error) {
-			keys, err := j.downloadKeys()
+	// The key cachers may be shared with other processes or persisted, so
+	// their keys are held to the policies of the downloaded keys.
+	if searchedKey != nil && !j.allowsCachedKey(*searchedKey) {
+		searchedKey, err = nil, ErrNoKeyFound
+	}
+
+	hit := err == nil || (err == ErrKeyExpired && searchedKey != nil)
+	span.SetAttribute(AttributeCacheHit, hit)
+	if hit {
+		j.observer.OnHit(ID)
+	} else {
+		j.observer.OnMiss(ID)
+	}
+
+	if err != nil && missing && time.Now().Before(missExpiry) {
+		return jose.JSONWebKey{}, ErrKeyNotFound
+	}
+
+	// Serve the stale key while the keys are downloaded again in the background.
+	if err == ErrKeyExpired && searchedKey != nil {
+		j.revalidate(ID)
+		return *searchedKey, nil
+	}
+
+	if err != nil || j.jwksExpired() {
+		if j.inCooldown() {
+			if err == nil {
+				return *searchedKey, nil
+			}
+			return jose.JSONWebKey{}, ErrRefreshCooldown
+		}
+
+		if err := ctx.Err(); err != nil {
+			return jose.JSONWebKey{}, err
+		}
+
+		// All simultaneous calls of `GetKey` will result in only a single call to `downloadKeys` per URI due to `sf.DoChan`.
+		// The download is not canceled with the first caller, as the others wait for it, each attempt being bounded
+		// by FetchTimeout. Every caller stops waiting once its own context is done.
+		ch := j.sf.DoChan(j.options.URI, func() (interface{}, error) {
+			keys, err := j.downloadKeysContext(detachedContext{parent: ctx})
 			if err != nil {
 				return nil, err
 			}
 			return keys, nil
 		})
-		if err != nil {
-			return jose.JSONWebKey{}, err
+
+		var res singleflight.Result
+		select {
+		case res = <-ch:
+		case <-ctx.Done():
+			return jose.JSONWebKey{}, ctx.Err()
+		}
+		span.SetAttribute(AttributeSharedDownload, res.Shared)
+		if observer, ok := j.observer.(SharedDownloadObserver); ok {
+			observer.OnSharedDownload(j.options.URI, res.Shared)
+		}
+		// Degrade to the cached key, or to a recent one, rather than failing the request.
+		if res.Err != nil {
+			key, ok := searchedKey, err == nil
+			if !ok {
+				key, ok = j.recentKey(ID)
+			}
+			if !ok {
+				return jose.JSONWebKey{}, res.Err
+			}
+			if !errors.Is(res.Err, ErrCircuitOpen) {
+				j.logger.Warn("JWKS download failed, serving the cached key", "kid", ID, "uri", j.options.URI, "error", res.Err)
+			}
+			return *key, nil
 		}
 
 		j.mu.Lock()
 		defer j.mu.Unlock()
 
-		addedKey, err := j.keyCacher.Add(ID, v.([]jose.JSONWebKey))
+		addedKey, err := j.addKeys(ctx, ID, res.Val.([]jose.JSONWebKey))
+		if errors.Is(err, ErrNoKeyFound) {
+			j.logger.Warn("key not found in the JWKS", "kid", ID, "uri", j.options.URI)
+			j.addMiss(ID)
+			return jose.JSONWebKey{}, ErrKeyNotFound
+		}
 		if err != nil {
 			return jose.JSONWebKey{}, err
 		}
+		delete(j.misses, ID)
+		j.observer.OnAdd(ID)
 
 		return *addedKey, nil
 	}
@@ -97,43 +572,350 @@ func (j *JWKClient) GetKey(ID string) (jose.JSONWebKey, error) {
 }
 
 func (j *JWKClient) downloadKeys() ([]jose.JSONWebKey, error) {
-	req, err := http.NewRequest("GET", j.options.URI, new(bytes.Buffer))
+	return j.downloadKeysContext(context.Background())
+}
+
+func (j *JWKClient) downloadKeysContext(ctx context.Context) (_ []jose.JSONWebKey, err error) {
+	if !j.breaker.allow() {
+		return []jose.JSONWebKey{}, ErrCircuitOpen
+	}
+	defer func() {
+		opened, closed := j.breaker.done(err, ctx.Err() != nil)
+		if opened {
+			j.logger.Warn("JWKS circuit breaker opened", "uri", j.options.URI, "open_duration", j.breaker.policy.OpenDuration, "error", err)
+		} else if closed {
+			j.logger.Info("JWKS circuit breaker closed", "uri", j.options.URI)
+		}
+	}()
+
+	ctx, span := startSpan(ctx, j.options.Tracer, SpanDownloadKeys)
+	defer func() {
+		span.End(err)
+		j.lastMu.Lock()
+		j.lastDownload = time.Now()
+		j.lastMu.Unlock()
+	}()
+
+	uris := append([]string{j.options.URI}, j.options.FallbackURIs...)
+	for i, uri := range uris {
+		keys, err := j.downloadKeysFrom(ctx, uri)
+		if err == nil || ctx.Err() != nil || i == len(uris)-1 {
+			return keys, err
+		}
+		j.logger.Warn("JWKS download failed, trying the next URI", "uri", uri, "next_uri", uris[i+1], "error", err)
+	}
+	return []jose.JSONWebKey{}, ErrNoKeyFound
+}
+
+// downloadKeysFrom downloads the JWKS of the URI,
+// retrying according to the retry policy.
+func (j *JWKClient) downloadKeysFrom(ctx context.Context, uri string) ([]jose.JSONWebKey, error) {
+	policy := j.options.RetryPolicy
+	for attempt := 1; ; attempt++ {
+		canRetry := attempt < policy.MaxAttempts
+		keys, retryable, err := j.downloadKeysOnce(ctx, uri, canRetry)
+		if err == nil || !retryable || !canRetry {
+			return keys, err
+		}
+
+		timer := time.NewTimer(policy.backoff(attempt))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return []jose.JSONWebKey{}, ctx.Err()
+		}
+	}
+}
+
+// downloadKeysOnce makes a single attempt at downloading the JWKS of the URI
+// and reports whether the attempt may be retried on failure. Responses with a
+// retryable status code are only considered failures when canRetry is set.
+func (j *JWKClient) downloadKeysOnce(ctx context.Context, uri string, canRetry bool) (keys []jose.JSONWebKey, retryable bool, err error) {
+	start, statusCode := time.Now(), 0
+	defer func() {
+		duration := time.Since(start)
+		j.observer.OnDownload(duration, statusCode, err)
+		if err != nil {
+			j.logger.Warn("JWKS download failed", "uri", uri, "status", statusCode, "duration", duration, "error", err)
+			return
+		}
+		j.logger.Debug("JWKS downloaded", "uri", uri, "status", statusCode, "duration", duration, "keys", len(keys))
+	}()
+
+	parent := ctx
+	if j.options.FetchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.options.FetchTimeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequest("GET", uri, new(bytes.Buffer))
 	if err != nil {
-		return []jose.JSONWebKey{}, err
+		return []jose.JSONWebKey{}, false, err
 	}
+	req = req.WithContext(ctx)
+	for key, values := range j.options.Headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	if j.options.UserAgent != "" {
+		req.Header.Set("User-Agent", j.options.UserAgent)
+	}
+
+	j.lastMu.Lock()
+	last := j.last
+	j.lastMu.Unlock()
+
+	// The validators of the JWKS of a mirror do not apply to the others.
+	if len(last.keys) > 0 && last.uri == uri {
+		if last.etag != "" {
+			req.Header.Set("If-None-Match", last.etag)
+		}
+		if last.lastModified != "" {
+			req.Header.Set("If-Modified-Since", last.lastModified)
+		}
+	}
+
 	resp, err := j.options.Client.Do(req)
 
 	if err != nil {
-		return []jose.JSONWebKey{}, err
+		// Network errors and timeouts are retryable, unless the context of the caller is done.
+		return []jose.JSONWebKey{}, parent.Err() == nil, err
 	}
 	defer resp.Body.Close()
+	statusCode = resp.StatusCode
+
+	if canRetry && j.options.RetryPolicy.retryableStatus(resp.StatusCode) {
+		return []jose.JSONWebKey{}, true, newJWKSFetchError(resp)
+	}
+
+	// The JWKS did not change since the last download.
+	if resp.StatusCode == http.StatusNotModified && len(last.keys) > 0 && last.uri == uri {
+		j.lastMu.Lock()
+		j.last.expiresAt = cacheExpiry(resp.Header, time.Now(), j.options.minCacheTTL())
+		j.last.refreshedAt = time.Now()
+		j.lastMu.Unlock()
+		return last.keys, false, nil
+	}
+
+	// Never decode the body of error responses, even if it is JSON.
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return []jose.JSONWebKey{}, false, newJWKSFetchError(resp)
+	}
 
 	if contentH := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentH, "application/json") &&
 		!strings.HasPrefix(contentH, "application/jwk-set+json") {
-		return []jose.JSONWebKey{}, ErrInvalidContentType
+		return []jose.JSONWebKey{}, false, ErrInvalidContentType
 	}
 
-	var jwks = JWKS{}
-	err = json.NewDecoder(resp.Body).Decode(&jwks)
+	maxSize := j.options.MaxResponseSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxJWKSSize
+	}
+	if resp.ContentLength > maxSize {
+		return []jose.JSONWebKey{}, false, ErrJWKSTooLarge
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return []jose.JSONWebKey{}, false, err
+	}
+	if int64(len(body)) > maxSize {
+		return []jose.JSONWebKey{}, false, ErrJWKSTooLarge
+	}
 
+	jwks, err := parseJWKS(body)
 	if err != nil {
-		return []jose.JSONWebKey{}, err
+		return []jose.JSONWebKey{}, false, err
 	}
 
 	if len(jwks.Keys) < 1 {
-		return []jose.JSONWebKey{}, ErrNoKeyFound
+		return []jose.JSONWebKey{}, false, ErrNoKeyFound
+	}
+
+	jwks.Keys, err = j.pinnedKeys(jwks.Keys)
+	if err != nil {
+		return []jose.JSONWebKey{}, false, err
+	}
+	jwks.Keys, err = j.trustedKeys(jwks.Keys)
+	if err != nil {
+		return []jose.JSONWebKey{}, false, err
+	}
+
+	j.lastMu.Lock()
+	j.last = jwksVersion{
+		uri:          uri,
+		keys:         jwks.Keys,
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+		expiresAt:    cacheExpiry(resp.Header, time.Now(), j.options.minCacheTTL()),
+		refreshedAt:  time.Now(),
 	}
+	j.lastMu.Unlock()
 
-	return jwks.Keys, nil
+	return jwks.Keys, false, nil
+}
+
+// allowsCachedKey reports whether the cached key passes the PinnedKeys
+// and X5CRoots policies applied to the keys of the downloaded JWKS.
+func (j *JWKClient) allowsCachedKey(key jose.JSONWebKey) bool {
+	if thumbprint, ok := j.pinned(key); !ok {
+		j.logger.Warn("cached key ignored, it is not pinned", "kid", key.KeyID, "thumbprint", thumbprint)
+		return false
+	}
+	if err := j.trusted(key, time.Now()); err != nil {
+		j.logger.Warn("cached key ignored, its certificate chain is not trusted", "kid", key.KeyID, "error", err)
+		return false
+	}
+	return true
+}
+
+// recentKey returns the key of the last downloaded JWKS if it has been
+// downloaded less than MaxStaleOnError ago.
+func (j *JWKClient) recentKey(ID string) (*jose.JSONWebKey, bool) {
+	if j.options.MaxStaleOnError <= 0 {
+		return nil, false
+	}
+
+	j.lastMu.Lock()
+	last := j.last
+	j.lastMu.Unlock()
+
+	if last.refreshedAt.IsZero() || time.Since(last.refreshedAt) >= j.options.MaxStaleOnError {
+		return nil, false
+	}
+	for _, key := range last.keys {
+		if key.KeyID == ID {
+			return &key, true
+		}
+	}
+	return nil, false
+}
+
+// jwksExpired reports whether the last downloaded JWKS is stale
+// according to its cache headers. It is always false unless
+// UseCacheHeaders is set.
+func (j *JWKClient) jwksExpired() bool {
+	if !j.options.UseCacheHeaders {
+		return false
+	}
+
+	j.lastMu.Lock()
+	defer j.lastMu.Unlock()
+
+	return !j.last.expiresAt.IsZero() && !time.Now().Before(j.last.expiresAt)
+}
+
+// addMiss remembers that the key ID is missing from the JWKS for
+// NegativeCacheTTL, dropping the expired entries. The caller must hold mu.
+func (j *JWKClient) addMiss(ID string) {
+	if j.options.NegativeCacheTTL <= 0 {
+		return
+	}
+
+	now := time.Now()
+	for missID, expiry := range j.misses {
+		if !now.Before(expiry) {
+			delete(j.misses, missID)
+		}
+	}
+	j.misses[ID] = now.Add(j.options.NegativeCacheTTL)
+}
+
+// inCooldown reports whether the JWKS has been downloaded
+// less than RefreshCooldown ago.
+func (j *JWKClient) inCooldown() bool {
+	if j.options.RefreshCooldown <= 0 {
+		return false
+	}
+
+	j.lastMu.Lock()
+	defer j.lastMu.Unlock()
+
+	return !j.lastDownload.IsZero() && time.Since(j.lastDownload) < j.options.RefreshCooldown
+}
+
+// cacheExpiry returns the time at which a JWKS response with the provided
+// headers becomes stale, at least minTTL from now, or the zero time if the
+// headers do not say.
+func cacheExpiry(h http.Header, now time.Time, minTTL time.Duration) time.Time {
+	expiry := headerExpiry(h, now)
+	if !expiry.IsZero() && expiry.Before(now.Add(minTTL)) {
+		return now.Add(minTTL)
+	}
+	return expiry
+}
+
+// headerExpiry returns the time at which a response with the provided
+// headers becomes stale, or the zero time if the headers do not say.
+// Cache-Control takes precedence over Expires as specified by RFC 7234.
+func headerExpiry(h http.Header, now time.Time) time.Time {
+	if cc := h.Get("Cache-Control"); cc != "" {
+		for _, directive := range strings.Split(cc, ",") {
+			directive = strings.ToLower(strings.TrimSpace(directive))
+			switch {
+			case directive == "no-cache" || directive == "no-store":
+				return now
+			case strings.HasPrefix(directive, "max-age="):
+				maxAge, err := strconv.Atoi(strings.Trim(directive[len("max-age="):], `"`))
+				if err != nil || maxAge < 0 {
+					return now
+				}
+				return now.Add(time.Duration(maxAge) * time.Second)
+			}
+		}
+	}
+
+	if expires := h.Get("Expires"); expires != "" {
+		t, err := http.ParseTime(expires)
+		if err != nil {
+			// Invalid dates, such as "0", represent a time in the past.
+			return now
+		}
+		return t
+	}
+
+	return time.Time{}
 }
 
 // GetSecret implements the GetSecret method of the SecretProvider interface.
 func (j *JWKClient) GetSecret(token *jwt.JSONWebToken) (interface{}, error) {
+	return j.GetSecretContext(context.Background(), token)
+}
+
+// GetSecretContext implements the ContextSecretProvider interface,
+// downloading the JWKS with the context if the key is not cached.
+func (j *JWKClient) GetSecretContext(ctx context.Context, token *jwt.JSONWebToken) (interface{}, error) {
 	if len(token.Headers) < 1 {
 		return nil, ErrNoJWTHeaders
 	}
 
+	if isUnsecured(token) {
+		return nil, ErrUnsecuredToken
+	}
+
 	header := token.Headers[0]
+	if !j.allowsAlgorithm(header.Algorithm) {
+		return nil, ErrInvalidAlgorithm
+	}
 
-	return j.GetKey(header.KeyID)
+	key, err := j.GetKeyContext(ctx, header.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	if !keyMatchesAlgorithm(key, header.Algorithm) {
+		return nil, ErrKeyAlgorithmMismatch
+	}
+	return key, nil
+}
+
+// allowsAlgorithm reports whether tokens signed with the
+// algorithm can be verified with the keys of the JWKS.
+func (j *JWKClient) allowsAlgorithm(alg string) bool {
+	allowed := j.options.AllowedAlgorithms
+	if len(allowed) == 0 {
+		allowed = []jose.SignatureAlgorithm{jose.RS256}
+	}
+	return containsAlgorithm(allowed, alg)
 }
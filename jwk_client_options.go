@@ -0,0 +1,173 @@
+package auth0
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"time"
+
+	"gopkg.in/square/go-jose.v2"
+)
+
+// JWKClientOption configures a JWKClient
+// created with NewJWKClientWithOptions.
+type JWKClientOption func(*jwkClientConfig)
+
+type jwkClientConfig struct {
+	options   JWKClientOptions
+	extractor RequestTokenExtractor
+	keyCacher KeyCacher
+	// keyCacherV2 takes precedence over keyCacher when set.
+	keyCacherV2 KeyCacherV2
+}
+
+// NewJWKClientWithOptions creates a JWKClient downloading the JWKS from the
+// uri, configured with the options. The defaults are the ones of NewJWKClient.
+//
+//	client := NewJWKClientWithOptions("https://mydomain.eu.auth0.com/.well-known/jwks.json",
+//		WithTimeout(5*time.Second),
+//		WithKeyCacher(NewLRUKeyCacher(10, time.Hour)),
+//	)
+func NewJWKClientWithOptions(uri string, opts ...JWKClientOption) *JWKClient {
+	config := jwkClientConfig{options: JWKClientOptions{URI: uri}}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	if config.keyCacherV2 != nil {
+		return NewJWKClientWithCacheV2(config.options, config.extractor, config.keyCacherV2)
+	}
+	return NewJWKClientWithCache(config.options, config.extractor, config.keyCacher)
+}
+
+// WithHTTPClient sets the HTTP client downloading the JWKS.
+// Defaults to http.DefaultClient.
+func WithHTTPClient(client *http.Client) JWKClientOption {
+	return func(c *jwkClientConfig) {
+		c.options.Client = client
+	}
+}
+
+// WithKeyCacher sets the key cacher of the client.
+// Defaults to a cacher keeping the keys forever.
+func WithKeyCacher(keyCacher KeyCacher) JWKClientOption {
+	return func(c *jwkClientConfig) {
+		c.keyCacher = keyCacher
+	}
+}
+
+// WithKeyCacherV2 sets the context-aware key cacher of the client,
+// taking precedence over WithKeyCacher.
+func WithKeyCacherV2(keyCacher KeyCacherV2) JWKClientOption {
+	return func(c *jwkClientConfig) {
+		c.keyCacherV2 = keyCacher
+	}
+}
+
+// WithExtractor sets the extractor of the tokens of the requests.
+// Defaults to FromHeader.
+func WithExtractor(extractor RequestTokenExtractor) JWKClientOption {
+	return func(c *jwkClientConfig) {
+		c.extractor = extractor
+	}
+}
+
+// WithTimeout bounds the duration of each attempt at downloading
+// the JWKS, setting FetchTimeout.
+func WithTimeout(timeout time.Duration) JWKClientOption {
+	return func(c *jwkClientConfig) {
+		c.options.FetchTimeout = timeout
+	}
+}
+
+// WithUserAgent sets the User-Agent header of the requests of the JWKS.
+func WithUserAgent(userAgent string) JWKClientOption {
+	return func(c *jwkClientConfig) {
+		c.options.UserAgent = userAgent
+	}
+}
+
+// WithHeader adds a header to the requests of the JWKS.
+func WithHeader(key, value string) JWKClientOption {
+	return func(c *jwkClientConfig) {
+		if c.options.Headers == nil {
+			c.options.Headers = http.Header{}
+		}
+		c.options.Headers.Add(key, value)
+	}
+}
+
+// WithFallbackURIs sets the URIs of the mirrors of the JWKS,
+// tried in order when the download from the URI fails.
+func WithFallbackURIs(uris ...string) JWKClientOption {
+	return func(c *jwkClientConfig) {
+		c.options.FallbackURIs = uris
+	}
+}
+
+// WithProxyURL sets the proxy of the requests of the JWKS,
+// unless an HTTP client is set with WithHTTPClient.
+func WithProxyURL(proxyURL *url.URL) JWKClientOption {
+	return func(c *jwkClientConfig) {
+		c.options.Transport.ProxyURL = proxyURL
+	}
+}
+
+// WithTLSConfig sets the TLS configuration of the requests of the JWKS,
+// such as a custom CA bundle, unless an HTTP client is set with
+// WithHTTPClient.
+func WithTLSConfig(config *tls.Config) JWKClientOption {
+	return func(c *jwkClientConfig) {
+		c.options.Transport.TLSConfig = config
+	}
+}
+
+// WithDialTimeout bounds the establishment of the connections to the JWKS
+// endpoint, unless an HTTP client is set with WithHTTPClient.
+func WithDialTimeout(timeout time.Duration) JWKClientOption {
+	return func(c *jwkClientConfig) {
+		c.options.Transport.DialTimeout = timeout
+	}
+}
+
+// AllowStaleOnError serves the keys of the last downloaded JWKS for up to
+// maxStale after its download when downloading the JWKS again fails, even
+// if the key cacher no longer holds them, setting MaxStaleOnError.
+func AllowStaleOnError(maxStale time.Duration) JWKClientOption {
+	return func(c *jwkClientConfig) {
+		c.options.MaxStaleOnError = maxStale
+	}
+}
+
+// WithRefreshInterval enables the background
+// refresh of the JWKS every interval.
+func WithRefreshInterval(interval time.Duration) JWKClientOption {
+	return func(c *jwkClientConfig) {
+		c.options.RefreshInterval = interval
+	}
+}
+
+// WithRetryPolicy sets how failed downloads of the JWKS are retried.
+func WithRetryPolicy(policy RetryPolicy) JWKClientOption {
+	return func(c *jwkClientConfig) {
+		c.options.RetryPolicy = policy
+	}
+}
+
+// WithAllowedAlgorithms sets the signature algorithms accepted by GetSecret,
+// instead of the default jose.RS256.
+func WithAllowedAlgorithms(algorithms ...jose.SignatureAlgorithm) JWKClientOption {
+	return func(c *jwkClientConfig) {
+		c.options.AllowedAlgorithms = algorithms
+	}
+}
+
+// WithJWKClientOptions applies the options of the struct-based
+// constructors, keeping the URI of NewJWKClientWithOptions, for the
+// settings with no functional option.
+func WithJWKClientOptions(options JWKClientOptions) JWKClientOption {
+	return func(c *jwkClientConfig) {
+		options.URI = c.options.URI
+		c.options = options
+	}
+}
@@ -0,0 +1,62 @@
+package auth0
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func TestNewJWKClientWithOptions(t *testing.T) {
+	key := genRSASSAJWK(jose.RS256, "keyRS256")
+	jwks := genJWKSServer(key)
+	defer jwks.Close()
+
+	var userAgent, gatewayKey string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		gatewayKey = r.Header.Get("X-Gateway-Key")
+		jwks.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	httpClient := &http.Client{}
+	keyCacher := NewLRUKeyCacher(10, time.Hour)
+	client := NewJWKClientWithOptions(ts.URL,
+		WithHTTPClient(httpClient),
+		WithKeyCacher(keyCacher),
+		WithTimeout(5*time.Second),
+		WithUserAgent("my-api/1.0"),
+		WithHeader("X-Gateway-Key", "key"),
+		WithAllowedAlgorithms(jose.RS256),
+		WithFallbackURIs(jwks.URL),
+		AllowStaleOnError(time.Minute),
+	)
+
+	_, err := client.GetKey("keyRS256")
+	assert.NoError(t, err)
+	assert.Equal(t, "my-api/1.0", userAgent)
+	assert.Equal(t, "key", gatewayKey)
+	assert.Equal(t, 1, keyCacher.Len())
+	assert.Equal(t, ts.URL, client.options.URI)
+	assert.Equal(t, 5*time.Second, client.options.FetchTimeout)
+	assert.Equal(t, httpClient, client.options.Client)
+	assert.Equal(t, []jose.SignatureAlgorithm{jose.RS256}, client.options.AllowedAlgorithms)
+	assert.Equal(t, []string{jwks.URL}, client.options.FallbackURIs)
+	assert.Equal(t, time.Minute, client.options.MaxStaleOnError)
+}
+
+func TestNewJWKClientWithOptionsDefaults(t *testing.T) {
+	client := NewJWKClientWithOptions("https://mydomain.eu.auth0.com/.well-known/jwks.json",
+		WithJWKClientOptions(JWKClientOptions{URI: "ignored", NegativeCacheTTL: time.Minute}),
+	)
+
+	assert.Equal(t, "https://mydomain.eu.auth0.com/.well-known/jwks.json", client.options.URI)
+	assert.Equal(t, time.Minute, client.options.NegativeCacheTTL)
+	assert.Equal(t, http.DefaultClient, client.options.Client)
+	assert.NotNil(t, client.extractor)
+	assert.NotNil(t, client.keyCacher)
+}
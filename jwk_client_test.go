@@ -1,12 +1,16 @@
 package auth0
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"golang.org/x/crypto/ed25519"
 	"gopkg.in/square/go-jose.v2/jwt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -164,6 +168,55 @@ func TestJWKClientRace(t *testing.T) {
 	}()
 }
 
+type sharedDownloadObserver struct {
+	NopCacheObserver
+	shared, unshared int32
+}
+
+func (o *sharedDownloadObserver) OnSharedDownload(uri string, shared bool) {
+	if shared {
+		atomic.AddInt32(&o.shared, 1)
+	} else {
+		atomic.AddInt32(&o.unshared, 1)
+	}
+}
+
+func TestJWKClientSharedDownload(t *testing.T) {
+	key := genRSASSAJWK(jose.RS256, "keyRS256")
+	jwks := genJWKSServer(key)
+	defer jwks.Close()
+
+	var calls int32
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		jwks.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	observer := &sharedDownloadObserver{}
+	client := NewJWKClient(JWKClientOptions{URI: ts.URL, Observer: observer}, nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.GetKey("keyRS256")
+			assert.NoError(t, err)
+		}()
+	}
+	eventually(t, func() bool { return atomic.LoadInt32(&calls) == 1 }, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Equal(t, int32(5), atomic.LoadInt32(&observer.shared))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&observer.unshared))
+}
+
 func TestGetKeyOfJWKClient(t *testing.T) {
 	opts, _, _, err := genNewTestServer(true)
 	if err != nil {
@@ -287,6 +340,32 @@ func TestJWKClient_customClient(t *testing.T) {
 	}
 }
 
+func TestGetSecretAllowedAlgorithms(t *testing.T) {
+	opts, tokenRS256, tokenES384, err := genNewTestServer(true)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	tokenHS256 := getTestTokenWithKid(defaultAudience, defaultIssuer, time.Now().Add(24*time.Hour), jose.HS256, defaultSecret, "keyRS256")
+
+	// Only RS256 is allowed by default.
+	allowed := opts.AllowedAlgorithms
+	opts.AllowedAlgorithms = nil
+	client := NewJWKClient(opts, nil)
+	testGetSecret(t, client, tokenRS256)
+	_, err = client.GetSecret(tokenES384)
+	assert.Equal(t, ErrInvalidAlgorithm, err)
+	_, err = client.GetSecret(tokenHS256)
+	assert.Equal(t, ErrInvalidAlgorithm, err)
+
+	opts.AllowedAlgorithms = allowed
+	client = NewJWKClient(opts, nil)
+	testGetSecret(t, client, tokenRS256)
+	testGetSecret(t, client, tokenES384)
+	_, err = client.GetSecret(tokenHS256)
+	assert.Equal(t, ErrInvalidAlgorithm, err)
+}
+
 func testGetSecret(t *testing.T, client *JWKClient, token *jwt.JSONWebToken) {
 	key, err := client.GetSecret(token)
 	assert.NoError(t, err)
@@ -302,3 +381,825 @@ func (m *mockRoundTripper) RoundTrip(req *http.Request) (*http.Response, error)
 	atomic.AddUint64(m.ops, 1)
 	return m.rt.RoundTrip(req)
 }
+
+func TestGetKeyContextCanceled(t *testing.T) {
+	key := genRSASSAJWK(jose.RS256, "keyRS256")
+	jwks := genJWKSServer(key)
+	defer jwks.Close()
+
+	var calls int32
+	received, release := make(chan struct{}), make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(received)
+		}
+		<-release
+		jwks.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	client := NewJWKClient(JWKClientOptions{URI: ts.URL}, nil)
+
+	// The first caller stops waiting, the others still get the shared download.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	errs := make(chan error)
+	go func() {
+		_, err := client.GetKeyContext(ctx, "keyRS256")
+		errs <- err
+	}()
+	<-received
+	keys := make(chan jose.JSONWebKey)
+	go func() {
+		key, err := client.GetKeyContext(context.Background(), "keyRS256")
+		assert.NoError(t, err)
+		keys <- key
+	}()
+	assert.Equal(t, context.DeadlineExceeded, <-errs)
+
+	close(release)
+	assert.Equal(t, "keyRS256", (<-keys).KeyID)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestJWKClientFetchTimeout(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer ts.Close()
+
+	client := NewJWKClient(JWKClientOptions{
+		URI:          ts.URL,
+		FetchTimeout: 20 * time.Millisecond,
+		RetryPolicy:  RetryPolicy{MaxAttempts: 2, BaseBackoff: time.Millisecond},
+	}, nil)
+
+	start := time.Now()
+	_, err := client.GetKey("keyRS256")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "got %v", err)
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts), "timed out attempts should be retried")
+}
+
+func TestJWKClientHeaders(t *testing.T) {
+	key := genRSASSAJWK(jose.RS256, "keyRS256")
+	jwks := genJWKSServer(key)
+	defer jwks.Close()
+
+	var headers http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header
+		jwks.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	client := NewJWKClient(JWKClientOptions{
+		URI:       ts.URL,
+		UserAgent: "my-api/1.0",
+		Headers:   http.Header{"X-Gateway-Key": {"key"}, "User-Agent": {"overridden"}},
+	}, nil)
+
+	_, err := client.GetKey("keyRS256")
+	assert.NoError(t, err)
+	assert.Equal(t, "my-api/1.0", headers.Get("User-Agent"))
+	assert.Equal(t, []string{"key"}, headers.Values("X-Gateway-Key"))
+}
+
+func TestJWKClientFallbackURIs(t *testing.T) {
+	key := genRSASSAJWK(jose.RS256, "keyRS256")
+	jwks := genJWKSServer(key)
+	defer jwks.Close()
+
+	var primaryCalls int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&primaryCalls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer primary.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	t.Run("pass - mirrors tried in order", func(t *testing.T) {
+		client := NewJWKClient(JWKClientOptions{
+			URI:          primary.URL,
+			FallbackURIs: []string{down.URL, jwks.URL},
+			RetryPolicy:  RetryPolicy{MaxAttempts: 2},
+		}, nil)
+
+		_, err := client.GetKey("keyRS256")
+		assert.NoError(t, err)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&primaryCalls))
+	})
+
+	t.Run("fail - every URI failing", func(t *testing.T) {
+		client := NewJWKClient(JWKClientOptions{URI: primary.URL, FallbackURIs: []string{down.URL}}, nil)
+
+		_, err := client.GetKey("keyRS256")
+		assert.Error(t, err)
+	})
+
+	t.Run("fail - context canceled", func(t *testing.T) {
+		var calls int32
+		mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+		}))
+		defer mirror.Close()
+		client := NewJWKClient(JWKClientOptions{URI: down.URL, FallbackURIs: []string{mirror.URL}}, nil)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := client.GetKeyContext(ctx, "keyRS256")
+		assert.True(t, errors.Is(err, context.Canceled), "got %v", err)
+		assert.Equal(t, int32(0), atomic.LoadInt32(&calls))
+	})
+}
+
+func TestJWKClientPrefetchCanceled(t *testing.T) {
+	key := genRSASSAJWK(jose.RS256, "keyRS256")
+	jwks := genJWKSServer(key)
+	defer jwks.Close()
+
+	var calls int32
+	received, release := make(chan struct{}), make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(received)
+		}
+		<-release
+		jwks.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	client := NewJWKClient(JWKClientOptions{URI: ts.URL}, nil)
+
+	// The key lookups sharing the download of a canceled prefetch get the key.
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error)
+	go func() {
+		errs <- client.Prefetch(ctx)
+	}()
+	<-received
+	keys := make(chan jose.JSONWebKey)
+	go func() {
+		key, err := client.GetKeyContext(context.Background(), "keyRS256")
+		assert.NoError(t, err)
+		keys <- key
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	assert.Equal(t, context.Canceled, <-errs)
+
+	close(release)
+	assert.Equal(t, "keyRS256", (<-keys).KeyID)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestJWKClientPrefetch(t *testing.T) {
+	key := genRSASSAJWK(jose.RS256, "keyRS256")
+	jwks := genJWKSServer(key)
+	defer jwks.Close()
+
+	var calls int32
+	var cacheControl atomic.Value
+	cacheControl.Store("max-age=3600")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", cacheControl.Load().(string))
+		jwks.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	t.Run("pass - keys cached", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		client := NewJWKClient(JWKClientOptions{URI: ts.URL, UseCacheHeaders: true}, nil)
+		assert.Equal(t, ErrNoJWKS, client.Healthy(context.Background()))
+
+		assert.NoError(t, client.Prefetch(context.Background()))
+		assert.NoError(t, client.Healthy(context.Background()))
+		_, err := client.GetKey("keyRS256")
+		assert.NoError(t, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("fail - stale JWKS", func(t *testing.T) {
+		cacheControl.Store("no-cache")
+		defer cacheControl.Store("max-age=3600")
+		client := NewJWKClient(JWKClientOptions{URI: ts.URL, UseCacheHeaders: true, MinCacheTTL: time.Millisecond}, nil)
+
+		assert.NoError(t, client.Prefetch(context.Background()))
+		time.Sleep(5 * time.Millisecond)
+		assert.Equal(t, ErrNoJWKS, client.Healthy(context.Background()))
+	})
+
+	t.Run("fail - download failed", func(t *testing.T) {
+		client := NewJWKClient(JWKClientOptions{URI: "http://127.0.0.1:1/jwks.json"}, nil)
+
+		assert.Error(t, client.Prefetch(context.Background()))
+		assert.Equal(t, ErrNoJWKS, client.Healthy(context.Background()))
+	})
+}
+
+func TestJWKClientMaxResponseSize(t *testing.T) {
+	key := genRSASSAJWK(jose.RS256, "keyRS256")
+	jwks, err := json.Marshal(JWKS{Keys: []jose.JSONWebKey{key.Public()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		maxSize       int64
+		chunked       bool
+		expectedError error
+	}{
+		{"pass - default limit", 0, false, nil},
+		{"pass - within limit", int64(len(jwks)), true, nil},
+		{"fail - content length over limit", int64(len(jwks)) - 1, false, ErrJWKSTooLarge},
+		{"fail - chunked body over limit", int64(len(jwks)) - 1, true, ErrJWKSTooLarge},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if test.chunked {
+					// Flushing before writing the body drops the Content-Length header.
+					w.(http.Flusher).Flush()
+				}
+				w.Write(jwks)
+			}))
+			defer ts.Close()
+
+			client := NewJWKClient(JWKClientOptions{URI: ts.URL, MaxResponseSize: test.maxSize}, nil)
+			_, err := client.GetKey("keyRS256")
+			assert.Equal(t, test.expectedError, err)
+		})
+	}
+}
+
+func TestGetKeyContextSuccess(t *testing.T) {
+	opts, tokenRS256, _, err := genNewTestServer(true)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	client := NewJWKClient(opts, nil)
+
+	key, err := client.GetKeyContext(context.Background(), tokenRS256.Headers[0].KeyID)
+	assert.NoError(t, err)
+	assert.Equal(t, "keyRS256", key.KeyID)
+}
+
+func TestJWKClientBackgroundRefresh(t *testing.T) {
+	opts, tokenRS256, _, err := genNewTestServer(true)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	var counter uint64
+	opts.Client = &http.Client{
+		Transport: &mockRoundTripper{
+			ops: &counter,
+			rt:  http.DefaultTransport,
+		},
+	}
+	opts.RefreshInterval = 10 * time.Millisecond
+	client := NewJWKClient(opts, nil)
+
+	eventually(t, func() bool {
+		return atomic.LoadUint64(&counter) >= 2
+	}, time.Second, 5*time.Millisecond)
+
+	client.Stop()
+	client.Stop()
+
+	refreshed := atomic.LoadUint64(&counter)
+	testGetSecret(t, client, tokenRS256)
+	assert.Equal(t, refreshed, atomic.LoadUint64(&counter), "the key should have been cached by the background refresh")
+
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, refreshed, atomic.LoadUint64(&counter), "no refresh should happen once stopped")
+}
+
+func TestJWKClientStopWithoutRefresh(t *testing.T) {
+	client := NewJWKClient(JWKClientOptions{URI: "invalidURI"}, nil)
+	client.Stop()
+}
+
+func TestJWKDownloadKeyNotModified(t *testing.T) {
+	jsonWebKeyRS256 := genRSASSAJWK(jose.RS256, "keyRS256")
+	jwks := JWKS{Keys: []jose.JSONWebKey{jsonWebKeyRS256.Public()}}
+	value, err := json.Marshal(&jwks)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	var full, notModified uint64
+	lastModified := time.Now().UTC().Format(http.TimeFormat)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` && r.Header.Get("If-Modified-Since") == lastModified {
+			atomic.AddUint64(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		atomic.AddUint64(&full, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", lastModified)
+		w.Write(value)
+	}))
+	defer ts.Close()
+
+	client := NewJWKClient(JWKClientOptions{URI: ts.URL}, nil)
+
+	for i := 0; i < 3; i++ {
+		keys, err := client.downloadKeys()
+		assert.NoError(t, err)
+		if assert.Len(t, keys, 1) {
+			assert.Equal(t, "keyRS256", keys[0].KeyID)
+		}
+	}
+
+	assert.Equal(t, uint64(1), full)
+	assert.Equal(t, uint64(2), notModified)
+}
+
+func TestCacheExpiry(t *testing.T) {
+	now := time.Now()
+	expires := now.Add(time.Hour).UTC().Truncate(time.Second)
+
+	tests := []struct {
+		name     string
+		header   http.Header
+		expected time.Time
+	}{
+		{
+			name:     "no cache headers",
+			header:   http.Header{},
+			expected: time.Time{},
+		},
+		{
+			name:     "max-age",
+			header:   http.Header{"Cache-Control": []string{"public, max-age=15, stale-while-revalidate=15"}},
+			expected: now.Add(15 * time.Second),
+		},
+		{
+			name:     "max-age takes precedence over expires",
+			header:   http.Header{"Cache-Control": []string{"max-age=15"}, "Expires": []string{expires.Format(http.TimeFormat)}},
+			expected: now.Add(15 * time.Second),
+		},
+		{
+			name:     "invalid max-age",
+			header:   http.Header{"Cache-Control": []string{"max-age=abc"}},
+			expected: now,
+		},
+		{
+			name:     "no-cache",
+			header:   http.Header{"Cache-Control": []string{"no-cache"}},
+			expected: now,
+		},
+		{
+			name:     "expires",
+			header:   http.Header{"Expires": []string{expires.Format(http.TimeFormat)}},
+			expected: expires,
+		},
+		{
+			name:     "invalid expires",
+			header:   http.Header{"Expires": []string{"0"}},
+			expected: now,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.True(t, test.expected.Equal(headerExpiry(test.header, now)), "unexpected expiry: %v", headerExpiry(test.header, now))
+		})
+	}
+}
+
+func TestJWKClientUseCacheHeaders(t *testing.T) {
+	jsonWebKeyRS256 := genRSASSAJWK(jose.RS256, "keyRS256")
+	value, err := json.Marshal(&JWKS{Keys: []jose.JSONWebKey{jsonWebKeyRS256.Public()}})
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	for _, useCacheHeaders := range []bool{false, true} {
+		var counter uint64
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddUint64(&counter, 1)
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Cache-Control", "max-age=0")
+			w.Write(value)
+		}))
+
+		client := NewJWKClient(JWKClientOptions{URI: ts.URL, UseCacheHeaders: useCacheHeaders, MinCacheTTL: 20 * time.Millisecond}, nil)
+		for i := 0; i < 3; i++ {
+			_, err := client.GetKey("keyRS256")
+			assert.NoError(t, err)
+			time.Sleep(30 * time.Millisecond)
+		}
+
+		if useCacheHeaders {
+			assert.Equal(t, uint64(3), atomic.LoadUint64(&counter), "the stale JWKS should be downloaded again")
+		} else {
+			assert.Equal(t, uint64(1), atomic.LoadUint64(&counter), "the cache headers should be ignored")
+		}
+		ts.Close()
+	}
+}
+
+func TestJWKClientMinCacheTTL(t *testing.T) {
+	key := genRSASSAJWK(jose.RS256, "keyRS256")
+	jwks := genJWKSServer(key)
+	defer jwks.Close()
+
+	for _, cacheControl := range []string{"no-cache", "no-store", "max-age=0"} {
+		t.Run(cacheControl, func(t *testing.T) {
+			var counter uint64
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddUint64(&counter, 1)
+				w.Header().Set("Cache-Control", cacheControl)
+				jwks.Config.Handler.ServeHTTP(w, r)
+			}))
+			defer ts.Close()
+
+			client := NewJWKClient(JWKClientOptions{URI: ts.URL, UseCacheHeaders: true}, nil)
+			for i := 0; i < 5; i++ {
+				_, err := client.GetKey("keyRS256")
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, uint64(1), atomic.LoadUint64(&counter), "the JWKS should be fresh for DefaultMinJWKSCacheTTL")
+		})
+	}
+}
+
+func TestJWKClientRefreshCooldown(t *testing.T) {
+	opts, tokenRS256, _, err := genNewTestServer(true)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	var counter uint64
+	opts.Client = &http.Client{
+		Transport: &mockRoundTripper{
+			ops: &counter,
+			rt:  http.DefaultTransport,
+		},
+	}
+	opts.RefreshCooldown = 50 * time.Millisecond
+	client := NewJWKClient(opts, nil)
+
+	testGetSecret(t, client, tokenRS256)
+
+	for i := 0; i < 5; i++ {
+		_, err = client.GetKey("unknownKey")
+		assert.Equal(t, ErrRefreshCooldown, err)
+	}
+	assert.Equal(t, uint64(1), atomic.LoadUint64(&counter))
+
+	// Cached keys are still served during the cooldown.
+	testGetSecret(t, client, tokenRS256)
+
+	time.Sleep(60 * time.Millisecond)
+	_, err = client.GetKey("unknownKey")
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+	assert.Equal(t, uint64(2), atomic.LoadUint64(&counter))
+}
+
+func TestJWKClientNegativeCache(t *testing.T) {
+	opts, tokenRS256, _, err := genNewTestServer(true)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	var counter uint64
+	opts.Client = &http.Client{
+		Transport: &mockRoundTripper{
+			ops: &counter,
+			rt:  http.DefaultTransport,
+		},
+	}
+	opts.NegativeCacheTTL = 50 * time.Millisecond
+	client := NewJWKClient(opts, nil)
+
+	for i := 0; i < 5; i++ {
+		_, err = client.GetKey("unknownKey")
+		assert.True(t, errors.Is(err, ErrKeyNotFound))
+		assert.True(t, errors.Is(err, ErrNoKeyFound))
+	}
+	assert.Equal(t, uint64(1), atomic.LoadUint64(&counter))
+
+	// Other keys are not affected by the negative cache.
+	testGetSecret(t, client, tokenRS256)
+
+	time.Sleep(60 * time.Millisecond)
+	_, err = client.GetKey("unknownKey")
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+	assert.Equal(t, uint64(2), atomic.LoadUint64(&counter))
+}
+
+func TestJWKDownloadKeyRetry(t *testing.T) {
+	jsonWebKeyRS256 := genRSASSAJWK(jose.RS256, "keyRS256")
+	value, err := json.Marshal(&JWKS{Keys: []jose.JSONWebKey{jsonWebKeyRS256.Public()}})
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	tests := []struct {
+		name             string
+		failures         uint64
+		policy           RetryPolicy
+		expectedAttempts uint64
+		expectError      bool
+	}{
+		{
+			name:             "pass - no failure",
+			failures:         0,
+			policy:           RetryPolicy{MaxAttempts: 3, BaseBackoff: time.Millisecond},
+			expectedAttempts: 1,
+		},
+		{
+			name:             "pass - transient failures",
+			failures:         2,
+			policy:           RetryPolicy{MaxAttempts: 3, BaseBackoff: time.Millisecond},
+			expectedAttempts: 3,
+		},
+		{
+			name:             "fail - too many failures",
+			failures:         3,
+			policy:           RetryPolicy{MaxAttempts: 3, BaseBackoff: time.Millisecond},
+			expectedAttempts: 3,
+			expectError:      true,
+		},
+		{
+			name:             "fail - no retry policy",
+			failures:         1,
+			policy:           RetryPolicy{},
+			expectedAttempts: 1,
+			expectError:      true,
+		},
+		{
+			name:             "fail - status not retryable",
+			failures:         1,
+			policy:           RetryPolicy{MaxAttempts: 3, BaseBackoff: time.Millisecond, RetryableStatusCodes: []int{http.StatusBadGateway}},
+			expectedAttempts: 1,
+			expectError:      true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var attempts uint64
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddUint64(&attempts, 1) <= test.failures {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write(value)
+			}))
+			defer ts.Close()
+
+			client := NewJWKClient(JWKClientOptions{URI: ts.URL, RetryPolicy: test.policy}, nil)
+			keys, err := client.downloadKeys()
+			if test.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, keys, 1)
+			}
+			assert.Equal(t, test.expectedAttempts, atomic.LoadUint64(&attempts))
+		})
+	}
+}
+
+func TestJWKClientStaleWhileRevalidate(t *testing.T) {
+	opts, tokenRS256, _, err := genNewTestServer(true)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	var counter uint64
+	opts.Client = &http.Client{
+		Transport: &mockRoundTripper{
+			ops: &counter,
+			rt:  http.DefaultTransport,
+		},
+	}
+	client := NewJWKClientWithCache(opts, nil, NewMemoryKeyCacherWithMaxStale(10*time.Millisecond, time.Hour, 5))
+
+	testGetSecret(t, client, tokenRS256)
+	time.Sleep(20 * time.Millisecond)
+
+	// The stale key is served and refreshed in the background.
+	testGetSecret(t, client, tokenRS256)
+	eventually(t, func() bool {
+		return atomic.LoadUint64(&counter) == 2
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestJWKClientAllowStaleOnError(t *testing.T) {
+	key := genRSASSAJWK(jose.RS256, "keyRS256")
+	jwks := genJWKSServer(key)
+	defer jwks.Close()
+
+	var failing int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&failing) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Cache-Control", "no-cache")
+		jwks.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name          string
+		options       JWKClientOptions
+		maxKeyAge     time.Duration
+		wait          time.Duration
+		expectedError error
+	}{
+		{
+			name:          "fail - expired key not served",
+			maxKeyAge:     10 * time.Millisecond,
+			wait:          20 * time.Millisecond,
+			expectedError: ErrJWKSFetchFailed,
+		},
+		{
+			name:      "pass - cached key of a stale JWKS served",
+			options:   JWKClientOptions{UseCacheHeaders: true, MinCacheTTL: time.Millisecond},
+			maxKeyAge: MaxKeyAgeNoCheck,
+			wait:      5 * time.Millisecond,
+		},
+		{
+			name:      "pass - recent key served",
+			options:   JWKClientOptions{MaxStaleOnError: time.Hour},
+			maxKeyAge: 10 * time.Millisecond,
+			wait:      20 * time.Millisecond,
+		},
+		{
+			name:          "fail - key too old",
+			options:       JWKClientOptions{MaxStaleOnError: 15 * time.Millisecond},
+			maxKeyAge:     10 * time.Millisecond,
+			wait:          20 * time.Millisecond,
+			expectedError: ErrJWKSFetchFailed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			atomic.StoreInt32(&failing, 0)
+			logger := &recordingLogger{}
+			test.options.URI, test.options.Logger = ts.URL, logger
+			client := NewJWKClientWithCache(test.options, nil, NewMemoryKeyCacher(test.maxKeyAge, 5))
+
+			_, err := client.GetKey("keyRS256")
+			assert.NoError(t, err)
+
+			atomic.StoreInt32(&failing, 1)
+			time.Sleep(test.wait)
+			_, err = client.GetKey("keyRS256")
+			if test.expectedError != nil {
+				assert.True(t, errors.Is(err, test.expectedError), "got %v", err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, logger.hasPrefix("WARN JWKS download failed, serving the cached key"))
+		})
+	}
+}
+
+func TestJWKClientRotatedOutKeys(t *testing.T) {
+	oldKey := genRSASSAJWK(jose.RS256, "old")
+	newKey := genRSASSAJWK(jose.RS256, "new")
+	jwksOld, jwksNew := genJWKSServer(oldKey), genJWKSServer(oldKey, newKey)
+	defer jwksOld.Close()
+	defer jwksNew.Close()
+	jwksRotated := genJWKSServer(newKey)
+	defer jwksRotated.Close()
+
+	var current atomic.Value
+	current.Store(jwksOld)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current.Load().(*httptest.Server).Config.Handler.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name        string
+		keyCacher   KeyCacher
+		expectedOld bool
+	}{
+		{"pass - keys replaced", NewLRUKeyCacher(10, time.Hour), false},
+		{"pass - keys added to a key cacher unable to replace them", &addOnlyKeyCacher{newMemoryPersistentKeyCacher()}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			current.Store(jwksOld)
+			client := NewJWKClientWithCache(JWKClientOptions{URI: ts.URL}, nil, test.keyCacher)
+			_, err := client.GetKey("old")
+			assert.NoError(t, err)
+
+			current.Store(jwksNew)
+			_, err = client.GetKey("new")
+			assert.NoError(t, err)
+			current.Store(jwksRotated)
+			assert.NoError(t, client.Prefetch(context.Background()))
+
+			_, err = test.keyCacher.Get("old")
+			assert.Equal(t, test.expectedOld, err == nil, "got %v", err)
+		})
+	}
+}
+
+// addOnlyKeyCacher hides the Set method of the key cacher.
+type addOnlyKeyCacher struct {
+	KeyCacher
+}
+
+func TestValidateWithJWKSAlgorithms(t *testing.T) {
+	keyPS256 := genRSASSAJWK(jose.PS256, "keyPS256")
+	keyRS384 := genRSASSAJWK(jose.RS384, "keyRS384")
+	keyES256 := genECDSAJWK(jose.ES256, "keyES256")
+	keyES512 := genECDSAJWK(jose.ES512, "keyES512")
+	otherES384 := genECDSAJWK(jose.ES384, "keyES256")
+	ts := genJWKSServer(keyPS256, keyRS384, keyES256, keyES512)
+	defer ts.Close()
+
+	client := NewJWKClient(JWKClientOptions{URI: ts.URL, AllowedAlgorithms: []jose.SignatureAlgorithm{jose.PS256, jose.RS256, jose.RS384, jose.ES256, jose.ES384, jose.ES512}}, nil)
+	configuration := NewConfigurationWithAlgorithms(client, defaultAudience, defaultIssuer, jose.PS256, jose.RS256, jose.RS384, jose.ES256, jose.ES384, jose.ES512)
+	validator := NewValidator(configuration, nil)
+	expiry := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		token   *jwt.JSONWebToken
+		wantErr error
+	}{
+		{"pass - PS256", getTestTokenWithKid(defaultAudience, defaultIssuer, expiry, jose.PS256, keyPS256, "keyPS256"), nil},
+		{"pass - RS384", getTestTokenWithKid(defaultAudience, defaultIssuer, expiry, jose.RS384, keyRS384, "keyRS384"), nil},
+		{"pass - ES256", getTestTokenWithKid(defaultAudience, defaultIssuer, expiry, jose.ES256, keyES256, "keyES256"), nil},
+		{"pass - ES512", getTestTokenWithKid(defaultAudience, defaultIssuer, expiry, jose.ES512, keyES512, "keyES512"), nil},
+		{"fail - RS256 with a PS256 key", getTestTokenWithKid(defaultAudience, defaultIssuer, expiry, jose.RS256, keyPS256, "keyPS256"), ErrKeyAlgorithmMismatch},
+		{"fail - ES384 with a P-256 key", getTestTokenWithKid(defaultAudience, defaultIssuer, expiry, jose.ES384, otherES384, "keyES256"), ErrKeyAlgorithmMismatch},
+		{"fail - PS256 with an EC key", getTestTokenWithKid(defaultAudience, defaultIssuer, expiry, jose.PS256, keyPS256, "keyES512"), ErrKeyAlgorithmMismatch},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.wantErr, validator.ValidateToken(test.token))
+		})
+	}
+}
+
+func TestValidateWithEdDSA(t *testing.T) {
+	keyRS256 := genRSASSAJWK(jose.RS256, "keyRS256")
+	keyEdDSA := genEd25519JWK("keyEdDSA")
+	ts := genJWKSServer(keyRS256, keyEdDSA)
+	defer ts.Close()
+
+	client := NewJWKClient(JWKClientOptions{URI: ts.URL, AllowedAlgorithms: []jose.SignatureAlgorithm{jose.RS256, jose.EdDSA}}, nil)
+	configuration := NewConfigurationWithAlgorithms(client, defaultAudience, defaultIssuer, jose.RS256, jose.EdDSA)
+	validator := NewValidator(configuration, nil)
+	expiry := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		token   *jwt.JSONWebToken
+		wantErr error
+	}{
+		{"pass - EdDSA", getTestTokenWithKid(defaultAudience, defaultIssuer, expiry, jose.EdDSA, keyEdDSA, "keyEdDSA"), nil},
+		{"pass - RS256", getTestTokenWithKid(defaultAudience, defaultIssuer, expiry, jose.RS256, keyRS256, "keyRS256"), nil},
+		{"fail - EdDSA with an RSA key", getTestTokenWithKid(defaultAudience, defaultIssuer, expiry, jose.EdDSA, keyEdDSA, "keyRS256"), ErrKeyAlgorithmMismatch},
+		{"fail - RS256 with an OKP key", getTestTokenWithKid(defaultAudience, defaultIssuer, expiry, jose.RS256, keyRS256, "keyEdDSA"), ErrKeyAlgorithmMismatch},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.wantErr, validator.ValidateToken(test.token))
+		})
+	}
+
+	key, err := client.GetKey("keyEdDSA")
+	assert.NoError(t, err)
+	assert.IsType(t, ed25519.PublicKey{}, key.Key)
+}
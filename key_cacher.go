@@ -1,6 +1,7 @@
 package auth0
 
 import (
+	"context"
 	"errors"
 	"time"
 
@@ -17,15 +18,101 @@ var (
 	MaxCacheSizeNoCheck = -1
 )
 
+// KeyCacher stores the keys downloaded by a JWKClient.
+// Get may return an expired key along with ErrKeyExpired, in which case
+// the JWKClient serves the stale key while it downloads the keys again
+// in the background.
 type KeyCacher interface {
 	Get(keyID string) (*jose.JSONWebKey, error)
 	Add(keyID string, webKeys []jose.JSONWebKey) (*jose.JSONWebKey, error)
 }
 
+// KeySetCacher is implemented by the key cachers replacing all their keys
+// at once. The JWKClient sets the keys of every downloaded JWKS instead of
+// adding them, so the keys rotated out of the JWKS are no longer served.
+// Bounded key cachers keep the last keys, the JWKClient sets the requested
+// key last.
+type KeySetCacher interface {
+	KeyCacher
+	Set(webKeys []jose.JSONWebKey) error
+}
+
+// KeyCacherV2 is a KeyCacher receiving the context of the key lookups, so
+// the remote key cachers, such as the Redis ones, stop waiting for their
+// storage once the request is canceled or its deadline exceeded. Set
+// replaces the cached keys with the keys of a downloaded JWKS, the requested
+// key being set last so the bounded key cachers keep it.
+// Use AdaptKeyCacher to use a KeyCacher where a KeyCacherV2 is expected.
+type KeyCacherV2 interface {
+	Get(ctx context.Context, keyID string) (*jose.JSONWebKey, error)
+	Add(ctx context.Context, keyID string, webKeys []jose.JSONWebKey) (*jose.JSONWebKey, error)
+	Set(ctx context.Context, webKeys []jose.JSONWebKey) error
+}
+
+type keyCacherAdapter struct {
+	cacher KeyCacher
+}
+
+// AdaptKeyCacher creates a KeyCacherV2 calling the cacher, ignoring the
+// contexts. Set calls the Set method of the KeySetCacher ones, and adds the
+// keys under the ID of each key otherwise.
+func AdaptKeyCacher(cacher KeyCacher) KeyCacherV2 {
+	return &keyCacherAdapter{cacher: cacher}
+}
+
+// Get obtains a key from the adapted cacher.
+func (a *keyCacherAdapter) Get(ctx context.Context, keyID string) (*jose.JSONWebKey, error) {
+	return a.cacher.Get(keyID)
+}
+
+// Add adds the downloaded keys into the adapted cacher.
+func (a *keyCacherAdapter) Add(ctx context.Context, keyID string, downloadedKeys []jose.JSONWebKey) (*jose.JSONWebKey, error) {
+	return a.cacher.Add(keyID, downloadedKeys)
+}
+
+// Set replaces the keys of the adapted cacher if it is a KeySetCacher,
+// and adds them otherwise.
+func (a *keyCacherAdapter) Set(ctx context.Context, downloadedKeys []jose.JSONWebKey) error {
+	if setter, ok := a.cacher.(KeySetCacher); ok {
+		return setter.Set(downloadedKeys)
+	}
+	for _, key := range downloadedKeys {
+		if _, err := a.cacher.Add(key.KeyID, downloadedKeys); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// adds tells whether the adapted cacher adds the keys
+// rather than replacing them.
+func (a *keyCacherAdapter) adds() bool {
+	_, ok := a.cacher.(KeySetCacher)
+	return !ok
+}
+
+// SetCacheObserver sets the observer of the adapted
+// cacher if it is an ObservedKeyCacher.
+func (a *keyCacherAdapter) SetCacheObserver(observer CacheObserver) {
+	if observed, ok := a.cacher.(ObservedKeyCacher); ok {
+		observed.SetCacheObserver(observer)
+	}
+}
+
+// SetLogger sets the logger of the adapted
+// cacher if it is a LoggedKeyCacher.
+func (a *keyCacherAdapter) SetLogger(logger Logger) {
+	if logged, ok := a.cacher.(LoggedKeyCacher); ok {
+		logged.SetLogger(logger)
+	}
+}
+
 type memoryKeyCacher struct {
 	entries      map[string]keyCacherEntry
 	maxKeyAge    time.Duration
+	maxStale     time.Duration
 	maxCacheSize int
+	observer     CacheObserver
 }
 
 type keyCacherEntry struct {
@@ -43,6 +130,19 @@ func NewMemoryKeyCacher(maxKeyAge time.Duration, maxCacheSize int) KeyCacher {
 	}
 }
 
+// NewMemoryKeyCacherWithMaxStale creates a new Keycacher interface with option
+// to set max age of cached keys, max size of the cache and max stale duration.
+// Keys expired for less than maxStale are still returned along with
+// ErrKeyExpired so they can be served while the keys are refreshed.
+func NewMemoryKeyCacherWithMaxStale(maxKeyAge time.Duration, maxStale time.Duration, maxCacheSize int) KeyCacher {
+	return &memoryKeyCacher{
+		entries:      map[string]keyCacherEntry{},
+		maxKeyAge:    maxKeyAge,
+		maxStale:     maxStale,
+		maxCacheSize: maxCacheSize,
+	}
+}
+
 func newMemoryPersistentKeyCacher() KeyCacher {
 	return &memoryKeyCacher{
 		entries:      map[string]keyCacherEntry{},
@@ -51,13 +151,17 @@ func newMemoryPersistentKeyCacher() KeyCacher {
 	}
 }
 
-// Get obtains a key from the cache, and checks if the key is expired
+// Get obtains a key from the cache, and checks if the key is expired.
+// A stale key is returned along with ErrKeyExpired.
 func (mkc *memoryKeyCacher) Get(keyID string) (*jose.JSONWebKey, error) {
 	searchKey, ok := mkc.entries[keyID]
 	if ok {
 		if mkc.maxKeyAge == MaxKeyAgeNoCheck || !mkc.keyIsExpired(keyID) {
 			return &searchKey.JSONWebKey, nil
 		}
+		if _, stale := mkc.entries[keyID]; stale {
+			return &searchKey.JSONWebKey, ErrKeyExpired
+		}
 		return nil, ErrKeyExpired
 	}
 	return nil, ErrNoKeyFound
@@ -91,10 +195,53 @@ func (mkc *memoryKeyCacher) Add(keyID string, downloadedKeys []jose.JSONWebKey)
 	return nil, ErrNoKeyFound
 }
 
+// Set implements the KeySetCacher interface, evicting the cached keys
+// missing from the downloaded keys, of which the last ones are kept when
+// the cache is bounded.
+func (mkc *memoryKeyCacher) Set(downloadedKeys []jose.JSONWebKey) error {
+	if mkc.maxCacheSize != -1 && len(downloadedKeys) > mkc.maxCacheSize {
+		downloadedKeys = downloadedKeys[len(downloadedKeys)-mkc.maxCacheSize:]
+	}
+	downloaded := make(map[string]bool, len(downloadedKeys))
+	for _, key := range downloadedKeys {
+		downloaded[key.KeyID] = true
+	}
+	for keyID := range mkc.entries {
+		if !downloaded[keyID] {
+			mkc.evict(keyID)
+		}
+	}
+
+	for _, key := range downloadedKeys {
+		mkc.entries[key.KeyID] = keyCacherEntry{
+			addedAt:    time.Now(),
+			JSONWebKey: key,
+		}
+	}
+	return nil
+}
+
+// SetCacheObserver implements the ObservedKeyCacher interface.
+func (mkc *memoryKeyCacher) SetCacheObserver(observer CacheObserver) {
+	mkc.observer = observer
+}
+
+// evict deletes the key from the cache and notifies the observer.
+func (mkc *memoryKeyCacher) evict(keyID string) {
+	delete(mkc.entries, keyID)
+	if mkc.observer != nil {
+		mkc.observer.OnEvict(keyID)
+	}
+}
+
 // keyIsExpired deletes the key from cache if it is expired
+// for longer than the max stale duration
 func (mkc *memoryKeyCacher) keyIsExpired(keyID string) bool {
-	if time.Now().After(mkc.entries[keyID].addedAt.Add(mkc.maxKeyAge)) {
-		delete(mkc.entries, keyID)
+	expiry := mkc.entries[keyID].addedAt.Add(mkc.maxKeyAge)
+	if time.Now().After(expiry) {
+		if !time.Now().Before(expiry.Add(mkc.maxStale)) {
+			mkc.evict(keyID)
+		}
 		return true
 	}
 	return false
@@ -111,6 +258,6 @@ func (mkc *memoryKeyCacher) handleOverflow() {
 				oldestEntryKeyID = entryKeyID
 			}
 		}
-		delete(mkc.entries, oldestEntryKeyID)
+		mkc.evict(oldestEntryKeyID)
 	}
 }
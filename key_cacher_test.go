@@ -1,6 +1,7 @@
 package auth0
 
 import (
+	"context"
 	"strconv"
 	"strings"
 	"testing"
@@ -427,3 +428,100 @@ func TestHandleOverflow(t *testing.T) {
 		})
 	}
 }
+
+func TestGetStale(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxStale      time.Duration
+		expectedKey   bool
+		expectedEntry bool
+	}{
+		{
+			name:          "stale key returned",
+			maxStale:      time.Duration(100) * time.Second,
+			expectedKey:   true,
+			expectedEntry: true,
+		},
+		{
+			name:          "key expired for longer than max stale",
+			maxStale:      time.Duration(1) * time.Second,
+			expectedKey:   false,
+			expectedEntry: false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mkc := NewMemoryKeyCacherWithMaxStale(time.Duration(1)*time.Second, test.maxStale, 1).(*memoryKeyCacher)
+			mkc.entries["key1"] = keyCacherEntry{time.Now().Add(time.Duration(-10) * time.Second), jose.JSONWebKey{KeyID: "key1"}}
+
+			key, err := mkc.Get("key1")
+			assert.Equal(t, ErrKeyExpired, err)
+			assert.Equal(t, test.expectedKey, key != nil)
+			_, ok := mkc.entries["key1"]
+			assert.Equal(t, test.expectedEntry, ok)
+		})
+	}
+}
+
+func TestSet(t *testing.T) {
+	downloadedKeys := []jose.JSONWebKey{{KeyID: "key2"}, {KeyID: "key3"}}
+	tests := []struct {
+		name         string
+		maxCacheSize int
+		expectedKeys []string
+	}{
+		{
+			name:         "unbounded cache",
+			maxCacheSize: MaxCacheSizeNoCheck,
+			expectedKeys: []string{"key2", "key3"},
+		},
+		{
+			name:         "bounded cache keeping the last keys",
+			maxCacheSize: 1,
+			expectedKeys: []string{"key3"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mkc := NewMemoryKeyCacher(MaxKeyAgeNoCheck, test.maxCacheSize).(*memoryKeyCacher)
+			mkc.entries["key1"] = keyCacherEntry{time.Now(), jose.JSONWebKey{KeyID: "key1"}}
+			mkc.entries["key2"] = keyCacherEntry{time.Now(), jose.JSONWebKey{KeyID: "key2"}}
+
+			assert.NoError(t, mkc.Set(downloadedKeys))
+			var keys []string
+			for keyID := range mkc.entries {
+				keys = append(keys, keyID)
+			}
+			assert.ElementsMatch(t, test.expectedKeys, keys)
+		})
+	}
+}
+
+func TestAdaptKeyCacher(t *testing.T) {
+	downloadedKeys := []jose.JSONWebKey{{KeyID: "key1", Key: []byte("secret1")}, {KeyID: "key2", Key: []byte("secret2")}}
+	ctx := context.Background()
+
+	t.Run("key set cacher", func(t *testing.T) {
+		mkc := NewMemoryKeyCacher(MaxKeyAgeNoCheck, MaxCacheSizeNoCheck).(*memoryKeyCacher)
+		mkc.entries["key0"] = keyCacherEntry{time.Now(), jose.JSONWebKey{KeyID: "key0"}}
+		cacher := AdaptKeyCacher(mkc)
+
+		assert.NoError(t, cacher.Set(ctx, downloadedKeys))
+		_, err := cacher.Get(ctx, "key0")
+		assert.Equal(t, ErrNoKeyFound, err)
+		key, err := cacher.Get(ctx, "key2")
+		assert.NoError(t, err)
+		assert.Equal(t, "key2", key.KeyID)
+	})
+
+	t.Run("key cacher", func(t *testing.T) {
+		lkc := NewLRUKeyCacher(10, MaxKeyAgeNoCheck)
+		cacher := AdaptKeyCacher(&addOnlyKeyCacher{lkc})
+
+		assert.NoError(t, cacher.Set(ctx, downloadedKeys))
+		assert.Equal(t, 2, lkc.Len())
+		key, err := cacher.Add(ctx, "key1", downloadedKeys)
+		assert.NoError(t, err)
+		assert.Equal(t, "key1", key.KeyID)
+	})
+}
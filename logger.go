@@ -0,0 +1,57 @@
+package auth0
+
+import (
+	"errors"
+
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// Logger logs the key downloads, cache refreshes and validation failures of
+// the JWKClient and the validator, with alternating key and value pairs.
+// A *slog.Logger implements Logger. Tokens are never logged, only their
+// key ID and algorithm.
+type Logger interface {
+	Debug(msg string, keysAndValues ...interface{})
+	Info(msg string, keysAndValues ...interface{})
+	Warn(msg string, keysAndValues ...interface{})
+	Error(msg string, keysAndValues ...interface{})
+}
+
+// LoggedKeyCacher is implemented by the key cachers logging their failures,
+// such as failing to persist the keys. The JWKClient sets the logger of its
+// options on its key cacher.
+type LoggedKeyCacher interface {
+	KeyCacher
+	SetLogger(logger Logger)
+}
+
+// WithLogger sets the logger of the validation failures.
+func WithLogger(logger Logger) ValidatorOption {
+	if logger == nil {
+		logger = nopLogger{}
+	}
+	return func(v *JWTValidator) {
+		v.logger = logger
+	}
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Warn(string, ...interface{})  {}
+func (nopLogger) Error(string, ...interface{}) {}
+
+// logFailure logs the failed validation of the token, nil if it could not be
+// extracted or parsed. Requests with no token are only logged at debug level.
+func (v *JWTValidator) logFailure(token *jwt.JSONWebToken, err error) {
+	keysAndValues := []interface{}{"error", err}
+	if token != nil && len(token.Headers) > 0 {
+		keysAndValues = append(keysAndValues, "kid", token.Headers[0].KeyID, "alg", token.Headers[0].Algorithm)
+	}
+	if errors.Is(err, ErrTokenNotFound) {
+		v.logger.Debug("no token in the request", keysAndValues...)
+		return
+	}
+	v.logger.Info("token validation failed", keysAndValues...)
+}
@@ -0,0 +1,94 @@
+package auth0
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+type recordingLogger struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+func (l *recordingLogger) log(level, msg string, keysAndValues []interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, strings.TrimSpace(fmt.Sprintln(append([]interface{}{level, msg}, keysAndValues...)...)))
+}
+
+// hasPrefix reports whether an entry starts with the prefix.
+func (l *recordingLogger) hasPrefix(prefix string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, entry := range l.entries {
+		if strings.HasPrefix(entry, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *recordingLogger) Debug(msg string, kv ...interface{}) { l.log("DEBUG", msg, kv) }
+func (l *recordingLogger) Info(msg string, kv ...interface{})  { l.log("INFO", msg, kv) }
+func (l *recordingLogger) Warn(msg string, kv ...interface{})  { l.log("WARN", msg, kv) }
+func (l *recordingLogger) Error(msg string, kv ...interface{}) { l.log("ERROR", msg, kv) }
+
+func TestValidatorLogger(t *testing.T) {
+	logger := &recordingLogger{}
+	validator := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), nil, WithLogger(logger))
+
+	expiry := time.Now().Add(-time.Hour).Truncate(time.Second).UTC()
+	expiredToken := getTestToken(defaultAudience, defaultIssuer, expiry, jose.HS256, defaultSecret)
+	_, err := validator.ValidateRequest(genTestMiddlewareRequest(expiredToken))
+	assert.Error(t, err)
+	_, err = validator.ValidateRequest(genTestMiddlewareRequest(""))
+	assert.Error(t, err)
+
+	assert.Equal(t, []string{
+		fmt.Sprintf("INFO token validation failed error square/go-jose/jwt: validation failed, token is expired (exp): exp=%s kid  alg HS256", expiry),
+		"DEBUG no token in the request error Token not found",
+	}, logger.entries)
+	for _, entry := range logger.entries {
+		assert.NotContains(t, entry, expiredToken)
+	}
+}
+
+func TestJWKClientLogger(t *testing.T) {
+	key := genRSASSAJWK(jose.RS256, "keyRS256")
+	ts := genJWKSServer(key)
+	defer ts.Close()
+
+	logger := &recordingLogger{}
+	client := NewJWKClient(JWKClientOptions{URI: ts.URL, Logger: logger}, nil)
+
+	_, err := client.GetKey("unknown")
+	assert.Equal(t, ErrKeyNotFound, err)
+
+	assert.Len(t, logger.entries, 3)
+	assert.True(t, strings.HasPrefix(logger.entries[0], "INFO JWKS client configured uri "+ts.URL))
+	assert.True(t, strings.HasPrefix(logger.entries[1], "DEBUG JWKS downloaded uri "+ts.URL+" status 200"))
+	assert.Equal(t, "WARN key not found in the JWKS kid unknown uri "+ts.URL, logger.entries[2])
+}
+
+func TestJWKClientLoggerDownloadFailure(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	logger := &recordingLogger{}
+	client := NewJWKClient(JWKClientOptions{URI: ts.URL, Logger: logger}, nil)
+
+	_, err := client.GetKey("keyRS256")
+	assert.Error(t, err)
+	assert.Len(t, logger.entries, 2)
+	assert.True(t, strings.HasPrefix(logger.entries[1], "WARN JWKS download failed uri "+ts.URL+" status 503"))
+}
@@ -0,0 +1,246 @@
+package auth0
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNoAuthorizationCode is returned when the callback has no code parameter.
+	ErrNoAuthorizationCode = errors.New("no authorization code in the callback")
+	// ErrNoIDToken is returned when the token endpoint issues no ID token,
+	// as when the openid scope is not requested.
+	ErrNoIDToken = errors.New("no ID token in the response")
+	// ErrAuthorizationFailed is matched by the errors of the callbacks
+	// reporting that the authorization server did not authenticate the user,
+	// such as when the user denies the consent. Use errors.As with
+	// *AuthorizationError to retrieve the OAuth2 error.
+	ErrAuthorizationFailed = errors.New("authorization failed")
+)
+
+// DefaultLoginScopes are the scopes requested by Login by default.
+var DefaultLoginScopes = []string{"openid", "profile", "email"}
+
+// AuthorizationError is returned when the callback holds the OAuth2 error of
+// the authorization server. It matches ErrAuthorizationFailed with errors.Is.
+type AuthorizationError struct {
+	// Code and Description are the OAuth2 error of the
+	// callback, such as "access_denied".
+	Code        string
+	Description string
+}
+
+func (e *AuthorizationError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("%s: %s: %s", ErrAuthorizationFailed, e.Code, e.Description)
+	}
+	return fmt.Sprintf("%s: %s", ErrAuthorizationFailed, e.Code)
+}
+
+// Is makes errors.Is match ErrAuthorizationFailed.
+func (e *AuthorizationError) Is(target error) bool {
+	return target == ErrAuthorizationFailed
+}
+
+// LoginOptions configures the authorization code grant login.
+type LoginOptions struct {
+	// Issuer is the Auth0 domain, such as https://mydomain.eu.auth0.com/,
+	// the default AuthorizeURL and TokenURL being its /authorize and
+	// /oauth/token endpoints.
+	Issuer string
+	// AuthorizeURL and TokenURL are the authorization and token endpoints,
+	// such as the endpoints of the OpenID configuration of the issuer.
+	AuthorizeURL string
+	TokenURL     string
+	// ClientID and ClientSecret authenticate the confidential
+	// client to the token endpoint in the body of the request.
+	ClientID     string
+	ClientSecret string
+	// PrivateKeyJWT authenticates the client with signed
+	// assertions instead of ClientSecret when set.
+	PrivateKeyJWT *PrivateKeyJWT
+	// RedirectURL is the callback URL of the application,
+	// allowed in the settings of the Auth0 application.
+	RedirectURL string
+	// Scopes are the scopes requested, which must include openid.
+	// Defaults to DefaultLoginScopes, add offline_access for a
+	// refresh token.
+	Scopes []string
+	// Audience is the identifier of the API the access token is
+	// requested for, an access token for the user info endpoint
+	// only when empty.
+	Audience string
+	// Params are additional parameters of the authorization
+	// requests, such as organization.
+	Params url.Values
+	// MaxAge requires the user to have authenticated at most MaxAge ago,
+	// re-authenticating them otherwise, when positive.
+	MaxAge time.Duration
+	Client *http.Client
+}
+
+// PendingLogin is the state of a login between the redirection of the
+// user to the authorization endpoint and the callback. It must be kept
+// by the application, such as in its server-side session or a cookie set
+// by a StateEncoder, and never sent as the state parameter, as its code
+// verifier proves the login is its own.
+type PendingLogin struct {
+	State        string `json:"state"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+	// ReturnTo is the URL of the application the user is
+	// redirected to after the login.
+	ReturnTo string `json:"return_to,omitempty"`
+}
+
+// LoginResult is the result of a successful login.
+type LoginResult struct {
+	// Token holds the access token, ID token and refresh token if
+	// requested, to start a session of RefreshTokens.
+	Token *Token
+	// IDToken is the validated ID token of the user.
+	IDToken *IDTokenClaims
+	// Claims are all the claims of the ID token, such as email.
+	Claims map[string]interface{}
+	// ReturnTo is the ReturnTo of the pending login.
+	ReturnTo string
+}
+
+// Login logs users in a backend-for-frontend with the OpenID Connect
+// authorization code grant and PKCE: AuthorizeURL starts the login of a
+// user, redirected to Auth0, and Callback completes it when Auth0 redirects
+// the user back to the RedirectURL.
+type Login struct {
+	options   LoginOptions
+	validator *JWTValidator
+}
+
+// NewLogin creates a new Login instance from the provided options, the ID
+// tokens being validated by the validator, whose audience is the client ID.
+func NewLogin(options LoginOptions, validator *JWTValidator) *Login {
+	if options.Client == nil {
+		options.Client = http.DefaultClient
+	}
+	if len(options.Scopes) == 0 {
+		options.Scopes = DefaultLoginScopes
+	}
+	if options.Issuer != "" {
+		issuer := strings.TrimSuffix(issuerURL(options.Issuer), "/")
+		if options.AuthorizeURL == "" {
+			options.AuthorizeURL = issuer + "/authorize"
+		}
+		if options.TokenURL == "" {
+			options.TokenURL = issuer + "/oauth/token"
+		}
+	}
+	return &Login{options: options, validator: validator}
+}
+
+// AuthorizeURL starts the login of a user, returning the URL of the
+// authorization endpoint to redirect them to and the pending login to keep
+// until the callback. The params are added to those of the options, such as
+// prompt or screen_hint. The application must only set ReturnTo to its own URLs.
+func (l *Login) AuthorizeURL(returnTo string, params url.Values) (string, *PendingLogin, error) {
+	pending := &PendingLogin{ReturnTo: returnTo}
+	var err error
+	if pending.State, err = GenerateState(); err != nil {
+		return "", nil, err
+	}
+	if pending.Nonce, err = GenerateNonce(); err != nil {
+		return "", nil, err
+	}
+	if pending.CodeVerifier, err = GeneratePKCEVerifier(); err != nil {
+		return "", nil, err
+	}
+
+	query := url.Values{}
+	for key, values := range l.options.Params {
+		query[key] = values
+	}
+	for key, values := range params {
+		query[key] = values
+	}
+	query.Set("response_type", "code")
+	query.Set("client_id", l.options.ClientID)
+	query.Set("redirect_uri", l.options.RedirectURL)
+	query.Set("scope", strings.Join(l.options.Scopes, " "))
+	query.Set("state", pending.State)
+	query.Set("nonce", pending.Nonce)
+	query.Set("code_challenge", PKCEChallenge(pending.CodeVerifier))
+	query.Set("code_challenge_method", "S256")
+	if l.options.Audience != "" {
+		query.Set("audience", l.options.Audience)
+	}
+	if l.options.MaxAge > 0 {
+		query.Set("max_age", strconv.FormatInt(int64(l.options.MaxAge/time.Second), 10))
+	}
+
+	separator := "?"
+	if strings.Contains(l.options.AuthorizeURL, "?") {
+		separator = "&"
+	}
+	return l.options.AuthorizeURL + separator + query.Encode(), pending, nil
+}
+
+// Callback completes the pending login on the callback request: it checks
+// the state, exchanges the authorization code for the tokens with the code
+// verifier, and validates the ID token and its nonce. The pending login must
+// be discarded afterwards, whatever the result.
+func (l *Login) Callback(ctx context.Context, r *http.Request, pending *PendingLogin) (*LoginResult, error) {
+	if pending == nil {
+		return nil, ErrInvalidState
+	}
+	if err := VerifyState(pending.State, r.FormValue("state")); err != nil {
+		return nil, err
+	}
+	if code := r.FormValue("error"); code != "" {
+		return nil, &AuthorizationError{Code: code, Description: r.FormValue("error_description")}
+	}
+	code := r.FormValue("code")
+	if code == "" {
+		return nil, ErrNoAuthorizationCode
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {l.options.RedirectURL},
+		"code_verifier": {pending.CodeVerifier},
+	}
+	if err := authenticateClient(form, l.options.ClientID, l.options.ClientSecret, l.options.PrivateKeyJWT, l.options.TokenURL); err != nil {
+		return nil, err
+	}
+	token, err := requestToken(ctx, l.options.Client, l.options.TokenURL, form)
+	if err != nil {
+		return nil, err
+	}
+	if token.IDToken == "" {
+		return nil, ErrNoIDToken
+	}
+
+	var opts []IDTokenOption
+	if l.options.MaxAge > 0 {
+		opts = append(opts, WithMaxAge(l.options.MaxAge))
+	}
+	idToken, err := l.validator.ValidateIDToken(token.IDToken, pending.Nonce, opts...)
+	if err != nil {
+		return nil, err
+	}
+	// The ID token is validated, its claims can be read without verification.
+	claims, err := PeekClaims(token.IDToken)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{
+		Token:    token,
+		IDToken:  idToken,
+		Claims:   claims,
+		ReturnTo: pending.ReturnTo,
+	}, nil
+}
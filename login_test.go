@@ -0,0 +1,161 @@
+package auth0
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// genTestLoginServer serves a token endpoint issuing an ID token with the
+// nonce for the code, after checking the code verifier against the challenge.
+func genTestLoginServer(t *testing.T, key jose.JSONWebKey, challenge, nonce *string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "authorization_code", r.PostFormValue("grant_type"))
+		assert.Equal(t, "https://app.example.com/callback", r.PostFormValue("redirect_uri"))
+		assert.Equal(t, "client", r.PostFormValue("client_id"))
+		assert.Equal(t, "secret", r.PostFormValue("client_secret"))
+
+		w.Header().Set("Content-Type", "application/json")
+		if r.PostFormValue("code") != "code" || PKCEChallenge(r.PostFormValue("code_verifier")) != *challenge {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		idToken := getTestTokenWithClaims(jose.RS256, key,
+			jwt.Claims{Issuer: defaultIssuer, Subject: "user", Audience: jwt.Audience{"client"}, Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+			map[string]interface{}{"nonce": *nonce, "email": "user@example.com"},
+		)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "token",
+			"id_token":     idToken,
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+		})
+	}))
+}
+
+func genTestLogin(tokenURL string, key jose.JSONWebKey) *Login {
+	configuration := NewConfiguration(NewKeyProvider(key.Public()), []string{"client"}, defaultIssuer, jose.RS256)
+	return NewLogin(LoginOptions{
+		AuthorizeURL: "https://mydomain.eu.auth0.com/authorize",
+		TokenURL:     tokenURL,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  "https://app.example.com/callback",
+		Audience:     "api",
+	}, NewValidator(configuration, nil))
+}
+
+func genTestCallbackRequest(query url.Values) *http.Request {
+	return httptest.NewRequest("GET", "https://app.example.com/callback?"+query.Encode(), nil)
+}
+
+func TestLogin(t *testing.T) {
+	key := genRSASSAJWK(jose.RS256, "")
+	var challenge, nonce string
+	ts := genTestLoginServer(t, key, &challenge, &nonce)
+	defer ts.Close()
+	login := genTestLogin(ts.URL, key)
+
+	authorizeURL, pending, err := login.AuthorizeURL("/account", url.Values{"prompt": {"login"}})
+	assert.NoError(t, err)
+	u, err := url.Parse(authorizeURL)
+	assert.NoError(t, err)
+	assert.Equal(t, "mydomain.eu.auth0.com", u.Host)
+	query := u.Query()
+	assert.Equal(t, "code", query.Get("response_type"))
+	assert.Equal(t, "client", query.Get("client_id"))
+	assert.Equal(t, "https://app.example.com/callback", query.Get("redirect_uri"))
+	assert.Equal(t, "openid profile email", query.Get("scope"))
+	assert.Equal(t, "api", query.Get("audience"))
+	assert.Equal(t, "login", query.Get("prompt"))
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+	assert.Equal(t, pending.State, query.Get("state"))
+	assert.Equal(t, pending.Nonce, query.Get("nonce"))
+	assert.Equal(t, PKCEChallenge(pending.CodeVerifier), query.Get("code_challenge"))
+	assert.Empty(t, query.Get("code_verifier"))
+	assert.Equal(t, "/account", pending.ReturnTo)
+	challenge, nonce = query.Get("code_challenge"), pending.Nonce
+
+	result, err := login.Callback(context.Background(), genTestCallbackRequest(url.Values{"state": {pending.State}, "code": {"code"}}), pending)
+	assert.NoError(t, err)
+	assert.Equal(t, "token", result.Token.AccessToken)
+	assert.Equal(t, "user", result.IDToken.Subject)
+	assert.Equal(t, "user@example.com", result.Claims["email"])
+	assert.Equal(t, "/account", result.ReturnTo)
+
+	// Each login has its own state, nonce and code verifier.
+	_, other, err := login.AuthorizeURL("", nil)
+	assert.NoError(t, err)
+	assert.NotEqual(t, pending.State, other.State)
+	assert.NotEqual(t, pending.Nonce, other.Nonce)
+	assert.NotEqual(t, pending.CodeVerifier, other.CodeVerifier)
+}
+
+func TestLoginCallbackFailures(t *testing.T) {
+	key := genRSASSAJWK(jose.RS256, "")
+	var challenge, nonce string
+	ts := genTestLoginServer(t, key, &challenge, &nonce)
+	defer ts.Close()
+	login := genTestLogin(ts.URL, key)
+	ctx := context.Background()
+
+	_, pending, err := login.AuthorizeURL("", nil)
+	assert.NoError(t, err)
+	challenge, nonce = PKCEChallenge(pending.CodeVerifier), pending.Nonce
+
+	_, err = login.Callback(ctx, genTestCallbackRequest(url.Values{"state": {"forged"}, "code": {"code"}}), pending)
+	assert.Equal(t, ErrInvalidState, err)
+	_, err = login.Callback(ctx, genTestCallbackRequest(url.Values{"code": {"code"}}), pending)
+	assert.Equal(t, ErrInvalidState, err)
+	_, err = login.Callback(ctx, genTestCallbackRequest(url.Values{"state": {pending.State}, "code": {"code"}}), nil)
+	assert.Equal(t, ErrInvalidState, err)
+	_, err = login.Callback(ctx, genTestCallbackRequest(url.Values{"state": {pending.State}}), pending)
+	assert.Equal(t, ErrNoAuthorizationCode, err)
+
+	_, err = login.Callback(ctx, genTestCallbackRequest(url.Values{
+		"state":             {pending.State},
+		"error":             {"access_denied"},
+		"error_description": {"User did not authorize the request"},
+	}), pending)
+	assert.True(t, errors.Is(err, ErrAuthorizationFailed), "got %v", err)
+	var authErr *AuthorizationError
+	assert.True(t, errors.As(err, &authErr))
+	assert.Equal(t, "access_denied", authErr.Code)
+
+	// The code of another login is rejected without its code verifier.
+	wrongVerifier := *pending
+	wrongVerifier.CodeVerifier = "other"
+	_, err = login.Callback(ctx, genTestCallbackRequest(url.Values{"state": {pending.State}, "code": {"code"}}), &wrongVerifier)
+	var tokenErr *TokenError
+	assert.True(t, errors.As(err, &tokenErr), "got %v", err)
+
+	wrongNonce := *pending
+	wrongNonce.Nonce = "other"
+	_, err = login.Callback(ctx, genTestCallbackRequest(url.Values{"state": {pending.State}, "code": {"code"}}), &wrongNonce)
+	assert.Equal(t, ErrInvalidNonce, err)
+}
+
+func TestNewLoginDefaults(t *testing.T) {
+	login := NewLogin(LoginOptions{Issuer: "mydomain.eu.auth0.com"}, nil)
+	assert.Equal(t, "https://mydomain.eu.auth0.com/authorize", login.options.AuthorizeURL)
+	assert.Equal(t, "https://mydomain.eu.auth0.com/oauth/token", login.options.TokenURL)
+	assert.Equal(t, DefaultLoginScopes, login.options.Scopes)
+	assert.Equal(t, http.DefaultClient, login.options.Client)
+
+	login = NewLogin(LoginOptions{Issuer: "https://mydomain.eu.auth0.com/", MaxAge: time.Hour}, nil)
+	authorizeURL, _, err := login.AuthorizeURL("", nil)
+	assert.NoError(t, err)
+	u, _ := url.Parse(authorizeURL)
+	assert.Equal(t, "/authorize", u.Path)
+	assert.Equal(t, "3600", u.Query().Get("max_age"))
+}
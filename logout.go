@@ -0,0 +1,110 @@
+package auth0
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// BackChannelLogoutEvent is the member of the events claim of the
+// back-channel logout tokens.
+const BackChannelLogoutEvent = "http://schemas.openid.net/event/backchannel-logout"
+
+var (
+	// ErrInvalidLogoutEvent is returned when the events claim of the logout
+	// token has no back-channel logout event.
+	ErrInvalidLogoutEvent = errors.New("no back-channel logout event in the events claim (events)")
+	// ErrNoLogoutSubject is returned when the logout
+	// token has neither a sub nor a sid claim.
+	ErrNoLogoutSubject = errors.New("no sub or sid claim in the logout token")
+	// ErrLogoutTokenNonce is returned when the logout token has a nonce
+	// claim, which would make it usable as an ID token.
+	ErrLogoutTokenNonce = errors.New("logout token must not have a nonce claim (nonce)")
+)
+
+// LogoutTokenClaims are the claims of an OpenID Connect
+// back-channel logout token.
+type LogoutTokenClaims struct {
+	jwt.Claims
+	SessionID string                     `json:"sid,omitempty"`
+	Events    map[string]json.RawMessage `json:"events,omitempty"`
+}
+
+// ValidateLogoutToken validates the compact serialized logout token following
+// the OpenID Connect Back-Channel Logout rules: its signature, issuer,
+// audience and expiry are validated like ID tokens, its events claim must
+// hold the back-channel logout event, it must have a sub or sid claim and
+// must not have a nonce claim. The audiences of the validator are the
+// client IDs of the application.
+func (v *JWTValidator) ValidateLogoutToken(raw string) (*LogoutTokenClaims, error) {
+	// The outcome is reported once the logout token checks are done.
+	ctx, start := context.Background(), time.Now()
+	claims := &LogoutTokenClaims{}
+	nonce := struct {
+		Nonce *string `json:"nonce"`
+	}{}
+	token, err := v.validateRaw(ctx, raw, v.parseRawToken, v.leeway, claims, &nonce)
+	if err == nil {
+		err = checkLogoutToken(claims, nonce.Nonce)
+	}
+	v.report(ctx, time.Since(start), token, nil, err)
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// checkLogoutToken applies the back-channel logout checks of
+// ValidateLogoutToken to the claims of the validated logout token.
+func checkLogoutToken(claims *LogoutTokenClaims, nonce *string) error {
+	var event map[string]interface{}
+	if err := json.Unmarshal(claims.Events[BackChannelLogoutEvent], &event); err != nil || event == nil {
+		return ErrInvalidLogoutEvent
+	}
+	if claims.Subject == "" && claims.SessionID == "" {
+		return ErrNoLogoutSubject
+	}
+	if nonce != nil {
+		return ErrLogoutTokenNonce
+	}
+	return nil
+}
+
+// LogoutFunc logs out the sessions of the logout token,
+// such as by revoking them with a MemoryRevocationList.
+type LogoutFunc func(ctx context.Context, claims *LogoutTokenClaims) error
+
+// NewBackChannelLogoutHandler creates the handler of the back-channel logout
+// requests of the identity provider. It validates the logout_token
+// parameter with ValidateLogoutToken and calls logout with its claims,
+// responding with 200 OK once logged out, or 400 Bad Request with an
+// OAuth2 error if the token is invalid or logout fails.
+func NewBackChannelLogoutHandler(v *JWTValidator, logout LogoutFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		claims, err := v.ValidateLogoutToken(r.PostFormValue("logout_token"))
+		if err == nil {
+			err = logout(r.Context(), claims)
+		}
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error":             "invalid_request",
+				"error_description": err.Error(),
+			})
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
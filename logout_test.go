@@ -0,0 +1,138 @@
+package auth0
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func genTestLogoutToken(registered jwt.Claims, claims map[string]interface{}) string {
+	return getTestTokenWithClaims(jose.HS256, defaultSecret, registered, claims)
+}
+
+func TestValidateLogoutToken(t *testing.T) {
+	registered := jwt.Claims{
+		Issuer:   defaultIssuer,
+		Audience: defaultAudience,
+		Subject:  "user1",
+		IssuedAt: jwt.NewNumericDate(time.Now()),
+		Expiry:   jwt.NewNumericDate(time.Now().Add(2 * time.Minute)),
+	}
+	events := map[string]interface{}{BackChannelLogoutEvent: map[string]interface{}{}}
+
+	tests := []struct {
+		name          string
+		registered    jwt.Claims
+		claims        map[string]interface{}
+		expectedError error
+	}{
+		{
+			name:       "pass - sub and sid",
+			registered: registered,
+			claims:     map[string]interface{}{"events": events, "sid": "session1"},
+		},
+		{
+			name:       "pass - sid only",
+			registered: jwt.Claims{Issuer: defaultIssuer, Audience: defaultAudience, Expiry: registered.Expiry},
+			claims:     map[string]interface{}{"events": events, "sid": "session1"},
+		},
+		{
+			name:          "fail - no events",
+			registered:    registered,
+			claims:        map[string]interface{}{"sid": "session1"},
+			expectedError: ErrInvalidLogoutEvent,
+		},
+		{
+			name:          "fail - event is not an object",
+			registered:    registered,
+			claims:        map[string]interface{}{"events": map[string]interface{}{BackChannelLogoutEvent: "logout"}},
+			expectedError: ErrInvalidLogoutEvent,
+		},
+		{
+			name:          "fail - no sub or sid",
+			registered:    jwt.Claims{Issuer: defaultIssuer, Audience: defaultAudience, Expiry: registered.Expiry},
+			claims:        map[string]interface{}{"events": events},
+			expectedError: ErrNoLogoutSubject,
+		},
+		{
+			name:          "fail - nonce",
+			registered:    registered,
+			claims:        map[string]interface{}{"events": events, "nonce": ""},
+			expectedError: ErrLogoutTokenNonce,
+		},
+		{
+			name:          "fail - other audience",
+			registered:    jwt.Claims{Issuer: defaultIssuer, Audience: jwt.Audience{"other"}, Subject: "user1", Expiry: registered.Expiry},
+			claims:        map[string]interface{}{"events": events},
+			expectedError: ErrInvalidAudience,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			validator := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), nil)
+			claims, err := validator.ValidateLogoutToken(genTestLogoutToken(test.registered, test.claims))
+			if test.expectedError != nil {
+				assert.True(t, errors.Is(err, test.expectedError), "got %v", err)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, "session1", claims.SessionID)
+			}
+		})
+	}
+}
+
+func TestBackChannelLogoutHandler(t *testing.T) {
+	registered := jwt.Claims{Issuer: defaultIssuer, Audience: defaultAudience, Subject: "user1", Expiry: jwt.NewNumericDate(time.Now().Add(2 * time.Minute))}
+	logoutToken := genTestLogoutToken(registered, map[string]interface{}{
+		"events": map[string]interface{}{BackChannelLogoutEvent: map[string]interface{}{}},
+		"sid":    "session1",
+	})
+	accessToken := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, defaultSecret)
+
+	revocations := NewMemoryRevocationList()
+	validator := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), nil)
+	handler := NewBackChannelLogoutHandler(validator, func(_ context.Context, claims *LogoutTokenClaims) error {
+		if claims.SessionID == "" {
+			return errors.New("no session")
+		}
+		revocations.RevokeSession(claims.SessionID, time.Now().Add(time.Hour))
+		return nil
+	})
+
+	post := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "http://localhost/logout", strings.NewReader(url.Values{"logout_token": {token}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post(logoutToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	revoked, _ := revocations.IsRevoked(context.Background(), RevocationClaims{SessionID: "session1"})
+	assert.True(t, revoked)
+
+	w = post(accessToken)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	response := map[string]string{}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, "invalid_request", response["error"])
+	assert.Equal(t, ErrInvalidLogoutEvent.Error(), response["error_description"])
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "http://localhost/logout", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
@@ -0,0 +1,169 @@
+package auth0
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// KeyCacherStats are the counters of an LRUKeyCacher.
+type KeyCacherStats struct {
+	Hits        uint64 // Get calls returning a key
+	Misses      uint64 // Get calls returning no key or an expired one
+	Evictions   uint64 // Keys removed to bound the size of the cache
+	Expirations uint64 // Keys removed once their TTL elapsed
+}
+
+// LRUKeyCacher is a KeyCacher bounded to a maximum number of keys, evicting
+// the least recently used ones, where each key expires after its TTL.
+// It is safe for concurrent use.
+type LRUKeyCacher struct {
+	maxEntries int
+	ttl        time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // Front is the most recently used key
+
+	hits, misses, evictions, expirations uint64
+
+	observer CacheObserver
+}
+
+type lruKeyCacherEntry struct {
+	expiresAt time.Time
+	jose.JSONWebKey
+}
+
+// NewLRUKeyCacher creates a new LRUKeyCacher holding at most maxEntries keys,
+// or any number of keys with MaxCacheSizeNoCheck, each expiring ttl after
+// being added, or never with MaxKeyAgeNoCheck.
+func NewLRUKeyCacher(maxEntries int, ttl time.Duration) *LRUKeyCacher {
+	return &LRUKeyCacher{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		entries:    map[string]*list.Element{},
+		order:      list.New(),
+	}
+}
+
+// Get obtains a key from the cache, marking it as recently used.
+// Expired keys are removed and ErrKeyExpired is returned.
+func (c *LRUKeyCacher) Get(keyID string) (*jose.JSONWebKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[keyID]
+	if !ok {
+		atomic.AddUint64(&c.misses, 1)
+		return nil, ErrNoKeyFound
+	}
+	entry := elem.Value.(*lruKeyCacherEntry)
+	if c.ttl != MaxKeyAgeNoCheck && !time.Now().Before(entry.expiresAt) {
+		c.remove(elem)
+		atomic.AddUint64(&c.expirations, 1)
+		atomic.AddUint64(&c.misses, 1)
+		return nil, ErrKeyExpired
+	}
+
+	c.order.MoveToFront(elem)
+	atomic.AddUint64(&c.hits, 1)
+	key := entry.JSONWebKey
+	return &key, nil
+}
+
+// Add adds the downloaded keys into the cache, the key with the ID being
+// the most recently used, evicting the least recently used keys on overflow.
+func (c *LRUKeyCacher) Add(keyID string, downloadedKeys []jose.JSONWebKey) (*jose.JSONWebKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var addingKey *jose.JSONWebKey
+	for i, key := range downloadedKeys {
+		if key.KeyID == keyID {
+			addingKey = &downloadedKeys[i]
+			continue
+		}
+		c.add(key)
+	}
+	if addingKey == nil || addingKey.Key == nil {
+		return nil, ErrNoKeyFound
+	}
+	c.add(*addingKey)
+
+	key := *addingKey
+	return &key, nil
+}
+
+// Set implements the KeySetCacher interface, removing
+// the cached keys missing from the downloaded keys.
+func (c *LRUKeyCacher) Set(downloadedKeys []jose.JSONWebKey) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	downloaded := make(map[string]bool, len(downloadedKeys))
+	for _, key := range downloadedKeys {
+		downloaded[key.KeyID] = true
+	}
+	for keyID, elem := range c.entries {
+		if !downloaded[keyID] {
+			c.remove(elem)
+			atomic.AddUint64(&c.evictions, 1)
+		}
+	}
+	for _, key := range downloadedKeys {
+		c.add(key)
+	}
+	return nil
+}
+
+// Stats returns the counters of the cache.
+func (c *LRUKeyCacher) Stats() KeyCacherStats {
+	return KeyCacherStats{
+		Hits:        atomic.LoadUint64(&c.hits),
+		Misses:      atomic.LoadUint64(&c.misses),
+		Evictions:   atomic.LoadUint64(&c.evictions),
+		Expirations: atomic.LoadUint64(&c.expirations),
+	}
+}
+
+// Len returns the number of keys in the cache, expired ones included.
+func (c *LRUKeyCacher) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *LRUKeyCacher) add(key jose.JSONWebKey) {
+	entry := &lruKeyCacherEntry{expiresAt: time.Now().Add(c.ttl), JSONWebKey: key}
+	if elem, ok := c.entries[key.KeyID]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key.KeyID] = c.order.PushFront(entry)
+	for c.maxEntries != MaxCacheSizeNoCheck && c.order.Len() > c.maxEntries {
+		c.remove(c.order.Back())
+		atomic.AddUint64(&c.evictions, 1)
+	}
+}
+
+// SetCacheObserver implements the ObservedKeyCacher interface.
+func (c *LRUKeyCacher) SetCacheObserver(observer CacheObserver) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.observer = observer
+}
+
+func (c *LRUKeyCacher) remove(elem *list.Element) {
+	keyID := elem.Value.(*lruKeyCacherEntry).KeyID
+	c.order.Remove(elem)
+	delete(c.entries, keyID)
+	if c.observer != nil {
+		c.observer.OnEvict(keyID)
+	}
+}
@@ -0,0 +1,98 @@
+package auth0
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func TestLRUKeyCacher(t *testing.T) {
+	keys := []jose.JSONWebKey{
+		{KeyID: "key1", Key: defaultSecret},
+		{KeyID: "key2", Key: defaultSecret},
+		{KeyID: "key3", Key: defaultSecret},
+	}
+	cacher := NewLRUKeyCacher(2, MaxKeyAgeNoCheck)
+
+	key, err := cacher.Add("key1", keys)
+	assert.NoError(t, err)
+	assert.Equal(t, "key1", key.KeyID)
+	assert.Equal(t, 2, cacher.Len())
+
+	// key1 was added last and key3 before it, key2 has been evicted.
+	_, err = cacher.Get("key2")
+	assert.Equal(t, ErrNoKeyFound, err)
+	_, err = cacher.Get("key3")
+	assert.NoError(t, err)
+
+	// key3 is now the most recently used key, key1 is evicted.
+	_, err = cacher.Add("key2", keys[1:2])
+	assert.NoError(t, err)
+	_, err = cacher.Get("key1")
+	assert.Equal(t, ErrNoKeyFound, err)
+	_, err = cacher.Get("key3")
+	assert.NoError(t, err)
+
+	_, err = cacher.Add("key4", keys)
+	assert.Equal(t, ErrNoKeyFound, err)
+
+	assert.Equal(t, KeyCacherStats{Hits: 2, Misses: 2, Evictions: 5}, cacher.Stats())
+}
+
+func TestLRUKeyCacherTTL(t *testing.T) {
+	keys := []jose.JSONWebKey{{KeyID: "key1", Key: defaultSecret}}
+
+	cacher := NewLRUKeyCacher(MaxCacheSizeNoCheck, time.Hour)
+	_, err := cacher.Add("key1", keys)
+	assert.NoError(t, err)
+	_, err = cacher.Get("key1")
+	assert.NoError(t, err)
+
+	cacher = NewLRUKeyCacher(MaxCacheSizeNoCheck, 0)
+	_, err = cacher.Add("key1", keys)
+	assert.NoError(t, err)
+	_, err = cacher.Get("key1")
+	assert.Equal(t, ErrKeyExpired, err)
+	_, err = cacher.Get("key1")
+	assert.Equal(t, ErrNoKeyFound, err)
+	assert.Equal(t, 0, cacher.Len())
+	assert.Equal(t, KeyCacherStats{Misses: 2, Expirations: 1}, cacher.Stats())
+}
+
+func TestLRUKeyCacherSet(t *testing.T) {
+	cacher := NewLRUKeyCacher(2, MaxKeyAgeNoCheck)
+	_, err := cacher.Add("key1", []jose.JSONWebKey{{KeyID: "key1", Key: defaultSecret}})
+	assert.NoError(t, err)
+
+	assert.NoError(t, cacher.Set([]jose.JSONWebKey{
+		{KeyID: "key2", Key: defaultSecret},
+		{KeyID: "key3", Key: defaultSecret},
+		{KeyID: "key4", Key: defaultSecret},
+	}))
+	assert.Equal(t, 2, cacher.Len())
+	for _, keyID := range []string{"key1", "key2"} {
+		_, err = cacher.Get(keyID)
+		assert.Equal(t, ErrNoKeyFound, err, keyID)
+	}
+	for _, keyID := range []string{"key3", "key4"} {
+		_, err = cacher.Get(keyID)
+		assert.NoError(t, err, keyID)
+	}
+	assert.Equal(t, uint64(2), cacher.Stats().Evictions)
+}
+
+func TestLRUKeyCacherWithJWKClient(t *testing.T) {
+	opts, tokenRS256, tokenES384, err := genNewTestServer(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cacher := NewLRUKeyCacher(10, time.Hour)
+	client := NewJWKClientWithCache(opts, nil, cacher)
+
+	testGetSecret(t, client, tokenRS256)
+	testGetSecret(t, client, tokenES384)
+	testGetSecret(t, client, tokenRS256)
+	assert.Equal(t, KeyCacherStats{Hits: 2, Misses: 1}, cacher.Stats())
+}
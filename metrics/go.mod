@@ -0,0 +1,31 @@
+module github.com/auth0-community/go-auth0/metrics
+
+go 1.18
+
+require (
+	github.com/auth0-community/go-auth0 v0.0.0-20261014151049-3d87a288140b
+	github.com/prometheus/client_golang v1.16.0
+	github.com/stretchr/testify v1.8.0
+	gopkg.in/square/go-jose.v2 v2.1.7
+)
+
+require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/golang/protobuf v1.5.3 // indirect
+	github.com/kr/text v0.2.0 // indirect
+	github.com/matttproud/golang_protobuf_extensions v1.0.4 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/prometheus/client_model v0.3.0 // indirect
+	github.com/prometheus/common v0.42.0 // indirect
+	github.com/prometheus/procfs v0.10.1 // indirect
+	github.com/rogpeppe/go-internal v1.9.0 // indirect
+	golang.org/x/crypto v0.0.0-20180802221240-56440b844dfe // indirect
+	golang.org/x/sync v0.2.0 // indirect
+	golang.org/x/sys v0.8.0 // indirect
+	google.golang.org/protobuf v1.30.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/auth0-community/go-auth0 => ../
@@ -0,0 +1,130 @@
+// Package metrics provides Prometheus metrics of the token validations,
+// JWKS downloads and key cache of go-auth0, wired through its observers.
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/auth0-community/go-auth0"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics implements auth0.ValidationObserver and auth0.SharedDownloadObserver,
+// recording the events into Prometheus metrics:
+//   - auth0_token_validations_total{result, reason}
+//   - auth0_token_validation_duration_seconds
+//   - auth0_jwks_downloads_total{status}
+//   - auth0_jwks_download_duration_seconds
+//   - auth0_jwks_download_lookups_total{shared}, the key lookups downloading
+//     the JWKS, shared="true" when simultaneous lookups shared the download
+//   - auth0_key_cache_requests_total{result}, where the hit ratio is the
+//     rate of result="hit" over the rate of all requests
+//   - auth0_key_cache_additions_total and auth0_key_cache_evictions_total
+type Metrics struct {
+	validations        *prometheus.CounterVec
+	validationDuration prometheus.Histogram
+	downloads          *prometheus.CounterVec
+	downloadDuration   prometheus.Histogram
+	downloadLookups    *prometheus.CounterVec
+	cacheRequests      *prometheus.CounterVec
+	cacheAdditions     prometheus.Counter
+	cacheEvictions     prometheus.Counter
+}
+
+// New creates the metrics and registers them with the registerer,
+// such as prometheus.DefaultRegisterer.
+func New(registerer prometheus.Registerer) (*Metrics, error) {
+	m := &Metrics{
+		validations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "auth0_token_validations_total",
+			Help: "Token validations by result and reason of the failure.",
+		}, []string{"result", "reason"}),
+		validationDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "auth0_token_validation_duration_seconds",
+			Help:    "Duration of the token validations.",
+			Buckets: prometheus.ExponentialBuckets(0.0001, 4, 8),
+		}),
+		downloads: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "auth0_jwks_downloads_total",
+			Help: "Download attempts of the JWKS by status code, 0 for network errors.",
+		}, []string{"status"}),
+		downloadDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name: "auth0_jwks_download_duration_seconds",
+			Help: "Duration of the download attempts of the JWKS.",
+		}),
+		downloadLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "auth0_jwks_download_lookups_total",
+			Help: "Key lookups downloading the JWKS by whether the download was shared.",
+		}, []string{"shared"}),
+		cacheRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "auth0_key_cache_requests_total",
+			Help: "Key cache requests by result, hit or miss.",
+		}, []string{"result"}),
+		cacheAdditions: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "auth0_key_cache_additions_total",
+			Help: "Downloaded keys added to the key cache.",
+		}),
+		cacheEvictions: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "auth0_key_cache_evictions_total",
+			Help: "Keys evicted from the key cache.",
+		}),
+	}
+
+	for _, c := range []prometheus.Collector{
+		m.validations, m.validationDuration, m.downloads, m.downloadDuration,
+		m.downloadLookups, m.cacheRequests, m.cacheAdditions, m.cacheEvictions,
+	} {
+		if err := registerer.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// OnValidation implements the auth0.ValidationObserver interface.
+func (m *Metrics) OnValidation(duration time.Duration, err error) {
+	m.validationDuration.Observe(duration.Seconds())
+	if err == nil {
+		m.validations.WithLabelValues("success", "").Inc()
+		return
+	}
+	m.validations.WithLabelValues("failure", Reason(err)).Inc()
+}
+
+// OnHit implements the auth0.CacheObserver interface.
+func (m *Metrics) OnHit(string) {
+	m.cacheRequests.WithLabelValues("hit").Inc()
+}
+
+// OnMiss implements the auth0.CacheObserver interface.
+func (m *Metrics) OnMiss(string) {
+	m.cacheRequests.WithLabelValues("miss").Inc()
+}
+
+// OnAdd implements the auth0.CacheObserver interface.
+func (m *Metrics) OnAdd(string) {
+	m.cacheAdditions.Inc()
+}
+
+// OnEvict implements the auth0.CacheObserver interface.
+func (m *Metrics) OnEvict(string) {
+	m.cacheEvictions.Inc()
+}
+
+// OnDownload implements the auth0.CacheObserver interface.
+func (m *Metrics) OnDownload(duration time.Duration, statusCode int, err error) {
+	m.downloadDuration.Observe(duration.Seconds())
+	m.downloads.WithLabelValues(strconv.Itoa(statusCode)).Inc()
+}
+
+// OnSharedDownload implements the auth0.SharedDownloadObserver interface.
+func (m *Metrics) OnSharedDownload(uri string, shared bool) {
+	m.downloadLookups.WithLabelValues(strconv.FormatBool(shared)).Inc()
+}
+
+// Reason returns the low cardinality reason label of a validation error,
+// see auth0.FailureReason.
+func Reason(err error) string {
+	return auth0.FailureReason(err)
+}
@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/auth0-community/go-auth0"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+var testSecret = []byte("secret")
+
+func getTestToken(t *testing.T, expiry time.Time) string {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: testSecret}, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := jwt.Signed(signer).Claims(jwt.Claims{
+		Issuer:   "issuer",
+		Audience: jwt.Audience{"audience"},
+		Expiry:   jwt.NewNumericDate(expiry),
+	}).CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestValidationMetrics(t *testing.T) {
+	m, err := New(prometheus.NewRegistry())
+	assert.NoError(t, err)
+
+	configuration := auth0.NewConfiguration(auth0.NewKeyProvider(testSecret), []string{"audience"}, "issuer", jose.HS256)
+	validator := auth0.NewValidator(configuration, nil, auth0.WithValidationObserver(m))
+
+	_, _ = validator.ValidateRawToken(getTestToken(t, time.Now().Add(time.Hour)))
+	_, _ = validator.ValidateRawToken(getTestToken(t, time.Now().Add(-time.Hour)))
+	_, _ = validator.ValidateRawToken(getTestToken(t, time.Now().Add(-time.Hour)))
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.validations.WithLabelValues("success", "")))
+	assert.Equal(t, float64(2), testutil.ToFloat64(m.validations.WithLabelValues("failure", "expired")))
+}
+
+func TestCacheMetrics(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	m, err := New(prometheus.NewRegistry())
+	assert.NoError(t, err)
+	client := auth0.NewJWKClient(auth0.JWKClientOptions{URI: ts.URL, Observer: m}, nil)
+
+	_, err = client.GetKey("key")
+	assert.Error(t, err)
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.cacheRequests.WithLabelValues("miss")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.downloads.WithLabelValues("500")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.downloadLookups.WithLabelValues("false")))
+}
+
+func TestReason(t *testing.T) {
+	assert.Equal(t, "token_not_found", Reason(auth0.ErrTokenNotFound))
+	assert.Equal(t, "invalid_audience", Reason(&auth0.ClaimError{Claim: "aud", Err: auth0.ErrInvalidAudience}))
+	assert.Equal(t, "insufficient_scope", Reason(&auth0.InsufficientScopeError{Missing: []string{"read:users"}}))
+	assert.Equal(t, "key_not_found", Reason(auth0.ErrKeyNotFound))
+	assert.Equal(t, "invalid_custom_claims", Reason(auth0.ErrInvalidCustomClaims))
+	assert.Equal(t, "jwks_unavailable", Reason(auth0.ErrCircuitOpen))
+	assert.Equal(t, "invalid", Reason(errors.New("malformed")))
+}
+
+func TestNewRegistersOnce(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	_, err := New(registry)
+	assert.NoError(t, err)
+	_, err = New(registry)
+	assert.Error(t, err)
+}
@@ -0,0 +1,345 @@
+package auth0
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+type contextKey string
+
+const (
+	// TokenContextKey is the request context key of the
+	// *jwt.JSONWebToken validated by the middleware.
+	TokenContextKey = contextKey("auth0-token")
+	// ClaimsContextKey is the request context key of the
+	// map[string]interface{} claims validated by the middleware.
+	ClaimsContextKey = contextKey("auth0-claims")
+)
+
+// ErrorHandler handles the requests rejected by the middleware.
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+// MiddlewareOption configures optional
+// behaviours of the middleware.
+type MiddlewareOption func(*middleware)
+
+// WithErrorHandler sets the handler called when the validation of the
+// request fails, or when a handler wrapper behind the middleware, such as
+// RequireScope, denies it.
+func WithErrorHandler(h ErrorHandler) MiddlewareOption {
+	return func(m *middleware) {
+		m.errorHandler = h
+	}
+}
+
+// WithIntrospection makes the middleware validate the opaque tokens of the
+// Authorization header, the ones not serialized as JWTs, with the client.
+// The claims returned by the introspection endpoint, stored under
+// ClaimsContextKey, are validated as those of the JWTs: their registered
+// claims, such as aud and iss, then the checks of the validator, such as
+// RequireScopes.
+// There is no token under TokenContextKey for opaque tokens.
+func WithIntrospection(client *IntrospectionClient) MiddlewareOption {
+	return func(m *middleware) {
+		m.introspection = client
+	}
+}
+
+// CredentialsOptional makes the middleware pass the requests with no token
+// through to the next handler, with no token or claims in their context, so
+// public endpoints can personalize the response of authenticated requests.
+// The requests with an invalid token are still rejected.
+func CredentialsOptional(optional bool) MiddlewareOption {
+	return func(m *middleware) {
+		m.credentialsOptional = optional
+	}
+}
+
+// SkipPaths makes the middleware pass the requests of the exact
+// URL paths, such as "/healthz", through with no validation. The paths
+// are matched once cleaned of their dot segments and duplicate slashes.
+func SkipPaths(paths ...string) MiddlewareOption {
+	set := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		set[p] = true
+	}
+	return SkipIf(func(r *http.Request) bool {
+		return set[cleanPath(r.URL.Path)]
+	})
+}
+
+// SkipPathPrefixes makes the middleware pass the requests of the URL paths
+// starting with one of the prefixes, such as "/metrics/", through with no
+// validation. The paths are matched once cleaned as with SkipPaths, so
+// "/metrics/../admin" does not match "/metrics/".
+func SkipPathPrefixes(prefixes ...string) MiddlewareOption {
+	return SkipIf(func(r *http.Request) bool {
+		cleaned := cleanPath(r.URL.Path)
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(cleaned, prefix) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// cleanPath returns the rooted path with no dot segments nor duplicate
+// slashes, keeping its trailing slash, as the routers resolve it.
+func cleanPath(p string) string {
+	cleaned := path.Clean("/" + p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
+// SkipIf makes the middleware pass the requests matching the predicate
+// through with no validation, with no token or claims in their context.
+// The rules of several options add up, a request matching any of them
+// is skipped.
+func SkipIf(skip func(r *http.Request) bool) MiddlewareOption {
+	return func(m *middleware) {
+		m.skips = append(m.skips, skip)
+	}
+}
+
+// WithPreflightHandler sets the handler of the CORS preflight requests,
+// which the middleware passes through to the next handler by default.
+func WithPreflightHandler(h http.Handler) MiddlewareOption {
+	return func(m *middleware) {
+		m.preflightHandler = h
+	}
+}
+
+// IsPreflightRequest tells whether the request is a CORS preflight request,
+// an OPTIONS request with an Access-Control-Request-Method header. Browsers
+// send no credentials with preflight requests, so the middleware never
+// validates them.
+func IsPreflightRequest(r *http.Request) bool {
+	return r != nil && r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+}
+
+type middleware struct {
+	validator           *JWTValidator
+	errorHandler        ErrorHandler
+	introspection       *IntrospectionClient
+	credentialsOptional bool
+	skips               []func(r *http.Request) bool
+	preflightHandler    http.Handler
+}
+
+// skip tells whether the request matches one of the skip rules.
+func (m *middleware) skip(r *http.Request) bool {
+	if r == nil || r.URL == nil {
+		return false
+	}
+	for _, skip := range m.skips {
+		if skip(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// Middleware creates a net/http middleware validating the token of the
+// requests with the validator. The validated token and its claims are
+// stored in the request context under TokenContextKey and ClaimsContextKey,
+// read them with TokenFromContext, ClaimsFromContext and RawTokenFromContext.
+// CORS preflight requests are passed through with no validation.
+func Middleware(validator *JWTValidator, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	m := &middleware{
+		validator:    validator,
+		errorHandler: DefaultErrorHandler,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if IsPreflightRequest(r) && m.preflightHandler != nil {
+				m.preflightHandler.ServeHTTP(w, r)
+				return
+			}
+			if IsPreflightRequest(r) || m.skip(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// The outcome is reported once, that of the introspection of the
+			// opaque tokens, and once the handler returns for the valid
+			// tokens, as the handler wrappers may still deny the request.
+			start := time.Now()
+			claims := map[string]interface{}{}
+			ctx, token, raw, err := m.validator.validateRequest(r, m.validator.leeway, true, &claims)
+			if opaque, ok := opaqueToken(r); ok && err != nil && m.introspection != nil {
+				token, raw = nil, opaque
+				claims, err = m.introspect(r, opaque)
+			}
+			duration := time.Since(start)
+			if err != nil {
+				m.validator.report(ctx, duration, token, claims, err)
+			}
+			if err != nil && m.credentialsOptional && errors.Is(err, ErrTokenNotFound) {
+				next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestDenialKey{}, &requestDenial{errorHandler: m.errorHandler})))
+				return
+			}
+			if err != nil {
+				m.errorHandler(w, r, err)
+				return
+			}
+
+			denial := &requestDenial{errorHandler: m.errorHandler}
+			defer func() {
+				m.validator.report(ctx, duration, token, claims, denial.err)
+			}()
+			next.ServeHTTP(w, r.WithContext(context.WithValue(NewContext(r.Context(), token, raw, claims), requestDenialKey{}, denial)))
+		})
+	}
+}
+
+// requestDenialKey is the context key of the requestDenial of the requests
+// passed to the handler by the middleware.
+type requestDenialKey struct{}
+
+// requestDenial records the denial of a request with a valid token by the
+// handler wrappers, reported by the middleware as the outcome of the request,
+// and holds the ErrorHandler of the middleware the wrappers respond with.
+type requestDenial struct {
+	err          error
+	errorHandler ErrorHandler
+}
+
+// requireClaims creates a handler wrapper responding with the error of the
+// check of the claims of the request context, or with ErrTokenNotFound when
+// there are none, through the ErrorHandler of the middleware, or
+// DefaultErrorHandler when the request did not go through one.
+func requireClaims(check func(claims map[string]interface{}) error) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			errorHandler := DefaultErrorHandler
+			denial, _ := r.Context().Value(requestDenialKey{}).(*requestDenial)
+			if denial != nil && denial.errorHandler != nil {
+				errorHandler = denial.errorHandler
+			}
+
+			claims, ok := ClaimsFromContext(r.Context())
+			if !ok {
+				errorHandler(w, r, ErrTokenNotFound)
+				return
+			}
+			if err := check(claims); err != nil {
+				if denial != nil {
+					denial.err = err
+				}
+				errorHandler(w, r, err)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// introspect validates the opaque token with the introspection client, and
+// its claims as those of the JWTs: the registered claims, such as aud and
+// iss, then the checks of the validator.
+func (m *middleware) introspect(r *http.Request, raw string) (map[string]interface{}, error) {
+	claims, err := m.introspection.Introspect(r.Context(), raw)
+	if err != nil {
+		return nil, err
+	}
+	registered, err := registeredClaims(claims)
+	if err != nil {
+		return nil, malformedError(err)
+	}
+	if err := m.validator.checkVerifiedClaims(contextWithRequest(r.Context(), r), registered, claims, m.validator.leeway); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// opaqueToken returns the token of the Authorization header
+// if it is not serialized as a JWS or JWE.
+func opaqueToken(r *http.Request) (string, bool) {
+	if r == nil {
+		return "", false
+	}
+	h := r.Header.Get("Authorization")
+	if len(h) <= 7 || !strings.EqualFold(h[0:7], "BEARER ") {
+		return "", false
+	}
+	raw := strings.TrimSpace(h[7:])
+	if n := strings.Count(raw, "."); n == 2 || n == 4 {
+		return "", false
+	}
+	return raw, true
+}
+
+// DefaultErrorHandler responds with the RFC 6750 Bearer error matching
+// the error, with no realm.
+func DefaultErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	defaultBearerErrorHandler(w, r, err)
+}
+
+var defaultBearerErrorHandler = NewBearerErrorHandler("")
+
+// NewBearerErrorHandler creates an ErrorHandler responding with the RFC 6750
+// Bearer error matching the error, using the realm if not empty:
+//   - 401 Unauthorized with no error code when the request has no token,
+//   - 403 Forbidden with error="insufficient_scope" when the token lacks
+//     the required scopes or permissions, or is not of the required kind,
+//   - 401 Unauthorized with error="invalid_token" otherwise.
+func NewBearerErrorHandler(realm string) ErrorHandler {
+	return func(w http.ResponseWriter, r *http.Request, err error) {
+		status, code := BearerError(err)
+
+		var params []string
+		if realm != "" {
+			params = append(params, fmt.Sprintf("realm=%q", realm))
+		}
+		if code != "" {
+			params = append(params, fmt.Sprintf("error=%q", code))
+		}
+
+		var scopeErr *InsufficientScopeError
+		if errors.As(err, &scopeErr) {
+			params = append(params, fmt.Sprintf("scope=%q", strings.Join(scopeErr.Missing, " ")))
+		}
+
+		challenge := "Bearer"
+		if len(params) > 0 {
+			challenge += " " + strings.Join(params, ", ")
+		}
+		w.Header().Set("WWW-Authenticate", challenge)
+		http.Error(w, http.StatusText(status), status)
+	}
+}
+
+// Bearer error codes defined by RFC 6750.
+const (
+	BearerErrorInvalidRequest    = "invalid_request"
+	BearerErrorInvalidToken      = "invalid_token"
+	BearerErrorInsufficientScope = "insufficient_scope"
+)
+
+// BearerError maps a validation error to the HTTP status
+// and the RFC 6750 Bearer error code of the response.
+// The code is empty when the request has no token.
+func BearerError(err error) (int, string) {
+	switch {
+	case errors.Is(err, ErrTokenNotFound):
+		return http.StatusUnauthorized, ""
+	case errors.Is(err, ErrNilRequest):
+		return http.StatusBadRequest, BearerErrorInvalidRequest
+	case errors.Is(err, ErrInsufficientScope), errors.Is(err, ErrInsufficientPermissions), errors.Is(err, ErrTokenKind):
+		return http.StatusForbidden, BearerErrorInsufficientScope
+	default:
+		return http.StatusUnauthorized, BearerErrorInvalidToken
+	}
+}
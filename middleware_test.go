@@ -0,0 +1,281 @@
+package auth0
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func genTestMiddlewareRequest(token string) *http.Request {
+	req := httptest.NewRequest("GET", "http://localhost", nil)
+	if token != "" {
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+	}
+	return req
+}
+
+func TestMiddleware(t *testing.T) {
+	registered := jwt.Claims{Issuer: defaultIssuer, Audience: defaultAudience, Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+	validToken := getTestTokenWithClaims(jose.HS256, defaultSecret, registered, map[string]interface{}{"scope": "read:users"})
+	expiredToken := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(-time.Hour), jose.HS256, defaultSecret)
+
+	tests := []struct {
+		name           string
+		opts           []ValidatorOption
+		token          string
+		expectedStatus int
+	}{
+		{"pass - valid token", nil, validToken, http.StatusOK},
+		{"fail - no token", nil, "", http.StatusUnauthorized},
+		{"fail - expired token", nil, expiredToken, http.StatusUnauthorized},
+		{"fail - insufficient scope", []ValidatorOption{RequireScopes("write:users")}, validToken, http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			validator := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), nil, test.opts...)
+			handler := Middleware(validator)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				claims, ok := r.Context().Value(ClaimsContextKey).(map[string]interface{})
+				assert.True(t, ok)
+				assert.Equal(t, "read:users", claims["scope"])
+				_, ok = r.Context().Value(TokenContextKey).(*jwt.JSONWebToken)
+				assert.True(t, ok)
+			}))
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, genTestMiddlewareRequest(test.token))
+			assert.Equal(t, test.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestMiddlewareErrorHandler(t *testing.T) {
+	validator := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), nil)
+
+	var handledErr error
+	handler := Middleware(validator, WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+		handledErr = err
+		w.WriteHeader(http.StatusTeapot)
+	}))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("The handler should not have been called")
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, genTestMiddlewareRequest(""))
+	assert.Equal(t, http.StatusTeapot, rec.Code)
+	assert.Equal(t, ErrTokenNotFound, handledErr)
+}
+
+func TestMiddlewareErrorHandlerWrappers(t *testing.T) {
+	validator := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), nil)
+	registered := jwt.Claims{Issuer: defaultIssuer, Audience: defaultAudience, Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+	token := getTestTokenWithClaims(jose.HS256, defaultSecret, registered, map[string]interface{}{"scope": "write:orders"})
+
+	// The wrappers respond with the error handler of the middleware.
+	handler := Middleware(validator, WithErrorHandler(NewBearerErrorHandler("api")), CredentialsOptional(true))(
+		RequireScope("read:orders")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Error("The handler should not have been called")
+		})),
+	)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, genTestMiddlewareRequest(token))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, `Bearer realm="api", error="insufficient_scope", scope="read:orders"`, rec.Header().Get("WWW-Authenticate"))
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, genTestMiddlewareRequest(""))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, `Bearer realm="api"`, rec.Header().Get("WWW-Authenticate"))
+}
+
+func TestMiddlewareRawToken(t *testing.T) {
+	validator := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), FromWebSocketProtocol("access_token"))
+	token := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, defaultSecret)
+
+	called := false
+	handler := Middleware(validator)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		// The token is extracted once, before its removal from the protocols.
+		raw, ok := RawTokenFromContext(r.Context())
+		assert.True(t, ok)
+		assert.Equal(t, token, raw)
+		assert.Equal(t, "access_token", r.Header.Get("Sec-WebSocket-Protocol"))
+	}))
+
+	r := httptest.NewRequest("GET", "http://localhost", nil)
+	r.Header.Set("Sec-WebSocket-Protocol", "access_token, "+token)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, called)
+}
+
+func TestCredentialsOptional(t *testing.T) {
+	validToken := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, defaultSecret)
+	expiredToken := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(-time.Hour), jose.HS256, defaultSecret)
+
+	tests := []struct {
+		name           string
+		token          string
+		expectedStatus int
+		expectedClaims bool
+	}{
+		{"pass - valid token", validToken, http.StatusOK, true},
+		{"pass - no token", "", http.StatusOK, false},
+		{"fail - expired token", expiredToken, http.StatusUnauthorized, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			validator := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), nil)
+			handler := Middleware(validator, CredentialsOptional(true))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, ok := ClaimsFromContext(r.Context())
+				assert.Equal(t, test.expectedClaims, ok)
+			}))
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, genTestMiddlewareRequest(test.token))
+			assert.Equal(t, test.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestMiddlewareSkipRules(t *testing.T) {
+	validator := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), nil)
+	handler := Middleware(validator,
+		SkipPaths("/healthz"),
+		SkipPathPrefixes("/metrics/"),
+		SkipIf(func(r *http.Request) bool { return r.Method == http.MethodOptions }),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		name           string
+		method         string
+		target         string
+		expectedStatus int
+	}{
+		{"pass - exact path", http.MethodGet, "http://localhost/healthz", http.StatusOK},
+		{"pass - path prefix", http.MethodGet, "http://localhost/metrics/go", http.StatusOK},
+		{"pass - predicate", http.MethodOptions, "http://localhost/users", http.StatusOK},
+		{"fail - path not exact", http.MethodGet, "http://localhost/healthz/more", http.StatusUnauthorized},
+		{"fail - prefix not matching", http.MethodGet, "http://localhost/metrics", http.StatusUnauthorized},
+		{"fail - not skipped", http.MethodGet, "http://localhost/users", http.StatusUnauthorized},
+		{"pass - cleaned path prefix", http.MethodGet, "http://localhost/metrics/./go", http.StatusOK},
+		{"pass - cleaned prefix with trailing slash", http.MethodGet, "http://localhost/admin/../metrics/", http.StatusOK},
+		{"fail - dot segments out of the prefix", http.MethodGet, "http://localhost/metrics/../admin", http.StatusUnauthorized},
+		{"fail - encoded dot segments out of the prefix", http.MethodGet, "http://localhost/metrics/%2e%2e/admin", http.StatusUnauthorized},
+		{"fail - dot segments out of the exact path", http.MethodGet, "http://localhost/healthz/../admin", http.StatusUnauthorized},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(test.method, test.target, nil))
+			assert.Equal(t, test.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestMiddlewarePreflight(t *testing.T) {
+	validator := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), nil)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+	preflight := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	genRequest := func(method string, preflight bool) *http.Request {
+		req := httptest.NewRequest(method, "http://localhost/users", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		if preflight {
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		}
+		return req
+	}
+
+	tests := []struct {
+		name           string
+		opts           []MiddlewareOption
+		req            *http.Request
+		expectedStatus int
+	}{
+		{"pass - preflight passed through", nil, genRequest(http.MethodOptions, true), http.StatusAccepted},
+		{"pass - preflight handler", []MiddlewareOption{WithPreflightHandler(preflight)}, genRequest(http.MethodOptions, true), http.StatusNoContent},
+		{"fail - OPTIONS not preflight", nil, genRequest(http.MethodOptions, false), http.StatusUnauthorized},
+		{"fail - not OPTIONS", []MiddlewareOption{WithPreflightHandler(preflight)}, genRequest(http.MethodPost, true), http.StatusUnauthorized},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			Middleware(validator, test.opts...)(next).ServeHTTP(rec, test.req)
+			assert.Equal(t, test.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestBearerErrorHandler(t *testing.T) {
+	tests := []struct {
+		name              string
+		realm             string
+		err               error
+		expectedStatus    int
+		expectedChallenge string
+	}{
+		{
+			name:              "no token",
+			err:               ErrTokenNotFound,
+			expectedStatus:    http.StatusUnauthorized,
+			expectedChallenge: `Bearer`,
+		},
+		{
+			name:              "no token with realm",
+			realm:             "api",
+			err:               ErrTokenNotFound,
+			expectedStatus:    http.StatusUnauthorized,
+			expectedChallenge: `Bearer realm="api"`,
+		},
+		{
+			name:              "invalid token",
+			realm:             "api",
+			err:               jwt.ErrExpired,
+			expectedStatus:    http.StatusUnauthorized,
+			expectedChallenge: `Bearer realm="api", error="invalid_token"`,
+		},
+		{
+			name:              "insufficient scope",
+			err:               &InsufficientScopeError{Missing: []string{"read:users", "write:users"}},
+			expectedStatus:    http.StatusForbidden,
+			expectedChallenge: `Bearer error="insufficient_scope", scope="read:users write:users"`,
+		},
+		{
+			name:              "insufficient permissions",
+			err:               &InsufficientPermissionsError{Missing: []string{"read:users"}},
+			expectedStatus:    http.StatusForbidden,
+			expectedChallenge: `Bearer error="insufficient_scope"`,
+		},
+		{
+			name:              "nil request",
+			err:               ErrNilRequest,
+			expectedStatus:    http.StatusBadRequest,
+			expectedChallenge: `Bearer error="invalid_request"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			NewBearerErrorHandler(test.realm)(rec, genTestMiddlewareRequest(""), test.err)
+			assert.Equal(t, test.expectedStatus, rec.Code)
+			assert.Equal(t, test.expectedChallenge, rec.Header().Get("WWW-Authenticate"))
+		})
+	}
+}
@@ -0,0 +1,20 @@
+module github.com/auth0-community/go-auth0/oauth2
+
+go 1.18
+
+require (
+	github.com/auth0-community/go-auth0 v0.0.0-20261014151049-3d87a288140b
+	github.com/stretchr/testify v1.4.0
+	golang.org/x/oauth2 v0.26.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/crypto v0.0.0-20180802221240-56440b844dfe // indirect
+	golang.org/x/sync v0.0.0-20200317015054-43a5402ce75a // indirect
+	gopkg.in/square/go-jose.v2 v2.1.7 // indirect
+	gopkg.in/yaml.v2 v2.2.2 // indirect
+)
+
+replace github.com/auth0-community/go-auth0 => ../
@@ -0,0 +1,48 @@
+// Package oauth2 adapts the token sources of go-auth0, such as
+// auth0.ClientCredentials, to golang.org/x/oauth2, so they plug into the
+// HTTP clients, gRPC credentials and SDKs accepting an oauth2.TokenSource.
+package oauth2
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/auth0-community/go-auth0"
+	oauth2api "golang.org/x/oauth2"
+)
+
+// NewTokenSource creates an oauth2.TokenSource of the tokens of the source,
+// requested with the context. The source caches the tokens, so the returned
+// oauth2.TokenSource does not need to be wrapped with oauth2.ReuseTokenSource.
+func NewTokenSource(ctx context.Context, source auth0.TokenSource) oauth2api.TokenSource {
+	return &tokenSource{ctx: ctx, source: source}
+}
+
+// NewClient creates an HTTP client authenticating the requests with the
+// tokens of the source, using the HTTP client of the context if any, as
+// oauth2.NewClient does.
+func NewClient(ctx context.Context, source auth0.TokenSource) *http.Client {
+	return oauth2api.NewClient(ctx, NewTokenSource(ctx, source))
+}
+
+type tokenSource struct {
+	ctx    context.Context
+	source auth0.TokenSource
+}
+
+// Token implements the oauth2.TokenSource interface.
+func (s *tokenSource) Token() (*oauth2api.Token, error) {
+	token, err := s.source.Token(s.ctx)
+	if err != nil {
+		return nil, err
+	}
+	t := &oauth2api.Token{
+		AccessToken: token.AccessToken,
+		TokenType:   token.TokenType,
+		Expiry:      token.Expiry,
+	}
+	if token.Scope != "" {
+		t = t.WithExtra(map[string]interface{}{"scope": token.Scope})
+	}
+	return t, nil
+}
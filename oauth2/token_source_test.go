@@ -0,0 +1,74 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/auth0-community/go-auth0"
+	"github.com/stretchr/testify/assert"
+)
+
+func genTestTokenServer(counter *uint64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint64(counter, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"token","token_type":"Bearer","scope":"read:users","expires_in":3600}`))
+	}))
+}
+
+func TestTokenSource(t *testing.T) {
+	var counter uint64
+	ts := genTestTokenServer(&counter)
+	defer ts.Close()
+
+	credentials := auth0.NewClientCredentials(auth0.ClientCredentialsOptions{TokenURL: ts.URL, ClientID: "client", ClientSecret: "secret"})
+	source := NewTokenSource(context.Background(), credentials)
+
+	token, err := source.Token()
+	assert.NoError(t, err)
+	assert.Equal(t, "token", token.AccessToken)
+	assert.Equal(t, "Bearer", token.TokenType)
+	assert.Equal(t, "read:users", token.Extra("scope"))
+	assert.WithinDuration(t, time.Now().Add(time.Hour), token.Expiry, time.Minute)
+	assert.True(t, token.Valid())
+
+	_, err = source.Token()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), atomic.LoadUint64(&counter), "the token should be cached by the source")
+}
+
+func TestTokenSourceError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":"invalid_client"}`))
+	}))
+	defer ts.Close()
+
+	credentials := auth0.NewClientCredentials(auth0.ClientCredentialsOptions{TokenURL: ts.URL})
+	_, err := NewTokenSource(context.Background(), credentials).Token()
+	assert.True(t, errors.Is(err, auth0.ErrTokenRequestFailed), "got %v", err)
+}
+
+func TestNewClient(t *testing.T) {
+	var counter uint64
+	tokenServer := genTestTokenServer(&counter)
+	defer tokenServer.Close()
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+	}))
+	defer api.Close()
+
+	credentials := auth0.NewClientCredentials(auth0.ClientCredentialsOptions{TokenURL: tokenServer.URL})
+	resp, err := NewClient(context.Background(), credentials).Get(api.URL)
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+}
@@ -0,0 +1,71 @@
+package auth0
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrInvalidOrganization is returned when the token has not been
+	// issued for the expected or one of the allowed Auth0 Organizations.
+	ErrInvalidOrganization = errors.New("invalid organization claim (org_id, org_name)")
+)
+
+// OrganizationID returns the org_id claim, added by Auth0 to the tokens
+// issued for a member of an Organization.
+func OrganizationID(claims map[string]interface{}) string {
+	id, _ := claims["org_id"].(string)
+	return id
+}
+
+// OrganizationName returns the org_name claim, added by Auth0 to
+// the tokens when the tenant is configured to include it.
+func OrganizationName(claims map[string]interface{}) string {
+	name, _ := claims["org_name"].(string)
+	return name
+}
+
+// WithExpectedOrganization makes the validator reject tokens not issued for
+// the organization whose ID is the org_id claim, such as "org_9ybsU1dN2dKfDkBi".
+func WithExpectedOrganization(id string) ValidatorOption {
+	return WithAllowedOrganizations(id)
+}
+
+// WithAllowedOrganizations makes the validator reject tokens not issued for
+// any of the organizations whose ID is the org_id claim.
+func WithAllowedOrganizations(ids ...string) ValidatorOption {
+	return withOrganizations(func(claims map[string]interface{}, id string) bool {
+		return OrganizationID(claims) == id
+	}, ids)
+}
+
+// WithExpectedOrganizationName makes the validator reject tokens not issued
+// for the organization whose name is the org_name claim, compared
+// case-insensitively as Auth0 stores organization names in lowercase.
+func WithExpectedOrganizationName(name string) ValidatorOption {
+	return WithAllowedOrganizationNames(name)
+}
+
+// WithAllowedOrganizationNames makes the validator reject tokens not issued
+// for any of the organizations whose name is the org_name claim.
+func WithAllowedOrganizationNames(names ...string) ValidatorOption {
+	return withOrganizations(func(claims map[string]interface{}, name string) bool {
+		claim := OrganizationName(claims)
+		return claim != "" && strings.EqualFold(claim, name)
+	}, names)
+}
+
+// withOrganizations adds the check of the tokens issued for one of the
+// organizations, matched against the claims by isOrganization.
+func withOrganizations(isOrganization func(claims map[string]interface{}, organization string) bool, organizations []string) ValidatorOption {
+	return func(v *JWTValidator) {
+		v.checks = append(v.checks, func(claims map[string]interface{}) error {
+			for _, organization := range organizations {
+				if organization != "" && isOrganization(claims, organization) {
+					return nil
+				}
+			}
+			return ErrInvalidOrganization
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package auth0
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestOrganization(t *testing.T) {
+	claims := map[string]interface{}{"org_id": "org_123", "org_name": "acme"}
+	assert.Equal(t, "org_123", OrganizationID(claims))
+	assert.Equal(t, "acme", OrganizationName(claims))
+	assert.Empty(t, OrganizationID(map[string]interface{}{}))
+	assert.Empty(t, OrganizationName(map[string]interface{}{"org_name": 42}))
+}
+
+func TestValidateWithOrganizations(t *testing.T) {
+	registered := jwt.Claims{Issuer: defaultIssuer, Audience: defaultAudience, Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+	acme := map[string]interface{}{"org_id": "org_123", "org_name": "acme"}
+	globex := map[string]interface{}{"org_id": "org_456"}
+
+	tests := []struct {
+		name    string
+		opt     ValidatorOption
+		claims  map[string]interface{}
+		wantErr error
+	}{
+		{"pass - expected organization ID", WithExpectedOrganization("org_123"), acme, nil},
+		{"pass - allowed organizations", WithAllowedOrganizations("org_456", "org_123"), globex, nil},
+		{"fail - other organization", WithExpectedOrganization("org_123"), globex, ErrInvalidOrganization},
+		{"fail - name as ID", WithExpectedOrganization("acme"), acme, ErrInvalidOrganization},
+		{"fail - no organization", WithAllowedOrganizations("org_123", "org_456"), map[string]interface{}{}, ErrInvalidOrganization},
+		{"fail - empty ID", WithExpectedOrganization(""), map[string]interface{}{}, ErrInvalidOrganization},
+		{"pass - expected organization name", WithExpectedOrganizationName("ACME"), acme, nil},
+		{"pass - allowed organization names", WithAllowedOrganizationNames("globex", "acme"), acme, nil},
+		{"pass - name starting with org_", WithExpectedOrganizationName("org_acme"), map[string]interface{}{"org_id": "org_123", "org_name": "org_acme"}, nil},
+		{"fail - ID as name", WithExpectedOrganizationName("org_123"), acme, ErrInvalidOrganization},
+		{"fail - no organization name", WithExpectedOrganizationName("globex"), globex, ErrInvalidOrganization},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			validator := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), nil, test.opt)
+			_, err := validator.ValidateRawToken(getTestTokenWithClaims(jose.HS256, defaultSecret, registered, test.claims))
+			assert.Equal(t, test.wantErr, err)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+// Package otel provides an OpenTelemetry auth0.Tracer, tracing the token
+// validations, key lookups and JWKS downloads of go-auth0.
+package otel
+
+import (
+	"context"
+
+	"github.com/auth0-community/go-auth0"
+	otelapi "go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName is the name of the tracer of the spans.
+const InstrumentationName = "github.com/auth0-community/go-auth0"
+
+// NewTracer creates an auth0.Tracer starting the spans with the provider,
+// or with the global provider if nil. Pass it to auth0.WithTracer and to
+// the Tracer option of the JWKClient:
+//
+//	tracer := otel.NewTracer(nil)
+//	client := auth0.NewJWKClient(auth0.JWKClientOptions{URI: uri, Tracer: tracer}, nil)
+//	validator := auth0.NewValidator(configuration, nil, auth0.WithTracer(tracer))
+func NewTracer(provider trace.TracerProvider) auth0.Tracer {
+	if provider == nil {
+		provider = otelapi.GetTracerProvider()
+	}
+	return &tracer{tracer: provider.Tracer(InstrumentationName)}
+}
+
+type tracer struct {
+	tracer trace.Tracer
+}
+
+// Start implements the auth0.Tracer interface.
+func (t *tracer) Start(ctx context.Context, name string) (context.Context, auth0.Span) {
+	kind := trace.SpanKindInternal
+	if name == auth0.SpanDownloadKeys {
+		kind = trace.SpanKindClient
+	}
+	ctx, span := t.tracer.Start(ctx, name, trace.WithSpanKind(kind))
+	return ctx, &otelSpan{span: span}
+}
+
+type otelSpan struct {
+	span trace.Span
+}
+
+// SetAttribute implements the auth0.Span interface.
+func (s *otelSpan) SetAttribute(key string, value interface{}) {
+	switch v := value.(type) {
+	case string:
+		s.span.SetAttributes(attribute.String(key, v))
+	case bool:
+		s.span.SetAttributes(attribute.Bool(key, v))
+	case int:
+		s.span.SetAttributes(attribute.Int(key, v))
+	}
+}
+
+// End implements the auth0.Span interface.
+func (s *otelSpan) End(err error) {
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
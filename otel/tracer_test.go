@@ -0,0 +1,73 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/auth0-community/go-auth0"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+var testSecret = []byte("secret")
+
+func getTestToken(t *testing.T) string {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: testSecret}, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := jwt.Signed(signer).Claims(jwt.Claims{
+		Issuer:   "issuer",
+		Audience: jwt.Audience{"audience"},
+		Expiry:   jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}).CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestTracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	configuration := auth0.NewConfiguration(auth0.NewKeyProvider(testSecret), []string{"audience"}, "issuer", jose.HS256)
+	validator := auth0.NewValidator(configuration, nil, auth0.WithTracer(NewTracer(provider)))
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "request")
+	r := httptest.NewRequest("GET", "http://localhost", nil).WithContext(ctx)
+	r.Header.Set("Authorization", "Bearer "+getTestToken(t))
+	_, err := validator.ValidateRequest(r)
+	assert.NoError(t, err)
+	parent.End()
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, auth0.SpanValidateRequest, spans[0].Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Contains(t, spans[0].Attributes(), attribute.String(auth0.AttributeIssuer, "issuer"))
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+}
+
+func TestSpanError(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	_, span := NewTracer(provider).Start(context.Background(), auth0.SpanDownloadKeys)
+	span.SetAttribute(auth0.AttributeCacheHit, false)
+	span.End(errors.New("unexpected status code 503 from JWKS endpoint"))
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Contains(t, spans[0].Attributes(), attribute.Bool(auth0.AttributeCacheHit, false))
+	assert.Len(t, spans[0].Events(), 1)
+}
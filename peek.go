@@ -0,0 +1,66 @@
+package auth0
+
+import (
+	"errors"
+
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+var (
+	// ErrTokenEncrypted is returned by PeekClaims for the encrypted
+	// tokens, whose claims cannot be read without their decryption key.
+	ErrTokenEncrypted = errors.New("token is encrypted")
+)
+
+// PeekHeaders decodes the protected header of the compact serialized token,
+// a JWS or a JWE, such as to read its kid or alg before selecting its key.
+//
+// The header is NOT verified: it may have been forged by the sender of the
+// token, and must not be trusted before the token is validated.
+func PeekHeaders(raw string) (_ jose.Header, err error) {
+	defer recoverPanic(panicInputToken, &err)
+	if err := (TokenLimits{}).checkLength(raw); err != nil {
+		return jose.Header{}, err
+	}
+	if isEncrypted(raw) {
+		enc, err := jose.ParseEncrypted(raw)
+		if err != nil {
+			return jose.Header{}, malformedError(err)
+		}
+		return enc.Header, nil
+	}
+
+	token, err := jwt.ParseSigned(raw)
+	if err != nil {
+		return jose.Header{}, malformedError(err)
+	}
+	return token.Headers[0], nil
+}
+
+// PeekClaims decodes the claims of the compact serialized JWS, such as to
+// read its iss to route it to the key provider of its tenant, or to log it.
+// It fails with ErrTokenEncrypted for the JWEs.
+//
+// The claims are NOT verified: neither the signature nor the registered
+// claims, such as exp or aud, are checked. They may have been forged by the
+// sender of the token, and must not be trusted before the token is validated.
+func PeekClaims(raw string) (_ map[string]interface{}, err error) {
+	defer recoverPanic(panicInputToken, &err)
+	if err := (TokenLimits{}).checkLength(raw); err != nil {
+		return nil, err
+	}
+	if isEncrypted(raw) {
+		return nil, ErrTokenEncrypted
+	}
+
+	token, err := jwt.ParseSigned(raw)
+	if err != nil {
+		return nil, malformedError(err)
+	}
+	claims := map[string]interface{}{}
+	if err := token.UnsafeClaimsWithoutVerification(&claims); err != nil {
+		return nil, malformedError(err)
+	}
+	return claims, nil
+}
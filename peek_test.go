@@ -0,0 +1,40 @@
+package auth0
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func TestPeek(t *testing.T) {
+	key := genRSASSAJWK(jose.RS256, "keyRS256")
+	raw := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(-time.Hour), jose.RS256, key)
+
+	header, err := PeekHeaders(raw)
+	assert.NoError(t, err)
+	assert.Equal(t, "keyRS256", header.KeyID)
+	assert.Equal(t, string(jose.RS256), header.Algorithm)
+
+	// The claims of expired tokens are read all the same.
+	claims, err := PeekClaims(raw)
+	assert.NoError(t, err)
+	assert.Equal(t, defaultIssuer, claims["iss"])
+
+	_, err = PeekHeaders("not a token")
+	assert.True(t, errors.Is(err, ErrTokenMalformed), "got %v", err)
+	_, err = PeekClaims("not a token")
+	assert.True(t, errors.Is(err, ErrTokenMalformed), "got %v", err)
+
+	encryptionKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	nested := getTestNestedToken(t, &encryptionKey.PublicKey)
+	header, err = PeekHeaders(nested)
+	assert.NoError(t, err)
+	assert.Equal(t, string(jose.RSA_OAEP), header.Algorithm)
+	_, err = PeekClaims(nested)
+	assert.Equal(t, ErrTokenEncrypted, err)
+}
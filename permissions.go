@@ -0,0 +1,120 @@
+package auth0
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrInsufficientPermissions is returned when the token does not have
+	// the required permissions. Use errors.As with *InsufficientPermissionsError
+	// to retrieve the missing permissions.
+	ErrInsufficientPermissions = errors.New("insufficient permissions")
+)
+
+// InsufficientPermissionsError lists the required permissions missing from a token.
+// When any of the permissions is required, all of them are listed.
+type InsufficientPermissionsError struct {
+	Missing []string
+}
+
+func (e *InsufficientPermissionsError) Error() string {
+	return fmt.Sprintf("%s: missing %s", ErrInsufficientPermissions, strings.Join(e.Missing, " "))
+}
+
+// Is makes errors.Is match ErrInsufficientPermissions.
+func (e *InsufficientPermissionsError) Is(target error) bool {
+	return target == ErrInsufficientPermissions
+}
+
+// Permissions returns the permissions of the permissions claim,
+// added by Auth0 to access tokens when RBAC is enabled for the API.
+func Permissions(claims map[string]interface{}) []string {
+	values, _ := claims["permissions"].([]interface{})
+	permissions := make([]string, 0, len(values))
+	for _, value := range values {
+		if permission, ok := value.(string); ok {
+			permissions = append(permissions, permission)
+		}
+	}
+	return permissions
+}
+
+// HasPermission reports whether the permissions claim contains the permission.
+func HasPermission(claims map[string]interface{}, permission string) bool {
+	for _, p := range Permissions(claims) {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAllPermissions reports whether the permissions claim contains all the permissions.
+func HasAllPermissions(claims map[string]interface{}, permissions ...string) bool {
+	return len(missingPermissions(claims, permissions)) == 0
+}
+
+// HasAnyPermission reports whether the permissions claim contains any of the permissions.
+func HasAnyPermission(claims map[string]interface{}, permissions ...string) bool {
+	return len(missingPermissions(claims, permissions)) < len(permissions)
+}
+
+func missingPermissions(claims map[string]interface{}, permissions []string) []string {
+	granted := map[string]bool{}
+	for _, permission := range Permissions(claims) {
+		granted[permission] = true
+	}
+
+	var missing []string
+	for _, permission := range permissions {
+		if !granted[permission] {
+			missing = append(missing, permission)
+		}
+	}
+	return missing
+}
+
+// CheckPermissions checks that the permissions claim contains all the permissions,
+// returning an *InsufficientPermissionsError listing the missing ones otherwise.
+func CheckPermissions(claims map[string]interface{}, permissions ...string) error {
+	if missing := missingPermissions(claims, permissions); len(missing) > 0 {
+		return &InsufficientPermissionsError{Missing: missing}
+	}
+	return nil
+}
+
+// RequirePermissions makes the validator reject tokens
+// that do not have all the permissions.
+func RequirePermissions(permissions ...string) ValidatorOption {
+	return func(v *JWTValidator) {
+		v.checks = append(v.checks, func(claims map[string]interface{}) error {
+			return CheckPermissions(claims, permissions...)
+		})
+	}
+}
+
+// RequireAnyPermission makes the validator reject tokens
+// that do not have any of the permissions.
+func RequireAnyPermission(permissions ...string) ValidatorOption {
+	return func(v *JWTValidator) {
+		v.checks = append(v.checks, func(claims map[string]interface{}) error {
+			if len(permissions) > 0 && !HasAnyPermission(claims, permissions...) {
+				return &InsufficientPermissionsError{Missing: permissions}
+			}
+			return nil
+		})
+	}
+}
+
+// RequirePermission wraps a handler behind the middleware to reject the
+// requests whose token does not have all the permissions, with 403 Forbidden
+// and the insufficient_scope Bearer error. Requests with no validated token
+// are rejected with 401.
+func RequirePermission(permissions ...string) func(http.Handler) http.Handler {
+	return requireClaims(func(claims map[string]interface{}) error {
+		return CheckPermissions(claims, permissions...)
+	})
+}
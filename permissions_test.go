@@ -0,0 +1,96 @@
+package auth0
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestPermissions(t *testing.T) {
+	claims := map[string]interface{}{"permissions": []interface{}{"read:users", "write:users", 42}}
+
+	assert.Equal(t, []string{"read:users", "write:users"}, Permissions(claims))
+	assert.Empty(t, Permissions(map[string]interface{}{}))
+
+	assert.True(t, HasPermission(claims, "read:users"))
+	assert.False(t, HasPermission(claims, "delete:users"))
+
+	assert.True(t, HasAllPermissions(claims, "read:users", "write:users"))
+	assert.False(t, HasAllPermissions(claims, "read:users", "delete:users"))
+
+	assert.True(t, HasAnyPermission(claims, "delete:users", "write:users"))
+	assert.False(t, HasAnyPermission(claims, "delete:users"))
+
+	err := CheckPermissions(claims, "read:users", "delete:users")
+	var permErr *InsufficientPermissionsError
+	if assert.True(t, errors.As(err, &permErr)) {
+		assert.Equal(t, []string{"delete:users"}, permErr.Missing)
+	}
+	assert.True(t, errors.Is(err, ErrInsufficientPermissions))
+}
+
+func TestValidateRequirePermissions(t *testing.T) {
+	configuration := NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256)
+	registered := jwt.Claims{Issuer: defaultIssuer, Audience: defaultAudience, Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+	token := getTestTokenWithClaims(jose.HS256, defaultSecret, registered, map[string]interface{}{"permissions": []string{"read:users"}})
+
+	tests := []struct {
+		name        string
+		option      ValidatorOption
+		expectError bool
+	}{
+		{"pass - all permissions", RequirePermissions("read:users"), false},
+		{"fail - missing permission", RequirePermissions("read:users", "write:users"), true},
+		{"pass - any permission", RequireAnyPermission("write:users", "read:users"), false},
+		{"fail - no permission", RequireAnyPermission("write:users"), true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			validator := NewValidator(configuration, nil, test.option)
+			_, err := validator.ValidateRawToken(token)
+			if test.expectError {
+				assert.True(t, errors.Is(err, ErrInsufficientPermissions))
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	validator := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), nil)
+	registered := jwt.Claims{Issuer: defaultIssuer, Audience: defaultAudience, Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+	token := getTestTokenWithClaims(jose.HS256, defaultSecret, registered, map[string]interface{}{"permissions": []string{"read:orders"}})
+
+	mux := http.NewServeMux()
+	mux.Handle("/orders", RequirePermission("read:orders")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	mux.Handle("/admin", RequirePermission("read:orders", "admin")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	handler := Middleware(validator)(mux)
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+	}{
+		{"pass - permission granted", "/orders", http.StatusOK},
+		{"fail - permission missing", "/admin", http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://localhost"+test.path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, test.expectedStatus, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package auth0
+
+import (
+	"crypto"
+	"encoding/base64"
+	"errors"
+
+	"gopkg.in/square/go-jose.v2"
+)
+
+// ErrNoPinnedKey is returned when none of the keys of
+// the JWKS has a thumbprint listed in PinnedKeys.
+var ErrNoPinnedKey = errors.New("no key of the JWKS is pinned")
+
+// KeyThumbprint returns the RFC 7638 SHA-256 thumbprint of the key,
+// base64url encoded, as listed in PinnedKeys. Compute the thumbprints of
+// the keys of a tenant with the public part of each key of its JWKS.
+func KeyThumbprint(key jose.JSONWebKey) (string, error) {
+	public := key.Public()
+	thumbprint, err := public.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(thumbprint), nil
+}
+
+// WithPinnedKeys only accepts the keys of the JWKS whose
+// thumbprint is one of the thumbprints, setting PinnedKeys.
+func WithPinnedKeys(thumbprints ...string) JWKClientOption {
+	return func(c *jwkClientConfig) {
+		c.options.PinnedKeys = thumbprints
+	}
+}
+
+// pinnedKeys returns the keys of the JWKS whose thumbprint is listed
+// in PinnedKeys, all the keys if PinnedKeys is empty.
+func (j *JWKClient) pinnedKeys(keys []jose.JSONWebKey) ([]jose.JSONWebKey, error) {
+	if len(j.options.PinnedKeys) == 0 {
+		return keys, nil
+	}
+
+	pinned := make([]jose.JSONWebKey, 0, len(keys))
+	for _, key := range keys {
+		if thumbprint, ok := j.pinned(key); !ok {
+			j.logger.Warn("key of the JWKS ignored, it is not pinned", "kid", key.KeyID, "thumbprint", thumbprint)
+			continue
+		}
+		pinned = append(pinned, key)
+	}
+	if len(pinned) == 0 {
+		return nil, ErrNoPinnedKey
+	}
+	return pinned, nil
+}
+
+// pinned reports whether the thumbprint of the key, also returned,
+// is listed in PinnedKeys, always true if PinnedKeys is empty.
+func (j *JWKClient) pinned(key jose.JSONWebKey) (string, bool) {
+	if len(j.options.PinnedKeys) == 0 {
+		return "", true
+	}
+	thumbprint, err := KeyThumbprint(key)
+	return thumbprint, err == nil && containsString(j.options.PinnedKeys, thumbprint)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
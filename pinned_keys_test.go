@@ -0,0 +1,69 @@
+package auth0
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func TestKeyThumbprint(t *testing.T) {
+	// RFC 7638 section 3.1 example.
+	key := jose.JSONWebKey{}
+	err := key.UnmarshalJSON([]byte(`{"kty":"RSA","e":"AQAB","kid":"2011-04-29","n":"0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	thumbprint, err := KeyThumbprint(key)
+	assert.NoError(t, err)
+	assert.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", thumbprint)
+}
+
+func TestPinnedKeys(t *testing.T) {
+	pinned := genRSASSAJWK(jose.RS256, "pinned")
+	other := genRSASSAJWK(jose.RS256, "other")
+	thumbprint, err := KeyThumbprint(pinned)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := genJWKSServer(pinned, other)
+	defer ts.Close()
+
+	client := NewJWKClientWithOptions(ts.URL, WithPinnedKeys(thumbprint))
+	_, err = client.GetKey("pinned")
+	assert.NoError(t, err)
+	_, err = client.GetKey("other")
+	assert.Equal(t, ErrKeyNotFound, err)
+
+	t.Run("no pinned key", func(t *testing.T) {
+		ts := genJWKSServer(other)
+		defer ts.Close()
+
+		client := NewJWKClient(JWKClientOptions{URI: ts.URL, PinnedKeys: []string{thumbprint}}, nil)
+		_, err := client.GetKey("other")
+		assert.Equal(t, ErrNoPinnedKey, err)
+	})
+
+	t.Run("cached keys", func(t *testing.T) {
+		ts := genJWKSServer(pinned)
+		defer ts.Close()
+
+		// A cacher shared with other processes holding an unpinned key,
+		// and an unpinned key under the ID of the pinned one.
+		forged := genRSASSAJWK(jose.RS256, "pinned")
+		cacher := NewMemoryKeyCacher(time.Hour, MaxCacheSizeNoCheck)
+		cacher.Add("other", []jose.JSONWebKey{other.Public()})
+		cacher.Add("pinned", []jose.JSONWebKey{forged.Public()})
+
+		client := NewJWKClientWithOptions(ts.URL, WithPinnedKeys(thumbprint), WithKeyCacher(cacher))
+		_, err := client.GetKey("other")
+		assert.Equal(t, ErrKeyNotFound, err)
+		key, err := client.GetKey("pinned")
+		assert.NoError(t, err)
+		keyThumbprint, _ := KeyThumbprint(key)
+		assert.Equal(t, thumbprint, keyThumbprint)
+	})
+}
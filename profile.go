@@ -0,0 +1,151 @@
+package auth0
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Profile is a profile of the JWT access tokens, such as
+// ProfileRFC9068, enforced with WithProfile.
+type Profile int
+
+const (
+	// ProfileRFC9068 is the JWT access token profile of RFC 9068. The tokens
+	// must have the at+jwt typ header and the iss, exp, aud, sub, client_id,
+	// iat and jti claims. The scope claim, if any, must be a space-delimited
+	// string, and the authorization_details claim, if any, a list of RFC 9396
+	// authorization details objects with a type.
+	ProfileRFC9068 Profile = iota + 1
+)
+
+func (p Profile) String() string {
+	switch p {
+	case ProfileRFC9068:
+		return "RFC 9068"
+	}
+	return fmt.Sprintf("Profile(%d)", int(p))
+}
+
+var (
+	// ErrProfileViolation is matched by the errors of the tokens
+	// not conforming to the profile of WithProfile.
+	ErrProfileViolation = errors.New("token does not conform to the profile")
+)
+
+// ProfileError is returned when a claim of the token does not conform to the
+// profile of the validator. It matches ErrProfileViolation with errors.Is.
+type ProfileError struct {
+	Profile Profile
+	// Claim is the name of the claim, such as "client_id".
+	Claim string
+	// Reason tells why the claim does not conform, such as "missing".
+	Reason string
+}
+
+func (e *ProfileError) Error() string {
+	return fmt.Sprintf("token does not conform to the %s profile: %s claim is %s", e.Profile, e.Claim, e.Reason)
+}
+
+// Is makes errors.Is match ErrProfileViolation.
+func (e *ProfileError) Is(target error) bool {
+	return target == ErrProfileViolation
+}
+
+// rfc9068StringClaims are the string claims required by RFC 9068.
+var rfc9068StringClaims = []string{"iss", "sub", "client_id", "jti"}
+
+// rfc9068NumericClaims are the NumericDate claims required by RFC 9068.
+var rfc9068NumericClaims = []string{"exp", "iat"}
+
+// WithProfile makes the validator enforce the JWT access token profile, along
+// with the checks of the configuration and of the other options. The typ
+// header required by the profile replaces the types of WithTokenTypes.
+func WithProfile(profile Profile) ValidatorOption {
+	return func(v *JWTValidator) {
+		switch profile {
+		case ProfileRFC9068:
+			v.types = []string{HeaderTypeAccessToken}
+			v.checks = append(v.checks, checkRFC9068)
+		}
+	}
+}
+
+// checkRFC9068 validates the claims required by RFC 9068,
+// and the format of its scope and authorization_details claims.
+func checkRFC9068(claims map[string]interface{}) error {
+	violation := func(claim, reason string) error {
+		return &ProfileError{Profile: ProfileRFC9068, Claim: claim, Reason: reason}
+	}
+
+	for _, claim := range rfc9068StringClaims {
+		value, ok := claims[claim]
+		if !ok {
+			return violation(claim, "missing")
+		}
+		if s, ok := value.(string); !ok || s == "" {
+			return violation(claim, "not a non-empty string")
+		}
+	}
+	for _, claim := range rfc9068NumericClaims {
+		value, ok := claims[claim]
+		if !ok {
+			return violation(claim, "missing")
+		}
+		if !isNumber(value) {
+			return violation(claim, "not a NumericDate")
+		}
+	}
+
+	switch aud := claims["aud"].(type) {
+	case nil:
+		return violation("aud", "missing")
+	case string:
+		if aud == "" {
+			return violation("aud", "empty")
+		}
+	case []interface{}:
+		if len(aud) == 0 {
+			return violation("aud", "empty")
+		}
+		for _, a := range aud {
+			if s, ok := a.(string); !ok || s == "" {
+				return violation("aud", "not a list of non-empty strings")
+			}
+		}
+	default:
+		return violation("aud", "not a string or a list of strings")
+	}
+
+	if scope, ok := claims["scope"]; ok {
+		if _, ok := scope.(string); !ok {
+			return violation("scope", "not a space-delimited string")
+		}
+	}
+
+	if details, ok := claims["authorization_details"]; ok {
+		list, ok := details.([]interface{})
+		if !ok {
+			return violation("authorization_details", "not a list")
+		}
+		for _, d := range list {
+			object, ok := d.(map[string]interface{})
+			if !ok {
+				return violation("authorization_details", "not a list of objects")
+			}
+			if typ, ok := object["type"].(string); !ok || typ == "" {
+				return violation("authorization_details", "missing the type of an object")
+			}
+		}
+	}
+	return nil
+}
+
+// isNumber reports whether the value of a claim is a JSON number.
+func isNumber(value interface{}) bool {
+	switch value.(type) {
+	case float64, json.Number, int64, int:
+		return true
+	}
+	return false
+}
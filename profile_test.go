@@ -0,0 +1,86 @@
+package auth0
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func getTestAccessToken(typ string, claims map[string]interface{}) string {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: defaultSecret}, (&jose.SignerOptions{}).WithType(jose.ContentType(typ)))
+	if err != nil {
+		panic(err)
+	}
+	raw, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	if err != nil {
+		panic(err)
+	}
+	return raw
+}
+
+func TestProfileRFC9068(t *testing.T) {
+	valid := func() map[string]interface{} {
+		return map[string]interface{}{
+			"iss":       defaultIssuer,
+			"aud":       defaultAudience,
+			"exp":       time.Now().Add(time.Hour).Unix(),
+			"iat":       time.Now().Unix(),
+			"sub":       "auth0|123",
+			"client_id": "client",
+			"jti":       "id",
+			"scope":     "read:users write:users",
+		}
+	}
+	with := func(claim string, value interface{}) map[string]interface{} {
+		claims := valid()
+		if value == nil {
+			delete(claims, claim)
+		} else {
+			claims[claim] = value
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name          string
+		typ           string
+		claims        map[string]interface{}
+		expectedClaim string
+	}{
+		{"pass - conforming token", "at+jwt", valid(), ""},
+		{"pass - authorization details", "at+jwt", with("authorization_details", []interface{}{map[string]interface{}{"type": "payment_initiation"}}), ""},
+		{"fail - JWT typ", "JWT", valid(), ""},
+		{"fail - missing sub", "at+jwt", with("sub", nil), "sub"},
+		{"fail - missing client_id", "at+jwt", with("client_id", nil), "client_id"},
+		{"fail - missing jti", "at+jwt", with("jti", nil), "jti"},
+		{"fail - missing iat", "at+jwt", with("iat", nil), "iat"},
+		{"fail - scope list", "at+jwt", with("scope", []string{"read:users"}), "scope"},
+		{"fail - authorization details with no type", "at+jwt", with("authorization_details", []interface{}{map[string]interface{}{"actions": []string{"read"}}}), "authorization_details"},
+		{"fail - authorization details object", "at+jwt", with("authorization_details", map[string]interface{}{"type": "payment_initiation"}), "authorization_details"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			validator := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), nil, WithProfile(ProfileRFC9068))
+			_, err := validator.ValidateRawToken(getTestAccessToken(test.typ, test.claims))
+			switch {
+			case test.typ != "at+jwt":
+				assert.True(t, errors.Is(err, ErrInvalidTokenType), "got %v", err)
+			case test.expectedClaim == "":
+				assert.NoError(t, err)
+			default:
+				var profileErr *ProfileError
+				assert.True(t, errors.As(err, &profileErr), "got %v", err)
+				assert.True(t, errors.Is(err, ErrProfileViolation))
+				if profileErr != nil {
+					assert.Equal(t, test.expectedClaim, profileErr.Claim)
+					assert.Equal(t, ProfileRFC9068, profileErr.Profile)
+				}
+			}
+		})
+	}
+}
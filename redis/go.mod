@@ -0,0 +1,24 @@
+module github.com/auth0-community/go-auth0/redis
+
+go 1.18
+
+require (
+	github.com/alicebob/miniredis/v2 v2.39.0
+	github.com/auth0-community/go-auth0 v0.0.0-20261014151049-3d87a288140b
+	github.com/redis/go-redis/v9 v9.17.3
+	github.com/stretchr/testify v1.4.0
+	gopkg.in/square/go-jose.v2 v2.1.7
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/yuin/gopher-lua v1.1.1 // indirect
+	golang.org/x/crypto v0.0.0-20180802221240-56440b844dfe // indirect
+	golang.org/x/sync v0.0.0-20200317015054-43a5402ce75a // indirect
+	gopkg.in/yaml.v2 v2.2.2 // indirect
+)
+
+replace github.com/auth0-community/go-auth0 => ../
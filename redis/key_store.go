@@ -0,0 +1,50 @@
+// Package redis provides a Redis auth0.KeyStore, sharing the keys
+// downloaded by the JWKClients of several instances.
+package redis
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/auth0-community/go-auth0"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// KeyStore is an auth0.KeyStore backed by a Redis client.
+type KeyStore struct {
+	client goredis.UniversalClient
+}
+
+// NewKeyStore creates a new KeyStore using the client, such as
+// a *redis.Client or a *redis.ClusterClient.
+func NewKeyStore(client goredis.UniversalClient) *KeyStore {
+	return &KeyStore{client: client}
+}
+
+// NewKeyCacher creates a new auth0.KeyCacher storing the keys in Redis
+// under the prefix followed by their ID, expiring after ttl.
+func NewKeyCacher(client goredis.UniversalClient, prefix string, ttl time.Duration) auth0.KeyCacher {
+	return auth0.NewSharedKeyCacher(NewKeyStore(client), prefix, ttl)
+}
+
+// NewKeyCacherV2 creates a new auth0.KeyCacherV2 storing the keys as
+// NewKeyCacher does, the Redis commands being bound to the context of
+// the key lookups.
+func NewKeyCacherV2(client goredis.UniversalClient, prefix string, ttl time.Duration) auth0.KeyCacherV2 {
+	return auth0.NewSharedKeyCacherV2(NewKeyStore(client), prefix, ttl)
+}
+
+// Get implements the Get method of the auth0.KeyStore interface.
+func (s *KeyStore) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := s.client.Get(ctx, key).Bytes()
+	if errors.Is(err, goredis.Nil) {
+		return nil, nil
+	}
+	return value, err
+}
+
+// Set implements the Set method of the auth0.KeyStore interface.
+func (s *KeyStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return s.client.Set(ctx, key, value, ttl).Err()
+}
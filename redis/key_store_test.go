@@ -0,0 +1,64 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/auth0-community/go-auth0"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func TestKeyCacher(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := goredis.NewClient(&goredis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	private, _ := rsa.GenerateKey(rand.Reader, 2048)
+	key := jose.JSONWebKey{Key: &private.PublicKey, KeyID: "keyRS256", Algorithm: string(jose.RS256), Use: "sig"}
+	cacher := NewKeyCacher(client, "jwks:", time.Hour)
+
+	_, err := cacher.Get("keyRS256")
+	assert.Equal(t, auth0.ErrNoKeyFound, err)
+
+	_, err = cacher.Add("keyRS256", []jose.JSONWebKey{key})
+	assert.NoError(t, err)
+	assert.True(t, server.Exists("jwks:keyRS256"))
+	assert.Equal(t, time.Hour, server.TTL("jwks:keyRS256"))
+
+	cached, err := NewKeyCacher(client, "jwks:", time.Hour).Get("keyRS256")
+	assert.NoError(t, err)
+	assert.Equal(t, "keyRS256", cached.KeyID)
+	assert.Equal(t, private.PublicKey.N, cached.Key.(*rsa.PublicKey).N)
+
+	server.FastForward(2 * time.Hour)
+	_, err = cacher.Get("keyRS256")
+	assert.Equal(t, auth0.ErrNoKeyFound, err)
+}
+
+func TestKeyCacherV2(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := goredis.NewClient(&goredis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	private, _ := rsa.GenerateKey(rand.Reader, 2048)
+	key := jose.JSONWebKey{Key: &private.PublicKey, KeyID: "keyRS256", Algorithm: string(jose.RS256), Use: "sig"}
+	cacher := NewKeyCacherV2(client, "jwks:", time.Hour)
+
+	assert.NoError(t, cacher.Set(context.Background(), []jose.JSONWebKey{key}))
+	assert.True(t, server.Exists("jwks:keyRS256"))
+
+	cached, err := cacher.Get(context.Background(), "keyRS256")
+	assert.NoError(t, err)
+	assert.Equal(t, "keyRS256", cached.KeyID)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = cacher.Get(ctx, "keyRS256")
+	assert.Equal(t, context.Canceled, err)
+}
@@ -0,0 +1,273 @@
+package auth0
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+var (
+	// ErrNoRefreshToken is returned when no refresh token
+	// is stored for the session.
+	ErrNoRefreshToken = errors.New("no refresh token for the session")
+	// ErrRefreshTokenRejected is matched by the errors of the refreshes
+	// rejected by the token endpoint with invalid_grant: the refresh token
+	// expired, was revoked, or was reused after its rotation, Auth0 then
+	// revoking all the refresh tokens of the session. The session is ended
+	// and the user must log in again. Use errors.As with *TokenError to
+	// retrieve the OAuth2 error.
+	ErrRefreshTokenRejected = errors.New("refresh token rejected")
+)
+
+// oauthErrorInvalidGrant is the OAuth2 error of
+// the rejected refresh tokens, see RFC 6749.
+const oauthErrorInvalidGrant = "invalid_grant"
+
+// RefreshTokenStore stores the refresh tokens of the sessions of a
+// backend-for-frontend securely, such as encrypted in a database shared
+// by its instances. Load returns an empty token and no error when nothing
+// is stored for the session. Methods are called concurrently.
+type RefreshTokenStore interface {
+	Load(ctx context.Context, sessionID string) (string, error)
+	Store(ctx context.Context, sessionID, refreshToken string) error
+	Delete(ctx context.Context, sessionID string) error
+}
+
+type memoryRefreshTokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]string
+}
+
+// NewMemoryRefreshTokenStore creates a RefreshTokenStore keeping the refresh
+// tokens in memory, for tests and the applications running a single instance.
+// The refresh tokens are lost when the application restarts.
+func NewMemoryRefreshTokenStore() RefreshTokenStore {
+	return &memoryRefreshTokenStore{tokens: map[string]string{}}
+}
+
+func (s *memoryRefreshTokenStore) Load(_ context.Context, sessionID string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokens[sessionID], nil
+}
+
+func (s *memoryRefreshTokenStore) Store(_ context.Context, sessionID, refreshToken string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[sessionID] = refreshToken
+	return nil
+}
+
+func (s *memoryRefreshTokenStore) Delete(_ context.Context, sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tokens, sessionID)
+	return nil
+}
+
+// RefreshTokensOptions configures the refresh token grant client.
+type RefreshTokensOptions struct {
+	// TokenURL is the token endpoint, such as
+	// https://mydomain.eu.auth0.com/oauth/token.
+	TokenURL string
+	// ClientID and ClientSecret authenticate the confidential
+	// client to the endpoint in the body of the request.
+	ClientID     string
+	ClientSecret string
+	// PrivateKeyJWT authenticates the client with signed
+	// assertions instead of ClientSecret when set.
+	PrivateKeyJWT *PrivateKeyJWT
+	// Scopes are the scopes requested, a subset of the scopes granted to
+	// the session, all of them when empty.
+	Scopes []string
+	// Store stores the refresh tokens of the sessions.
+	// Defaults to NewMemoryRefreshTokenStore.
+	Store  RefreshTokenStore
+	Client *http.Client
+	// ExpiryDelta is how long before its expiry the access token is refreshed,
+	// so it does not expire in flight. Defaults to DefaultTokenExpiryDelta.
+	ExpiryDelta time.Duration
+	// RequestTimeout bounds each refresh, which is not canceled with the
+	// callers waiting for it so the rotated refresh token is always stored.
+	// Defaults to DefaultTokenRequestTimeout.
+	RequestTimeout time.Duration
+	// MaxCacheEntries is the maximum number of sessions whose access token
+	// is cached, the least recently used ones being evicted beyond and
+	// refreshed on their next call. Defaults to DefaultTokenCacheSize.
+	MaxCacheEntries int
+}
+
+// RefreshTokens keeps the access tokens of the sessions of a
+// backend-for-frontend fresh with the refresh token grant, storing the
+// refresh token rotated by each refresh. The access tokens are cached by
+// session until shortly before their expiry.
+//
+// The simultaneous refreshes of a session result in a single request, as
+// sending a rotated refresh token again is detected by Auth0 as a reuse.
+// Applications running several instances must route the requests of a
+// session to the same instance, or refresh within the reuse interval.
+type RefreshTokens struct {
+	options RefreshTokensOptions
+
+	tokens *tokenCache[string] // Access tokens by session
+	sf     singleflight.Group
+}
+
+// NewRefreshTokens creates a new RefreshTokens
+// instance from the provided options.
+func NewRefreshTokens(options RefreshTokensOptions) *RefreshTokens {
+	if options.Client == nil {
+		options.Client = http.DefaultClient
+	}
+	if options.Store == nil {
+		options.Store = NewMemoryRefreshTokenStore()
+	}
+	if options.ExpiryDelta <= 0 {
+		options.ExpiryDelta = DefaultTokenExpiryDelta
+	}
+	if options.RequestTimeout <= 0 {
+		options.RequestTimeout = DefaultTokenRequestTimeout
+	}
+	if options.MaxCacheEntries <= 0 {
+		options.MaxCacheEntries = DefaultTokenCacheSize
+	}
+	return &RefreshTokens{
+		options: options,
+		tokens:  newTokenCache[string](options.MaxCacheEntries, options.ExpiryDelta),
+	}
+}
+
+// Start starts the session with the token issued by the authorization code
+// grant, which must hold a refresh token, requested with the offline_access
+// scope.
+func (c *RefreshTokens) Start(ctx context.Context, sessionID string, token *Token) error {
+	if token.RefreshToken == "" {
+		return ErrNoRefreshToken
+	}
+	if err := c.options.Store.Store(ctx, sessionID, token.RefreshToken); err != nil {
+		return err
+	}
+	c.add(sessionID, token)
+	return nil
+}
+
+// Token returns the cached access token of the session, refreshing it when
+// there is none or it is about to expire.
+func (c *RefreshTokens) Token(ctx context.Context, sessionID string) (*Token, error) {
+	if token, ok := c.tokens.get(sessionID, time.Now()); ok {
+		return token, nil
+	}
+	return c.Refresh(ctx, sessionID)
+}
+
+// Refresh requests a new access token for the session with its refresh
+// token, storing the refresh token rotated by the endpoint, if any, instead
+// of returning it. The session is ended when the endpoint rejects the refresh
+// token, with an error matching ErrRefreshTokenRejected. Simultaneous
+// refreshes of the session result in a single request, every caller waiting
+// for it until its own context is done. The request is not canceled with the
+// callers, since the refresh token may be rotated by the time they are gone.
+func (c *RefreshTokens) Refresh(ctx context.Context, sessionID string) (*Token, error) {
+	ch := c.sf.DoChan(sessionID, func() (interface{}, error) {
+		ctx, cancel := detach(ctx, c.options.RequestTimeout)
+		defer cancel()
+		return c.refresh(ctx, sessionID)
+	})
+
+	var res singleflight.Result
+	select {
+	case res = <-ch:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if res.Err != nil {
+		return nil, res.Err
+	}
+	return res.Val.(*Token), nil
+}
+
+func (c *RefreshTokens) refresh(ctx context.Context, sessionID string) (*Token, error) {
+	refreshToken, err := c.options.Store.Load(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if refreshToken == "" {
+		return nil, ErrNoRefreshToken
+	}
+
+	form, err := c.form(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	token, err := requestToken(ctx, c.options.Client, c.options.TokenURL, form)
+	var tokenErr *TokenError
+	if errors.As(err, &tokenErr) && tokenErr.Code == oauthErrorInvalidGrant {
+		if endErr := c.End(ctx, sessionID); endErr != nil {
+			return nil, endErr
+		}
+		return nil, &classifiedError{class: ErrRefreshTokenRejected, cause: err}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The refresh token is kept when the endpoint does not rotate it.
+	if token.RefreshToken != "" && token.RefreshToken != refreshToken {
+		if err := c.options.Store.Store(ctx, sessionID, token.RefreshToken); err != nil {
+			return nil, err
+		}
+	}
+	return c.add(sessionID, token), nil
+}
+
+// End ends the session, such as when the user logs out, deleting its
+// refresh token from the store and its cached access token.
+func (c *RefreshTokens) End(ctx context.Context, sessionID string) error {
+	c.tokens.remove(sessionID)
+	return c.options.Store.Delete(ctx, sessionID)
+}
+
+// TokenSource returns the source of the access tokens of the session, such
+// as for the oauth2 adapter of the outgoing requests.
+func (c *RefreshTokens) TokenSource(sessionID string) TokenSource {
+	return &sessionTokenSource{tokens: c, sessionID: sessionID}
+}
+
+// add caches the access token of the session and returns it. The refresh
+// token is neither kept in memory nor returned. Tokens with no expiry are
+// not cached, the session being refreshed on every call.
+func (c *RefreshTokens) add(sessionID string, token *Token) *Token {
+	cached := *token
+	cached.RefreshToken = ""
+	c.tokens.add(sessionID, &cached)
+	return &cached
+}
+
+func (c *RefreshTokens) form(refreshToken string) (url.Values, error) {
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	}
+	if err := authenticateClient(form, c.options.ClientID, c.options.ClientSecret, c.options.PrivateKeyJWT, c.options.TokenURL); err != nil {
+		return nil, err
+	}
+	if len(c.options.Scopes) > 0 {
+		form.Set("scope", strings.Join(c.options.Scopes, " "))
+	}
+	return form, nil
+}
+
+type sessionTokenSource struct {
+	tokens    *RefreshTokens
+	sessionID string
+}
+
+func (s *sessionTokenSource) Token(ctx context.Context) (*Token, error) {
+	return s.tokens.Token(ctx, s.sessionID)
+}
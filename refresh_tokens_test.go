@@ -0,0 +1,216 @@
+package auth0
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// genTestRefreshServer serves a token endpoint rotating the refresh tokens,
+// rejecting the rotated ones as reused.
+func genTestRefreshServer(t *testing.T, counter *uint64, expiresIn int) *httptest.Server {
+	var mu sync.Mutex
+	current := "refresh0"
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "refresh_token", r.PostFormValue("grant_type"))
+		assert.Equal(t, "client", r.PostFormValue("client_id"))
+		assert.Equal(t, "secret", r.PostFormValue("client_secret"))
+
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if r.PostFormValue("refresh_token") != current {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant", "error_description": "Unknown or invalid refresh token."})
+			return
+		}
+		n := atomic.AddUint64(counter, 1)
+		current = "refresh" + strconv.FormatUint(n, 10)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token":  "token" + strconv.FormatUint(n, 10),
+			"refresh_token": current,
+			"token_type":    "Bearer",
+			"expires_in":    expiresIn,
+		})
+	}))
+}
+
+func genTestRefreshTokens(uri string, store RefreshTokenStore) *RefreshTokens {
+	return NewRefreshTokens(RefreshTokensOptions{
+		TokenURL:     uri,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Store:        store,
+	})
+}
+
+func TestRefreshTokens(t *testing.T) {
+	var counter uint64
+	ts := genTestRefreshServer(t, &counter, 3600)
+	defer ts.Close()
+
+	ctx := context.Background()
+	store := NewMemoryRefreshTokenStore()
+	client := genTestRefreshTokens(ts.URL, store)
+
+	assert.Equal(t, ErrNoRefreshToken, client.Start(ctx, "session", &Token{AccessToken: "token0"}))
+	_, err := client.Token(ctx, "session")
+	assert.Equal(t, ErrNoRefreshToken, err)
+
+	// The access token of the login is cached, not its refresh token.
+	assert.NoError(t, client.Start(ctx, "session", &Token{AccessToken: "token0", RefreshToken: "refresh0", Expiry: time.Now().Add(time.Hour)}))
+	token, err := client.Token(ctx, "session")
+	assert.NoError(t, err)
+	assert.Equal(t, "token0", token.AccessToken)
+	assert.Empty(t, token.RefreshToken)
+
+	token, err = client.Refresh(ctx, "session")
+	assert.NoError(t, err)
+	assert.Equal(t, "token1", token.AccessToken)
+	assert.Empty(t, token.RefreshToken)
+	refreshToken, _ := store.Load(ctx, "session")
+	assert.Equal(t, "refresh1", refreshToken)
+
+	// The access token is cached.
+	token, err = client.Token(ctx, "session")
+	assert.NoError(t, err)
+	assert.Equal(t, "token1", token.AccessToken)
+	assert.Equal(t, uint64(1), atomic.LoadUint64(&counter))
+
+	assert.NoError(t, client.End(ctx, "session"))
+	_, err = client.Token(ctx, "session")
+	assert.Equal(t, ErrNoRefreshToken, err)
+}
+
+func TestRefreshTokensNoExpiry(t *testing.T) {
+	var counter uint64
+	ts := genTestRefreshServer(t, &counter, 0)
+	defer ts.Close()
+
+	ctx := context.Background()
+	client := genTestRefreshTokens(ts.URL, NewMemoryRefreshTokenStore())
+
+	// Tokens with no expiry are not cached.
+	assert.NoError(t, client.Start(ctx, "session", &Token{AccessToken: "token0", RefreshToken: "refresh0"}))
+	for _, expected := range []string{"token1", "token2"} {
+		token, err := client.Token(ctx, "session")
+		assert.NoError(t, err)
+		assert.Equal(t, expected, token.AccessToken)
+		assert.True(t, token.Expiry.IsZero())
+	}
+	assert.Equal(t, uint64(2), atomic.LoadUint64(&counter))
+}
+
+func TestRefreshTokensCacheSize(t *testing.T) {
+	var counter uint64
+	ts := genTestRefreshServer(t, &counter, 3600)
+	defer ts.Close()
+
+	ctx := context.Background()
+	client := NewRefreshTokens(RefreshTokensOptions{
+		TokenURL:        ts.URL,
+		ClientID:        "client",
+		ClientSecret:    "secret",
+		MaxCacheEntries: 1,
+	})
+	assert.Equal(t, DefaultTokenCacheSize, NewRefreshTokens(RefreshTokensOptions{}).options.MaxCacheEntries)
+
+	expiry := time.Now().Add(time.Hour)
+	assert.NoError(t, client.Start(ctx, "a", &Token{AccessToken: "tokenA", RefreshToken: "refresh0", Expiry: expiry}))
+	assert.NoError(t, client.Start(ctx, "b", &Token{AccessToken: "tokenB", RefreshToken: "refresh0", Expiry: expiry}))
+
+	// The access token of the least recently used session was evicted.
+	token, err := client.Token(ctx, "b")
+	assert.NoError(t, err)
+	assert.Equal(t, "tokenB", token.AccessToken)
+	token, err = client.Token(ctx, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, "token1", token.AccessToken)
+	assert.Equal(t, uint64(1), atomic.LoadUint64(&counter))
+}
+
+func TestRefreshTokensReuse(t *testing.T) {
+	var counter uint64
+	ts := genTestRefreshServer(t, &counter, 3600)
+	defer ts.Close()
+
+	ctx := context.Background()
+	store := NewMemoryRefreshTokenStore()
+	client := genTestRefreshTokens(ts.URL, store)
+	assert.NoError(t, client.Start(ctx, "session", &Token{AccessToken: "token0", RefreshToken: "refresh0"}))
+
+	// Simultaneous refreshes never send a rotated refresh token again.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.Refresh(ctx, "session")
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	// A rotated refresh token sent again is rejected, ending the session.
+	assert.NoError(t, store.Store(ctx, "session", "refresh0"))
+	_, err := client.Refresh(ctx, "session")
+	assert.True(t, errors.Is(err, ErrRefreshTokenRejected), "got %v", err)
+	var tokenErr *TokenError
+	if assert.True(t, errors.As(err, &tokenErr)) {
+		assert.Equal(t, "invalid_grant", tokenErr.Code)
+	}
+	refreshToken, _ := store.Load(ctx, "session")
+	assert.Empty(t, refreshToken)
+	_, err = client.TokenSource("session").Token(ctx)
+	assert.Equal(t, ErrNoRefreshToken, err)
+}
+
+func TestRefreshTokensCanceled(t *testing.T) {
+	var counter uint64
+	rotating := genTestRefreshServer(t, &counter, 3600)
+	defer rotating.Close()
+	received, release := make(chan struct{}), make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadUint64(&counter) == 0 {
+			close(received)
+			<-release
+		}
+		rotating.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	store := NewMemoryRefreshTokenStore()
+	client := genTestRefreshTokens(ts.URL, store)
+	assert.NoError(t, client.Start(context.Background(), "session", &Token{AccessToken: "token0", RefreshToken: "refresh0"}))
+
+	// The first caller leaves once the endpoint has received the refresh token.
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error)
+	go func() {
+		_, err := client.Refresh(ctx, "session")
+		errs <- err
+	}()
+	<-received
+	cancel()
+	assert.Equal(t, context.Canceled, <-errs)
+
+	// The refresh token rotated after the caller left is stored.
+	close(release)
+	eventually(t, func() bool {
+		refreshToken, _ := store.Load(context.Background(), "session")
+		return refreshToken == "refresh1"
+	}, time.Second, time.Millisecond)
+
+	token, err := client.Refresh(context.Background(), "session")
+	assert.NoError(t, err)
+	assert.Equal(t, "token2", token.AccessToken)
+}
@@ -0,0 +1,114 @@
+package auth0
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrTokenReplayed is returned when the jti of the token
+// has already been seen by the ReplayDetector of the validator.
+var ErrTokenReplayed = errors.New("token has already been used (jti)")
+
+// ReplayDetector detects the replay of the tokens or proofs
+// identified by their jti claim.
+type ReplayDetector interface {
+	// Seen reports whether the jti has already been seen, and remembers
+	// it until exp otherwise. It must be safe for concurrent use.
+	Seen(jti string, exp time.Time) bool
+}
+
+// ClockedReplayDetector is implemented by the replay detectors expiring the
+// jti they remember with a clock. The validator sets its own clock on them,
+// the one of WithClock, so the jti expire on the time of the validation.
+type ClockedReplayDetector interface {
+	ReplayDetector
+	SetClock(clock func() time.Time)
+}
+
+// WithReplayDetector makes the validator reject the tokens whose jti claim
+// has already been seen by the detector, for one-time use tokens on
+// high-value endpoints. Tokens with no jti are accepted. The detector is
+// consulted once all the other checks passed, including for the tokens of
+// the validation cache, so rejected tokens do not use up their jti.
+func WithReplayDetector(detector ReplayDetector) ValidatorOption {
+	return func(v *JWTValidator) {
+		v.replay = detector
+		if clocked, ok := detector.(ClockedReplayDetector); ok {
+			clocked.SetClock(v.now)
+		}
+	}
+}
+
+// checkReplay rejects the claims whose jti has already been seen.
+// The jti is remembered until the expiry of the token plus the leeway.
+func (v *JWTValidator) checkReplay(claims map[string]interface{}) error {
+	if v.replay == nil {
+		return nil
+	}
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		return nil
+	}
+	exp := v.now()
+	if e, ok := claims["exp"].(float64); ok {
+		exp = time.Unix(int64(e), 0)
+	}
+	if v.replay.Seen(jti, exp.Add(v.leeway)) {
+		return ErrTokenReplayed
+	}
+	return nil
+}
+
+// MemoryReplayDetector is an in-memory ReplayDetector remembering each jti
+// until its expiry. It is safe for concurrent use.
+type MemoryReplayDetector struct {
+	maxEntries int
+
+	mu      sync.Mutex
+	clock   func() time.Time
+	entries map[string]time.Time // Expiry of the seen jti
+}
+
+// NewMemoryReplayDetector creates an in-memory ReplayDetector remembering at
+// most maxEntries jti, unbounded if not positive. Once full with no expired
+// jti to remove, every jti is reported as seen, rejecting the tokens rather
+// than forgetting jti that could then be replayed.
+func NewMemoryReplayDetector(maxEntries int) *MemoryReplayDetector {
+	return &MemoryReplayDetector{
+		maxEntries: maxEntries,
+		clock:      time.Now,
+		entries:    map[string]time.Time{},
+	}
+}
+
+// SetClock implements the ClockedReplayDetector interface.
+func (d *MemoryReplayDetector) SetClock(clock func() time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.clock = clock
+}
+
+// Seen implements the ReplayDetector interface.
+func (d *MemoryReplayDetector) Seen(jti string, exp time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.clock()
+
+	if expiresAt, ok := d.entries[jti]; ok && now.Before(expiresAt) {
+		return true
+	}
+	if d.maxEntries > 0 && len(d.entries) >= d.maxEntries {
+		for key, expiresAt := range d.entries {
+			if !now.Before(expiresAt) {
+				delete(d.entries, key)
+			}
+		}
+		if len(d.entries) >= d.maxEntries {
+			return true
+		}
+	}
+	d.entries[jti] = exp
+	return false
+}
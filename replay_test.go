@@ -0,0 +1,78 @@
+package auth0
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestMemoryReplayDetector(t *testing.T) {
+	detector := NewMemoryReplayDetector(2)
+
+	assert.False(t, detector.Seen("jti1", time.Now().Add(time.Hour)))
+	assert.True(t, detector.Seen("jti1", time.Now().Add(time.Hour)))
+
+	assert.False(t, detector.Seen("expired", time.Now().Add(-time.Second)))
+	assert.False(t, detector.Seen("expired", time.Now().Add(time.Hour)), "expired jti should be forgotten")
+
+	assert.True(t, detector.Seen("jti3", time.Now().Add(time.Hour)), "a full detector should report every jti as seen")
+	assert.True(t, detector.Seen("jti1", time.Now().Add(time.Hour)))
+
+	detector = NewMemoryReplayDetector(1)
+	assert.False(t, detector.Seen("expired", time.Now().Add(-time.Second)))
+	assert.False(t, detector.Seen("jti2", time.Now().Add(time.Hour)), "expired jti should be removed when full")
+}
+
+func TestReplayDetector(t *testing.T) {
+	expiry := jwt.NewNumericDate(time.Now().Add(time.Hour))
+	token := getTestTokenWithClaims(jose.HS256, defaultSecret, jwt.Claims{Issuer: defaultIssuer, Audience: defaultAudience, Expiry: expiry, ID: "jti1"})
+	noJTI := getTestTokenWithClaims(jose.HS256, defaultSecret, jwt.Claims{Issuer: defaultIssuer, Audience: defaultAudience, Expiry: expiry})
+	otherAudience := getTestTokenWithClaims(jose.HS256, defaultSecret, jwt.Claims{Issuer: defaultIssuer, Audience: jwt.Audience{"other"}, Expiry: expiry, ID: "jti2"})
+
+	for _, cached := range []bool{false, true} {
+		name := "validator"
+		opts := []ValidatorOption{WithReplayDetector(NewMemoryReplayDetector(0))}
+		if cached {
+			name = "validator with validation cache"
+			opts = append(opts, WithValidationCache(time.Minute, 0))
+		}
+		t.Run(name, func(t *testing.T) {
+			validator := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), nil, opts...)
+
+			_, err := validator.ValidateRawToken(token)
+			assert.NoError(t, err)
+			_, err = validator.ValidateRawToken(token)
+			assert.Equal(t, ErrTokenReplayed, err)
+			_, err = validator.ValidateRequest(genTestMiddlewareRequest(token))
+			assert.Equal(t, ErrTokenReplayed, err)
+
+			_, err = validator.ValidateRawToken(noJTI)
+			assert.NoError(t, err)
+			_, err = validator.ValidateRawToken(noJTI)
+			assert.NoError(t, err, "tokens with no jti should be accepted")
+
+			_, err = validator.ValidateRawToken(otherAudience)
+			assert.True(t, errors.Is(err, ErrInvalidAudience))
+			_, err = validator.ValidateRawToken(otherAudience)
+			assert.True(t, errors.Is(err, ErrInvalidAudience), "rejected tokens should not use up their jti, got %v", err)
+		})
+	}
+}
+
+func TestReplayDetectorClock(t *testing.T) {
+	// The validator runs two hours in the past, the jti expires on its clock.
+	now := time.Now().Add(-2 * time.Hour)
+	expiry := jwt.NewNumericDate(now.Add(30 * time.Minute))
+	token := getTestTokenWithClaims(jose.HS256, defaultSecret, jwt.Claims{Issuer: defaultIssuer, Audience: defaultAudience, Expiry: expiry, ID: "jti1"})
+	validator := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), nil,
+		WithReplayDetector(NewMemoryReplayDetector(0)), WithClock(func() time.Time { return now }))
+
+	_, err := validator.ValidateRawToken(token)
+	assert.NoError(t, err)
+	_, err = validator.ValidateRawToken(token)
+	assert.Equal(t, ErrTokenReplayed, err)
+}
@@ -0,0 +1,56 @@
+package auth0
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrMissingClaims is returned when the token does not have the claims
+	// required by WithRequiredClaims. Use errors.As with *MissingClaimsError
+	// to retrieve the missing claims.
+	ErrMissingClaims = errors.New("missing required claims")
+)
+
+// MissingClaimsError lists the required claims missing from a token.
+type MissingClaimsError struct {
+	Missing []string
+}
+
+func (e *MissingClaimsError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrMissingClaims, strings.Join(e.Missing, ", "))
+}
+
+// Is makes errors.Is match ErrMissingClaims.
+func (e *MissingClaimsError) Is(target error) bool {
+	return target == ErrMissingClaims
+}
+
+// CheckRequiredClaims checks that the claims have all the named claims,
+// returning a *MissingClaimsError listing the missing ones otherwise.
+// Claims whose value is null are missing.
+func CheckRequiredClaims(claims map[string]interface{}, names ...string) error {
+	var missing []string
+	for _, name := range names {
+		if claims[name] == nil {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return &MissingClaimsError{Missing: missing}
+	}
+	return nil
+}
+
+// WithRequiredClaims makes the validator reject the tokens that do not have all
+// the top-level claims the application depends on, such as "sub", "jti" or
+// the namespaced "https://example.com/tenant_id", so the handlers need not
+// check them.
+func WithRequiredClaims(names ...string) ValidatorOption {
+	return func(v *JWTValidator) {
+		v.checks = append(v.checks, func(claims map[string]interface{}) error {
+			return CheckRequiredClaims(claims, names...)
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package auth0
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestCheckRequiredClaims(t *testing.T) {
+	tests := []struct {
+		name            string
+		claims          map[string]interface{}
+		required        []string
+		expectedMissing []string
+	}{
+		{
+			name:     "pass - all claims",
+			claims:   map[string]interface{}{"sub": "auth0|123", "jti": "id", "https://example.com/tenant_id": "acme"},
+			required: []string{"sub", "jti", "https://example.com/tenant_id"},
+		},
+		{
+			name:   "pass - no required claim",
+			claims: map[string]interface{}{},
+		},
+		{
+			name:     "pass - empty values",
+			claims:   map[string]interface{}{"sub": "", "roles": []interface{}{}},
+			required: []string{"sub", "roles"},
+		},
+		{
+			name:            "fail - missing claims",
+			claims:          map[string]interface{}{"sub": "auth0|123"},
+			required:        []string{"sub", "jti", "https://example.com/tenant_id"},
+			expectedMissing: []string{"jti", "https://example.com/tenant_id"},
+		},
+		{
+			name:            "fail - null claim",
+			claims:          map[string]interface{}{"jti": nil},
+			required:        []string{"jti"},
+			expectedMissing: []string{"jti"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := CheckRequiredClaims(test.claims, test.required...)
+			if test.expectedMissing == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.True(t, errors.Is(err, ErrMissingClaims))
+			var missingErr *MissingClaimsError
+			if assert.True(t, errors.As(err, &missingErr)) {
+				assert.Equal(t, test.expectedMissing, missingErr.Missing)
+			}
+		})
+	}
+}
+
+func TestValidateWithRequiredClaims(t *testing.T) {
+	configuration := NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256)
+	validator := NewValidator(configuration, nil, WithRequiredClaims("sub", "https://example.com/tenant_id"))
+	registered := jwt.Claims{Issuer: defaultIssuer, Audience: defaultAudience, Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour)), Subject: "auth0|123"}
+
+	_, err := validator.ValidateRawToken(getTestTokenWithClaims(jose.HS256, defaultSecret, registered, map[string]interface{}{"https://example.com/tenant_id": "acme"}))
+	assert.NoError(t, err)
+
+	_, err = validator.ValidateRawToken(getTestTokenWithClaims(jose.HS256, defaultSecret, registered))
+	assert.True(t, errors.Is(err, ErrMissingClaims), "got %v", err)
+	assert.EqualError(t, err, "missing required claims: https://example.com/tenant_id")
+	assert.Equal(t, "missing_claims", FailureReason(err))
+}
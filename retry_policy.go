@@ -0,0 +1,89 @@
+package auth0
+
+import (
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+const (
+	// DefaultRetryBaseBackoff is the wait before the first retry
+	// when RetryPolicy.BaseBackoff is not set.
+	DefaultRetryBaseBackoff = 100 * time.Millisecond
+)
+
+var (
+	// DefaultRetryableStatusCodes are the response status codes retried
+	// when RetryPolicy.RetryableStatusCodes is not set.
+	DefaultRetryableStatusCodes = []int{
+		http.StatusRequestTimeout,
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	}
+)
+
+// RetryPolicy configures the retries of failed JWKS downloads
+// with an exponential backoff. Network errors and responses with
+// a retryable status code are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of download attempts,
+	// including the first one. Values lower than 2 disable retries.
+	MaxAttempts int
+	// BaseBackoff is the wait before the first retry. It is doubled
+	// on every subsequent retry. Defaults to DefaultRetryBaseBackoff.
+	BaseBackoff time.Duration
+	// MaxBackoff caps the wait between two attempts when positive.
+	MaxBackoff time.Duration
+	// Jitter is the fraction, between 0 and 1, of every wait that is
+	// randomized to spread the retries of concurrent clients.
+	Jitter float64
+	// RetryableStatusCodes are the response status codes that are retried.
+	// Defaults to DefaultRetryableStatusCodes.
+	RetryableStatusCodes []int
+}
+
+// backoff returns the wait after the provided failed attempt.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	base := p.BaseBackoff
+	if base <= 0 {
+		base = DefaultRetryBaseBackoff
+	}
+
+	backoff := base
+	for i := 1; i < attempt; i++ {
+		backoff *= 2
+		if p.MaxBackoff > 0 && backoff >= p.MaxBackoff {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+
+	if p.Jitter > 0 {
+		jitter := p.Jitter
+		if jitter > 1 {
+			jitter = 1
+		}
+		backoff -= time.Duration(rand.Float64() * jitter * float64(backoff))
+	}
+
+	return backoff
+}
+
+// retryableStatus reports whether a response with the provided status code is retried.
+func (p RetryPolicy) retryableStatus(code int) bool {
+	codes := p.RetryableStatusCodes
+	if codes == nil {
+		codes = DefaultRetryableStatusCodes
+	}
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
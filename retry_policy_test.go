@@ -0,0 +1,60 @@
+package auth0
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   RetryPolicy
+		attempt  int
+		expected time.Duration
+	}{
+		{
+			name:     "default base backoff",
+			policy:   RetryPolicy{},
+			attempt:  1,
+			expected: DefaultRetryBaseBackoff,
+		},
+		{
+			name:     "exponential backoff",
+			policy:   RetryPolicy{BaseBackoff: 10 * time.Millisecond},
+			attempt:  3,
+			expected: 40 * time.Millisecond,
+		},
+		{
+			name:     "capped backoff",
+			policy:   RetryPolicy{BaseBackoff: 10 * time.Millisecond, MaxBackoff: 25 * time.Millisecond},
+			attempt:  10,
+			expected: 25 * time.Millisecond,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.policy.backoff(test.attempt))
+		})
+	}
+}
+
+func TestRetryPolicyBackoffJitter(t *testing.T) {
+	policy := RetryPolicy{BaseBackoff: 100 * time.Millisecond, Jitter: 0.5}
+	for i := 0; i < 100; i++ {
+		backoff := policy.backoff(1)
+		assert.True(t, backoff > 50*time.Millisecond && backoff <= 100*time.Millisecond, "unexpected backoff %v", backoff)
+	}
+}
+
+func TestRetryPolicyRetryableStatus(t *testing.T) {
+	assert.True(t, RetryPolicy{}.retryableStatus(http.StatusServiceUnavailable))
+	assert.False(t, RetryPolicy{}.retryableStatus(http.StatusNotFound))
+
+	policy := RetryPolicy{RetryableStatusCodes: []int{http.StatusNotFound}}
+	assert.True(t, policy.retryableStatus(http.StatusNotFound))
+	assert.False(t, policy.retryableStatus(http.StatusServiceUnavailable))
+}
@@ -0,0 +1,139 @@
+package auth0
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrTokenRevoked is returned when the RevocationChecker
+// of the validator reports the token as revoked.
+var ErrTokenRevoked = errors.New("token has been revoked")
+
+// RevocationClaims are the claims of a validated token passed to the
+// RevocationChecker.
+type RevocationClaims struct {
+	Subject   string // sub
+	ID        string // jti
+	SessionID string // sid
+	IssuedAt  time.Time
+	// Claims are all the claims of the token.
+	Claims map[string]interface{}
+}
+
+// RevocationChecker rejects the tokens revoked by the application, such as
+// the tokens of logged-out sessions or banned users.
+type RevocationChecker interface {
+	// IsRevoked reports whether the token of the claims is revoked. An error,
+	// such as a failure to reach a shared denylist, rejects the token.
+	// It must be safe for concurrent use.
+	IsRevoked(ctx context.Context, claims RevocationClaims) (bool, error)
+}
+
+// WithRevocationChecker makes the validator reject the tokens revoked
+// according to the checker with ErrTokenRevoked.
+//
+// The checker is called once the signature, the registered claims and the
+// checks of the other options passed, and the checks bound to the request
+// such as WithCertificateBoundTokens, before the ReplayDetector. It is
+// also called for the tokens of the validation cache, so revocations
+// take effect immediately.
+func WithRevocationChecker(checker RevocationChecker) ValidatorOption {
+	return func(v *JWTValidator) {
+		v.revocation = checker
+	}
+}
+
+func (v *JWTValidator) checkRevocation(ctx context.Context, claims map[string]interface{}) error {
+	if v.revocation == nil {
+		return nil
+	}
+	revocationClaims := RevocationClaims{Claims: claims}
+	revocationClaims.Subject, _ = claims["sub"].(string)
+	revocationClaims.ID, _ = claims["jti"].(string)
+	revocationClaims.SessionID, _ = claims["sid"].(string)
+	if iat, ok := claims["iat"].(float64); ok {
+		revocationClaims.IssuedAt = time.Unix(int64(iat), 0)
+	}
+
+	revoked, err := v.revocation.IsRevoked(ctx, revocationClaims)
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return ErrTokenRevoked
+	}
+	return nil
+}
+
+// MemoryRevocationList is an in-memory RevocationChecker of the tokens,
+// sessions and subjects revoked by the application. Each revocation is
+// kept until the time provided, such as the expiry of the last token
+// that may be issued before the revocation. It is safe for concurrent use.
+type MemoryRevocationList struct {
+	mu       sync.RWMutex
+	tokens   map[string]time.Time // Revoked jti
+	sessions map[string]time.Time // Revoked sid
+	subjects map[string]time.Time // Revoked sub
+}
+
+// NewMemoryRevocationList creates an empty MemoryRevocationList.
+func NewMemoryRevocationList() *MemoryRevocationList {
+	return &MemoryRevocationList{
+		tokens:   map[string]time.Time{},
+		sessions: map[string]time.Time{},
+		subjects: map[string]time.Time{},
+	}
+}
+
+// RevokeToken revokes the token with the jti until the time provided.
+func (l *MemoryRevocationList) RevokeToken(jti string, until time.Time) {
+	l.revoke(l.tokens, jti, until)
+}
+
+// RevokeSession revokes the tokens of the session with the sid,
+// such as on back-channel logout, until the time provided.
+func (l *MemoryRevocationList) RevokeSession(sid string, until time.Time) {
+	l.revoke(l.sessions, sid, until)
+}
+
+// RevokeSubject revokes the tokens of the subject,
+// such as a banned user, until the time provided.
+func (l *MemoryRevocationList) RevokeSubject(sub string, until time.Time) {
+	l.revoke(l.subjects, sub, until)
+}
+
+func (l *MemoryRevocationList) revoke(revocations map[string]time.Time, value string, until time.Time) {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key, expiresAt := range revocations {
+		if !now.Before(expiresAt) {
+			delete(revocations, key)
+		}
+	}
+	revocations[value] = until
+}
+
+// IsRevoked implements the RevocationChecker interface.
+func (l *MemoryRevocationList) IsRevoked(_ context.Context, claims RevocationClaims) (bool, error) {
+	now := time.Now()
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return isRevoked(l.tokens, claims.ID, now) ||
+		isRevoked(l.sessions, claims.SessionID, now) ||
+		isRevoked(l.subjects, claims.Subject, now), nil
+}
+
+func isRevoked(revocations map[string]time.Time, value string, now time.Time) bool {
+	if value == "" {
+		return false
+	}
+	until, ok := revocations[value]
+	return ok && now.Before(until)
+}
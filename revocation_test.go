@@ -0,0 +1,124 @@
+package auth0
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+type revocationCheckerFunc func(ctx context.Context, claims RevocationClaims) (bool, error)
+
+func (f revocationCheckerFunc) IsRevoked(ctx context.Context, claims RevocationClaims) (bool, error) {
+	return f(ctx, claims)
+}
+
+func TestRevocationChecker(t *testing.T) {
+	registered := jwt.Claims{
+		Issuer:   defaultIssuer,
+		Audience: defaultAudience,
+		Subject:  "user1",
+		ID:       "jti1",
+		IssuedAt: jwt.NewNumericDate(time.Now()),
+		Expiry:   jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}
+	token := getTestTokenWithClaims(jose.HS256, defaultSecret, registered, map[string]interface{}{"sid": "session1"})
+	until := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name          string
+		revoke        func(l *MemoryRevocationList)
+		expectedError error
+	}{
+		{
+			name:   "pass - not revoked",
+			revoke: func(l *MemoryRevocationList) { l.RevokeToken("jti2", until) },
+		},
+		{
+			name:          "fail - token revoked",
+			revoke:        func(l *MemoryRevocationList) { l.RevokeToken("jti1", until) },
+			expectedError: ErrTokenRevoked,
+		},
+		{
+			name:          "fail - session revoked",
+			revoke:        func(l *MemoryRevocationList) { l.RevokeSession("session1", until) },
+			expectedError: ErrTokenRevoked,
+		},
+		{
+			name:          "fail - subject revoked",
+			revoke:        func(l *MemoryRevocationList) { l.RevokeSubject("user1", until) },
+			expectedError: ErrTokenRevoked,
+		},
+		{
+			name:   "pass - revocation expired",
+			revoke: func(l *MemoryRevocationList) { l.RevokeSubject("user1", time.Now().Add(-time.Second)) },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			list := NewMemoryRevocationList()
+			test.revoke(list)
+			validator := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), nil, WithRevocationChecker(list))
+
+			_, err := validator.ValidateRawToken(token)
+			assert.Equal(t, test.expectedError, err)
+		})
+	}
+
+	t.Run("fail - revoked after caching", func(t *testing.T) {
+		list := NewMemoryRevocationList()
+		validator := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), nil,
+			WithRevocationChecker(list), WithValidationCache(time.Minute, 0))
+
+		_, err := validator.ValidateRequest(genTestMiddlewareRequest(token))
+		assert.NoError(t, err)
+		list.RevokeSession("session1", until)
+		_, err = validator.ValidateRequest(genTestMiddlewareRequest(token))
+		assert.Equal(t, ErrTokenRevoked, err)
+	})
+
+	t.Run("pass - claims of the checker", func(t *testing.T) {
+		var got RevocationClaims
+		checker := revocationCheckerFunc(func(_ context.Context, claims RevocationClaims) (bool, error) {
+			got = claims
+			return false, nil
+		})
+		validator := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), nil, WithRevocationChecker(checker))
+
+		_, err := validator.ValidateRawToken(token)
+		assert.NoError(t, err)
+		assert.Equal(t, "user1", got.Subject)
+		assert.Equal(t, "jti1", got.ID)
+		assert.Equal(t, "session1", got.SessionID)
+		assert.Equal(t, registered.IssuedAt.Time(), got.IssuedAt)
+		assert.Equal(t, "session1", got.Claims["sid"])
+	})
+
+	t.Run("fail - error of the checker", func(t *testing.T) {
+		errDenylist := errors.New("denylist unavailable")
+		checker := revocationCheckerFunc(func(context.Context, RevocationClaims) (bool, error) {
+			return false, errDenylist
+		})
+		validator := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), nil, WithRevocationChecker(checker))
+
+		_, err := validator.ValidateRawToken(token)
+		assert.Equal(t, errDenylist, err)
+	})
+
+	t.Run("pass - revoked tokens do not use up their jti", func(t *testing.T) {
+		list := NewMemoryRevocationList()
+		list.RevokeToken("jti1", until)
+		replay := NewMemoryReplayDetector(0)
+		validator := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), nil,
+			WithRevocationChecker(list), WithReplayDetector(replay))
+
+		_, err := validator.ValidateRawToken(token)
+		assert.Equal(t, ErrTokenRevoked, err)
+		assert.False(t, replay.Seen("jti1", until))
+	})
+}
@@ -0,0 +1,75 @@
+package auth0
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrInsufficientScope is returned when the token does not have the
+	// required scopes. Use errors.As with *InsufficientScopeError to
+	// retrieve the missing scopes.
+	ErrInsufficientScope = errors.New("insufficient scope")
+)
+
+// InsufficientScopeError lists the required scopes missing from a token.
+type InsufficientScopeError struct {
+	Missing []string
+}
+
+func (e *InsufficientScopeError) Error() string {
+	return fmt.Sprintf("%s: missing %s", ErrInsufficientScope, strings.Join(e.Missing, " "))
+}
+
+// Is makes errors.Is match ErrInsufficientScope.
+func (e *InsufficientScopeError) Is(target error) bool {
+	return target == ErrInsufficientScope
+}
+
+// Scopes returns the scopes of the space-delimited scope claim.
+func Scopes(claims map[string]interface{}) []string {
+	scope, _ := claims["scope"].(string)
+	return strings.Fields(scope)
+}
+
+// CheckScopes checks that the scope claim contains all the scopes,
+// returning an *InsufficientScopeError listing the missing ones otherwise.
+func CheckScopes(claims map[string]interface{}, scopes ...string) error {
+	granted := map[string]bool{}
+	for _, scope := range Scopes(claims) {
+		granted[scope] = true
+	}
+
+	var missing []string
+	for _, scope := range scopes {
+		if !granted[scope] {
+			missing = append(missing, scope)
+		}
+	}
+	if len(missing) > 0 {
+		return &InsufficientScopeError{Missing: missing}
+	}
+	return nil
+}
+
+// RequireScopes makes the validator reject tokens
+// that do not have all the scopes.
+func RequireScopes(scopes ...string) ValidatorOption {
+	return func(v *JWTValidator) {
+		v.checks = append(v.checks, func(claims map[string]interface{}) error {
+			return CheckScopes(claims, scopes...)
+		})
+	}
+}
+
+// RequireScope wraps a handler behind the middleware to reject the requests
+// whose token does not have all the scopes, with 403 Forbidden and the
+// insufficient_scope Bearer error, so the scopes required by each route are
+// set next to it. Requests with no validated token are rejected with 401.
+func RequireScope(scopes ...string) func(http.Handler) http.Handler {
+	return requireClaims(func(claims map[string]interface{}) error {
+		return CheckScopes(claims, scopes...)
+	})
+}
@@ -0,0 +1,106 @@
+package auth0
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestCheckScopes(t *testing.T) {
+	tests := []struct {
+		name            string
+		claims          map[string]interface{}
+		scopes          []string
+		expectedMissing []string
+	}{
+		{
+			name:   "pass - all scopes",
+			claims: map[string]interface{}{"scope": "read:users  write:users openid"},
+			scopes: []string{"read:users", "write:users"},
+		},
+		{
+			name:   "pass - no required scope",
+			claims: map[string]interface{}{},
+		},
+		{
+			name:            "fail - missing scope",
+			claims:          map[string]interface{}{"scope": "read:users"},
+			scopes:          []string{"read:users", "write:users", "delete:users"},
+			expectedMissing: []string{"write:users", "delete:users"},
+		},
+		{
+			name:            "fail - no scope claim",
+			claims:          map[string]interface{}{},
+			scopes:          []string{"read:users"},
+			expectedMissing: []string{"read:users"},
+		},
+		{
+			name:            "fail - invalid scope claim",
+			claims:          map[string]interface{}{"scope": []string{"read:users"}},
+			scopes:          []string{"read:users"},
+			expectedMissing: []string{"read:users"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := CheckScopes(test.claims, test.scopes...)
+			if test.expectedMissing == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.True(t, errors.Is(err, ErrInsufficientScope))
+			var scopeErr *InsufficientScopeError
+			if assert.True(t, errors.As(err, &scopeErr)) {
+				assert.Equal(t, test.expectedMissing, scopeErr.Missing)
+			}
+		})
+	}
+}
+
+func TestValidateRequireScopes(t *testing.T) {
+	configuration := NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256)
+	validator := NewValidator(configuration, nil, RequireScopes("read:users"))
+	registered := jwt.Claims{Issuer: defaultIssuer, Audience: defaultAudience, Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+
+	_, err := validator.ValidateRawToken(getTestTokenWithClaims(jose.HS256, defaultSecret, registered, map[string]interface{}{"scope": "read:users"}))
+	assert.NoError(t, err)
+
+	_, err = validator.ValidateRawToken(getTestTokenWithClaims(jose.HS256, defaultSecret, registered, map[string]interface{}{"scope": "write:users"}))
+	assert.True(t, errors.Is(err, ErrInsufficientScope))
+}
+
+func TestRequireScope(t *testing.T) {
+	tests := []struct {
+		name              string
+		claims            map[string]interface{}
+		expectedStatus    int
+		expectedChallenge string
+	}{
+		{"pass - scope granted", map[string]interface{}{"scope": "read:orders write:orders"}, http.StatusOK, ""},
+		{"fail - scope missing", map[string]interface{}{"scope": "write:orders"}, http.StatusForbidden, `Bearer error="insufficient_scope", scope="read:orders"`},
+		{"fail - no claims", nil, http.StatusUnauthorized, "Bearer"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := RequireScope("read:orders")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			req := httptest.NewRequest("GET", "http://localhost/orders", nil)
+			if test.claims != nil {
+				req = req.WithContext(NewContext(req.Context(), nil, "", test.claims))
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, test.expectedStatus, rec.Code)
+			assert.Equal(t, test.expectedChallenge, rec.Header().Get("WWW-Authenticate"))
+		})
+	}
+}
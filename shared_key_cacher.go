@@ -0,0 +1,113 @@
+package auth0
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// KeyStore is the storage shared by the instances using a shared key
+// cacher, such as a Redis client. Get returns a nil value and no error
+// when nothing is stored under the key.
+type KeyStore interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+type sharedKeyCacher struct {
+	store  KeyStore
+	prefix string
+	ttl    time.Duration
+}
+
+// NewSharedKeyCacher creates a new KeyCacher storing the keys in the store
+// under the prefix followed by their ID, so the instances sharing the store
+// download the JWKS once after a key rotation. The keys expire from the
+// store after ttl, or never with MaxKeyAgeNoCheck.
+// Failing to store the downloaded keys does not fail Add, the keys are
+// downloaded again on the next Get.
+func NewSharedKeyCacher(store KeyStore, prefix string, ttl time.Duration) KeyCacher {
+	return &legacySharedKeyCacher{cacher: newSharedKeyCacher(store, prefix, ttl)}
+}
+
+// NewSharedKeyCacherV2 creates a new KeyCacherV2 storing the keys as
+// NewSharedKeyCacher does, passing the context of the key lookups to the
+// store. The store has no deletion, Set adds the keys and the keys rotated
+// out of the JWKS expire from the store after ttl.
+func NewSharedKeyCacherV2(store KeyStore, prefix string, ttl time.Duration) KeyCacherV2 {
+	return newSharedKeyCacher(store, prefix, ttl)
+}
+
+func newSharedKeyCacher(store KeyStore, prefix string, ttl time.Duration) *sharedKeyCacher {
+	if ttl == MaxKeyAgeNoCheck {
+		ttl = 0
+	}
+	return &sharedKeyCacher{
+		store:  store,
+		prefix: prefix,
+		ttl:    ttl,
+	}
+}
+
+// Get obtains a key from the store.
+func (c *sharedKeyCacher) Get(ctx context.Context, keyID string) (*jose.JSONWebKey, error) {
+	value, err := c.store.Get(ctx, c.prefix+keyID)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, ErrNoKeyFound
+	}
+
+	key := &jose.JSONWebKey{}
+	if err := json.Unmarshal(value, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Add adds the downloaded keys into the store.
+func (c *sharedKeyCacher) Add(ctx context.Context, keyID string, downloadedKeys []jose.JSONWebKey) (*jose.JSONWebKey, error) {
+	c.Set(ctx, downloadedKeys)
+
+	for _, key := range downloadedKeys {
+		if key.KeyID == keyID && key.Key != nil {
+			return &key, nil
+		}
+	}
+	return nil, ErrNoKeyFound
+}
+
+// Set adds the downloaded keys into the store, stopping
+// once the context is canceled or its deadline exceeded.
+func (c *sharedKeyCacher) Set(ctx context.Context, downloadedKeys []jose.JSONWebKey) error {
+	for _, key := range downloadedKeys {
+		if ctx.Err() != nil {
+			return nil
+		}
+		value, err := json.Marshal(key.Public())
+		if err != nil {
+			continue
+		}
+		c.store.Set(ctx, c.prefix+key.KeyID, value, c.ttl)
+	}
+	return nil
+}
+
+// legacySharedKeyCacher is the KeyCacher of NewSharedKeyCacher,
+// accessing the store with no deadline.
+type legacySharedKeyCacher struct {
+	cacher *sharedKeyCacher
+}
+
+// Get obtains a key from the store.
+func (c *legacySharedKeyCacher) Get(keyID string) (*jose.JSONWebKey, error) {
+	return c.cacher.Get(context.Background(), keyID)
+}
+
+// Add adds the downloaded keys into the store.
+func (c *legacySharedKeyCacher) Add(keyID string, downloadedKeys []jose.JSONWebKey) (*jose.JSONWebKey, error) {
+	return c.cacher.Add(context.Background(), keyID, downloadedKeys)
+}
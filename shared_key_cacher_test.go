@@ -0,0 +1,126 @@
+package auth0
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+type mockKeyStore struct {
+	mu     sync.Mutex
+	values map[string][]byte
+	ttls   map[string]time.Duration
+	err    error
+}
+
+func newMockKeyStore() *mockKeyStore {
+	return &mockKeyStore{values: map[string][]byte{}, ttls: map[string]time.Duration{}}
+}
+
+func (s *mockKeyStore) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.values[key], s.err
+}
+
+func (s *mockKeyStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.values[key] = value
+	s.ttls[key] = ttl
+	return nil
+}
+
+func TestSharedKeyCacher(t *testing.T) {
+	keyRS256 := genRSASSAJWK(jose.RS256, "keyRS256")
+	keyES384 := genECDSAJWK(jose.ES384, "keyES384")
+	keys := []jose.JSONWebKey{keyRS256.Public(), keyES384.Public()}
+
+	store := newMockKeyStore()
+	cacher := NewSharedKeyCacher(store, "jwks:", time.Hour)
+
+	_, err := cacher.Get("keyRS256")
+	assert.Equal(t, ErrNoKeyFound, err)
+
+	key, err := cacher.Add("keyRS256", keys)
+	assert.NoError(t, err)
+	assert.Equal(t, "keyRS256", key.KeyID)
+	assert.Len(t, store.values, 2)
+	assert.Equal(t, time.Hour, store.ttls["jwks:keyES384"])
+
+	// Another instance sharing the store gets the keys without downloading them.
+	other := NewSharedKeyCacher(store, "jwks:", time.Hour)
+	key, err = other.Get("keyES384")
+	assert.NoError(t, err)
+	assert.Equal(t, "keyES384", key.KeyID)
+	assert.Equal(t, string(jose.ES384), key.Algorithm)
+	assert.True(t, key.Valid())
+
+	_, err = cacher.Add("keyPS256", keys)
+	assert.Equal(t, ErrNoKeyFound, err)
+
+	store.err = errors.New("store unavailable")
+	key, err = cacher.Add("keyRS256", keys)
+	assert.NoError(t, err, "failing to store the keys should not fail Add")
+	assert.Equal(t, "keyRS256", key.KeyID)
+	_, err = cacher.Get("keyRS256")
+	assert.Equal(t, store.err, err)
+}
+
+func TestSharedKeyCacherWithJWKClient(t *testing.T) {
+	opts, tokenRS256, _, err := genNewTestServer(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := newMockKeyStore()
+
+	var counter uint64
+	opts.Client = &http.Client{Transport: &mockRoundTripper{ops: &counter, rt: http.DefaultTransport}}
+	testGetSecret(t, NewJWKClientWithCache(opts, nil, NewSharedKeyCacher(store, "jwks:", MaxKeyAgeNoCheck)), tokenRS256)
+	testGetSecret(t, NewJWKClientWithCache(opts, nil, NewSharedKeyCacher(store, "jwks:", MaxKeyAgeNoCheck)), tokenRS256)
+	assert.Equal(t, uint64(1), counter)
+	assert.Equal(t, time.Duration(0), store.ttls["jwks:keyRS256"])
+}
+
+func TestSharedKeyCacherV2(t *testing.T) {
+	keyRS256 := genRSASSAJWK(jose.RS256, "keyRS256")
+	keys := []jose.JSONWebKey{keyRS256.Public()}
+
+	store := newMockKeyStore()
+	cacher := NewSharedKeyCacherV2(store, "jwks:", time.Hour)
+
+	assert.NoError(t, cacher.Set(context.Background(), keys))
+	key, err := cacher.Get(context.Background(), "keyRS256")
+	assert.NoError(t, err)
+	assert.Equal(t, "keyRS256", key.KeyID)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = cacher.Get(ctx, "keyRS256")
+	assert.Equal(t, context.Canceled, err)
+
+	// The lookups of a canceled request do not wait for the store.
+	opts, tokenRS256, _, err := genNewTestServer(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewJWKClientWithOptions(opts.URI, WithKeyCacherV2(NewSharedKeyCacherV2(newMockKeyStore(), "jwks:", time.Hour)))
+	testGetSecret(t, client, tokenRS256)
+	_, err = client.GetKeyContext(ctx, "keyRS256")
+	assert.Equal(t, context.Canceled, err)
+}
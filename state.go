@@ -0,0 +1,181 @@
+package auth0
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultStateTTL is the default time the values
+// encoded by a StateEncoder are valid.
+const DefaultStateTTL = 10 * time.Minute
+
+var (
+	// ErrInvalidState is returned when the state parameter of the callback
+	// does not match the expected state, as in a forged callback, or when an
+	// encoded state is malformed or its signature invalid.
+	ErrInvalidState = errors.New("invalid state parameter")
+	// ErrStateExpired is returned when the encoded state is valid
+	// but older than the TTL of the StateEncoder.
+	ErrStateExpired = errors.New("state expired")
+)
+
+// GenerateState returns a random state parameter for an authorization request,
+// holding 256 bits of entropy from crypto/rand.
+func GenerateState() (string, error) {
+	return randomString(32)
+}
+
+// GenerateNonce returns a random nonce for an authentication request,
+// holding 256 bits of entropy from crypto/rand.
+func GenerateNonce() (string, error) {
+	return randomString(32)
+}
+
+// GeneratePKCEVerifier returns a random PKCE code verifier, the 43 base64url
+// characters of 256 bits of entropy from crypto/rand, see RFC 7636.
+func GeneratePKCEVerifier() (string, error) {
+	return randomString(32)
+}
+
+// PKCEChallenge returns the S256 code challenge of
+// the code verifier, see RFC 7636.
+func PKCEChallenge(verifier string) string {
+	hash := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+// VerifyState compares the state parameter of a callback with the expected
+// state in constant time, returning ErrInvalidState if either is empty or
+// they differ.
+func VerifyState(expected, actual string) error {
+	if expected == "" || actual == "" || subtle.ConstantTimeCompare([]byte(expected), []byte(actual)) != 1 {
+		return ErrInvalidState
+	}
+	return nil
+}
+
+// StateEncoder encodes values, such as a PendingLogin, into URL and cookie
+// safe strings signed with HMAC-SHA256 and expiring after a TTL, so the state
+// of the logins is kept by the user agent instead of a server-side store
+// shared by the instances of the application.
+//
+// The encoded values are signed, not encrypted: they are readable by whoever
+// holds them. Values holding a PKCE code verifier must not be sent as the
+// state parameter, which is returned along with the code, but kept in an
+// HttpOnly cookie with SetCookie.
+type StateEncoder struct {
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// NewStateEncoder creates a new StateEncoder signing with the secret, which
+// should be at least 32 random bytes shared by the instances of the
+// application. The values are valid for the TTL, DefaultStateTTL if not positive.
+func NewStateEncoder(secret []byte, ttl time.Duration) *StateEncoder {
+	if ttl <= 0 {
+		ttl = DefaultStateTTL
+	}
+	return &StateEncoder{secret: secret, ttl: ttl, now: time.Now}
+}
+
+// encodedState is the signed payload of the encoded values.
+type encodedState struct {
+	Expiry int64           `json:"exp"`
+	Value  json.RawMessage `json:"v"`
+}
+
+// Encode returns the signed encoding of the JSON of the value.
+func (e *StateEncoder) Encode(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(encodedState{Expiry: e.now().Add(e.ttl).Unix(), Value: data})
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(e.sign(encoded)), nil
+}
+
+// Decode verifies the signature and expiry of the encoded value and decodes
+// it into dest, returning ErrInvalidState if it is malformed or is not signed
+// by the encoder, ErrStateExpired if it is older than the TTL.
+func (e *StateEncoder) Decode(encoded string, dest interface{}) error {
+	parts := strings.Split(encoded, ".")
+	if len(parts) != 2 {
+		return ErrInvalidState
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(signature, e.sign(parts[0])) {
+		return ErrInvalidState
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return ErrInvalidState
+	}
+	state := encodedState{}
+	if err := json.Unmarshal(payload, &state); err != nil {
+		return ErrInvalidState
+	}
+	if !e.now().Before(time.Unix(state.Expiry, 0)) {
+		return ErrStateExpired
+	}
+	if err := json.Unmarshal(state.Value, dest); err != nil {
+		return ErrInvalidState
+	}
+	return nil
+}
+
+// SetCookie sets the named HttpOnly, Secure and SameSite=Lax cookie holding
+// the encoded value, expiring with it. Lax cookies are sent along with the
+// redirection of the user back to the application after the login.
+func (e *StateEncoder) SetCookie(w http.ResponseWriter, name string, value interface{}) error {
+	encoded, err := e.Encode(value)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    encoded,
+		Path:     "/",
+		MaxAge:   int(e.ttl / time.Second),
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+// ReadCookie decodes the value of the named cookie of the request into dest
+// and deletes the cookie, so the value is used once. It returns
+// ErrInvalidState when the request has no such cookie.
+func (e *StateEncoder) ReadCookie(w http.ResponseWriter, r *http.Request, name string, dest interface{}) error {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return ErrInvalidState
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Path:     "/",
+		MaxAge:   -1,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return e.Decode(cookie.Value, dest)
+}
+
+func (e *StateEncoder) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, e.secret)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,86 @@
+package auth0
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateState(t *testing.T) {
+	for _, generate := range []func() (string, error){GenerateState, GenerateNonce, GeneratePKCEVerifier} {
+		a, err := generate()
+		assert.NoError(t, err)
+		b, err := generate()
+		assert.NoError(t, err)
+		assert.Len(t, a, 43)
+		assert.NotEqual(t, a, b)
+	}
+}
+
+func TestPKCEChallenge(t *testing.T) {
+	// Example of RFC 7636, appendix B.
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", PKCEChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+}
+
+func TestVerifyState(t *testing.T) {
+	assert.NoError(t, VerifyState("state", "state"))
+	assert.Equal(t, ErrInvalidState, VerifyState("state", "other"))
+	assert.Equal(t, ErrInvalidState, VerifyState("state", ""))
+	assert.Equal(t, ErrInvalidState, VerifyState("", ""))
+}
+
+func TestStateEncoder(t *testing.T) {
+	now := time.Now()
+	encoder := NewStateEncoder([]byte("secret"), time.Minute)
+	encoder.now = func() time.Time { return now }
+
+	encoded, err := encoder.Encode(PendingLogin{State: "state", Nonce: "nonce", CodeVerifier: "verifier"})
+	assert.NoError(t, err)
+	pending := PendingLogin{}
+	assert.NoError(t, encoder.Decode(encoded, &pending))
+	assert.Equal(t, PendingLogin{State: "state", Nonce: "nonce", CodeVerifier: "verifier"}, pending)
+
+	// The values of another secret and the altered values are rejected.
+	other := NewStateEncoder([]byte("other"), time.Minute)
+	assert.Equal(t, ErrInvalidState, other.Decode(encoded, &pending))
+	parts := strings.Split(encoded, ".")
+	forged, _ := other.Encode(PendingLogin{State: "forged"})
+	assert.Equal(t, ErrInvalidState, encoder.Decode(strings.Split(forged, ".")[0]+"."+parts[1], &pending))
+	assert.Equal(t, ErrInvalidState, encoder.Decode(parts[0], &pending))
+	assert.Equal(t, ErrInvalidState, encoder.Decode("", &pending))
+	assert.Equal(t, ErrInvalidState, encoder.Decode(encoded+".x", &pending))
+
+	encoder.now = func() time.Time { return now.Add(time.Minute) }
+	assert.Equal(t, ErrStateExpired, encoder.Decode(encoded, &pending))
+
+	assert.Equal(t, DefaultStateTTL, NewStateEncoder([]byte("secret"), 0).ttl)
+}
+
+func TestStateEncoderCookie(t *testing.T) {
+	encoder := NewStateEncoder([]byte("secret"), time.Minute)
+
+	w := httptest.NewRecorder()
+	assert.NoError(t, encoder.SetCookie(w, "login", PendingLogin{State: "state"}))
+	cookies := w.Result().Cookies()
+	assert.Len(t, cookies, 1)
+	assert.True(t, cookies[0].HttpOnly)
+	assert.True(t, cookies[0].Secure)
+	assert.Equal(t, http.SameSiteLaxMode, cookies[0].SameSite)
+	assert.Equal(t, 60, cookies[0].MaxAge)
+
+	r := httptest.NewRequest("GET", "/callback", nil)
+	r.AddCookie(cookies[0])
+	w = httptest.NewRecorder()
+	pending := PendingLogin{}
+	assert.NoError(t, encoder.ReadCookie(w, r, "login", &pending))
+	assert.Equal(t, "state", pending.State)
+	// The cookie is deleted once read.
+	assert.Equal(t, -1, w.Result().Cookies()[0].MaxAge)
+
+	r = httptest.NewRequest("GET", "/callback", nil)
+	assert.Equal(t, ErrInvalidState, encoder.ReadCookie(httptest.NewRecorder(), r, "login", &pending))
+}
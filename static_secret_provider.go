@@ -0,0 +1,151 @@
+package auth0
+
+import (
+	stded25519 "crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/ed25519"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// ErrNoPEMKey is returned by NewPEMSecretProvider
+// when the file holds no public key or certificate.
+var ErrNoPEMKey = errors.New("no public key in the PEM file")
+
+// staticSecretProvider provides the key of the token
+// among a fixed set of keys, selected by kid.
+type staticSecretProvider struct {
+	keys []jose.JSONWebKey
+	// anyKID makes the provider use its only key for any kid,
+	// for keys that have no kid of their own.
+	anyKID bool
+}
+
+// NewStaticSecretProvider creates a provider of the key of the token among
+// the keys, selected by the kid header of the token, for tests, air-gapped
+// environments and identity providers with no JWKS endpoint. A single
+// key is also used for tokens with no kid.
+func NewStaticSecretProvider(keys ...jose.JSONWebKey) SecretProvider {
+	return &staticSecretProvider{keys: publicKeys(keys)}
+}
+
+// NewPEMSecretProvider creates a provider of the key of the token among the
+// public keys of the PEM file, as PUBLIC KEY, RSA PUBLIC KEY or CERTIFICATE
+// blocks. The kid of each key is its RFC 7638 thumbprint. A file holding a
+// single key is used whatever the kid of the token.
+func NewPEMSecretProvider(path string) (SecretProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := parsePEMKeys(data)
+	if err != nil {
+		return nil, err
+	}
+	return &staticSecretProvider{keys: keys, anyKID: true}, nil
+}
+
+// parsePEMKeys parses the public keys of the PEM data,
+// identified by their thumbprint.
+func parsePEMKeys(data []byte) ([]jose.JSONWebKey, error) {
+	var keys []jose.JSONWebKey
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		var key interface{}
+		var err error
+		switch block.Type {
+		case "PUBLIC KEY":
+			key, err = x509.ParsePKIXPublicKey(block.Bytes)
+		case "RSA PUBLIC KEY":
+			key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+		case "CERTIFICATE":
+			var cert *x509.Certificate
+			cert, err = x509.ParseCertificate(block.Bytes)
+			if err == nil {
+				key = cert.PublicKey
+			}
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s PEM block: %w", block.Type, err)
+		}
+
+		jwk := jose.JSONWebKey{Key: joseKey(key)}
+		if jwk.KeyID, err = KeyThumbprint(jwk); err != nil {
+			return nil, err
+		}
+		keys = append(keys, jwk)
+	}
+
+	if len(keys) == 0 {
+		return nil, ErrNoPEMKey
+	}
+	return keys, nil
+}
+
+// GetSecret implements the SecretProvider interface.
+func (p *staticSecretProvider) GetSecret(token *jwt.JSONWebToken) (interface{}, error) {
+	if len(token.Headers) < 1 {
+		return nil, ErrNoJWTHeaders
+	}
+	if isUnsecured(token) {
+		return nil, ErrUnsecuredToken
+	}
+
+	header := token.Headers[0]
+	key, ok := p.key(header.KeyID)
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	if !keyMatchesAlgorithm(key, header.Algorithm) {
+		return nil, ErrKeyAlgorithmMismatch
+	}
+	return key, nil
+}
+
+// key returns the key with the kid, or the only key of the
+// provider if the kid is empty or any kid is accepted.
+func (p *staticSecretProvider) key(kid string) (jose.JSONWebKey, bool) {
+	for _, key := range p.keys {
+		if key.KeyID == kid {
+			return key, true
+		}
+	}
+	if len(p.keys) == 1 && (kid == "" || p.anyKID) {
+		return p.keys[0], true
+	}
+	return jose.JSONWebKey{}, false
+}
+
+// publicKeys returns the public part of the keys, keeping
+// the symmetric keys as is.
+func publicKeys(keys []jose.JSONWebKey) []jose.JSONWebKey {
+	public := make([]jose.JSONWebKey, 0, len(keys))
+	for _, key := range keys {
+		if _, ok := key.Key.([]byte); ok {
+			public = append(public, key)
+			continue
+		}
+		public = append(public, key.Public())
+	}
+	return public
+}
+
+// joseKey converts the keys parsed by crypto/x509 to the types of go-jose.
+func joseKey(key interface{}) interface{} {
+	if k, ok := key.(stded25519.PublicKey); ok {
+		return ed25519.PublicKey(k)
+	}
+	return key
+}
@@ -0,0 +1,165 @@
+package auth0
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func TestStaticSecretProvider(t *testing.T) {
+	rsaKey := genRSASSAJWK(jose.RS256, "keyRS256")
+	ecKey := genECDSAJWK(jose.ES384, "keyES384")
+	unknownKey := genRSASSAJWK(jose.RS256, "unknown")
+	noKID := genRSASSAJWK(jose.RS256, "")
+
+	tests := []struct {
+		name          string
+		keys          []jose.JSONWebKey
+		token         string
+		expectedError error
+	}{
+		{
+			name:  "pass - RSA key selected by kid",
+			keys:  []jose.JSONWebKey{rsaKey, ecKey},
+			token: getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.RS256, rsaKey),
+		},
+		{
+			name:  "pass - ECDSA key selected by kid",
+			keys:  []jose.JSONWebKey{rsaKey, ecKey},
+			token: getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.ES384, ecKey),
+		},
+		{
+			name:  "pass - single key and no kid",
+			keys:  []jose.JSONWebKey{noKID},
+			token: getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.RS256, noKID),
+		},
+		{
+			name:          "fail - unknown kid",
+			keys:          []jose.JSONWebKey{rsaKey},
+			token:         getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.RS256, unknownKey),
+			expectedError: ErrKeyNotFound,
+		},
+		{
+			name:          "fail - signed by another key with the same kid",
+			keys:          []jose.JSONWebKey{rsaKey},
+			token:         getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.RS256, genRSASSAJWK(jose.RS256, "keyRS256")),
+			expectedError: ErrInvalidSignature,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider := NewStaticSecretProvider(test.keys...)
+			validator := NewValidator(NewConfigurationTrustProvider(provider, defaultAudience, defaultIssuer), nil)
+			_, err := validator.ValidateRawToken(test.token)
+			if test.expectedError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, test.expectedError), "got %v", err)
+			}
+		})
+	}
+}
+
+func writePEMFile(t *testing.T, blocks ...*pem.Block) string {
+	path := filepath.Join(t.TempDir(), "keys.pem")
+	var data []byte
+	for _, block := range blocks {
+		data = append(data, pem.EncodeToMemory(block)...)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPEMSecretProvider(t *testing.T) {
+	rsaKey := genRSASSAJWK(jose.RS256, "keyRS256")
+	ecKey := genECDSAJWK(jose.ES384, "keyES384")
+	rsaDER, err := x509.MarshalPKIXPublicKey(&rsaKey.Key.(*rsa.PrivateKey).PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecDER, err := x509.MarshalPKIXPublicKey(&ecKey.Key.(*ecdsa.PrivateKey).PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caKey := rsaKey.Key.(*rsa.PrivateKey)
+	cert := genCertificate(t, "signing", &caKey.PublicKey, nil, caKey, false)
+
+	rsaToken := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.RS256, rsaKey)
+	ecKey.KeyID, err = KeyThumbprint(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecToken := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.ES384, ecKey)
+
+	tests := []struct {
+		name          string
+		blocks        []*pem.Block
+		token         string
+		expectedError error
+	}{
+		{
+			name:   "pass - single PKIX key with any kid",
+			blocks: []*pem.Block{{Type: "PUBLIC KEY", Bytes: rsaDER}},
+			token:  rsaToken,
+		},
+		{
+			name:   "pass - single PKCS1 key with any kid",
+			blocks: []*pem.Block{{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&caKey.PublicKey)}},
+			token:  rsaToken,
+		},
+		{
+			name:   "pass - certificate",
+			blocks: []*pem.Block{{Type: "CERTIFICATE", Bytes: cert.Raw}},
+			token:  rsaToken,
+		},
+		{
+			name:   "pass - several keys selected by thumbprint",
+			blocks: []*pem.Block{{Type: "PUBLIC KEY", Bytes: rsaDER}, {Type: "PUBLIC KEY", Bytes: ecDER}},
+			token:  ecToken,
+		},
+		{
+			name:          "fail - several keys and unknown kid",
+			blocks:        []*pem.Block{{Type: "PUBLIC KEY", Bytes: rsaDER}, {Type: "PUBLIC KEY", Bytes: ecDER}},
+			token:         rsaToken,
+			expectedError: ErrKeyNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider, err := NewPEMSecretProvider(writePEMFile(t, test.blocks...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			validator := NewValidator(NewConfigurationTrustProvider(provider, defaultAudience, defaultIssuer), nil)
+			_, err = validator.ValidateRawToken(test.token)
+			if test.expectedError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, test.expectedError), "got %v", err)
+			}
+		})
+	}
+
+	t.Run("fail - no key", func(t *testing.T) {
+		_, err := NewPEMSecretProvider(writePEMFile(t, &pem.Block{Type: "PRIVATE KEY", Bytes: []byte("ignored")}))
+		assert.Equal(t, ErrNoPEMKey, err)
+	})
+
+	t.Run("fail - missing file", func(t *testing.T) {
+		_, err := NewPEMSecretProvider(filepath.Join(t.TempDir(), "missing.pem"))
+		assert.True(t, errors.Is(err, os.ErrNotExist))
+	})
+}
@@ -0,0 +1,376 @@
+package auth0
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// DefaultMaxTenants is the default maximum number of
+// clients held by a TenantRegistry.
+const DefaultMaxTenants = 100
+
+// DefaultTenantFailureTTL is the default time the failures
+// to create the client of an issuer are remembered.
+const DefaultTenantFailureTTL = 30 * time.Second
+
+// DefaultTenantCreateTimeout is the default time limit
+// of the creation of the client of an issuer.
+const DefaultTenantCreateTimeout = 30 * time.Second
+
+// TenantRegistryOptions configures a TenantRegistry.
+type TenantRegistryOptions struct {
+	// JWKClientOptions are the options of the client of each issuer.
+	// The URI is replaced by the jwks_uri of the OpenID configuration
+	// of the issuer.
+	JWKClientOptions JWKClientOptions
+	// MaxTenants is the maximum number of clients that verified a token, the
+	// least recently used ones being evicted and stopped beyond. Up to
+	// MaxTenants clients that have not verified a token yet are held in
+	// addition to them, and are evicted among themselves: they never evict
+	// the verified clients. Defaults to DefaultMaxTenants.
+	MaxTenants int
+	// NewClient creates the client of an issuer.
+	// Defaults to NewJWKClientFromIssuerContext with JWKClientOptions.
+	NewClient func(ctx context.Context, issuer string) (*JWKClient, error)
+	// TrustedIssuer tells whether the issuer is trusted, such as registered
+	// in the database of the tenants, before its client is created and its
+	// OpenID configuration downloaded. Untrusted issuers fail with
+	// ErrUnknownIssuer and policy errors are returned as is. The policy is
+	// not evaluated again for the issuers of the clients of the registry,
+	// call Remove once an issuer is no longer trusted. It is required: no
+	// issuer is trusted when nil. See TrustIssuers.
+	TrustedIssuer func(ctx context.Context, issuer string) (bool, error)
+	// FailureTTL is how long the failures to trust an issuer or to create
+	// its client are remembered, the lookups of the issuer failing with the
+	// same error meanwhile, so that tokens forged with its issuer do not
+	// download its OpenID configuration again. Defaults to DefaultTenantFailureTTL.
+	FailureTTL time.Duration
+	// CreateTimeout bounds the creation of the client of an issuer, policy
+	// included, which is not canceled with the callers waiting for it.
+	// Defaults to DefaultTenantCreateTimeout.
+	CreateTimeout time.Duration
+}
+
+// TrustIssuers returns a TrustedIssuer policy trusting the issuers.
+func TrustIssuers(issuers ...string) func(ctx context.Context, issuer string) (bool, error) {
+	trusted := make(map[string]bool, len(issuers))
+	for _, issuer := range issuers {
+		trusted[issuer] = true
+	}
+	return func(_ context.Context, issuer string) (bool, error) {
+		return trusted[issuer], nil
+	}
+}
+
+// TenantRegistry holds the JWKClient of each issuer, such as the Auth0
+// tenants of the customers of a SaaS backend, created on the first token of
+// the issuer from its OpenID configuration. The number of clients is bounded,
+// the least recently used ones being evicted. It is safe for concurrent use.
+//
+// The registry is a SecretProvider looking up the keys of the token with the
+// client of its issuer, read from the unverified claims of the token. Only
+// the issuers trusted by TrustedIssuer are discovered, and a new client only
+// counts among the MaxTenants clients once the signature of a token has been
+// verified with one of its keys.
+type TenantRegistry struct {
+	options TenantRegistryOptions
+
+	mu       sync.Mutex
+	clients  map[string]*list.Element // Verified and pending clients, by issuer
+	order    *list.List               // Verified clients, front is the most recently used
+	pending  *list.List               // Clients yet to verify a token, front is the most recent
+	failures map[string]*list.Element
+	failed   *list.List // Failures, front is the most recent
+
+	sf singleflight.Group // Used to collapse the creations of the client of an issuer
+}
+
+type tenantRegistryEntry struct {
+	issuer   string
+	client   *JWKClient
+	verified bool
+}
+
+type tenantRegistryFailure struct {
+	issuer    string
+	err       error
+	expiresAt time.Time
+}
+
+// NewTenantRegistry creates a new TenantRegistry with no clients.
+func NewTenantRegistry(options TenantRegistryOptions) *TenantRegistry {
+	if options.MaxTenants <= 0 {
+		options.MaxTenants = DefaultMaxTenants
+	}
+	if options.FailureTTL <= 0 {
+		options.FailureTTL = DefaultTenantFailureTTL
+	}
+	if options.CreateTimeout <= 0 {
+		options.CreateTimeout = DefaultTenantCreateTimeout
+	}
+	if options.NewClient == nil {
+		clientOptions := options.JWKClientOptions
+		options.NewClient = func(ctx context.Context, issuer string) (*JWKClient, error) {
+			return NewJWKClientFromIssuerContext(ctx, issuer, clientOptions, nil)
+		}
+	}
+	return &TenantRegistry{
+		options:  options,
+		clients:  map[string]*list.Element{},
+		order:    list.New(),
+		pending:  list.New(),
+		failures: map[string]*list.Element{},
+		failed:   list.New(),
+	}
+}
+
+// Client returns the client of the issuer, creating it if the registry holds
+// none and the issuer is trusted. Simultaneous calls for the same issuer
+// result in a single creation, every caller waiting for it until its own
+// context is done. Failures are remembered for FailureTTL, the calls for the
+// issuer failing with the same error meanwhile.
+func (t *TenantRegistry) Client(ctx context.Context, issuer string) (*JWKClient, error) {
+	client, _, err := t.client(ctx, issuer)
+	return client, err
+}
+
+// client returns the client of the issuer, reporting
+// whether it has verified the signature of a token.
+func (t *TenantRegistry) client(ctx context.Context, issuer string) (*JWKClient, bool, error) {
+	if client, verified, ok := t.get(issuer); ok {
+		return client, verified, nil
+	}
+	if err := t.failure(issuer); err != nil {
+		return nil, false, err
+	}
+
+	ch := t.sf.DoChan(issuer, func() (interface{}, error) {
+		if client, _, ok := t.get(issuer); ok {
+			return client, nil
+		}
+		ctx, cancel := detach(ctx, t.options.CreateTimeout)
+		defer cancel()
+		client, err := t.create(ctx, issuer)
+		if err != nil {
+			t.addFailure(issuer, err)
+			return nil, err
+		}
+		t.addPending(issuer, client)
+		return client, nil
+	})
+
+	var res singleflight.Result
+	select {
+	case res = <-ch:
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	}
+	if res.Err != nil {
+		return nil, false, res.Err
+	}
+	return res.Val.(*JWKClient), false, nil
+}
+
+// create creates the client of the issuer if it is trusted.
+func (t *TenantRegistry) create(ctx context.Context, issuer string) (*JWKClient, error) {
+	if t.options.TrustedIssuer == nil {
+		return nil, ErrUnknownIssuer
+	}
+	trusted, err := t.options.TrustedIssuer(ctx, issuer)
+	if err != nil {
+		return nil, err
+	}
+	if !trusted {
+		return nil, ErrUnknownIssuer
+	}
+	return t.options.NewClient(ctx, issuer)
+}
+
+// Remove stops and removes the client of the issuer, if any,
+// and forgets its failure.
+func (t *TenantRegistry) Remove(issuer string) {
+	t.mu.Lock()
+	if failure, ok := t.failures[issuer]; ok {
+		t.forget(failure)
+	}
+	elem, ok := t.clients[issuer]
+	if ok {
+		t.remove(elem)
+	}
+	t.mu.Unlock()
+
+	if ok {
+		elem.Value.(*tenantRegistryEntry).client.Stop()
+	}
+}
+
+// Len returns the number of clients of the registry
+// that have verified the signature of a token.
+func (t *TenantRegistry) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.order.Len()
+}
+
+// Stop stops and removes all the clients of the registry.
+func (t *TenantRegistry) Stop() {
+	t.mu.Lock()
+	var clients []*JWKClient
+	for _, elem := range t.clients {
+		clients = append(clients, elem.Value.(*tenantRegistryEntry).client)
+		t.remove(elem)
+	}
+	t.mu.Unlock()
+
+	for _, client := range clients {
+		client.Stop()
+	}
+}
+
+// GetSecret implements the SecretProvider interface.
+func (t *TenantRegistry) GetSecret(token *jwt.JSONWebToken) (interface{}, error) {
+	return t.GetSecretContext(context.Background(), token)
+}
+
+// GetSecretContext implements the ContextSecretProvider interface.
+func (t *TenantRegistry) GetSecretContext(ctx context.Context, token *jwt.JSONWebToken) (interface{}, error) {
+	claims := jwt.Claims{}
+	if err := token.UnsafeClaimsWithoutVerification(&claims); err != nil {
+		return nil, err
+	}
+	if claims.Issuer == "" {
+		return nil, ErrUnknownIssuer
+	}
+	client, verified, err := t.client(ctx, claims.Issuer)
+	if err != nil {
+		return nil, err
+	}
+	key, err := client.GetSecretContext(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	// A new client joins the registry once a token of its issuer is genuine.
+	if !verified && verifyClaims(token, key, []interface{}{&jwt.Claims{}}) == nil {
+		t.verify(claims.Issuer, client)
+	}
+	return key, nil
+}
+
+// get returns the client of the issuer, marking it as recently used
+// and reporting whether it has verified the signature of a token.
+func (t *TenantRegistry) get(issuer string) (*JWKClient, bool, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	elem, ok := t.clients[issuer]
+	if !ok {
+		return nil, false, false
+	}
+	entry := elem.Value.(*tenantRegistryEntry)
+	if entry.verified {
+		t.order.MoveToFront(elem)
+	}
+	return entry.client, entry.verified, true
+}
+
+// addPending adds the new client of the issuer, evicting and stopping
+// the oldest clients yet to verify a token on overflow.
+func (t *TenantRegistry) addPending(issuer string, client *JWKClient) {
+	t.mu.Lock()
+	t.clients[issuer] = t.pending.PushFront(&tenantRegistryEntry{issuer: issuer, client: client})
+	evicted := t.evict(t.pending)
+	t.mu.Unlock()
+
+	for _, client := range evicted {
+		client.Stop()
+	}
+}
+
+// verify moves the client of the issuer among the verified clients,
+// evicting and stopping the least recently used ones on overflow.
+func (t *TenantRegistry) verify(issuer string, client *JWKClient) {
+	t.mu.Lock()
+	elem, ok := t.clients[issuer]
+	if !ok || elem.Value.(*tenantRegistryEntry).client != client || elem.Value.(*tenantRegistryEntry).verified {
+		t.mu.Unlock()
+		return
+	}
+	t.pending.Remove(elem)
+	t.clients[issuer] = t.order.PushFront(&tenantRegistryEntry{issuer: issuer, client: client, verified: true})
+	evicted := t.evict(t.order)
+	t.mu.Unlock()
+
+	for _, client := range evicted {
+		client.Stop()
+	}
+}
+
+// evict removes the back entries of the list beyond MaxTenants
+// and returns their clients. The caller must hold mu.
+func (t *TenantRegistry) evict(l *list.List) []*JWKClient {
+	var evicted []*JWKClient
+	for l.Len() > t.options.MaxTenants {
+		elem := l.Back()
+		evicted = append(evicted, elem.Value.(*tenantRegistryEntry).client)
+		t.remove(elem)
+	}
+	return evicted
+}
+
+// remove removes the entry. The caller must hold mu.
+func (t *TenantRegistry) remove(elem *list.Element) {
+	entry := elem.Value.(*tenantRegistryEntry)
+	if entry.verified {
+		t.order.Remove(elem)
+	} else {
+		t.pending.Remove(elem)
+	}
+	delete(t.clients, entry.issuer)
+}
+
+// failure returns the unexpired failure of the issuer, if any.
+func (t *TenantRegistry) failure(issuer string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	elem, ok := t.failures[issuer]
+	if !ok {
+		return nil
+	}
+	failure := elem.Value.(*tenantRegistryFailure)
+	if !time.Now().Before(failure.expiresAt) {
+		t.forget(elem)
+		return nil
+	}
+	return failure.err
+}
+
+// forget removes the failure. The caller must hold mu.
+func (t *TenantRegistry) forget(elem *list.Element) {
+	t.failed.Remove(elem)
+	delete(t.failures, elem.Value.(*tenantRegistryFailure).issuer)
+}
+
+// addFailure remembers the failure of the issuer for FailureTTL,
+// forgetting the oldest failures beyond MaxTenants.
+func (t *TenantRegistry) addFailure(issuer string, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if elem, ok := t.failures[issuer]; ok {
+		t.forget(elem)
+	}
+	t.failures[issuer] = t.failed.PushFront(&tenantRegistryFailure{
+		issuer:    issuer,
+		err:       err,
+		expiresAt: time.Now().Add(t.options.FailureTTL),
+	})
+	for t.failed.Len() > t.options.MaxTenants {
+		t.forget(t.failed.Back())
+	}
+}
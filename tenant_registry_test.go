@@ -0,0 +1,236 @@
+package auth0
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func TestTenantRegistry(t *testing.T) {
+	ts1, key1 := genNewDiscoveryServer("/", "/.well-known/jwks.json")
+	defer ts1.Close()
+	ts2, key2 := genNewDiscoveryServer("/", "/.well-known/jwks.json")
+	defer ts2.Close()
+	issuer1, issuer2 := ts1.URL+"/", ts2.URL+"/"
+
+	token1 := getTestToken(defaultAudience, issuer1, time.Now().Add(time.Hour), jose.RS256, *key1)
+	token2 := getTestToken(defaultAudience, issuer2, time.Now().Add(time.Hour), jose.RS256, *key2)
+	forged := getTestToken(defaultAudience, issuer1, time.Now().Add(time.Hour), jose.RS256, *key2)
+
+	t.Run("pass - lazy creation per issuer", func(t *testing.T) {
+		registry := NewTenantRegistry(TenantRegistryOptions{TrustedIssuer: TrustIssuers(issuer1, issuer2)})
+		defer registry.Stop()
+		validator := NewValidator(NewConfigurationTrustProvider(registry, defaultAudience, ""), nil, WithTrustedIssuers(issuer1, issuer2))
+
+		assert.Equal(t, 0, registry.Len())
+		_, err := validator.ValidateRawToken(token1)
+		assert.NoError(t, err)
+		_, err = validator.ValidateRawToken(token2)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, registry.Len())
+
+		_, err = validator.ValidateRawToken(forged)
+		assert.Error(t, err)
+	})
+
+	t.Run("pass - least recently used evicted", func(t *testing.T) {
+		registry := NewTenantRegistry(TenantRegistryOptions{MaxTenants: 1, TrustedIssuer: TrustIssuers(issuer1, issuer2)})
+		defer registry.Stop()
+		validator := NewValidator(NewConfigurationTrustProvider(registry, defaultAudience, ""), nil, WithTrustedIssuers(issuer1, issuer2))
+
+		_, err := validator.ValidateRawToken(token1)
+		assert.NoError(t, err)
+		client1, err := registry.Client(context.Background(), issuer1)
+		assert.NoError(t, err)
+		_, err = validator.ValidateRawToken(token2)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, registry.Len())
+
+		again, err := registry.Client(context.Background(), issuer1)
+		assert.NoError(t, err)
+		assert.True(t, client1 != again)
+
+		registry.Remove(issuer2)
+		assert.Equal(t, 0, registry.Len())
+	})
+
+	t.Run("pass - clients join once a token is verified", func(t *testing.T) {
+		registry := NewTenantRegistry(TenantRegistryOptions{MaxTenants: 1, TrustedIssuer: TrustIssuers(issuer1, issuer2)})
+		defer registry.Stop()
+		validator := NewValidator(NewConfigurationTrustProvider(registry, defaultAudience, ""), nil, WithTrustedIssuers(issuer1, issuer2))
+
+		_, err := validator.ValidateRawToken(forged)
+		assert.Error(t, err)
+		assert.Equal(t, 0, registry.Len())
+
+		_, err = validator.ValidateRawToken(token1)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, registry.Len())
+		client1, err := registry.Client(context.Background(), issuer1)
+		assert.NoError(t, err)
+
+		// The clients yet to verify a token never evict the verified ones.
+		_, err = registry.Client(context.Background(), issuer2)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, registry.Len())
+		again, err := registry.Client(context.Background(), issuer1)
+		assert.NoError(t, err)
+		assert.True(t, client1 == again)
+	})
+
+	t.Run("pass - simultaneous creations collapsed", func(t *testing.T) {
+		var created int32
+		release := make(chan struct{})
+		registry := NewTenantRegistry(TenantRegistryOptions{
+			NewClient: func(ctx context.Context, issuer string) (*JWKClient, error) {
+				atomic.AddInt32(&created, 1)
+				<-release
+				return NewJWKClient(JWKClientOptions{URI: issuer + ".well-known/jwks.json"}, nil), nil
+			},
+			TrustedIssuer: TrustIssuers(issuer1),
+		})
+		defer registry.Stop()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := registry.Client(context.Background(), issuer1)
+				assert.NoError(t, err)
+			}()
+		}
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+		wg.Wait()
+		assert.Equal(t, int32(1), atomic.LoadInt32(&created))
+	})
+
+	t.Run("fail - creation failures cached", func(t *testing.T) {
+		var created int32
+		failure := errors.New("discovery failed")
+		registry := NewTenantRegistry(TenantRegistryOptions{
+			NewClient: func(ctx context.Context, issuer string) (*JWKClient, error) {
+				atomic.AddInt32(&created, 1)
+				return nil, failure
+			},
+			TrustedIssuer: TrustIssuers(issuer1),
+			FailureTTL:    time.Minute,
+		})
+
+		for i := 0; i < 2; i++ {
+			_, err := registry.Client(context.Background(), issuer1)
+			assert.Equal(t, failure, err)
+		}
+		assert.Equal(t, int32(1), atomic.LoadInt32(&created))
+		assert.Equal(t, 0, registry.Len())
+
+		// Removing the issuer forgets its failure.
+		registry.Remove(issuer1)
+		_, err := registry.Client(context.Background(), issuer1)
+		assert.Equal(t, failure, err)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&created))
+	})
+
+	t.Run("pass - creation not canceled with the first caller", func(t *testing.T) {
+		received, release := make(chan struct{}), make(chan struct{})
+		registry := NewTenantRegistry(TenantRegistryOptions{
+			NewClient: func(ctx context.Context, issuer string) (*JWKClient, error) {
+				close(received)
+				select {
+				case <-release:
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+				return NewJWKClient(JWKClientOptions{URI: issuer + ".well-known/jwks.json"}, nil), nil
+			},
+			TrustedIssuer: TrustIssuers(issuer1),
+		})
+		defer registry.Stop()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errs := make(chan error)
+		go func() {
+			_, err := registry.Client(ctx, issuer1)
+			errs <- err
+		}()
+		<-received
+		clients := make(chan *JWKClient)
+		go func() {
+			client, err := registry.Client(context.Background(), issuer1)
+			assert.NoError(t, err)
+			clients <- client
+		}()
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+		assert.Equal(t, context.Canceled, <-errs)
+
+		close(release)
+		assert.NotNil(t, <-clients)
+	})
+
+	t.Run("fail - untrusted issuer not discovered", func(t *testing.T) {
+		var created, evaluated int32
+		policyErr := errors.New("database unavailable")
+		registry := NewTenantRegistry(TenantRegistryOptions{
+			NewClient: func(ctx context.Context, issuer string) (*JWKClient, error) {
+				atomic.AddInt32(&created, 1)
+				return NewJWKClient(JWKClientOptions{URI: issuer + ".well-known/jwks.json"}, nil), nil
+			},
+			TrustedIssuer: func(ctx context.Context, issuer string) (bool, error) {
+				atomic.AddInt32(&evaluated, 1)
+				switch issuer {
+				case issuer1:
+					return true, nil
+				case issuer2:
+					return false, nil
+				default:
+					return false, policyErr
+				}
+			},
+		})
+		defer registry.Stop()
+
+		_, err := registry.Client(context.Background(), issuer1)
+		assert.NoError(t, err)
+		for i := 0; i < 2; i++ {
+			_, err = registry.Client(context.Background(), issuer2)
+			assert.Equal(t, ErrUnknownIssuer, err)
+			_, err = registry.Client(context.Background(), "https://attacker.example.com/")
+			assert.Equal(t, policyErr, err)
+		}
+		assert.Equal(t, int32(1), atomic.LoadInt32(&created))
+		assert.Equal(t, int32(3), atomic.LoadInt32(&evaluated))
+
+		validator := NewValidator(NewConfigurationTrustProvider(registry, defaultAudience, ""), nil, WithIssuerValidator(func(string) bool { return true }))
+		_, err = validator.ValidateRawToken(token2)
+		assert.True(t, errors.Is(err, ErrUnknownIssuer), "got %v", err)
+	})
+
+	t.Run("fail - no issuer policy", func(t *testing.T) {
+		var created int32
+		registry := NewTenantRegistry(TenantRegistryOptions{
+			NewClient: func(ctx context.Context, issuer string) (*JWKClient, error) {
+				atomic.AddInt32(&created, 1)
+				return NewJWKClient(JWKClientOptions{URI: issuer + ".well-known/jwks.json"}, nil), nil
+			},
+		})
+		validator := NewValidator(NewConfigurationTrustProvider(registry, defaultAudience, ""), nil, WithIssuerValidator(func(string) bool { return true }))
+		_, err := validator.ValidateRawToken(token1)
+		assert.True(t, errors.Is(err, ErrUnknownIssuer), "got %v", err)
+		assert.Equal(t, int32(0), atomic.LoadInt32(&created))
+	})
+
+	t.Run("fail - no issuer", func(t *testing.T) {
+		registry := NewTenantRegistry(TenantRegistryOptions{TrustedIssuer: TrustIssuers(issuer1)})
+		validator := NewValidator(NewConfigurationTrustProvider(registry, defaultAudience, ""), nil)
+		_, err := validator.ValidateRawToken(getTestToken(defaultAudience, "", time.Now().Add(time.Hour), jose.RS256, *key1))
+		assert.True(t, errors.Is(err, ErrUnknownIssuer), "got %v", err)
+	})
+}
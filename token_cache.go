@@ -0,0 +1,97 @@
+package auth0
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// DefaultTokenCacheSize is the default maximum number of access
+// tokens cached by a TokenExchange or a RefreshTokens.
+const DefaultTokenCacheSize = 1000
+
+// tokenCache caches at most maxEntries access tokens, evicting the least
+// recently used ones beyond. The tokens expiring within delta are removed
+// when looked up.
+type tokenCache[K comparable] struct {
+	maxEntries int
+	delta      time.Duration
+
+	mu      sync.Mutex
+	entries map[K]*list.Element
+	order   *list.List // Front is the most recently used token
+}
+
+type tokenCacheEntry[K comparable] struct {
+	key   K
+	token *Token
+}
+
+func newTokenCache[K comparable](maxEntries int, delta time.Duration) *tokenCache[K] {
+	return &tokenCache[K]{
+		maxEntries: maxEntries,
+		delta:      delta,
+		entries:    map[K]*list.Element{},
+		order:      list.New(),
+	}
+}
+
+// get returns the token of the key unless it expires within the
+// delta at now, marking it as recently used.
+func (c *tokenCache[K]) get(key K, now time.Time) (*Token, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	token := elem.Value.(*tokenCacheEntry[K]).token
+	if token.expired(now, c.delta) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return token, true
+}
+
+// add caches the token under the key, evicting the least recently used
+// tokens beyond maxEntries. Tokens with no expiry are not cached, and
+// remove the token cached under the key.
+func (c *tokenCache[K]) add(key K, token *Token) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if token.Expiry.IsZero() {
+		c.removeLocked(key)
+		return
+	}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*tokenCacheEntry[K]).token = token
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&tokenCacheEntry[K]{key: key, token: token})
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*tokenCacheEntry[K]).key)
+	}
+}
+
+// remove removes the token cached under the key, if any.
+func (c *tokenCache[K]) remove(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.removeLocked(key)
+}
+
+// removeLocked removes the token cached under the key.
+// The caller must hold mu.
+func (c *tokenCache[K]) removeLocked(key K) {
+	if elem, ok := c.entries[key]; ok {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+	}
+}
@@ -0,0 +1,42 @@
+package auth0
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenCache(t *testing.T) {
+	now := time.Now()
+	cache := newTokenCache[string](2, time.Minute)
+
+	cache.add("fresh", &Token{AccessToken: "fresh", Expiry: now.Add(time.Hour)})
+	cache.add("expiring", &Token{AccessToken: "expiring", Expiry: now.Add(30 * time.Second)})
+	token, ok := cache.get("fresh", now)
+	assert.True(t, ok)
+	assert.Equal(t, "fresh", token.AccessToken)
+
+	// The tokens expiring within the delta are removed when looked up.
+	_, ok = cache.get("expiring", now)
+	assert.False(t, ok)
+	assert.Equal(t, 1, cache.order.Len())
+
+	// Tokens with no expiry are not cached and replace the cached one.
+	cache.add("fresh", &Token{AccessToken: "no expiry"})
+	_, ok = cache.get("fresh", now)
+	assert.False(t, ok)
+
+	// The least recently used tokens are evicted beyond the maximum.
+	for _, key := range []string{"a", "b", "a", "c"} {
+		cache.add(key, &Token{AccessToken: key, Expiry: now.Add(time.Hour)})
+	}
+	_, ok = cache.get("b", now)
+	assert.False(t, ok)
+	_, ok = cache.get("a", now)
+	assert.True(t, ok)
+	cache.remove("a")
+	_, ok = cache.get("a", now)
+	assert.False(t, ok)
+	assert.Equal(t, 1, cache.order.Len())
+}
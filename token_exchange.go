@@ -0,0 +1,168 @@
+package auth0
+
+import (
+	"context"
+	"crypto/sha256"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Token types of RFC 8693 token exchange.
+const (
+	TokenTypeAccessToken = "urn:ietf:params:oauth:token-type:access_token"
+	TokenTypeIDToken     = "urn:ietf:params:oauth:token-type:id_token"
+	TokenTypeJWT         = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+// TokenExchangeOptions configures the token exchange (RFC 8693) client.
+type TokenExchangeOptions struct {
+	// TokenURL is the token endpoint, such as
+	// https://mydomain.eu.auth0.com/oauth/token.
+	TokenURL string
+	// ClientID and ClientSecret authenticate the client
+	// to the endpoint in the body of the request.
+	ClientID     string
+	ClientSecret string
+	// PrivateKeyJWT authenticates the client with signed
+	// assertions instead of ClientSecret when set.
+	PrivateKeyJWT *PrivateKeyJWT
+	// SubjectTokenType is the type of the exchanged tokens.
+	// Defaults to TokenTypeAccessToken.
+	SubjectTokenType string
+	// RequestedTokenType is the type of the requested tokens,
+	// chosen by the endpoint when empty.
+	RequestedTokenType string
+	// Scopes are the scopes requested for the downstream audiences.
+	Scopes []string
+	Client *http.Client
+	// ExpiryDelta is how long before its expiry the token is requested
+	// again, so it does not expire in flight. Defaults to DefaultTokenExpiryDelta.
+	ExpiryDelta time.Duration
+	// RequestTimeout bounds each exchange, which is not canceled with the
+	// callers waiting for it. Defaults to DefaultTokenRequestTimeout.
+	RequestTimeout time.Duration
+	// MaxCacheEntries is the maximum number of cached tokens, the least
+	// recently used ones being evicted beyond. Defaults to
+	// DefaultTokenCacheSize.
+	MaxCacheEntries int
+}
+
+// TokenExchange exchanges the tokens of incoming requests for tokens of
+// downstream audiences with the token exchange grant (RFC 8693), such as
+// when fanning out to internal services on behalf of the caller. The tokens
+// are cached by subject token and audience until shortly before their expiry.
+type TokenExchange struct {
+	options TokenExchangeOptions
+
+	tokens *tokenCache[tokenExchangeKey] // Exchanged tokens
+	sf     singleflight.Group            // Used to collapse the exchanges of the same token
+}
+
+type tokenExchangeKey struct {
+	subject  [sha256.Size]byte
+	audience string
+}
+
+// NewTokenExchange creates a new TokenExchange
+// instance from the provided options.
+func NewTokenExchange(options TokenExchangeOptions) *TokenExchange {
+	if options.Client == nil {
+		options.Client = http.DefaultClient
+	}
+	if options.SubjectTokenType == "" {
+		options.SubjectTokenType = TokenTypeAccessToken
+	}
+	if options.ExpiryDelta <= 0 {
+		options.ExpiryDelta = DefaultTokenExpiryDelta
+	}
+	if options.RequestTimeout <= 0 {
+		options.RequestTimeout = DefaultTokenRequestTimeout
+	}
+	if options.MaxCacheEntries <= 0 {
+		options.MaxCacheEntries = DefaultTokenCacheSize
+	}
+	return &TokenExchange{
+		options: options,
+		tokens:  newTokenCache[tokenExchangeKey](options.MaxCacheEntries, options.ExpiryDelta),
+	}
+}
+
+// Exchange returns a token of the audience on behalf of the subject token,
+// exchanging it unless a token of the audience has already been issued for
+// the subject and is not about to expire. Simultaneous exchanges of the same
+// token for the same audience result in a single request, every caller
+// waiting for it until its own context is done.
+func (e *TokenExchange) Exchange(ctx context.Context, subjectToken, audience string) (*Token, error) {
+	key := tokenExchangeKey{subject: sha256.Sum256([]byte(subjectToken)), audience: audience}
+	if token, ok := e.tokens.get(key, time.Now()); ok {
+		return token, nil
+	}
+
+	ch := e.sf.DoChan(string(key.subject[:])+audience, func() (interface{}, error) {
+		ctx, cancel := detach(ctx, e.options.RequestTimeout)
+		defer cancel()
+		form, err := e.form(subjectToken, audience)
+		if err != nil {
+			return nil, err
+		}
+		token, err := requestToken(ctx, e.options.Client, e.options.TokenURL, form)
+		if err != nil {
+			return nil, err
+		}
+		// Tokens with no expiry are not cached, as the subject tokens expire.
+		e.tokens.add(key, token)
+		return token, nil
+	})
+
+	var res singleflight.Result
+	select {
+	case res = <-ch:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if res.Err != nil {
+		return nil, res.Err
+	}
+	return res.Val.(*Token), nil
+}
+
+// TokenSource returns the source of the tokens of the audience on behalf of
+// the subject token, such as for the oauth2 adapter of the outgoing requests.
+func (e *TokenExchange) TokenSource(subjectToken, audience string) TokenSource {
+	return &exchangedTokenSource{exchange: e, subjectToken: subjectToken, audience: audience}
+}
+
+func (e *TokenExchange) form(subjectToken, audience string) (url.Values, error) {
+	form := url.Values{
+		"grant_type":         {"urn:ietf:params:oauth:grant-type:token-exchange"},
+		"subject_token":      {subjectToken},
+		"subject_token_type": {e.options.SubjectTokenType},
+	}
+	if err := authenticateClient(form, e.options.ClientID, e.options.ClientSecret, e.options.PrivateKeyJWT, e.options.TokenURL); err != nil {
+		return nil, err
+	}
+	if audience != "" {
+		form.Set("audience", audience)
+	}
+	if e.options.RequestedTokenType != "" {
+		form.Set("requested_token_type", e.options.RequestedTokenType)
+	}
+	if len(e.options.Scopes) > 0 {
+		form.Set("scope", strings.Join(e.options.Scopes, " "))
+	}
+	return form, nil
+}
+
+type exchangedTokenSource struct {
+	exchange     *TokenExchange
+	subjectToken string
+	audience     string
+}
+
+func (s *exchangedTokenSource) Token(ctx context.Context) (*Token, error) {
+	return s.exchange.Exchange(ctx, s.subjectToken, s.audience)
+}
@@ -0,0 +1,166 @@
+package auth0
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func genTestTokenExchangeServer(t *testing.T, counter *uint64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint64(counter, 1)
+		assert.Equal(t, "urn:ietf:params:oauth:grant-type:token-exchange", r.PostFormValue("grant_type"))
+		assert.Equal(t, TokenTypeAccessToken, r.PostFormValue("subject_token_type"))
+		assert.Equal(t, "client", r.PostFormValue("client_id"))
+		assert.Equal(t, "secret", r.PostFormValue("client_secret"))
+		assert.Equal(t, "read:orders", r.PostFormValue("scope"))
+
+		if r.PostFormValue("subject_token") == "revoked" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token":      r.PostFormValue("subject_token") + "@" + r.PostFormValue("audience"),
+			"issued_token_type": TokenTypeAccessToken,
+			"token_type":        "Bearer",
+			"expires_in":        3600,
+		})
+	}))
+}
+
+func TestTokenExchange(t *testing.T) {
+	var counter uint64
+	ts := genTestTokenExchangeServer(t, &counter)
+	defer ts.Close()
+
+	exchange := NewTokenExchange(TokenExchangeOptions{
+		TokenURL:     ts.URL,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Scopes:       []string{"read:orders"},
+	})
+
+	tests := []struct {
+		name            string
+		subject         string
+		audience        string
+		expectedToken   string
+		expectedCounter uint64
+	}{
+		{name: "pass - exchanged", subject: "user1", audience: "orders", expectedToken: "user1@orders", expectedCounter: 1},
+		{name: "pass - cached", subject: "user1", audience: "orders", expectedToken: "user1@orders", expectedCounter: 1},
+		{name: "pass - other audience", subject: "user1", audience: "billing", expectedToken: "user1@billing", expectedCounter: 2},
+		{name: "pass - other subject", subject: "user2", audience: "orders", expectedToken: "user2@orders", expectedCounter: 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token, err := exchange.Exchange(context.Background(), test.subject, test.audience)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedToken, token.AccessToken)
+			assert.Equal(t, TokenTypeAccessToken, token.IssuedTokenType)
+			assert.Equal(t, test.expectedCounter, atomic.LoadUint64(&counter))
+		})
+	}
+
+	t.Run("pass - token source", func(t *testing.T) {
+		token, err := exchange.TokenSource("user1", "billing").Token(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, "user1@billing", token.AccessToken)
+		assert.Equal(t, uint64(3), atomic.LoadUint64(&counter))
+	})
+
+	t.Run("fail - rejected subject token", func(t *testing.T) {
+		_, err := exchange.Exchange(context.Background(), "revoked", "orders")
+		var tokenErr *TokenError
+		if assert.True(t, errors.As(err, &tokenErr)) {
+			assert.Equal(t, "invalid_grant", tokenErr.Code)
+		}
+		_, err = exchange.Exchange(context.Background(), "revoked", "orders")
+		assert.True(t, errors.Is(err, ErrTokenRequestFailed))
+		assert.Equal(t, uint64(5), atomic.LoadUint64(&counter), "failed exchanges should not be cached")
+	})
+}
+
+func TestTokenExchangeCacheSize(t *testing.T) {
+	var counter uint64
+	ts := genTestTokenExchangeServer(t, &counter)
+	defer ts.Close()
+
+	exchange := NewTokenExchange(TokenExchangeOptions{
+		TokenURL:        ts.URL,
+		ClientID:        "client",
+		ClientSecret:    "secret",
+		Scopes:          []string{"read:orders"},
+		MaxCacheEntries: 2,
+	})
+	assert.Equal(t, DefaultTokenCacheSize, NewTokenExchange(TokenExchangeOptions{}).options.MaxCacheEntries)
+
+	for _, subject := range []string{"user1", "user2", "user1", "user3"} {
+		_, err := exchange.Exchange(context.Background(), subject, "orders")
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, uint64(3), atomic.LoadUint64(&counter))
+
+	// The least recently used token was evicted, not the one used again.
+	_, err := exchange.Exchange(context.Background(), "user1", "orders")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), atomic.LoadUint64(&counter))
+	_, err = exchange.Exchange(context.Background(), "user2", "orders")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4), atomic.LoadUint64(&counter))
+}
+
+func TestTokenExchangeCanceled(t *testing.T) {
+	var counter uint64
+	exchanges := genTestTokenExchangeServer(t, &counter)
+	defer exchanges.Close()
+	received, release := make(chan struct{}), make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadUint64(&counter) == 0 {
+			close(received)
+			<-release
+		}
+		exchanges.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	exchange := NewTokenExchange(TokenExchangeOptions{
+		TokenURL:     ts.URL,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Scopes:       []string{"read:orders"},
+	})
+
+	// The first caller leaves, the others get the token of the shared exchange.
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error)
+	go func() {
+		_, err := exchange.Exchange(ctx, "user1", "orders")
+		errs <- err
+	}()
+	<-received
+	shared := make(chan *Token)
+	go func() {
+		token, err := exchange.Exchange(context.Background(), "user1", "orders")
+		assert.NoError(t, err)
+		shared <- token
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	assert.Equal(t, context.Canceled, <-errs)
+
+	close(release)
+	assert.Equal(t, "user1@orders", (<-shared).AccessToken)
+	assert.Equal(t, uint64(1), atomic.LoadUint64(&counter))
+}
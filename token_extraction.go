@@ -1,8 +1,13 @@
 package auth0
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"gopkg.in/square/go-jose.v2/jwt"
@@ -14,6 +19,9 @@ var (
 	ErrTokenNotFound = errors.New("Token not found")
 	// ErrNilRequest is returned by the FromHeader if the request is nil
 	ErrNilRequest = errors.New("Request nil")
+	// ErrFormTooLarge is returned by the FromForm extractor if the
+	// form body of the request is larger than maxFormSize.
+	ErrFormTooLarge = errors.New("form body too large")
 )
 
 // RequestTokenExtractor can extract a JWT
@@ -31,57 +39,251 @@ func (f RequestTokenExtractorFunc) Extract(r *http.Request) (*jwt.JSONWebToken,
 	return f(r)
 }
 
+// RawRequestTokenExtractor is implemented by the extractors able to return
+// the compact serialized token of the request, such as the ones of this
+// package. The validation cache of WithValidationCache requires it.
+type RawRequestTokenExtractor interface {
+	RequestTokenExtractor
+	ExtractRaw(r *http.Request) (string, error)
+}
+
+// RawRequestTokenExtractorFunc function conforming to the
+// RawRequestTokenExtractor interface, returning the compact serialized token.
+type RawRequestTokenExtractorFunc func(r *http.Request) (string, error)
+
+// ExtractRaw calls f(r)
+func (f RawRequestTokenExtractorFunc) ExtractRaw(r *http.Request) (string, error) {
+	return f(r)
+}
+
+// Extract parses the token returned by f(r), rejecting unsecured tokens.
+func (f RawRequestTokenExtractorFunc) Extract(r *http.Request) (*jwt.JSONWebToken, error) {
+	raw, err := f(r)
+	if err != nil {
+		return nil, err
+	}
+	return parseSigned(raw)
+}
+
+// TokenNotFoundError is returned by the extractor of FromMultiple when
+// none of the extractors found a token. It matches ErrTokenNotFound.
+type TokenNotFoundError struct {
+	// Extractors is the number of extractors tried.
+	Extractors int
+}
+
+func (e *TokenNotFoundError) Error() string {
+	return fmt.Sprintf("token not found by any of the %d extractors", e.Extractors)
+}
+
+// Is makes errors.Is match the error with ErrTokenNotFound.
+func (e *TokenNotFoundError) Is(target error) bool {
+	return target == ErrTokenNotFound
+}
+
 // FromMultiple combines multiple extractors by chaining.
+// The first token found is returned, an extractor failing with an error
+// other than ErrTokenNotFound stops the chain. A *TokenNotFoundError is
+// returned when none of the extractors found a token.
 func FromMultiple(extractors ...RequestTokenExtractor) RequestTokenExtractor {
+	raws := make([]RawRequestTokenExtractor, 0, len(extractors))
+	for _, e := range extractors {
+		if raw, ok := e.(RawRequestTokenExtractor); ok {
+			raws = append(raws, raw)
+		}
+	}
+	// The combined extractor returns the raw token when all the extractors do.
+	if len(raws) == len(extractors) {
+		return RawRequestTokenExtractorFunc(func(r *http.Request) (string, error) {
+			for _, e := range raws {
+				raw, err := e.ExtractRaw(r)
+				if errors.Is(err, ErrTokenNotFound) {
+					continue
+				} else if err != nil {
+					return "", err
+				}
+				return raw, nil
+			}
+			return "", &TokenNotFoundError{Extractors: len(extractors)}
+		})
+	}
+
 	return RequestTokenExtractorFunc(func(r *http.Request) (*jwt.JSONWebToken, error) {
 		for _, e := range extractors {
 			token, err := e.Extract(r)
-			if err == ErrTokenNotFound {
+			if errors.Is(err, ErrTokenNotFound) {
 				continue
 			} else if err != nil {
 				return nil, err
 			}
 			return token, nil
 		}
-		return nil, ErrTokenNotFound
+		return nil, &TokenNotFoundError{Extractors: len(extractors)}
 	})
 }
 
 // FromHeader looks for the request in the
-// authentication header or call ParseMultipartForm
-// if not present.
-// TODO: Implement parsing form data.
+// authentication header. Combine it with FromForm
+// to look in form data when not present.
 func FromHeader(r *http.Request) (*jwt.JSONWebToken, error) {
-	if r == nil {
-		return nil, ErrNilRequest
-	}
-	raw := ""
-	if h := r.Header.Get("Authorization"); len(h) > 7 && strings.EqualFold(h[0:7], "BEARER ") {
-		raw = h[7:]
-	}
-	if raw == "" {
-		return nil, ErrTokenNotFound
+	return FromHeaderWithScheme("Bearer").Extract(r)
+}
+
+// FromHeaderWithScheme returns an extractor looking for the JWT in the
+// Authorization header using any of the schemes, such as "Bearer" or "JWT",
+// compared case-insensitively. Extra whitespace around the scheme and the
+// token is ignored. The Bearer scheme is used when no scheme is provided.
+func FromHeaderWithScheme(schemes ...string) RequestTokenExtractor {
+	if len(schemes) == 0 {
+		schemes = []string{"Bearer"}
 	}
-	return jwt.ParseSigned(raw)
+	return RawRequestTokenExtractorFunc(func(r *http.Request) (string, error) {
+		if r == nil {
+			return "", ErrNilRequest
+		}
+		h := strings.TrimSpace(r.Header.Get("Authorization"))
+		i := strings.IndexAny(h, " \t")
+		if i < 0 {
+			return "", ErrTokenNotFound
+		}
+		scheme, raw := h[:i], strings.TrimSpace(h[i:])
+		for _, s := range schemes {
+			if strings.EqualFold(scheme, s) && raw != "" {
+				return raw, nil
+			}
+		}
+		return "", ErrTokenNotFound
+	})
 }
 
 // FromParams returns the JWT when passed as the URL query param "token".
 func FromParams(r *http.Request) (*jwt.JSONWebToken, error) {
-	if r == nil {
-		return nil, ErrNilRequest
-	}
-	raw := r.URL.Query().Get("token")
-	if raw == "" {
-		return nil, ErrTokenNotFound
-	}
-	return jwt.ParseSigned(raw)
+	return FromParameter("token").Extract(r)
+}
+
+// FromParameter returns an extractor looking for the JWT in the URL query
+// parameter with the provided name, for requests that cannot carry headers
+// such as WebSocket upgrades or signed download links.
+// Tokens in URLs end up in logs and browser history, so this extractor is
+// never used by default and should only be combined with short-lived tokens.
+func FromParameter(name string) RequestTokenExtractor {
+	return RawRequestTokenExtractorFunc(func(r *http.Request) (string, error) {
+		if r == nil {
+			return "", ErrNilRequest
+		}
+		raw := r.URL.Query().Get(name)
+		if raw == "" {
+			return "", ErrTokenNotFound
+		}
+		return raw, nil
+	})
 }
 
 // FromCookie returns the JWT when passed in a Cookie as "access_token".
 func FromCookie(r *http.Request) (*jwt.JSONWebToken, error) {
-	raw, err := r.Cookie("access_token")
-	if err != nil {
-		return nil, ErrTokenNotFound
-	}
-	return jwt.ParseSigned(raw.Value)
+	return FromCookieNamed("access_token").Extract(r)
+}
+
+// FromCookieNamed returns an extractor looking for the JWT in the cookie
+// with the provided name, such as an HttpOnly cookie set for a SPA.
+// The value of the cookie is URL-decoded and may be prefixed with "Bearer ".
+func FromCookieNamed(name string) RequestTokenExtractor {
+	return RawRequestTokenExtractorFunc(func(r *http.Request) (string, error) {
+		if r == nil {
+			return "", ErrNilRequest
+		}
+		cookie, err := r.Cookie(name)
+		if err != nil {
+			return "", ErrTokenNotFound
+		}
+		raw, err := url.QueryUnescape(cookie.Value)
+		if err != nil {
+			return "", err
+		}
+		if len(raw) > 7 && strings.EqualFold(raw[0:7], "BEARER ") {
+			raw = raw[7:]
+		}
+		if raw == "" {
+			return "", ErrTokenNotFound
+		}
+		return raw, nil
+	})
+}
+
+// maxFormSize is the size limit of the form
+// bodies read by FromForm, as in http.Request.ParseForm.
+const maxFormSize = 10 << 20
+
+// FromForm returns an extractor looking for the JWT in the field with
+// the provided name, such as "access_token", of an
+// application/x-www-form-urlencoded POST body.
+// The body is restored once read, so handlers can still read it.
+func FromForm(field string) RequestTokenExtractor {
+	return RawRequestTokenExtractorFunc(func(r *http.Request) (string, error) {
+		if r == nil {
+			return "", ErrNilRequest
+		}
+		if r.Method != http.MethodPost || r.Body == nil {
+			return "", ErrTokenNotFound
+		}
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil || mediaType != "application/x-www-form-urlencoded" {
+			return "", ErrTokenNotFound
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxFormSize+1))
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+		if err != nil {
+			return "", err
+		}
+		if len(body) > maxFormSize {
+			return "", ErrFormTooLarge
+		}
+
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return "", err
+		}
+		raw := values.Get(field)
+		if raw == "" {
+			return "", ErrTokenNotFound
+		}
+		return raw, nil
+	})
+}
+
+// FromWebSocketProtocol returns an extractor looking for the JWT in the
+// Sec-WebSocket-Protocol header, where browsers unable to set the
+// Authorization header of a WebSocket send it as the protocol following
+// the marker, such as "access_token" in "access_token, <token>".
+// The token is removed from the protocols of the request, so the upgrader
+// does not negotiate it and can answer with the marker instead.
+func FromWebSocketProtocol(marker string) RequestTokenExtractor {
+	return RawRequestTokenExtractorFunc(func(r *http.Request) (string, error) {
+		if r == nil {
+			return "", ErrNilRequest
+		}
+		var protocols []string
+		for _, h := range r.Header.Values("Sec-WebSocket-Protocol") {
+			for _, p := range strings.Split(h, ",") {
+				if p = strings.TrimSpace(p); p != "" {
+					protocols = append(protocols, p)
+				}
+			}
+		}
+
+		for i, p := range protocols {
+			if p != marker || i+1 == len(protocols) {
+				continue
+			}
+			raw := protocols[i+1]
+			protocols = append(protocols[:i+1], protocols[i+2:]...)
+			r.Header.Set("Sec-WebSocket-Protocol", strings.Join(protocols, ", "))
+			return raw, nil
+		}
+		return "", ErrTokenNotFound
+	})
 }
@@ -1,10 +1,13 @@
 package auth0
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -182,3 +185,198 @@ func TestFromParams(t *testing.T) {
 		})
 	}
 }
+
+func TestFromCookieNamed(t *testing.T) {
+	referenceToken := getTestToken(defaultAudience, defaultIssuer, time.Now(), jose.HS256, defaultSecret)
+
+	tests := []struct {
+		name    string
+		cookie  *http.Cookie
+		wantErr error
+	}{
+		{"valid cookie", &http.Cookie{Name: "session", Value: referenceToken}, nil},
+		{"url-encoded bearer prefix", &http.Cookie{Name: "session", Value: "Bearer%20" + referenceToken}, nil},
+		{"other cookie", &http.Cookie{Name: "access_token", Value: referenceToken}, ErrTokenNotFound},
+		{"empty cookie", &http.Cookie{Name: "session", Value: ""}, ErrTokenNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("", "http://example.com", nil)
+			r.AddCookie(tt.cookie)
+			token, err := FromCookieNamed("session").Extract(r)
+			if err != tt.wantErr {
+				t.Errorf("FromCookieNamed() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && token == nil {
+				t.Error("FromCookieNamed() should return the token")
+			}
+		})
+	}
+
+	if _, err := FromCookieNamed("session").Extract(nil); err != ErrNilRequest {
+		t.Errorf("FromCookieNamed() error = %v, want %v", err, ErrNilRequest)
+	}
+}
+
+func TestFromParameter(t *testing.T) {
+	referenceToken := getTestToken(defaultAudience, defaultIssuer, time.Now(), jose.HS256, defaultSecret)
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr error
+	}{
+		{"valid parameter", "http://example.com?access_token=" + referenceToken, nil},
+		{"other parameter", "http://example.com?token=" + referenceToken, ErrTokenNotFound},
+		{"empty parameter", "http://example.com?access_token=", ErrTokenNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := FromParameter("access_token").Extract(httptest.NewRequest("", tt.url, nil))
+			if err != tt.wantErr {
+				t.Errorf("FromParameter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFromMultipleNotFound(t *testing.T) {
+	extractor := FromMultiple(RequestTokenExtractorFunc(FromHeader), FromCookieNamed("session"), FromMultiple(RequestTokenExtractorFunc(FromParams)))
+	_, err := extractor.Extract(httptest.NewRequest("", "http://example.com", nil))
+
+	var notFound *TokenNotFoundError
+	if !errors.As(err, &notFound) || notFound.Extractors != 3 {
+		t.Errorf("FromMultiple() error = %v, want a *TokenNotFoundError for 3 extractors", err)
+	}
+	if !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("FromMultiple() error = %v, should match ErrTokenNotFound", err)
+	}
+}
+
+func TestFromForm(t *testing.T) {
+	referenceToken := getTestToken(defaultAudience, defaultIssuer, time.Now(), jose.HS256, defaultSecret)
+
+	tests := []struct {
+		name        string
+		method      string
+		contentType string
+		body        string
+		wantErr     error
+	}{
+		{"valid form", http.MethodPost, "application/x-www-form-urlencoded", "state=abc&access_token=" + referenceToken, nil},
+		{"form with charset", http.MethodPost, "application/x-www-form-urlencoded; charset=utf-8", "access_token=" + referenceToken, nil},
+		{"other field", http.MethodPost, "application/x-www-form-urlencoded", "token=" + referenceToken, ErrTokenNotFound},
+		{"json body", http.MethodPost, "application/json", `{"access_token":"` + referenceToken + `"}`, ErrTokenNotFound},
+		{"get request", http.MethodGet, "application/x-www-form-urlencoded", "access_token=" + referenceToken, ErrTokenNotFound},
+		{"too large", http.MethodPost, "application/x-www-form-urlencoded", "access_token=" + strings.Repeat("a", maxFormSize), ErrFormTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "http://example.com", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+			_, err := FromForm("access_token").Extract(r)
+			if err != tt.wantErr {
+				t.Errorf("FromForm() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			body, _ := io.ReadAll(r.Body)
+			if string(body) != tt.body {
+				t.Error("FromForm() should restore the body of the request")
+			}
+		})
+	}
+}
+
+func TestFromWebSocketProtocol(t *testing.T) {
+	referenceToken := getTestToken(defaultAudience, defaultIssuer, time.Now(), jose.HS256, defaultSecret)
+
+	tests := []struct {
+		name              string
+		protocols         []string
+		wantErr           error
+		expectedProtocols string
+	}{
+		{"token after marker", []string{"access_token, " + referenceToken}, nil, "access_token"},
+		{"other protocols", []string{"graphql-ws, access_token, " + referenceToken}, nil, "graphql-ws, access_token"},
+		{"several headers", []string{"graphql-ws", "access_token," + referenceToken}, nil, "graphql-ws, access_token"},
+		{"no marker", []string{"graphql-ws, " + referenceToken}, ErrTokenNotFound, "graphql-ws, " + referenceToken},
+		{"marker without token", []string{"graphql-ws, access_token"}, ErrTokenNotFound, "graphql-ws, access_token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("", "http://example.com", nil)
+			for _, p := range tt.protocols {
+				r.Header.Add("Sec-WebSocket-Protocol", p)
+			}
+			_, err := FromWebSocketProtocol("access_token").Extract(r)
+			if err != tt.wantErr {
+				t.Errorf("FromWebSocketProtocol() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := strings.Join(r.Header.Values("Sec-WebSocket-Protocol"), ", "); got != tt.expectedProtocols {
+				t.Errorf("FromWebSocketProtocol() protocols = %q, want %q", got, tt.expectedProtocols)
+			}
+		})
+	}
+}
+
+func TestFromHeaderWithScheme(t *testing.T) {
+	referenceToken := getTestToken(defaultAudience, defaultIssuer, time.Now(), jose.HS256, defaultSecret)
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantErr       error
+	}{
+		{"bearer scheme", "Bearer " + referenceToken, nil},
+		{"jwt scheme", "JWT " + referenceToken, nil},
+		{"lower case scheme", "jwt " + referenceToken, nil},
+		{"extra whitespace", "  Token \t " + referenceToken + " ", nil},
+		{"other scheme", "Basic dXNlcjpwYXNz", ErrTokenNotFound},
+		{"scheme without token", "JWT   ", ErrTokenNotFound},
+		{"token without scheme", referenceToken, ErrTokenNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("", "http://example.com", nil)
+			r.Header.Set("Authorization", tt.authorization)
+			_, err := FromHeaderWithScheme("Bearer", "JWT", "Token").Extract(r)
+			if err != tt.wantErr {
+				t.Errorf("FromHeaderWithScheme() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRawRequestTokenExtractor(t *testing.T) {
+	token := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, defaultSecret)
+	req := httptest.NewRequest("GET", "http://localhost?token="+token, nil)
+	req.Header.Add("Authorization", "Bearer "+token)
+
+	tests := []struct {
+		name      string
+		extractor RequestTokenExtractor
+		raw       bool
+	}{
+		{"header with scheme", FromHeaderWithScheme(), true},
+		{"parameter", FromParameter("token"), true},
+		{"multiple raw extractors", FromMultiple(FromHeaderWithScheme(), FromParameter("token")), true},
+		{"multiple with a func extractor", FromMultiple(RequestTokenExtractorFunc(FromHeader), FromParameter("token")), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			extractor, ok := test.extractor.(RawRequestTokenExtractor)
+			if ok != test.raw {
+				t.Fatalf("Extractor should implement RawRequestTokenExtractor: %v, but got: %v", test.raw, ok)
+			}
+			if !ok {
+				return
+			}
+			raw, err := extractor.ExtractRaw(req)
+			if err != nil || raw != token {
+				t.Errorf("Raw token should have been extracted, but got: %q, %v", raw, err)
+			}
+		})
+	}
+}
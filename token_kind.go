@@ -0,0 +1,109 @@
+package auth0
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// TokenKind tells the tokens issued to users from the machine-to-machine
+// tokens issued to applications with the client credentials grant.
+type TokenKind int
+
+const (
+	// UserToken is the kind of the tokens issued to users.
+	UserToken TokenKind = iota + 1
+	// MachineToken is the kind of the machine-to-machine tokens issued to
+	// applications with the client credentials grant.
+	MachineToken
+)
+
+func (k TokenKind) String() string {
+	switch k {
+	case UserToken:
+		return "user"
+	case MachineToken:
+		return "machine-to-machine"
+	}
+	return fmt.Sprintf("TokenKind(%d)", int(k))
+}
+
+// Claims of the machine-to-machine tokens issued by Auth0.
+const (
+	grantTypeClientCredentials = "client-credentials"
+	clientsSubjectSuffix       = "@clients"
+)
+
+var (
+	// ErrTokenKind is returned when the token is not of the kind required
+	// by RequireTokenKind or RequireKind. Use errors.As with *TokenKindError
+	// to retrieve the kinds.
+	ErrTokenKind = errors.New("token is not of the required kind")
+)
+
+// TokenKindError is returned when the token is not of the required kind.
+// It matches ErrTokenKind with errors.Is.
+type TokenKindError struct {
+	Required, Actual TokenKind
+}
+
+func (e *TokenKindError) Error() string {
+	return fmt.Sprintf("%s: %s token, %s token required", ErrTokenKind, e.Actual, e.Required)
+}
+
+// Is makes errors.Is match ErrTokenKind.
+func (e *TokenKindError) Is(target error) bool {
+	return target == ErrTokenKind
+}
+
+// KindOf returns the kind of the token of the claims: MachineToken if its gty
+// claim is "client-credentials" or its sub claim ends with "@clients", as in
+// the tokens of the client credentials grant of Auth0, UserToken otherwise.
+func KindOf(claims map[string]interface{}) TokenKind {
+	gty, _ := claims["gty"].(string)
+	sub, _ := claims["sub"].(string)
+	return tokenKind(gty, sub)
+}
+
+// Kind returns the kind of the token of the claims, see KindOf.
+func (c *Auth0Claims) Kind() TokenKind {
+	return tokenKind(c.GrantType, c.Subject)
+}
+
+func tokenKind(gty, sub string) TokenKind {
+	if gty == grantTypeClientCredentials || strings.HasSuffix(sub, clientsSubjectSuffix) {
+		return MachineToken
+	}
+	return UserToken
+}
+
+// CheckTokenKind checks that the token of the claims is of the kind,
+// returning a *TokenKindError otherwise.
+func CheckTokenKind(claims map[string]interface{}, kind TokenKind) error {
+	if actual := KindOf(claims); actual != kind {
+		return &TokenKindError{Required: kind, Actual: actual}
+	}
+	return nil
+}
+
+// RequireTokenKind makes the validator reject the tokens
+// that are not of the kind, such as the user tokens of a
+// service only called by other services.
+func RequireTokenKind(kind TokenKind) ValidatorOption {
+	return func(v *JWTValidator) {
+		v.checks = append(v.checks, func(claims map[string]interface{}) error {
+			return CheckTokenKind(claims, kind)
+		})
+	}
+}
+
+// RequireKind wraps a handler behind the middleware to reject the requests
+// whose token is not of the kind with 403 Forbidden, so the routes reserved
+// to users or to other services are restricted next to them. Requests with
+// no validated token are rejected with 401.
+func RequireKind(kind TokenKind) func(http.Handler) http.Handler {
+	return requireClaims(func(claims map[string]interface{}) error {
+		return CheckTokenKind(claims, kind)
+	})
+}
@@ -0,0 +1,78 @@
+package auth0
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestKindOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		claims   map[string]interface{}
+		expected TokenKind
+	}{
+		{"client credentials grant", map[string]interface{}{"gty": "client-credentials", "sub": "abc@clients"}, MachineToken},
+		{"grant type only", map[string]interface{}{"gty": "client-credentials"}, MachineToken},
+		{"clients subject only", map[string]interface{}{"sub": "abc@clients"}, MachineToken},
+		{"user", map[string]interface{}{"sub": "auth0|123", "gty": "password"}, UserToken},
+		{"no claims", map[string]interface{}{}, UserToken},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, KindOf(test.claims))
+		})
+	}
+
+	claims := Auth0Claims{Claims: jwt.Claims{Subject: "abc@clients"}}
+	assert.Equal(t, MachineToken, claims.Kind())
+	assert.Equal(t, "machine-to-machine", MachineToken.String())
+}
+
+func TestRequireTokenKind(t *testing.T) {
+	configuration := NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256)
+	validator := NewValidator(configuration, nil, RequireTokenKind(MachineToken))
+	registered := jwt.Claims{Issuer: defaultIssuer, Audience: defaultAudience, Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+
+	registered.Subject = "abc@clients"
+	_, err := validator.ValidateRawToken(getTestTokenWithClaims(jose.HS256, defaultSecret, registered))
+	assert.NoError(t, err)
+
+	registered.Subject = "auth0|123"
+	_, err = validator.ValidateRawToken(getTestTokenWithClaims(jose.HS256, defaultSecret, registered))
+	assert.True(t, errors.Is(err, ErrTokenKind), "got %v", err)
+	assert.EqualError(t, err, "token is not of the required kind: user token, machine-to-machine token required")
+}
+
+func TestRequireKind(t *testing.T) {
+	tests := []struct {
+		name           string
+		claims         map[string]interface{}
+		expectedStatus int
+	}{
+		{"pass - user token", map[string]interface{}{"sub": "auth0|123"}, http.StatusOK},
+		{"fail - machine token", map[string]interface{}{"sub": "abc@clients", "gty": "client-credentials"}, http.StatusForbidden},
+		{"fail - no claims", nil, http.StatusUnauthorized},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := RequireKind(UserToken)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			req := httptest.NewRequest("GET", "http://localhost/profile", nil)
+			if test.claims != nil {
+				req = req.WithContext(NewContext(req.Context(), nil, "", test.claims))
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, test.expectedStatus, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package auth0
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// Default limits of the tokens, see TokenLimits.
+const (
+	DefaultMaxTokenLength  = 16 * 1024
+	DefaultMaxTokenHeaders = 1
+	DefaultMaxNestingDepth = 2
+)
+
+const (
+	tokenLimitLength       = "length"
+	tokenLimitHeaders      = "headers"
+	tokenLimitNestingDepth = "nesting depth"
+)
+
+var (
+	// ErrTokenTooLarge is matched by the errors of the tokens
+	// exceeding the limits of the validator, see TokenLimits.
+	ErrTokenTooLarge = errors.New("token exceeds the limits")
+)
+
+// TokenLimits bounds the tokens before any decoding or cryptographic work,
+// so oversized tokens sent by attackers are rejected early. Zero fields
+// default to the Default limits, negative ones disable the limit.
+type TokenLimits struct {
+	// MaxLength is the maximum length of the compact serialized token,
+	// checked before it is parsed. Defaults to DefaultMaxTokenLength.
+	MaxLength int
+	// MaxHeaders is the maximum number of JOSE headers of the signed token,
+	// one per signature. Defaults to DefaultMaxTokenHeaders.
+	MaxHeaders int
+	// MaxNestingDepth is the maximum number of JOSE layers of the token: 1 for
+	// a signed token, 2 for a signed then encrypted one. Encrypted tokens are
+	// rejected before their decryption when it is lower than 2.
+	// Defaults to DefaultMaxNestingDepth.
+	MaxNestingDepth int
+}
+
+// TokenLimitError is returned when a token exceeds one of the TokenLimits.
+// It matches ErrTokenTooLarge and ErrTokenMalformed with errors.Is.
+type TokenLimitError struct {
+	// Limit is the exceeded limit: "length", "headers" or "nesting depth".
+	Limit string
+	// Max is the value of the limit and Value the one of the token.
+	Max, Value int
+}
+
+func (e *TokenLimitError) Error() string {
+	return fmt.Sprintf("token %s %d exceeds the limit of %d", e.Limit, e.Value, e.Max)
+}
+
+// Is makes errors.Is match ErrTokenTooLarge and ErrTokenMalformed.
+func (e *TokenLimitError) Is(target error) bool {
+	return target == ErrTokenTooLarge || target == ErrTokenMalformed
+}
+
+// WithTokenLimits sets the limits of the tokens validated by the validator,
+// instead of the defaults of TokenLimits. The limits apply to the tokens
+// parsed by the validator, the extractors of this package parsing the
+// tokens apply the defaults.
+func WithTokenLimits(limits TokenLimits) ValidatorOption {
+	return func(v *JWTValidator) {
+		v.limits = limits
+	}
+}
+
+// tokenLimit returns the value of the limit, its default if zero: ok is
+// false when the limit is disabled.
+func tokenLimit(value, defaultValue int) (max int, ok bool) {
+	if value == 0 {
+		return defaultValue, true
+	}
+	return value, value > 0
+}
+
+// checkLength rejects the compact serialized tokens longer than MaxLength.
+func (l TokenLimits) checkLength(raw string) error {
+	if max, ok := tokenLimit(l.MaxLength, DefaultMaxTokenLength); ok && len(raw) > max {
+		return &TokenLimitError{Limit: tokenLimitLength, Max: max, Value: len(raw)}
+	}
+	return nil
+}
+
+// checkDepth rejects the tokens of more than MaxNestingDepth layers.
+func (l TokenLimits) checkDepth(depth int) error {
+	if max, ok := tokenLimit(l.MaxNestingDepth, DefaultMaxNestingDepth); ok && depth > max {
+		return &TokenLimitError{Limit: tokenLimitNestingDepth, Max: max, Value: depth}
+	}
+	return nil
+}
+
+// checkToken rejects the signed tokens of more than MaxHeaders headers or,
+// counting the outer layers around them, of more than MaxNestingDepth
+// layers, the ones holding a nested token being one layer deeper.
+func (l TokenLimits) checkToken(token *jwt.JSONWebToken, outer int) error {
+	if max, ok := tokenLimit(l.MaxHeaders, DefaultMaxTokenHeaders); ok && len(token.Headers) > max {
+		return &TokenLimitError{Limit: tokenLimitHeaders, Max: max, Value: len(token.Headers)}
+	}
+	depth := outer + 1
+	for _, header := range token.Headers {
+		if cty, _ := header.ExtraHeaders[jose.HeaderContentType].(string); strings.EqualFold(cty, "JWT") {
+			depth++
+			break
+		}
+	}
+	return l.checkDepth(depth)
+}
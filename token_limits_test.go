@@ -0,0 +1,87 @@
+package auth0
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestTokenLimits(t *testing.T) {
+	registered := jwt.Claims{Issuer: defaultIssuer, Audience: defaultAudience, Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+	token := getTestTokenWithClaims(jose.HS256, defaultSecret, registered)
+	large := getTestTokenWithClaims(jose.HS256, defaultSecret, registered, map[string]interface{}{"padding": strings.Repeat("a", DefaultMaxTokenLength)})
+
+	signer, _ := jose.NewMultiSigner([]jose.SigningKey{{Algorithm: jose.HS256, Key: defaultSecret}, {Algorithm: jose.HS256, Key: defaultSecret}}, nil)
+	payload, _ := json.Marshal(registered)
+	jws, _ := signer.Sign(payload)
+	multi := jws.FullSerialize()
+
+	tests := []struct {
+		name          string
+		limits        TokenLimits
+		token         string
+		expectedLimit string
+	}{
+		{"pass - default limits", TokenLimits{}, token, ""},
+		{"fail - longer than the default length", TokenLimits{}, large, tokenLimitLength},
+		{"pass - length limit disabled", TokenLimits{MaxLength: -1}, large, ""},
+		{"fail - longer than the length", TokenLimits{MaxLength: 100}, token, tokenLimitLength},
+		{"fail - more headers than the default", TokenLimits{}, multi, tokenLimitHeaders},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			validator := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), nil, WithTokenLimits(test.limits))
+			_, rawErr := validator.ValidateRawToken(test.token)
+			_, requestErr := validator.ValidateRequest(genTestMiddlewareRequest(test.token))
+			for _, err := range []error{rawErr, requestErr} {
+				if test.expectedLimit == "" {
+					assert.NoError(t, err)
+					continue
+				}
+				var limitErr *TokenLimitError
+				assert.True(t, errors.As(err, &limitErr), "got %v", err)
+				assert.True(t, errors.Is(err, ErrTokenTooLarge))
+				assert.True(t, errors.Is(err, ErrTokenMalformed))
+				if limitErr != nil {
+					assert.Equal(t, test.expectedLimit, limitErr.Limit)
+				}
+			}
+		})
+	}
+}
+
+func TestTokenLimitsNestingDepth(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	nested := getTestNestedToken(t, &key.PublicKey)
+
+	var calls int32
+	provider := DecryptionKeyProviderFunc(func(_ *jwt.NestedJSONWebToken) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return key, nil
+	})
+	configuration := NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256)
+
+	_, err := NewValidator(configuration, nil, WithDecryptionKeyProvider(provider)).ValidateRawToken(nested)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), calls)
+
+	// Nested tokens are rejected before their decryption.
+	_, err = NewValidator(configuration, nil, WithDecryptionKeyProvider(provider), WithTokenLimits(TokenLimits{MaxNestingDepth: 1})).ValidateRawToken(nested)
+	var limitErr *TokenLimitError
+	assert.True(t, errors.As(err, &limitErr), "got %v", err)
+	if limitErr != nil {
+		assert.Equal(t, tokenLimitNestingDepth, limitErr.Limit)
+		assert.Equal(t, 2, limitErr.Value)
+	}
+	assert.Equal(t, int32(1), calls)
+}
@@ -0,0 +1,58 @@
+package auth0
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/square/go-jose.v2"
+)
+
+// HeaderTypeAccessToken is the typ header of the JWT access tokens
+// of RFC 9068, such as the ones Auth0 issues with the RFC 9068 profile.
+const HeaderTypeAccessToken = "at+jwt"
+
+var (
+	// ErrInvalidTokenType is returned when the typ header of the token
+	// is not one of the types accepted by the validator.
+	ErrInvalidTokenType = errors.New("invalid token type")
+)
+
+// WithTokenTypes makes the validator accept only the tokens whose typ header
+// is one of the types, compared case-insensitively and with or without the
+// "application/" prefix as RFC 7515 allows, so the other JWTs of the issuer,
+// such as its ID tokens, are rejected before their key is looked up.
+func WithTokenTypes(types ...string) ValidatorOption {
+	return func(v *JWTValidator) {
+		v.types = types
+	}
+}
+
+// RequireAccessTokenType makes the validator accept only the RFC 9068
+// access tokens, whose typ header is at+jwt or application/at+jwt.
+func RequireAccessTokenType() ValidatorOption {
+	return WithTokenTypes(HeaderTypeAccessToken)
+}
+
+// checkType validates the typ header of the token
+// against the types accepted by the validator.
+func (v *JWTValidator) checkType(header jose.Header) error {
+	if len(v.types) == 0 {
+		return nil
+	}
+	typ, _ := header.ExtraHeaders[jose.HeaderType].(string)
+	for _, t := range v.types {
+		if strings.EqualFold(mediaType(typ), mediaType(t)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: typ %q", ErrInvalidTokenType, typ)
+}
+
+// mediaType removes the "application/" prefix of the media type, if any.
+func mediaType(typ string) string {
+	if len(typ) > len("application/") && strings.EqualFold(typ[:len("application/")], "application/") {
+		return typ[len("application/"):]
+	}
+	return typ
+}
@@ -0,0 +1,43 @@
+package auth0
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func TestRequireAccessTokenType(t *testing.T) {
+	tests := []struct {
+		name          string
+		typ           string
+		opts          []ValidatorOption
+		expectedError error
+	}{
+		{"pass - no required type", "JWT", nil, nil},
+		{"pass - at+jwt", "at+jwt", []ValidatorOption{RequireAccessTokenType()}, nil},
+		{"pass - application/at+jwt", "application/at+jwt", []ValidatorOption{RequireAccessTokenType()}, nil},
+		{"pass - case-insensitive", "AT+JWT", []ValidatorOption{RequireAccessTokenType()}, nil},
+		{"fail - JWT", "JWT", []ValidatorOption{RequireAccessTokenType()}, ErrInvalidTokenType},
+		{"pass - one of the types", "logout+jwt", []ValidatorOption{WithTokenTypes("at+jwt", "logout+jwt")}, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			headers := map[jose.HeaderKey]interface{}{}
+			if test.typ != "" {
+				headers[jose.HeaderType] = test.typ
+			}
+			provider := &countingSecretProvider{}
+			validator := NewValidator(NewConfiguration(provider, defaultAudience, defaultIssuer, jose.HS256), nil, test.opts...)
+			_, err := validator.ValidateRawToken(getTestTokenWithHeaders(headers))
+			if test.expectedError == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, test.expectedError), "got %v", err)
+			assert.Equal(t, int32(0), provider.calls)
+		})
+	}
+}
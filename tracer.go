@@ -0,0 +1,60 @@
+package auth0
+
+import "context"
+
+// Names of the spans started by the validator and the JWKClient.
+const (
+	SpanValidateRequest = "auth0.ValidateRequest"
+	SpanGetKey          = "auth0.GetKey"
+	SpanDownloadKeys    = "auth0.downloadKeys"
+)
+
+// Attributes set on the spans.
+const (
+	AttributeKeyID          = "auth0.kid"
+	AttributeIssuer         = "auth0.issuer"
+	AttributeCacheHit       = "auth0.cache_hit"
+	AttributeSharedDownload = "auth0.shared_download"
+)
+
+// Tracer starts the spans of the validations, key lookups and JWKS
+// downloads, so they show up in distributed traces. The spans of the
+// key lookups and downloads are children of the validation span when
+// the validator is configured with a JWKClient. The
+// github.com/auth0-community/go-auth0/otel module provides an
+// OpenTelemetry Tracer.
+type Tracer interface {
+	// Start starts a span as a child of the span of the context, if any,
+	// and returns a context holding the new span.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a span started by a Tracer.
+type Span interface {
+	// SetAttribute sets an attribute of the span. The value is
+	// a string or a bool.
+	SetAttribute(key string, value interface{})
+	// End ends the span, recording the error if not nil.
+	End(err error)
+}
+
+// WithTracer sets the tracer of the spans of the validations.
+func WithTracer(tracer Tracer) ValidatorOption {
+	return func(v *JWTValidator) {
+		v.tracer = tracer
+	}
+}
+
+// startSpan starts a span with the tracer, or a span doing
+// nothing if the tracer is nil.
+func startSpan(ctx context.Context, tracer Tracer, name string) (context.Context, Span) {
+	if tracer == nil {
+		return ctx, nopSpan{}
+	}
+	return tracer.Start(ctx, name)
+}
+
+type nopSpan struct{}
+
+func (nopSpan) SetAttribute(string, interface{}) {}
+func (nopSpan) End(error)                        {}
@@ -0,0 +1,122 @@
+package auth0
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+type recordedSpan struct {
+	name       string
+	parent     string
+	attributes map[string]interface{}
+	err        error
+	ended      bool
+}
+
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+type recordingSpanKey struct{}
+
+func (t *recordingTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	span := &recordedSpan{name: name, attributes: map[string]interface{}{}}
+	if parent, ok := ctx.Value(recordingSpanKey{}).(*recordedSpan); ok {
+		span.parent = parent.name
+	}
+	t.mu.Lock()
+	t.spans = append(t.spans, span)
+	t.mu.Unlock()
+	return context.WithValue(ctx, recordingSpanKey{}, span), &recordingTracerSpan{t, span}
+}
+
+type recordingTracerSpan struct {
+	tracer *recordingTracer
+	span   *recordedSpan
+}
+
+func (s *recordingTracerSpan) SetAttribute(key string, value interface{}) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.span.attributes[key] = value
+}
+
+func (s *recordingTracerSpan) End(err error) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.span.err = err
+	s.span.ended = true
+}
+
+func TestTracer(t *testing.T) {
+	key := genRSASSAJWK(jose.RS256, "keyRS256")
+	ts := genJWKSServer(key)
+	defer ts.Close()
+
+	tracer := &recordingTracer{}
+	client := NewJWKClient(JWKClientOptions{URI: ts.URL, Tracer: tracer}, nil)
+	validator := NewValidator(NewConfiguration(client, defaultAudience, defaultIssuer, jose.RS256), nil, WithTracer(tracer))
+
+	// The kid header is set from the JWK.
+	raw := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.RS256, key)
+
+	for i := 0; i < 2; i++ {
+		_, err := validator.ValidateRequest(genTestMiddlewareRequest(raw))
+		assert.NoError(t, err)
+	}
+
+	names := []string{}
+	for _, span := range tracer.spans {
+		assert.True(t, span.ended)
+		assert.NoError(t, span.err)
+		names = append(names, fmt.Sprintf("%s<%s", span.name, span.parent))
+	}
+	assert.Equal(t, []string{
+		"auth0.ValidateRequest<",
+		"auth0.GetKey<auth0.ValidateRequest",
+		"auth0.downloadKeys<auth0.GetKey",
+		"auth0.ValidateRequest<",
+		"auth0.GetKey<auth0.ValidateRequest",
+	}, names)
+
+	assert.Equal(t, "keyRS256", tracer.spans[0].attributes[AttributeKeyID])
+	assert.Equal(t, defaultIssuer, tracer.spans[0].attributes[AttributeIssuer])
+	assert.Equal(t, false, tracer.spans[1].attributes[AttributeCacheHit])
+	assert.Equal(t, true, tracer.spans[4].attributes[AttributeCacheHit])
+}
+
+func TestTracerFailure(t *testing.T) {
+	tracer := &recordingTracer{}
+	validator := NewValidator(NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256), nil, WithTracer(tracer))
+
+	_, err := validator.ValidateRequest(httptest.NewRequest("GET", "http://localhost", nil))
+	assert.Equal(t, ErrTokenNotFound, err)
+	assert.Len(t, tracer.spans, 1)
+	assert.Equal(t, ErrTokenNotFound, tracer.spans[0].err)
+}
+
+func TestRequestContextPropagation(t *testing.T) {
+	key := genRSASSAJWK(jose.RS256, "keyRS256")
+	ts := genJWKSServer(key)
+	defer ts.Close()
+
+	client := NewJWKClient(JWKClientOptions{URI: ts.URL}, nil)
+	validator := NewValidator(NewConfiguration(client, defaultAudience, defaultIssuer, jose.RS256), nil)
+
+	// The kid header is set from the JWK.
+	raw := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.RS256, key)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := validator.ValidateRequest(genTestMiddlewareRequest(raw).WithContext(ctx))
+	assert.True(t, errors.Is(err, context.Canceled))
+}
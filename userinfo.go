@@ -0,0 +1,189 @@
+package auth0
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrUserInfoUnauthorized is returned when the userinfo
+	// endpoint rejects the access token.
+	ErrUserInfoUnauthorized = errors.New("access token rejected by the userinfo endpoint")
+	// ErrNoUserInfoEndpoint is returned when the OpenID
+	// configuration has no userinfo_endpoint.
+	ErrNoUserInfoEndpoint = errors.New("no userinfo_endpoint in the OpenID configuration")
+)
+
+// UserInfo holds the standard claims about the user
+// returned by the userinfo endpoint.
+type UserInfo struct {
+	Subject             string `json:"sub"`
+	Name                string `json:"name,omitempty"`
+	GivenName           string `json:"given_name,omitempty"`
+	FamilyName          string `json:"family_name,omitempty"`
+	MiddleName          string `json:"middle_name,omitempty"`
+	Nickname            string `json:"nickname,omitempty"`
+	PreferredUsername   string `json:"preferred_username,omitempty"`
+	Profile             string `json:"profile,omitempty"`
+	Picture             string `json:"picture,omitempty"`
+	Website             string `json:"website,omitempty"`
+	Email               string `json:"email,omitempty"`
+	EmailVerified       bool   `json:"email_verified,omitempty"`
+	Gender              string `json:"gender,omitempty"`
+	Birthdate           string `json:"birthdate,omitempty"`
+	Zoneinfo            string `json:"zoneinfo,omitempty"`
+	Locale              string `json:"locale,omitempty"`
+	PhoneNumber         string `json:"phone_number,omitempty"`
+	PhoneNumberVerified bool   `json:"phone_number_verified,omitempty"`
+	// Claims are all the claims of the response, including
+	// updated_at, address and the custom claims.
+	Claims map[string]interface{} `json:"-"`
+}
+
+// UserInfoClientOptions configures the userinfo endpoint client.
+type UserInfoClientOptions struct {
+	// URI is the userinfo endpoint, such as
+	// https://mydomain.eu.auth0.com/userinfo. It is discovered
+	// from the OpenID configuration of Issuer when empty.
+	URI    string
+	Issuer string
+	Client *http.Client
+	// CacheTTL is how long the user info is cached by access token.
+	// The endpoint is called on every call when zero.
+	CacheTTL time.Duration
+}
+
+// UserInfoClient retrieves the claims about the user
+// of an access token from the userinfo endpoint.
+type UserInfoClient struct {
+	options UserInfoClientOptions
+
+	uriMu sync.Mutex // Used to lock reads/writes to the discovered URI
+	uri   string
+
+	mu    sync.Mutex               // Used to lock reads/writes to the cache
+	cache map[[32]byte]userInfoHit // User info, by hash of the token
+}
+
+type userInfoHit struct {
+	userInfo  *UserInfo
+	expiresAt time.Time
+}
+
+// NewUserInfoClient creates a new UserInfoClient
+// instance from the provided options.
+func NewUserInfoClient(options UserInfoClientOptions) *UserInfoClient {
+	if options.Client == nil {
+		options.Client = http.DefaultClient
+	}
+	return &UserInfoClient{
+		options: options,
+		uri:     options.URI,
+		cache:   map[[32]byte]userInfoHit{},
+	}
+}
+
+// UserInfo returns the claims about the user of the access token. The
+// userinfo endpoint is discovered on the first call when the client has
+// no URI, and the discovery is retried on the next call if it fails.
+func (c *UserInfoClient) UserInfo(ctx context.Context, accessToken string) (*UserInfo, error) {
+	hash := sha256.Sum256([]byte(accessToken))
+	now := time.Now()
+
+	c.mu.Lock()
+	hit, ok := c.cache[hash]
+	if ok && !now.Before(hit.expiresAt) {
+		delete(c.cache, hash)
+		ok = false
+	}
+	c.mu.Unlock()
+	if ok {
+		return hit.userInfo, nil
+	}
+
+	uri, err := c.endpoint(ctx)
+	if err != nil {
+		return nil, err
+	}
+	userInfo, err := c.userInfo(ctx, uri, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.options.CacheTTL > 0 {
+		c.mu.Lock()
+		for h, hit := range c.cache {
+			if !now.Before(hit.expiresAt) {
+				delete(c.cache, h)
+			}
+		}
+		c.cache[hash] = userInfoHit{userInfo: userInfo, expiresAt: now.Add(c.options.CacheTTL)}
+		c.mu.Unlock()
+	}
+	return userInfo, nil
+}
+
+// endpoint returns the URI of the userinfo endpoint,
+// discovering it if the client has none.
+func (c *UserInfoClient) endpoint(ctx context.Context) (string, error) {
+	c.uriMu.Lock()
+	defer c.uriMu.Unlock()
+
+	if c.uri != "" {
+		return c.uri, nil
+	}
+	config, err := FetchOpenIDConfiguration(ctx, c.options.Client, c.options.Issuer)
+	if err != nil {
+		return "", err
+	}
+	if config.UserInfoEndpoint == "" {
+		return "", ErrNoUserInfoEndpoint
+	}
+	c.uri = config.UserInfoEndpoint
+	return c.uri, nil
+}
+
+func (c *UserInfoClient) userInfo(ctx context.Context, uri, accessToken string) (*UserInfo, error) {
+	req, err := http.NewRequest("GET", uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.options.Client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, ErrUserInfoUnauthorized
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d from userinfo endpoint", resp.StatusCode)
+	}
+	if contentH := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentH, "application/json") {
+		return nil, ErrInvalidContentType
+	}
+
+	var body json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	userInfo := &UserInfo{}
+	if err := json.Unmarshal(body, userInfo); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(body, &userInfo.Claims); err != nil {
+		return nil, err
+	}
+	return userInfo, nil
+}
@@ -0,0 +1,95 @@
+package auth0
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func genTestUserInfoServer(t *testing.T, counter *uint64) *httptest.Server {
+	mux := http.NewServeMux()
+	ts := httptest.NewServer(mux)
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(OpenIDConfiguration{
+			Issuer:           ts.URL + "/",
+			JWKSURI:          ts.URL + "/.well-known/jwks.json",
+			UserInfoEndpoint: ts.URL + "/userinfo",
+		})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint64(counter, 1)
+		if r.Header.Get("Authorization") != "Bearer valid" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"sub":                              "auth0|123",
+			"name":                             "Jane Doe",
+			"email":                            "jane@example.com",
+			"email_verified":                   true,
+			"updated_at":                       "2023-01-01T00:00:00.000Z",
+			"https://example.com/subscription": "premium",
+		})
+	})
+	return ts
+}
+
+func TestUserInfo(t *testing.T) {
+	var counter uint64
+	ts := genTestUserInfoServer(t, &counter)
+	defer ts.Close()
+
+	tests := []struct {
+		name    string
+		options UserInfoClientOptions
+	}{
+		{name: "pass - discovered endpoint", options: UserInfoClientOptions{Issuer: ts.URL + "/"}},
+		{name: "pass - URI", options: UserInfoClientOptions{URI: ts.URL + "/userinfo"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			userInfo, err := NewUserInfoClient(test.options).UserInfo(context.Background(), "valid")
+			if assert.NoError(t, err) {
+				assert.Equal(t, "auth0|123", userInfo.Subject)
+				assert.Equal(t, "Jane Doe", userInfo.Name)
+				assert.Equal(t, "jane@example.com", userInfo.Email)
+				assert.True(t, userInfo.EmailVerified)
+				assert.Equal(t, "premium", userInfo.Claims["https://example.com/subscription"])
+				assert.Equal(t, "2023-01-01T00:00:00.000Z", userInfo.Claims["updated_at"])
+			}
+		})
+	}
+
+	t.Run("fail - rejected access token", func(t *testing.T) {
+		_, err := NewUserInfoClient(UserInfoClientOptions{URI: ts.URL + "/userinfo"}).UserInfo(context.Background(), "invalid")
+		assert.Equal(t, ErrUserInfoUnauthorized, err)
+	})
+}
+
+func TestUserInfoCache(t *testing.T) {
+	var counter uint64
+	ts := genTestUserInfoServer(t, &counter)
+	defer ts.Close()
+
+	client := NewUserInfoClient(UserInfoClientOptions{URI: ts.URL + "/userinfo", CacheTTL: time.Minute})
+	for i := 0; i < 3; i++ {
+		_, err := client.UserInfo(context.Background(), "valid")
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, uint64(1), atomic.LoadUint64(&counter), "the user info should be cached")
+
+	for i := 0; i < 2; i++ {
+		_, err := client.UserInfo(context.Background(), "invalid")
+		assert.Equal(t, ErrUserInfoUnauthorized, err)
+	}
+	assert.Equal(t, uint64(3), atomic.LoadUint64(&counter), "errors should not be cached")
+}
@@ -0,0 +1,112 @@
+package auth0
+
+import (
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// WithValidationCache makes the validator remember the tokens it validated
+// for the ttl, capped by the remaining lifetime of each token, so clients
+// sending the same token on every request skip the verification of its
+// signature. The tokens are keyed by the SHA-256 of their compact
+// serialization, so a token differing by a single byte is verified again.
+// At most maxEntries tokens are cached, unbounded if not positive.
+//
+// Cached tokens are accepted until the end of the ttl even if their key is
+// removed from the JWKS in the meantime, so keep the ttl short. Only the
+// requests whose extractor is a RawRequestTokenExtractor, such as the default
+// one, and ValidateRawToken use the cache.
+func WithValidationCache(ttl time.Duration, maxEntries int) ValidatorOption {
+	return func(v *JWTValidator) {
+		v.cache = &validationCache{
+			ttl:        ttl,
+			maxEntries: maxEntries,
+			entries:    map[[sha256.Size]byte]validationCacheEntry{},
+		}
+	}
+}
+
+type validationCache struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[[sha256.Size]byte]validationCacheEntry
+}
+
+type validationCacheEntry struct {
+	token     *jwt.JSONWebToken
+	expiresAt time.Time
+}
+
+// get returns the token if it has been validated less than the ttl ago and
+// is not expired at now. The cache may be nil.
+func (c *validationCache) get(raw string, now time.Time) (*jwt.JSONWebToken, bool) {
+	if c == nil {
+		return nil, false
+	}
+	key := sha256.Sum256([]byte(raw))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !now.Before(entry.expiresAt) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.token, true
+}
+
+// add remembers the validated token until the end of the ttl or its
+// expiry, whichever comes first, from now. The cache may be nil.
+func (c *validationCache) add(raw string, token *jwt.JSONWebToken, now time.Time) {
+	if c == nil {
+		return
+	}
+	claims := jwt.Claims{}
+	if err := token.UnsafeClaimsWithoutVerification(&claims); err != nil || claims.Expiry == 0 {
+		return
+	}
+	expiresAt := now.Add(c.ttl)
+	if expiry := claims.Expiry.Time(); expiry.Before(expiresAt) {
+		expiresAt = expiry
+	}
+	if !now.Before(expiresAt) {
+		return
+	}
+	key := sha256.Sum256([]byte(raw))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.maxEntries > 0 && len(c.entries) >= c.maxEntries {
+		c.evict(now)
+	}
+	c.entries[key] = validationCacheEntry{token: token, expiresAt: expiresAt}
+}
+
+// evict removes the expired entries, or an arbitrary
+// one if none is expired. The caller must hold mu.
+func (c *validationCache) evict(now time.Time) {
+	evicted := false
+	for key, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, key)
+			evicted = true
+		}
+	}
+	if evicted {
+		return
+	}
+	for key := range c.entries {
+		delete(c.entries, key)
+		return
+	}
+}
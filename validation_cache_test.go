@@ -0,0 +1,101 @@
+package auth0
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+type countingSecretProvider struct {
+	calls int32
+}
+
+func (p *countingSecretProvider) GetSecret(_ *jwt.JSONWebToken) (interface{}, error) {
+	atomic.AddInt32(&p.calls, 1)
+	return defaultSecret, nil
+}
+
+func TestValidationCache(t *testing.T) {
+	provider := &countingSecretProvider{}
+	validator := NewValidator(NewConfiguration(provider, defaultAudience, defaultIssuer, jose.HS256), nil, WithValidationCache(time.Minute, 10))
+
+	token := getTestTokenWithClaims(jose.HS256, defaultSecret,
+		jwt.Claims{Issuer: defaultIssuer, Audience: defaultAudience, Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+		map[string]interface{}{"scope": "read:users"},
+	)
+
+	for i := 0; i < 3; i++ {
+		claims := map[string]interface{}{}
+		_, err := validator.ValidateRequestClaims(genTestMiddlewareRequest(token), &claims)
+		assert.NoError(t, err)
+		assert.Equal(t, "read:users", claims["scope"])
+	}
+	_, err := validator.ValidateRawToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), provider.calls)
+
+	other := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, defaultSecret)
+	_, err = validator.ValidateRequest(genTestMiddlewareRequest(other))
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), provider.calls)
+}
+
+func TestValidationCacheFailures(t *testing.T) {
+	provider := &countingSecretProvider{}
+	validator := NewValidator(NewConfiguration(provider, defaultAudience, defaultIssuer, jose.HS256), nil, WithValidationCache(time.Minute, 10))
+
+	forged := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, []byte("other secret"))
+	for i := 0; i < 2; i++ {
+		_, err := validator.ValidateRequest(genTestMiddlewareRequest(forged))
+		assert.True(t, errors.Is(err, ErrInvalidSignature))
+	}
+	assert.Equal(t, int32(2), provider.calls)
+}
+
+func TestValidationCacheExpiry(t *testing.T) {
+	token := getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, defaultSecret)
+	parsed, err := jwt.ParseSigned(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		ttl        time.Duration
+		expiry     time.Time
+		expectedOK bool
+	}{
+		{"cached", time.Minute, time.Now().Add(time.Hour), true},
+		{"ttl elapsed", -time.Minute, time.Now().Add(time.Hour), false},
+		{"token expired", time.Minute, time.Now().Add(-time.Second), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token := getTestToken(defaultAudience, defaultIssuer, test.expiry, jose.HS256, defaultSecret)
+			parsed, err := jwt.ParseSigned(token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cache := &validationCache{ttl: test.ttl, entries: map[[32]byte]validationCacheEntry{}}
+			cache.add(token, parsed, time.Now())
+			_, ok := cache.get(token, time.Now())
+			assert.Equal(t, test.expectedOK, ok)
+		})
+	}
+
+	t.Run("max entries", func(t *testing.T) {
+		cache := &validationCache{ttl: time.Minute, maxEntries: 1, entries: map[[32]byte]validationCacheEntry{}}
+		other := getTestToken(defaultAudience, "other", time.Now().Add(time.Hour), jose.HS256, defaultSecret)
+		cache.add(token, parsed, time.Now())
+		cache.add(other, parsed, time.Now())
+		assert.Len(t, cache.entries, 1)
+		_, ok := cache.get(other, time.Now())
+		assert.True(t, ok)
+	})
+}
@@ -0,0 +1,27 @@
+package auth0
+
+import "time"
+
+// ValidationObserver is notified of the result of each token validation
+// of a JWTValidator, to report it to a monitoring system. The error is nil
+// for valid tokens. OnValidation is called synchronously and must be safe
+// for concurrent use.
+type ValidationObserver interface {
+	OnValidation(duration time.Duration, err error)
+}
+
+// WithValidationObserver sets the observer notified of the results of the
+// validations, including the requests with no token or a malformed one.
+func WithValidationObserver(observer ValidationObserver) ValidatorOption {
+	return func(v *JWTValidator) {
+		v.observer = observer
+	}
+}
+
+// observe notifies the observer of the validator, if any,
+// of the result of a validation lasting duration.
+func (v *JWTValidator) observe(duration time.Duration, err error) {
+	if v.observer != nil {
+		v.observer.OnValidation(duration, err)
+	}
+}
@@ -0,0 +1,44 @@
+package auth0
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+type recordingValidationObserver struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+func (o *recordingValidationObserver) OnValidation(_ time.Duration, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.errs = append(o.errs, err)
+}
+
+func TestValidationObserver(t *testing.T) {
+	observer := &recordingValidationObserver{}
+	configuration := NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256)
+	validator := NewValidator(configuration, nil, WithValidationObserver(observer))
+
+	_, err := validator.ValidateRawToken(getTestToken(defaultAudience, defaultIssuer, time.Now().Add(time.Hour), jose.HS256, defaultSecret))
+	assert.NoError(t, err)
+	_, err = validator.ValidateRawToken(getTestToken(defaultAudience, defaultIssuer, time.Now().Add(-time.Hour), jose.HS256, defaultSecret))
+	assert.True(t, errors.Is(err, jwt.ErrExpired))
+	_, err = validator.ValidateRawToken("malformed")
+	assert.Error(t, err)
+	_, err = validator.ValidateRequest(genTestMiddlewareRequest(""))
+	assert.Equal(t, ErrTokenNotFound, err)
+
+	assert.Len(t, observer.errs, 4)
+	assert.NoError(t, observer.errs[0])
+	assert.True(t, errors.Is(observer.errs[1], jwt.ErrExpired))
+	assert.Error(t, observer.errs[2])
+	assert.Equal(t, ErrTokenNotFound, observer.errs[3])
+}
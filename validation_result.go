@@ -0,0 +1,98 @@
+package auth0
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// ValidationResult is the metadata of the validation of a token, so callers
+// can log and meter the decisions uniformly.
+type ValidationResult struct {
+	// Token is the validated token, nil if the validation failed.
+	Token *jwt.JSONWebToken
+	// Claims are the verified claims of the token, nil if the validation failed.
+	Claims map[string]interface{}
+	// Raw is the compact serialized token, empty if it could not be extracted,
+	// or if the extractor of the validator is not a RawRequestTokenExtractor.
+	Raw string
+	// KeyID is the kid header of the token, empty if it could not be parsed.
+	KeyID string
+	// CacheHit reports whether the token was found in the validation cache
+	// of WithValidationCache, with no verification of its signature.
+	CacheHit bool
+	// Duration is the duration of the validation.
+	Duration time.Duration
+	// Leeway is the leeway applied to the exp, nbf and iat claims.
+	Leeway time.Duration
+}
+
+// resultContextKey is the key of the result of the validation
+// in the context passed down the validation.
+type resultContextKey struct{}
+
+func contextWithResult(ctx context.Context, result *ValidationResult) context.Context {
+	return context.WithValue(ctx, resultContextKey{}, result)
+}
+
+func resultFromContext(ctx context.Context) (*ValidationResult, bool) {
+	result, ok := ctx.Value(resultContextKey{}).(*ValidationResult)
+	return result, ok
+}
+
+// ValidateRequestResult validates the token within the http request as
+// ValidateRequest does, and returns the metadata of the validation. The
+// result is returned along with the error of failed validations, with no
+// token or claims.
+func (v *JWTValidator) ValidateRequestResult(r *http.Request) (*ValidationResult, error) {
+	result := &ValidationResult{Leeway: v.leeway}
+	if r != nil {
+		r = r.WithContext(contextWithResult(r.Context(), result))
+	}
+	start := time.Now()
+	claims := map[string]interface{}{}
+	token, err := v.validateRequestWithLeeway(r, v.leeway, &claims)
+	return result.complete(start, token, claims, err)
+}
+
+// ValidateRawTokenResult validates the compact serialized token as
+// ValidateRawToken does, and returns the metadata of the validation. The
+// result is returned along with the error of failed validations, with no
+// token or claims.
+func (v *JWTValidator) ValidateRawTokenResult(raw string) (*ValidationResult, error) {
+	result := &ValidationResult{Leeway: v.leeway}
+	ctx := contextWithResult(context.Background(), result)
+	start := time.Now()
+	claims := map[string]interface{}{}
+	token, err := v.validateRaw(ctx, raw, v.parseRawToken, v.leeway, &claims)
+	v.report(ctx, time.Since(start), token, nil, err)
+	return result.complete(start, token, claims, err)
+}
+
+// complete sets the outcome of the validation started at start on the result.
+func (res *ValidationResult) complete(start time.Time, token *jwt.JSONWebToken, claims map[string]interface{}, err error) (*ValidationResult, error) {
+	res.Duration = time.Since(start)
+	if err != nil {
+		return res, err
+	}
+	res.Token = token
+	res.Claims = claims
+	return res, nil
+}
+
+// setResultToken sets the raw token, if not empty, and the
+// key ID of the token on the result of the context, if any.
+func setResultToken(ctx context.Context, raw string, token *jwt.JSONWebToken) {
+	result, ok := resultFromContext(ctx)
+	if !ok {
+		return
+	}
+	if raw != "" {
+		result.Raw = raw
+	}
+	if token != nil && len(token.Headers) > 0 {
+		result.KeyID = token.Headers[0].KeyID
+	}
+}
@@ -0,0 +1,68 @@
+package auth0
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestValidateRequestResult(t *testing.T) {
+	configuration := NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256)
+	validator := NewValidator(configuration, nil, WithValidationCache(time.Minute, 10), WithLeeway(30*time.Second))
+	raw := getTestTokenWithHeaders(map[jose.HeaderKey]interface{}{"kid": "kid"})
+
+	result, err := validator.ValidateRequestResult(genTestMiddlewareRequest(raw))
+	assert.NoError(t, err)
+	assert.NotNil(t, result.Token)
+	assert.Equal(t, defaultIssuer, result.Claims["iss"])
+	assert.Equal(t, raw, result.Raw)
+	assert.Equal(t, "kid", result.KeyID)
+	assert.False(t, result.CacheHit)
+	assert.Equal(t, 30*time.Second, result.Leeway)
+	assert.NotZero(t, result.Duration)
+
+	result, err = validator.ValidateRequestResult(genTestMiddlewareRequest(raw))
+	assert.NoError(t, err)
+	assert.True(t, result.CacheHit)
+	assert.Equal(t, defaultIssuer, result.Claims["iss"])
+
+	result, err = validator.ValidateRawTokenResult(raw)
+	assert.NoError(t, err)
+	assert.True(t, result.CacheHit)
+	assert.Equal(t, "kid", result.KeyID)
+}
+
+func TestValidateRequestResultFailures(t *testing.T) {
+	configuration := NewConfiguration(defaultSecretProvider, defaultAudience, defaultIssuer, jose.HS256)
+	// The tokens expire in an hour.
+	validator := NewValidator(configuration, nil, WithClock(func() time.Time { return time.Now().Add(2 * time.Hour) }))
+
+	raw := getTestTokenWithHeaders(map[jose.HeaderKey]interface{}{"kid": "kid"})
+	result, err := validator.ValidateRequestResult(genTestMiddlewareRequest(raw))
+	assert.True(t, errors.Is(err, ErrTokenExpired), "got %v", err)
+	assert.Nil(t, result.Token)
+	assert.Nil(t, result.Claims)
+	assert.Equal(t, raw, result.Raw)
+	assert.Equal(t, "kid", result.KeyID)
+	assert.Equal(t, jwt.DefaultLeeway, result.Leeway)
+
+	result, err = validator.ValidateRequestResult(genTestMiddlewareRequest(""))
+	assert.Equal(t, ErrTokenNotFound, err)
+	assert.Empty(t, result.Raw)
+
+	// The results of the extractors returning no raw token have none.
+	extractor := RequestTokenExtractorFunc(func(r *http.Request) (*jwt.JSONWebToken, error) {
+		return FromHeader(r)
+	})
+	validator = NewValidator(configuration, extractor)
+	raw = getTestTokenWithHeaders(map[jose.HeaderKey]interface{}{"kid": "kid"})
+	result, err = validator.ValidateRequestResult(genTestMiddlewareRequest(raw))
+	assert.NoError(t, err)
+	assert.Empty(t, result.Raw)
+	assert.Equal(t, "kid", result.KeyID)
+}
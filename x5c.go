@@ -0,0 +1,111 @@
+package auth0
+
+import (
+	"bytes"
+	"crypto"
+	stded25519 "crypto/ed25519"
+	"crypto/x509"
+	"errors"
+	"time"
+
+	"golang.org/x/crypto/ed25519"
+	"gopkg.in/square/go-jose.v2"
+)
+
+var (
+	// ErrInvalidCertificateChain is returned when none of the keys of the
+	// JWKS has an x5c certificate chain issued by the roots of X5CRoots.
+	ErrInvalidCertificateChain = errors.New("no key of the JWKS has a trusted certificate chain")
+	// ErrNoCertificate is returned by GetCertificate
+	// when the key has no x5c certificate chain.
+	ErrNoCertificate = errors.New("key has no certificate")
+	// ErrCertificateKeyMismatch is returned when the leaf certificate of
+	// the x5c chain of a key does not certify the public key of the JWK.
+	ErrCertificateKeyMismatch = errors.New("certificate does not match the public key of the JWK")
+)
+
+// verifyCertificateChain verifies that the x5c chain of the key is issued
+// by the roots and that its leaf certifies the public key of the JWK.
+func verifyCertificateChain(key jose.JSONWebKey, roots *x509.CertPool, now time.Time) error {
+	if len(key.Certificates) == 0 {
+		return ErrNoCertificate
+	}
+	leaf := key.Certificates[0]
+	intermediates := x509.NewCertPool()
+	for _, cert := range key.Certificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return err
+	}
+	if !publicKeysEqual(leaf.PublicKey, key.Public().Key) {
+		return ErrCertificateKeyMismatch
+	}
+	return nil
+}
+
+// publicKeysEqual reports whether the public key
+// of a certificate is the public key of a JWK.
+func publicKeysEqual(certKey, jwkKey interface{}) bool {
+	if k, ok := jwkKey.(ed25519.PublicKey); ok {
+		// x509 parses Ed25519 keys as crypto/ed25519 keys, a distinct type.
+		certEd25519, ok := certKey.(stded25519.PublicKey)
+		return ok && bytes.Equal(certEd25519, k)
+	}
+	if k, ok := certKey.(interface{ Equal(crypto.PublicKey) bool }); ok {
+		return k.Equal(jwkKey)
+	}
+	return false
+}
+
+// trustedKeys returns the keys of the JWKS whose certificate chain is
+// issued by the roots of X5CRoots, all the keys if X5CRoots is not set.
+func (j *JWKClient) trustedKeys(keys []jose.JSONWebKey) ([]jose.JSONWebKey, error) {
+	if j.options.X5CRoots == nil {
+		return keys, nil
+	}
+
+	now := time.Now()
+	trusted := make([]jose.JSONWebKey, 0, len(keys))
+	for _, key := range keys {
+		if err := j.trusted(key, now); err != nil {
+			j.logger.Warn("key of the JWKS ignored, its certificate chain is not trusted", "kid", key.KeyID, "error", err)
+			continue
+		}
+		trusted = append(trusted, key)
+	}
+	if len(trusted) == 0 {
+		return nil, ErrInvalidCertificateChain
+	}
+	return trusted, nil
+}
+
+// trusted verifies that the certificate chain of the key is issued by
+// the roots of X5CRoots at now, if X5CRoots is set.
+func (j *JWKClient) trusted(key jose.JSONWebKey, now time.Time) error {
+	if j.options.X5CRoots == nil {
+		return nil
+	}
+	return verifyCertificateChain(key, j.options.X5CRoots, now)
+}
+
+// GetCertificate returns the leaf certificate of the x5c chain of the key
+// associated with the provided ID. The chain has been verified when
+// the client is configured with X5CRoots.
+func (j *JWKClient) GetCertificate(ID string) (*x509.Certificate, error) {
+	key, err := j.GetKey(ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(key.Certificates) == 0 {
+		return nil, ErrNoCertificate
+	}
+	return key.Certificates[0], nil
+}
@@ -0,0 +1,120 @@
+package auth0
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// genCertificate creates a certificate of the public key signed by the
+// parent, self-signed if nil.
+func genCertificate(t *testing.T, name string, pub interface{}, parent *x509.Certificate, parentKey *rsa.PrivateKey, isCA bool) *x509.Certificate {
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	if parent == nil {
+		parent = template
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestX5CRoots(t *testing.T) {
+	caKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca := genCertificate(t, "root", &caKey.PublicKey, nil, caKey, true)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	otherCAKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherCA := genCertificate(t, "other root", &otherCAKey.PublicKey, nil, otherCAKey, true)
+
+	trusted := genRSASSAJWK(jose.RS256, "trusted")
+	trusted.Certificates = []*x509.Certificate{genCertificate(t, "trusted", &trusted.Key.(*rsa.PrivateKey).PublicKey, ca, caKey, false)}
+	untrusted := genRSASSAJWK(jose.RS256, "untrusted")
+	untrusted.Certificates = []*x509.Certificate{genCertificate(t, "untrusted", &untrusted.Key.(*rsa.PrivateKey).PublicKey, otherCA, otherCAKey, false)}
+	mismatch := genRSASSAJWK(jose.RS256, "mismatch")
+	mismatch.Certificates = trusted.Certificates
+	noChain := genRSASSAJWK(jose.RS256, "no chain")
+
+	ts := genJWKSServer(trusted, untrusted, mismatch, noChain)
+	defer ts.Close()
+
+	tests := []struct {
+		kid           string
+		expectedError error
+	}{
+		{"trusted", nil},
+		{"untrusted", ErrKeyNotFound},
+		{"mismatch", ErrKeyNotFound},
+		{"no chain", ErrKeyNotFound},
+	}
+
+	client := NewJWKClient(JWKClientOptions{URI: ts.URL, X5CRoots: roots}, nil)
+	for _, test := range tests {
+		t.Run(test.kid, func(t *testing.T) {
+			_, err := client.GetKey(test.kid)
+			assert.Equal(t, test.expectedError, err)
+		})
+	}
+
+	cert, err := client.GetCertificate("trusted")
+	assert.NoError(t, err)
+	assert.Equal(t, "trusted", cert.Subject.CommonName)
+
+	t.Run("no trusted key", func(t *testing.T) {
+		ts := genJWKSServer(untrusted, noChain)
+		defer ts.Close()
+
+		client := NewJWKClient(JWKClientOptions{URI: ts.URL, X5CRoots: roots}, nil)
+		_, err := client.GetKey("untrusted")
+		assert.Equal(t, ErrInvalidCertificateChain, err)
+	})
+
+	t.Run("no roots", func(t *testing.T) {
+		client := NewJWKClient(JWKClientOptions{URI: ts.URL}, nil)
+		_, err := client.GetKey("untrusted")
+		assert.NoError(t, err)
+		_, err = client.GetCertificate("no chain")
+		assert.Equal(t, ErrNoCertificate, err)
+	})
+}
+
+func TestVerifyCertificateChainMismatch(t *testing.T) {
+	key := genRSASSAJWK(jose.RS256, "key")
+	other := genRSASSAJWK(jose.RS256, "other")
+	otherKey := other.Key.(*rsa.PrivateKey)
+	ca := genCertificate(t, "root", &otherKey.PublicKey, nil, otherKey, true)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	key.Certificates = []*x509.Certificate{ca}
+	assert.Equal(t, ErrCertificateKeyMismatch, verifyCertificateChain(key, roots, time.Now()))
+	other.Certificates = []*x509.Certificate{ca}
+	assert.NoError(t, verifyCertificateChain(other, roots, time.Now()))
+}